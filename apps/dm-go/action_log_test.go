@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestActionLogReplay(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	sessionAuth = NewSessionAuth()
+
+	app := fiber.New()
+	setupRoutes(app)
+
+	player := createTestCharacter(true, "Hero")
+	enemy := createTestCharacter(false, "Goblin")
+	enemy.Stats.HP, enemy.Stats.MaxHP = 200, 200
+	initial := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+	initial.TurnOrder = []ID{player.ID, enemy.ID}
+
+	sessionID := "seed-log-session"
+	stateManager.SetState(sessionID, initial)
+	eventStore.CreateSession(sessionID, "Seed log")
+	eventStore.SaveSnapshot(sessionID, initial.Round, initial)
+
+	postStatus := func(path string, body fiber.Map, status int) {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", path, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("session-id", sessionID)
+		if resp, err := app.Test(req); err != nil || resp.StatusCode != status {
+			t.Fatalf("Request to %s failed: %v", path, err)
+		}
+	}
+	post := func(path string, body fiber.Map) { postStatus(path, body, 200) }
+
+	attack := Action{Kind: "Attack", Attacker: player.ID, Target: enemy.ID, Weapon: player.Weapons[0].ID}
+	post("/tools/apply_action", fiber.Map{"state": initial, "action": attack, "seed": 1111})
+	state, _ := stateManager.GetState(sessionID)
+	defend := Action{Kind: "Defend", Actor: enemy.ID}
+	post("/tools/apply_actions", fiber.Map{"state": state, "actions": []Action{defend, attack}, "seed": 2222})
+
+	// A rejected action changes nothing and isn't logged
+	state, _ = stateManager.GetState(sessionID)
+	postStatus("/tools/apply_action", fiber.Map{"state": state, "action": Action{Kind: "Attack", Attacker: enemy.ID, Target: enemy.ID}, "seed": 3333}, 422)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/sessions/"+sessionID+"/actions", nil))
+	if err != nil || resp.StatusCode != 200 {
+		t.Fatalf("Failed to fetch actions: %v", err)
+	}
+	var body struct {
+		Actions []ActionRecord `json:"actions"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+
+	wantSeeds := []int64{1111, 2222, 2223}
+	if len(body.Actions) != len(wantSeeds) {
+		t.Fatalf("Expected %d recorded actions, got %+v", len(wantSeeds), body.Actions)
+	}
+	for i, record := range body.Actions {
+		if record.Seed != wantSeeds[i] {
+			t.Errorf("Action %d: expected seed %d, got %d", i, wantSeeds[i], record.Seed)
+		}
+	}
+	if body.Actions[1].Action.Kind != "Defend" || body.Actions[2].Round != 2 {
+		t.Errorf("Expected the batch's actions with their rounds, got %+v", body.Actions[1:])
+	}
+
+	// Replaying the log from the first snapshot reproduces the session exactly
+	replayed, _ := eventStore.GetSnapshotAtRound(sessionID, 1)
+	for _, record := range body.Actions {
+		*replayed = ApplyAction(*replayed, record.Action, record.Seed).State
+	}
+	current, _ := stateManager.GetState(sessionID)
+	got, _ := json.Marshal(replayed)
+	want, _ := json.Marshal(current)
+	if string(got) != string(want) {
+		t.Errorf("Expected replay to match the session\ngot:  %s\nwant: %s", got, want)
+	}
+
+	if resp, _ := app.Test(httptest.NewRequest("GET", "/sessions/missing/actions", nil)); resp.StatusCode != 404 {
+		t.Errorf("Expected 404 for an unknown session, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// adminTokenHeader carries the token for admin endpoints
+const adminTokenHeader = "X-Admin-Token"
+
+// adminToken unlocks the admin endpoints. They don't exist while it's empty,
+// so a server only offers them when ADMIN_TOKEN is set.
+var adminToken string
+
+// requireAdmin only lets requests presenting the admin token through
+func requireAdmin(c *fiber.Ctx) error {
+	if adminToken == "" {
+		return c.Status(404).JSON(fiber.Map{"error": "Admin endpoints are disabled; set ADMIN_TOKEN to enable them"})
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Get(adminTokenHeader)), []byte(adminToken)) != 1 {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid admin token"})
+	}
+	return c.Next()
+}
+
+// handleSetState replaces a session's state outright, so QA and support can
+// put a game into the exact position needed to reproduce a bug. The state is
+// snapshotted like a round end and the session's undo history is dropped.
+func handleSetState(c *fiber.Ctx) error {
+	// Params point into fasthttp's reused buffer, and storing the state
+	// rewrites the map key, so keep a copy that outlives the request
+	sessionID := strings.Clone(c.Params("sessionId"))
+
+	unlock := sessionLocks.Lock(sessionID)
+	defer unlock()
+	if _, exists := stateManager.GetState(sessionID); !exists {
+		return c.Status(404).JSON(fiber.Map{"error": "Session not found"})
+	}
+
+	var req struct {
+		State State `json:"state"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := validateStatePayload(req.State); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := ValidateState(req.State); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid state: " + err.Error()})
+	}
+
+	logger := sessionLogger(sessionID)
+	stateManager.SetState(sessionID, req.State)
+	stateManager.ClearUndo(sessionID)
+	if err := eventStore.SaveSnapshot(sessionID, req.State.Round, req.State); err != nil {
+		logger.Error("Failed to save snapshot", "error", err)
+	}
+	turnTimers.Reset(sessionID, req.State)
+	eventBus.Publish(sessionID, req.State, []Event{})
+
+	logger.Warn("Session state set by admin", "round", req.State.Round, "characters", len(req.State.Characters))
+
+	return c.JSON(fiber.Map{
+		"success":   true,
+		"sessionId": sessionID,
+		"state":     req.State,
+	})
+}
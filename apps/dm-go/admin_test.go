@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAdminSetState(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	sessionAuth = NewSessionAuth()
+	defer func() { adminToken = "" }()
+	app := fiber.New()
+	setupRoutes(app)
+
+	hero := createTestCharacter(true, "Hero")
+	goblin := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+	stateManager.SetState("admin-session", state)
+
+	// A goblin on its last hit point, on the hero's turn in round 7
+	contrived := state
+	contrived.Characters = append([]Character(nil), state.Characters...)
+	contrived.Characters[1].Stats.HP = 1
+	contrived.Round = 7
+	contrived.TurnOrder = []ID{hero.ID, goblin.ID}
+	contrived.CurrentTurn = 0
+	body, _ := json.Marshal(fiber.Map{"state": contrived})
+
+	put := func(path, token string, body []byte) int {
+		req := httptest.NewRequest("PUT", path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set(adminTokenHeader, token)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp.StatusCode
+	}
+
+	if status := put("/sessions/admin-session/state", "anything", body); status != 404 {
+		t.Errorf("Expected the endpoint to be disabled without ADMIN_TOKEN, got %d", status)
+	}
+
+	adminToken = "secret"
+	if status := put("/sessions/admin-session/state", "", body); status != 401 {
+		t.Errorf("Expected 401 without the admin token, got %d", status)
+	}
+	if status := put("/sessions/admin-session/state", "wrong", body); status != 401 {
+		t.Errorf("Expected 401 with the wrong admin token, got %d", status)
+	}
+	if status := put("/sessions/missing/state", "secret", body); status != 404 {
+		t.Errorf("Expected 404 for an unknown session, got %d", status)
+	}
+
+	broken := contrived
+	broken.CurrentTurn = 5
+	brokenBody, _ := json.Marshal(fiber.Map{"state": broken})
+	if status := put("/sessions/admin-session/state", "secret", brokenBody); status != 400 {
+		t.Errorf("Expected an invalid state to be rejected, got %d", status)
+	}
+
+	if status := put("/sessions/admin-session/state", "secret", body); status != 200 {
+		t.Fatalf("Expected the state to be set, got %d", status)
+	}
+	if snapshot, err := eventStore.GetLatestSnapshot("admin-session"); err != nil || snapshot == nil || snapshot.Round != 7 {
+		t.Errorf("Expected the forced state to be snapshotted, got %v %v", snapshot, err)
+	}
+
+	// Actions now play out from the contrived state
+	SetDamageResolver(func(attacker, target *Character, weapon *Weapon, rng *SeededRNG) (int, bool) {
+		return 5, true
+	})
+	defer SetDamageResolver(nil)
+	if status, resp := postJSON(t, app, "/game/admin-session/action", []byte(`{"action":"attack"}`)); status != 200 {
+		t.Fatalf("Expected the attack to apply, got %d: %s", status, resp)
+	}
+	after, _ := stateManager.GetState("admin-session")
+	if after.Round != 7 || !after.IsComplete || GetCharacterByID(after, goblin.ID).Stats.HP != 0 {
+		t.Errorf("Expected the hero to finish off the 1 HP goblin in round 7, got round %d, complete %v", after.Round, after.IsComplete)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+// Enemy AI difficulty tiers, set per session in HouseRules.AIDifficulty
+const (
+	aiEasy   = "easy"   // Sometimes blunders into a random valid action
+	aiNormal = "normal" // The threat-driven heuristic; also used when unset
+	aiHard   = "hard"   // Goes for kills, weighs ready abilities and heals when hurt
+)
+
+// easyBlunderChance is the percent chance an easy enemy ignores the heuristic
+const easyBlunderChance = 35
+
+// hardHealThreshold is the HP percentage below which a hard enemy heals itself
+const hardHealThreshold = 30
+
+// aiDifficultyKnown reports whether a session's AI difficulty names a tier
+func aiDifficultyKnown(difficulty string) bool {
+	return difficulty == "" || difficulty == aiEasy || difficulty == aiNormal || difficulty == aiHard
+}
+
+// aiRNG seeds the AI's choices from the session seed and whose turn it is, so
+// the same state always gets the same decision
+func aiRNG(state State) *SeededRNG {
+	return turnRNG(state, "ai")
+}
+
+// easyEnemyAction blunders into a random valid action easyBlunderChance
+// percent of the time. It never flees or concedes, which would only cut the
+// fight short, and returns false to leave the turn to the heuristic.
+func easyEnemyAction(state State, enemy *Character) (Action, bool) {
+	if current := GetCurrentCharacter(state); current == nil || current.ID != enemy.ID {
+		return Action{}, false
+	}
+	rng := aiRNG(state)
+	if rng.RollD100() > easyBlunderChance {
+		return Action{}, false
+	}
+	action, ok := RandomValidAction(state, rng)
+	if !ok || action.Kind == "Flee" || action.Kind == "Concede" {
+		return Action{}, false
+	}
+	return action, true
+}
+
+// aiOption is an attack or damaging ability the AI could use on a target
+type aiOption struct {
+	action Action
+	target *Character
+	damage int // Expected damage, using the d6's average
+}
+
+// hardEnemyAction heals when badly hurt, then finishes off any opponent it
+// expects to defeat this turn, picking the highest-threat one and the first
+// weapon or ability that does the job. Otherwise it hits the normal
+// heuristic's target with whichever ready ability or weapon does the most
+// damage, saving cooldowns when the weapon does as well. It returns false
+// when there is nothing to hit.
+func hardEnemyAction(state State, enemy *Character) (Action, bool) {
+	if enemy.Stats.HP*100 <= enemy.Stats.MaxHP*hardHealThreshold {
+		for _, ability := range enemy.Abilities {
+			if ability.Effect == "heal" && !triggersOnItsOwn(ability) && RemainingCooldown(enemy, ability.ID) == 0 {
+				return Action{Kind: "Ability", Actor: enemy.ID, Ability: ability.ID, Target: enemy.ID}, true
+			}
+		}
+	}
+
+	options := hardEnemyOptions(state, enemy)
+	if len(options) == 0 {
+		return Action{}, false
+	}
+
+	finishable := func(char *Character) bool {
+		for _, option := range options {
+			if option.target.ID == char.ID && option.damage >= char.Stats.HP {
+				return true
+			}
+		}
+		return false
+	}
+	reachable := func(char *Character) bool {
+		for _, option := range options {
+			if option.target.ID == char.ID {
+				return true
+			}
+		}
+		return false
+	}
+	// Finish with the first option that kills, keeping stronger abilities ready
+	if target := pickAttackTargetWhere(state, enemy, finishable); target != nil {
+		for _, option := range options {
+			if option.target.ID == target.ID && option.damage >= target.Stats.HP {
+				return option.action, true
+			}
+		}
+	}
+
+	target := pickAttackTargetWhere(state, enemy, reachable)
+	if target == nil {
+		return Action{}, false
+	}
+	var best *aiOption
+	for i, option := range options {
+		if option.target.ID == target.ID && (best == nil || option.damage > best.damage) {
+			best = &options[i]
+		}
+	}
+	return best.action, true
+}
+
+// hardEnemyOptions lists every weapon attack and ready damaging ability the
+// enemy could use on an opponent this turn, with the damage each is expected
+// to deal. Weapons come first so a tie keeps abilities off cooldown. Allies
+// are left out even under friendly fire, as the AI never picks them.
+func hardEnemyOptions(state State, enemy *Character) []aiOption {
+	var options []aiOption
+	minDamage := minimumDamage(state.Rules)
+	expected := func(base int, target *Character, ignoresDefense bool) int {
+		damage := base + 3
+		if !ignoresDefense {
+			damage -= target.Stats.Defense
+		}
+		if damage < minDamage {
+			damage = minDamage
+		}
+		return damage
+	}
+
+	for i := range state.Characters {
+		target := &state.Characters[i]
+		if !isActive(*target) || CharacterTeam(*target) == CharacterTeam(*enemy) {
+			continue
+		}
+		if len(enemy.Weapons) == 0 {
+			options = append(options, aiOption{
+				action: Action{Kind: "Attack", Attacker: enemy.ID, Target: target.ID},
+				target: target,
+				damage: expected(1+scalingBonus(enemy, ""), target, false),
+			})
+		}
+		for _, weapon := range enemy.Weapons {
+			if isRangedWeapon(weapon) && !HasLineOfSight(state, enemy.Position, target.Position) {
+				continue
+			}
+			options = append(options, aiOption{
+				action: Action{Kind: "Attack", Attacker: enemy.ID, Target: target.ID, Weapon: weapon.ID},
+				target: target,
+				damage: expected(weapon.Damage+scalingBonus(enemy, weapon.Scaling), target, weapon.IgnoresDefense),
+			})
+		}
+	}
+
+	for _, ability := range enemy.Abilities {
+		if ability.Effect != "damage" || triggersOnItsOwn(ability) || RemainingCooldown(enemy, ability.ID) > 0 {
+			continue
+		}
+		power := ability.Power
+		if ability.Scaling != "" {
+			power += scalingBonus(enemy, ability.Scaling)
+		}
+		for _, id := range AbilityTargets(state, *enemy, ability) {
+			target := GetCharacterByID(state, id)
+			if CharacterTeam(*target) == CharacterTeam(*enemy) {
+				continue
+			}
+			options = append(options, aiOption{
+				action: Action{Kind: "Ability", Actor: enemy.ID, Ability: ability.ID, Target: id},
+				target: target,
+				damage: expected(power, target, !abilityReducedByDefense(state.Rules, ability)),
+			})
+		}
+	}
+	return options
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// aiDuelState has a goblin to move against a taunting knight and a nearly
+// dead mage
+func aiDuelState(difficulty string, seed int64) (State, *Character) {
+	knight := createTestCharacter(true, "Knight")
+	mage := createTestCharacter(true, "Mage")
+	mage.Stats.HP = 4
+	goblin := createTestCharacter(false, "Goblin")
+	goblin.Abilities[0] = Ability{ID: NewID(), Name: "Fire Bomb", Effect: "damage", Power: 20, Cooldown: 3}
+
+	state := CreateInitialState([]Character{knight, mage}, []Character{goblin}, seed)
+	state.TurnOrder = []ID{goblin.ID, knight.ID, mage.ID}
+	state.Threat = map[ID]int{knight.ID: 30}
+	state.Rules.AIDifficulty = difficulty
+	return state, GetCharacterByID(state, goblin.ID)
+}
+
+func TestAIDifficultyTiers(t *testing.T) {
+	state, goblin := aiDuelState(aiNormal, 1)
+	knight, mage := state.Characters[0], state.Characters[1]
+	if normal := heuristicEnemyAction(state, goblin); normal.Kind != "Attack" || normal.Target != knight.ID {
+		t.Errorf("Expected the normal AI to attack the taunting knight, got %+v", normal)
+	}
+
+	// Hard goes for the kill, and uses the bomb on the knight once nobody is finishable
+	state.Rules.AIDifficulty = aiHard
+	if hard := heuristicEnemyAction(state, goblin); hard.Kind != "Attack" || hard.Target != mage.ID {
+		t.Errorf("Expected the hard AI to finish off the mage with its weapon, got %+v", hard)
+	}
+	state.Characters[1].Stats.HP = 30
+	if hard := heuristicEnemyAction(state, goblin); hard.Kind != "Ability" || hard.Target != knight.ID {
+		t.Errorf("Expected the hard AI to bomb the knight, got %+v", hard)
+	}
+	goblin.AbilityCooldowns[string(goblin.Abilities[0].ID)] = 2
+	if hard := heuristicEnemyAction(state, goblin); hard.Kind != "Attack" || hard.Target != knight.ID {
+		t.Errorf("Expected the hard AI to fall back to its weapon while the bomb cools down, got %+v", hard)
+	}
+
+	// Easy blunders on some seeds, always the same way for the same state
+	blunders := 0
+	for seed := int64(1); seed <= 20; seed++ {
+		state, goblin := aiDuelState(aiEasy, seed)
+		easy := heuristicEnemyAction(state, goblin)
+		if !reflect.DeepEqual(easy, heuristicEnemyAction(state, goblin)) {
+			t.Fatalf("Expected the easy AI to be deterministic for seed %d", seed)
+		}
+		if easy.Kind == "Flee" || easy.Kind == "Concede" {
+			t.Errorf("Expected the easy AI never to give up, got %+v", easy)
+		}
+		state.Rules.AIDifficulty = aiNormal
+		if !reflect.DeepEqual(easy, heuristicEnemyAction(state, goblin)) {
+			blunders++
+			if resolution := ApplyAction(state, easy, seed); resolution.RejectReason != "" {
+				t.Errorf("Expected the easy AI's blunder %+v to be valid, got %v", easy, resolution.Logs)
+			}
+		}
+	}
+	if blunders == 0 || blunders == 20 {
+		t.Errorf("Expected the easy AI to blunder on some seeds but not all, got %d of 20", blunders)
+	}
+}
+
+func TestHardAIHealsWhenHurt(t *testing.T) {
+	state, goblin := aiDuelState(aiHard, 1)
+	goblin.Abilities = append(goblin.Abilities, Ability{ID: NewID(), Name: "Mend", Effect: "heal", Power: 10, Cooldown: 2})
+	goblin.Stats.HP = 8
+
+	action := heuristicEnemyAction(state, goblin)
+	if action.Kind != "Ability" || action.Ability != goblin.Abilities[1].ID || action.Target != goblin.ID {
+		t.Fatalf("Expected the hurt goblin to heal itself, got %+v", action)
+	}
+	if resolution := ApplyAction(state, action, 1); GetCharacterByID(resolution.State, goblin.ID).Stats.HP <= 8 {
+		t.Errorf("Expected the heal to apply, got %v", resolution.Logs)
+	}
+}
+
+func TestHardAIIgnoresAlliesUnderFriendlyFire(t *testing.T) {
+	knight := createTestCharacter(true, "Knight")
+	knight.Position = Position{X: 4, Y: 0}
+	archer := createTestCharacter(false, "Archer")
+	archer.Weapons[0].Name = "Short Bow"
+	archer.Abilities = nil
+	orc := createTestCharacter(false, "Orc")
+	orc.Position = Position{X: 0, Y: 2}
+
+	state := CreateInitialState([]Character{knight}, []Character{archer, orc}, 1)
+	state.TurnOrder = []ID{archer.ID, knight.ID, orc.ID}
+	state.Obstacles = []Position{{X: 2, Y: 0}}
+	state.Rules.FriendlyFire = true
+	state.Rules.AIDifficulty = aiHard
+
+	if action, ok := hardEnemyAction(state, GetCharacterByID(state, archer.ID)); ok {
+		t.Errorf("Expected the hard AI to find no opponent in sight, got %+v", action)
+	}
+	if action := heuristicEnemyAction(state, GetCharacterByID(state, archer.ID)); action.Kind != "Defend" {
+		t.Errorf("Expected the archer to defend rather than shoot its ally, got %+v", action)
+	}
+}
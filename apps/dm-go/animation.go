@@ -0,0 +1,64 @@
+package main
+
+import "strings"
+
+// Animation hints attached to events so the frontend can play an effect from
+// the source position to the target position
+
+// weaponEffect picks the animation for a weapon attack from the weapon's name
+func weaponEffect(weapon Weapon) string {
+	name := strings.ToLower(weapon.Name)
+	switch {
+	case containsAny(name, "bow", "arrow", "crossbow", "sling"):
+		return "arrow"
+	case containsAny(name, "dagger", "knife", "spear", "rapier"):
+		return "stab"
+	case containsAny(name, "hammer", "mace", "club", "bash", "fist", "shield"):
+		return "bash"
+	case containsAny(name, "staff", "wand"):
+		return "magic"
+	default:
+		return "slash"
+	}
+}
+
+// abilityEffect picks the animation for an ability from its name, falling back to its effect type
+func abilityEffect(ability Ability) string {
+	name := strings.ToLower(ability.Name)
+	switch {
+	case containsAny(name, "fire", "flame", "burn"):
+		return "fireball"
+	case containsAny(name, "ice", "frost"):
+		return "frost"
+	case containsAny(name, "lightning", "shock", "thunder"):
+		return "lightning"
+	}
+
+	if _, ok := parseRegenEffect(ability.Effect); ok {
+		return "regen"
+	}
+	switch ability.Effect {
+	case "heal", "buff", "debuff", "taunt":
+		return ability.Effect
+	default:
+		return "strike"
+	}
+}
+
+// positionOf returns a copy of the character's position for an event
+func positionOf(char *Character) *Position {
+	if char == nil {
+		return nil
+	}
+	pos := char.Position
+	return &pos
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, substr := range substrs {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+	return false
+}
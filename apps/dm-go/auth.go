@@ -0,0 +1,185 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Header and cookie used to present a session token
+const (
+	playerTokenHeader = "X-Player-Token"
+	playerTokenCookie = "player_token"
+)
+
+// SessionAuth tracks which characters each session token may act for
+type SessionAuth struct {
+	mu     sync.RWMutex
+	tokens map[string]map[string][]ID // sessionID -> token -> owned character IDs
+	gm     map[string]string          // sessionID -> DM token, which may also use the GM controls
+	store  SessionTokenStore          // Keeps tokens across restarts; nil holds them in memory only
+}
+
+// SessionToken is an issued token and the characters it may act for
+type SessionToken struct {
+	Token        string `json:"token"`
+	CharacterIDs []ID   `json:"characterIds"`
+	GM           bool   `json:"gm,omitempty"` // The session's DM token
+}
+
+// SessionTokenStore persists the tokens issued for each session
+type SessionTokenStore interface {
+	SaveSessionToken(sessionID string, token SessionToken) error
+	GetSessionTokens(sessionID string) ([]SessionToken, error)
+}
+
+// NewSessionAuth creates a new session auth store that keeps tokens in memory
+func NewSessionAuth() *SessionAuth {
+	return &SessionAuth{
+		tokens: make(map[string]map[string][]ID),
+		gm:     make(map[string]string),
+	}
+}
+
+// NewPersistentSessionAuth creates a session auth store that saves issued
+// tokens to the store and loads a session's tokens from it on first use, so
+// sessions stay protected after a restart
+func NewPersistentSessionAuth(store SessionTokenStore) *SessionAuth {
+	sa := NewSessionAuth()
+	sa.store = store
+	return sa
+}
+
+// IssueToken creates a token for a session that owns the given characters
+func (sa *SessionAuth) IssueToken(sessionID string, characterIDs []ID) string {
+	return sa.issue(sessionID, characterIDs, false)
+}
+
+// IssueGMToken issues the session's DM token, which owns the given characters
+// and is the only token allowed to use the GM controls
+func (sa *SessionAuth) IssueGMToken(sessionID string, characterIDs []ID) string {
+	return sa.issue(sessionID, characterIDs, true)
+}
+
+func (sa *SessionAuth) issue(sessionID string, characterIDs []ID, gm bool) string {
+	token := uuid.New().String()
+	if sa.store != nil {
+		stored := SessionToken{Token: token, CharacterIDs: characterIDs, GM: gm}
+		if err := sa.store.SaveSessionToken(sessionID, stored); err != nil {
+			sessionLogger(sessionID).Error("Failed to save session token", "error", err)
+		}
+	}
+
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	if sa.tokens[sessionID] == nil {
+		sa.tokens[sessionID] = make(map[string][]ID)
+	}
+	sa.tokens[sessionID][token] = characterIDs
+	if gm {
+		sa.gm[sessionID] = token
+	}
+	return token
+}
+
+// load reads a session's tokens from the store the first time they're needed.
+// Sessions the store has no tokens for are remembered as unprotected.
+func (sa *SessionAuth) load(sessionID string) error {
+	if sa.store == nil {
+		return nil
+	}
+	sa.mu.RLock()
+	_, cached := sa.tokens[sessionID]
+	sa.mu.RUnlock()
+	if cached {
+		return nil
+	}
+
+	stored, err := sa.store.GetSessionTokens(sessionID)
+	if err != nil {
+		return err
+	}
+
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	if _, cached := sa.tokens[sessionID]; cached {
+		return nil
+	}
+	sessionTokens := make(map[string][]ID, len(stored))
+	for _, token := range stored {
+		sessionTokens[token.Token] = token.CharacterIDs
+		if token.GM {
+			sa.gm[sessionID] = token.Token
+		}
+	}
+	sa.tokens[sessionID] = sessionTokens
+	return nil
+}
+
+// Authorize reports whether the token may act for the character. Sessions
+// without issued tokens are unprotected and allow any action.
+func (sa *SessionAuth) Authorize(sessionID, token string, characterID ID) bool {
+	if err := sa.load(sessionID); err != nil {
+		sessionLogger(sessionID).Error("Failed to load session tokens", "error", err)
+		return false
+	}
+	sa.mu.RLock()
+	defer sa.mu.RUnlock()
+	sessionTokens := sa.tokens[sessionID]
+	if len(sessionTokens) == 0 {
+		return true
+	}
+	for _, id := range sessionTokens[token] {
+		if id == characterID {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizeGM reports whether the token is the session's DM token. Like
+// Authorize, sessions without issued tokens are unprotected.
+func (sa *SessionAuth) AuthorizeGM(sessionID, token string) bool {
+	if err := sa.load(sessionID); err != nil {
+		sessionLogger(sessionID).Error("Failed to load session tokens", "error", err)
+		return false
+	}
+	sa.mu.RLock()
+	defer sa.mu.RUnlock()
+	if len(sa.tokens[sessionID]) == 0 {
+		return true
+	}
+	gmToken, exists := sa.gm[sessionID]
+	return exists && token != "" && token == gmToken
+}
+
+// DeleteSession removes all tokens for a session
+func (sa *SessionAuth) DeleteSession(sessionID string) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	delete(sa.tokens, sessionID)
+	delete(sa.gm, sessionID)
+}
+
+// issueSessionTokens issues a player token owning the player characters and a
+// DM token owning the enemies
+func issueSessionTokens(sessionID string, state State) (playerToken, dmToken string) {
+	var playerIDs, enemyIDs []ID
+	for _, char := range state.Characters {
+		if char.IsPlayer {
+			playerIDs = append(playerIDs, char.ID)
+		} else {
+			enemyIDs = append(enemyIDs, char.ID)
+		}
+	}
+	return sessionAuth.IssueToken(sessionID, playerIDs), sessionAuth.IssueGMToken(sessionID, enemyIDs)
+}
+
+// requestToken reads the session token from the request header, falling back to the cookie
+func requestToken(c *fiber.Ctx) string {
+	if token := c.Get(playerTokenHeader); token != "" {
+		return token
+	}
+	return c.Cookies(playerTokenCookie)
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSessionAuthAuthorize(t *testing.T) {
+	sa := NewSessionAuth()
+	hero, ally, goblin := NewID(), NewID(), NewID()
+
+	// Sessions without tokens are open
+	if !sa.Authorize("open-session", "", goblin) {
+		t.Error("Expected session without tokens to allow any action")
+	}
+
+	heroToken := sa.IssueToken("auth-session", []ID{hero})
+	allyToken := sa.IssueToken("auth-session", []ID{ally})
+
+	if !sa.Authorize("auth-session", heroToken, hero) {
+		t.Error("Expected token to act for its own character")
+	}
+	if sa.Authorize("auth-session", heroToken, ally) {
+		t.Error("Token should not act for another player's character")
+	}
+	if sa.Authorize("auth-session", allyToken, goblin) {
+		t.Error("Token should not act for an enemy")
+	}
+	if sa.Authorize("auth-session", "", hero) {
+		t.Error("Missing token should be rejected on a protected session")
+	}
+}
+
+func TestSessionTokensSurviveRestart(t *testing.T) {
+	store, err := NewEventStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+	defer store.Close()
+
+	hero, goblin := NewID(), NewID()
+	before := NewPersistentSessionAuth(store)
+	heroToken := before.IssueToken("saved-session", []ID{hero})
+	gmToken := before.IssueGMToken("saved-session", []ID{goblin})
+
+	after := NewPersistentSessionAuth(store)
+	if after.Authorize("saved-session", "", hero) {
+		t.Error("Expected the reloaded session to stay protected")
+	}
+	if !after.Authorize("saved-session", heroToken, hero) {
+		t.Error("Expected the saved player token to still act for its character")
+	}
+	if after.AuthorizeGM("saved-session", heroToken) || !after.AuthorizeGM("saved-session", gmToken) {
+		t.Error("Expected only the saved DM token to use the GM controls")
+	}
+	if !after.Authorize("other-session", "", hero) {
+		t.Error("Expected a session without saved tokens to stay open")
+	}
+}
+
+func TestActionEndpointsRequireOwnership(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	sessionAuth = NewSessionAuth()
+
+	app := fiber.New()
+	setupRoutes(app)
+
+	player := createTestCharacter(true, "Player")
+	enemy := createTestCharacter(false, "Enemy")
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+	state.TurnOrder = []ID{player.ID, enemy.ID} // Player acts first
+
+	body, _ := json.Marshal(fiber.Map{"sessionId": "owned-session", "state": state})
+	status, resp := postJSON(t, app, "/sessions", body)
+	if status != 200 {
+		t.Fatalf("Failed to create session: %d %s", status, resp)
+	}
+	var created struct {
+		PlayerToken string `json:"playerToken"`
+		DMToken     string `json:"dmToken"`
+	}
+	json.Unmarshal([]byte(resp), &created)
+	if created.PlayerToken == "" || created.DMToken == "" {
+		t.Fatalf("Expected player and DM tokens, got %s", resp)
+	}
+
+	gameAction := func(token string) int {
+		req := httptest.NewRequest("POST", "/game/owned-session/action", strings.NewReader(`{"action":"defend"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set(playerTokenHeader, token)
+		}
+		r, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return r.StatusCode
+	}
+
+	t.Run("RecreateExistingSession", func(t *testing.T) {
+		if status, resp := postJSON(t, app, "/sessions", body); status != 409 {
+			t.Errorf("Expected 409 when recreating a session, got %d %s", status, resp)
+		}
+	})
+
+	t.Run("UnauthorizedGameAction", func(t *testing.T) {
+		if status := gameAction(""); status != 403 {
+			t.Errorf("Expected 403 without token, got %d", status)
+		}
+		if status := gameAction(created.DMToken); status != 403 {
+			t.Errorf("Expected 403 when DM token drives a player, got %d", status)
+		}
+	})
+
+	t.Run("AuthorizedGameAction", func(t *testing.T) {
+		if status := gameAction(created.PlayerToken); status != 200 {
+			t.Errorf("Expected 200 with player token, got %d", status)
+		}
+	})
+
+	t.Run("PlayerTokenCannotDriveEnemy", func(t *testing.T) {
+		current, _ := stateManager.GetState("owned-session")
+		body, _ := json.Marshal(fiber.Map{
+			"state":  current,
+			"action": Action{Kind: "Defend", Actor: enemy.ID},
+			"seed":   1,
+		})
+		req := httptest.NewRequest("POST", "/tools/apply_action", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("session-id", "owned-session")
+		req.Header.Set(playerTokenHeader, created.PlayerToken)
+		r, _ := app.Test(req)
+		if r.StatusCode != 403 {
+			t.Errorf("Expected 403 when player token drives an enemy, got %d", r.StatusCode)
+		}
+
+		req = httptest.NewRequest("POST", "/tools/apply_action", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("session-id", "owned-session")
+		req.Header.Set(playerTokenHeader, created.DMToken)
+		r, _ = app.Test(req)
+		if r.StatusCode != 200 {
+			t.Errorf("Expected 200 when DM token drives an enemy, got %d", r.StatusCode)
+		}
+	})
+
+	t.Run("TamperedStateIgnored", func(t *testing.T) {
+		SetDamageResolver(func(attacker, target *Character, weapon *Weapon, rng *SeededRNG) (int, bool) {
+			return 5, true
+		})
+		defer SetDamageResolver(nil)
+
+		for _, path := range []string{"/tools/apply_action", "/tools/apply_actions"} {
+			live, _ := stateManager.GetState("owned-session")
+			enemyHP := GetCharacterByID(live, enemy.ID).Stats.HP
+
+			// The player's token holder posts a state with the enemy at 1 HP
+			tampered := deepCopyState(live)
+			reindexCharacters(&tampered)
+			GetCharacterByID(tampered, enemy.ID).Stats.HP = 1
+			attack := Action{Kind: "Attack", Attacker: player.ID, Target: enemy.ID, Weapon: player.Weapons[0].ID}
+			payload := fiber.Map{"state": tampered, "action": attack, "seed": 1}
+			if path == "/tools/apply_actions" {
+				payload = fiber.Map{"state": tampered, "actions": []Action{attack}, "seed": 1}
+			}
+			body, _ := json.Marshal(payload)
+			req := httptest.NewRequest("POST", path, strings.NewReader(string(body)))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("session-id", "owned-session")
+			req.Header.Set(playerTokenHeader, created.PlayerToken)
+			if r, _ := app.Test(req); r.StatusCode != 200 {
+				t.Fatalf("%s: expected the attack to be applied, got %d", path, r.StatusCode)
+			}
+
+			// The attack lands on the server's state, not the posted one
+			after, _ := stateManager.GetState("owned-session")
+			if got := GetCharacterByID(after, enemy.ID).Stats.HP; got != enemyHP-5 || after.IsComplete {
+				t.Errorf("%s: expected the enemy to drop from %d to %d HP, got %d", path, enemyHP, enemyHP-5, got)
+			}
+
+			// Hand the turn back to the player for the next endpoint
+			stateManager.SetState("owned-session", ApplyAction(after, Action{Kind: "Defend", Actor: enemy.ID}, 1).State)
+		}
+	})
+}
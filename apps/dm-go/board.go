@@ -0,0 +1,81 @@
+package main
+
+import "fmt"
+
+// Board modes, set per session in HouseRules.BoardMode. The board is the
+// square the game page draws, boardRadius squares either side of (0,0).
+const (
+	boardInfinite = "infinite" // Positions are unbounded; also used when unset
+	boardBounded  = "bounded"  // Characters and obstacles must stay on the board
+	boardWrap     = "wrap"     // As bounded, but opposite edges join up, so sight lines and flanks carry across them
+)
+
+// boardSize is the number of squares along each side of the board
+const boardSize = 2*boardRadius + 1
+
+// boardModeKnown reports whether a session's board mode is one the engine plays
+func boardModeKnown(mode string) bool {
+	return mode == "" || mode == boardInfinite || mode == boardBounded || mode == boardWrap
+}
+
+// boardLimited reports whether positions must stay on the board
+func boardLimited(rules HouseRules) bool {
+	return rules.BoardMode == boardBounded || rules.BoardMode == boardWrap
+}
+
+// onBoard reports whether a position is one of the board's squares
+func onBoard(pos Position) bool {
+	return pos.X >= -boardRadius && pos.X <= boardRadius && pos.Y >= -boardRadius && pos.Y <= boardRadius
+}
+
+// checkBoard returns an error for a board mode the engine doesn't play, or
+// naming the first character or obstacle off a bounded or wrapping board
+func checkBoard(state State) error {
+	if !boardModeKnown(state.Rules.BoardMode) {
+		return fmt.Errorf("unknown board mode %q", state.Rules.BoardMode)
+	}
+	if !boardLimited(state.Rules) {
+		return nil
+	}
+	for _, char := range state.Characters {
+		if !onBoard(char.Position) {
+			return fmt.Errorf("%s at (%d, %d) is off the %s board", char.Name, char.Position.X, char.Position.Y, state.Rules.BoardMode)
+		}
+	}
+	for _, obstacle := range state.Obstacles {
+		if !onBoard(obstacle) {
+			return fmt.Errorf("obstacle at (%d, %d) is off the %s board", obstacle.X, obstacle.Y, state.Rules.BoardMode)
+		}
+	}
+	return nil
+}
+
+// wrapCoord brings a coordinate back into the board's range,
+// -boardRadius to boardRadius. Applied to the difference between two
+// coordinates, it gives the shorter way round a wrapping board.
+func wrapCoord(n int) int {
+	return ((n+boardRadius)%boardSize+boardSize)%boardSize - boardRadius
+}
+
+// wrapPosition brings a position back onto a wrapping board
+func wrapPosition(pos Position) Position {
+	return Position{X: wrapCoord(pos.X), Y: wrapCoord(pos.Y)}
+}
+
+// boardOffset returns how far to is from from along each axis. On a wrapping
+// board it takes the shorter way, which may cross an edge.
+func boardOffset(rules HouseRules, from, to Position) (dx, dy int) {
+	dx, dy = to.X-from.X, to.Y-from.Y
+	if rules.BoardMode == boardWrap {
+		dx, dy = wrapCoord(dx), wrapCoord(dy)
+	}
+	return dx, dy
+}
+
+// sameSquare reports whether two positions are the same square of the board
+func sameSquare(rules HouseRules, a, b Position) bool {
+	if rules.BoardMode == boardWrap {
+		return wrapPosition(a) == wrapPosition(b)
+	}
+	return a == b
+}
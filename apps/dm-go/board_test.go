@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBoundedBoardRejectsOffBoardPositions(t *testing.T) {
+	hero := createTestCharacter(true, "Hero")
+	hero.Position = Position{X: 3, Y: 0}
+	goblin := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+	state.TurnOrder = []ID{hero.ID, goblin.ID}
+
+	if err := ValidateState(state); err != nil {
+		t.Fatalf("Expected an unbounded board to allow any position, got %v", err)
+	}
+	state.Rules.BoardMode = boardBounded
+	resolution := ApplyAction(state, Action{Kind: "Defend", Actor: hero.ID}, 1)
+	if resolution.RejectReason != RejectInvalidState {
+		t.Errorf("Expected a character off the bounded board to be rejected, got %q", resolution.RejectReason)
+	}
+
+	state.Characters[0].Position = Position{X: 2, Y: 0}
+	state.Obstacles = []Position{{X: 0, Y: -3}}
+	if err := ValidateState(state); err == nil || !strings.Contains(err.Error(), "obstacle") {
+		t.Errorf("Expected an obstacle off the board to be rejected, got %v", err)
+	}
+	state.Obstacles = nil
+	state.Rules.BoardMode = "wrapp"
+	if err := ValidateState(state); err == nil || !strings.Contains(err.Error(), "unknown board mode") {
+		t.Errorf("Expected a state with an unknown board mode to be rejected, got %v", err)
+	}
+
+	scenario := `
+name: Edge
+rules:
+  boardMode: wrap
+players:
+  - name: Hero
+    position: {x: 0, y: 5}
+    stats: {hp: 10, maxHp: 10, attack: 5, defense: 1, speed: 3}
+`
+	if _, err := parseScenario([]byte(scenario)); err == nil || !strings.Contains(err.Error(), "off the wrap board") {
+		t.Errorf("Expected a scenario character off the board to be rejected, got %v", err)
+	}
+	if _, err := parseScenario([]byte(strings.Replace(scenario, "wrap", "donut", 1))); err == nil || !strings.Contains(err.Error(), "unknown board mode") {
+		t.Errorf("Expected an unknown board mode to be rejected, got %v", err)
+	}
+}
+
+func TestWrappingLineOfSight(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		obstacle Position
+		from, to Position
+		want     bool
+	}{
+		{"AcrossTheEdge", boardWrap, Position{X: 0, Y: 0}, Position{X: -2, Y: 0}, Position{X: 2, Y: 0}, true},
+		{"BlockedAcrossTheEdge", boardWrap, Position{X: 2, Y: 0}, Position{X: -2, Y: 0}, Position{X: 1, Y: 0}, false},
+		{"BoundedStaysOnTheBoard", boardBounded, Position{X: 0, Y: 0}, Position{X: -2, Y: 0}, Position{X: 2, Y: 0}, false},
+		{"BoundedIgnoresTheFarSide", boardBounded, Position{X: 2, Y: 0}, Position{X: -2, Y: 0}, Position{X: 1, Y: 0}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := State{Obstacles: []Position{tt.obstacle}, Rules: HouseRules{BoardMode: tt.mode}}
+			if got := HasLineOfSight(state, tt.from, tt.to); got != tt.want {
+				t.Errorf("HasLineOfSight(%v, %v) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrappingFlank(t *testing.T) {
+	SetDamageResolver(func(attacker, target *Character, weapon *Weapon, rng *SeededRNG) (int, bool) {
+		return 10, true
+	})
+	defer SetDamageResolver(nil)
+
+	attack := func(mode string) int {
+		hero := createTestCharacter(true, "Hero")
+		hero.Position = Position{X: 2, Y: 0}
+		ally := createTestCharacter(true, "Ally")
+		ally.Position = Position{X: -1, Y: 0}
+		orc := createTestCharacter(false, "Orc")
+		orc.Stats.HP, orc.Stats.MaxHP = 100, 100
+		orc.Position = Position{X: -2, Y: 0}
+
+		state := CreateInitialState([]Character{hero, ally}, []Character{orc}, 12345)
+		state.TurnOrder = []ID{hero.ID, ally.ID, orc.ID}
+		state.Rules.FlankingBonus = 3
+		state.Rules.BoardMode = mode
+
+		resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: hero.ID, Target: orc.ID, Weapon: hero.Weapons[0].ID}, 12345)
+		return 100 - GetCharacterByID(resolution.State, orc.ID).Stats.HP
+	}
+
+	if damage := attack(boardWrap); damage != 13 {
+		t.Errorf("Expected the hero and ally to flank across the wrapped edge, got %d damage", damage)
+	}
+	if damage := attack(boardBounded); damage != 10 {
+		t.Errorf("Expected no flank across a bounded edge, got %d damage", damage)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+// characterIndex maps character IDs to their position in State.Characters.
+// The slice stays the source of truth: the index isn't serialized, and a
+// position is only trusted when the character there still has the ID, so a
+// stale or missing index costs a linear scan rather than a wrong answer.
+type characterIndex map[ID]int
+
+// reindexCharacters rebuilds the state's character index. Call it after
+// adding, removing or reordering characters so lookups stay fast; states
+// decoded from JSON have no index until one is built.
+func reindexCharacters(state *State) {
+	index := make(characterIndex, len(state.Characters))
+	for i := range state.Characters {
+		index[state.Characters[i].ID] = i
+	}
+	state.index = index
+}
+
+// characterPosition finds a character's position in state.Characters, or -1
+func characterPosition(state State, id ID) int {
+	if i, ok := state.index[id]; ok && i < len(state.Characters) && state.Characters[i].ID == id {
+		return i
+	}
+	for i := range state.Characters {
+		if state.Characters[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
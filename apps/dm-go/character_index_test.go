@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCharacterIndexConsistency(t *testing.T) {
+	hero := createTestCharacter(true, "Hero")
+	goblin := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+
+	assertIndexed := func(step string) {
+		t.Helper()
+		if len(state.index) != len(state.Characters) {
+			t.Errorf("%s: expected %d indexed characters, got %d", step, len(state.Characters), len(state.index))
+		}
+		for i := range state.Characters {
+			if pos, ok := state.index[state.Characters[i].ID]; !ok || pos != i {
+				t.Errorf("%s: expected %s at position %d, got %d", step, state.Characters[i].Name, i, pos)
+			}
+			if char := GetCharacterByID(state, state.Characters[i].ID); char != &state.Characters[i] {
+				t.Errorf("%s: expected the lookup to return %s in the slice", step, state.Characters[i].Name)
+			}
+		}
+	}
+	assertIndexed("initial state")
+
+	orc := createTestCharacter(false, "Orc")
+	state.Characters = append(state.Characters, orc)
+	reindexCharacters(&state)
+	assertIndexed("after adding")
+
+	state.Characters = append(state.Characters[:0:0], state.Characters[1:]...)
+	reindexCharacters(&state)
+	assertIndexed("after removing")
+	if GetCharacterByID(state, hero.ID) != nil {
+		t.Error("Expected the removed hero not to be found")
+	}
+
+	// Without a reindex lookups fall back to scanning rather than going wrong
+	state.Characters[0], state.Characters[1] = state.Characters[1], state.Characters[0]
+	if char := GetCharacterByID(state, orc.ID); char == nil || char.Name != "Orc" {
+		t.Errorf("Expected a stale index to still find the orc, got %v", char)
+	}
+
+	// Deep copies, which go through JSON, rebuild the index for their own slice
+	original := state
+	state = deepCopyState(original)
+	assertIndexed("after a deep copy")
+	if GetCharacterByID(state, orc.ID) == GetCharacterByID(original, orc.ID) {
+		t.Error("Expected the deep copy's lookups to return its own characters")
+	}
+}
+
+// largeBattle has count characters split between two sides
+func largeBattle(count int) State {
+	var players, enemies []Character
+	for i := 0; i < count; i++ {
+		char := createTestCharacter(i%2 == 0, fmt.Sprintf("Fighter %d", i))
+		if char.IsPlayer {
+			players = append(players, char)
+		} else {
+			enemies = append(enemies, char)
+		}
+	}
+	return CreateInitialState(players, enemies, 12345)
+}
+
+func benchmarkLookups(b *testing.B, state State) {
+	for i := 0; i < b.N; i++ {
+		for _, id := range state.TurnOrder {
+			if GetCharacterByID(state, id) == nil {
+				b.Fatal("Character not found")
+			}
+		}
+	}
+}
+
+func BenchmarkGetCharacterByID(b *testing.B) {
+	for _, count := range []int{10, 100, 500} {
+		state := largeBattle(count)
+		b.Run(fmt.Sprintf("indexed/%d", count), func(b *testing.B) { benchmarkLookups(b, state) })
+
+		unindexed := state
+		unindexed.index = nil
+		b.Run(fmt.Sprintf("scan/%d", count), func(b *testing.B) { benchmarkLookups(b, unindexed) })
+	}
+}
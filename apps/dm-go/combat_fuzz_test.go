@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Rerun a single failing case with: go test -run TestCombatInvariants -args -combat.seed=N
+var combatSeed = flag.Int64("combat.seed", 0, "run the combat invariant test for this seed only")
+
+const (
+	combatFuzzRuns     = 300
+	combatFuzzMaxSteps = 500
+)
+
+func TestCombatInvariants(t *testing.T) {
+	if *combatSeed != 0 {
+		if err := runRandomCombat(*combatSeed); err != nil {
+			t.Fatalf("seed %d: %v", *combatSeed, err)
+		}
+		return
+	}
+	for seed := int64(1); seed <= combatFuzzRuns; seed++ {
+		if err := runRandomCombat(seed); err != nil {
+			t.Fatalf("seed %d: %v (rerun with -args -combat.seed=%d)", seed, err, seed)
+		}
+	}
+}
+
+// runRandomCombat plays a randomly built fight to the end with random valid
+// actions, checking the state's invariants after every step
+func runRandomCombat(seed int64) error {
+	rng := NewSeededRNG(seed)
+	state := randomCombatState(rng, seed)
+	if err := checkCombatInvariants(state); err != nil {
+		return fmt.Errorf("initial state: %w", err)
+	}
+
+	for step := 0; !state.IsComplete; step++ {
+		if step == combatFuzzMaxSteps {
+			return fmt.Errorf("combat still running after %d steps", step)
+		}
+		action, ok := RandomValidAction(state, rng)
+		if !ok {
+			return fmt.Errorf("step %d: no action available in round %d", step, state.Round)
+		}
+
+		resolution := ApplyAction(state, action, int64(rng.RandomInt(0, 1<<30)))
+		if !actionResolved(state, resolution.State) {
+			return fmt.Errorf("step %d: valid %s action %+v was rejected: %s", step, action.Kind, action, strings.Join(resolution.Logs, "; "))
+		}
+		state = resolution.State
+		if err := checkCombatInvariants(state); err != nil {
+			return fmt.Errorf("step %d after %s: %w", step, action.Kind, err)
+		}
+	}
+	return nil
+}
+
+func checkCombatInvariants(state State) error {
+	if err := ValidateState(state); err != nil {
+		return err
+	}
+	for _, char := range state.Characters {
+		if char.Stats.HP < 0 || char.Stats.HP > char.Stats.MaxHP {
+			return fmt.Errorf("%s has %d/%d HP", char.Name, char.Stats.HP, char.Stats.MaxHP)
+		}
+		if char.MaxItems > 0 && len(char.Items) > char.MaxItems {
+			return fmt.Errorf("%s carries %d items, over the limit of %d", char.Name, len(char.Items), char.MaxItems)
+		}
+		for ability, cooldown := range char.AbilityCooldowns {
+			if cooldown < 0 {
+				return fmt.Errorf("%s has negative cooldown %d on %s", char.Name, cooldown, ability)
+			}
+		}
+	}
+	if !state.IsComplete {
+		if current := GetCurrentCharacter(state); current == nil || !isActive(*current) {
+			return fmt.Errorf("turn %d belongs to a character who can't act", state.CurrentTurn)
+		}
+	}
+	return nil
+}
+
+// randomCombatState builds a small fight with random stats, gear, positions
+// and cover. IDs are derived from the seed so a seed always replays the same.
+func randomCombatState(rng *SeededRNG, seed int64) State {
+	weapons := []string{"Sword", "Longbow", "Dagger", "Club"}
+	abilities := []Ability{
+		{Name: "Fireball", Effect: "damage", Power: 8, Cooldown: 3},
+		{Name: "Mend", Effect: "heal", Power: 10, Cooldown: 2},
+		{Name: "Renew", Effect: "regen 3 for 2 turns", Cooldown: 4},
+		{Name: "Shield Taunt", Effect: "taunt", Power: 5, Cooldown: 2},
+		{Name: "Thorns", Effect: passiveReflect, Power: 2, Passive: true, Trigger: triggerHit},
+		{Name: "Riposte", Effect: passiveCounter, Passive: true, Trigger: triggerMissed},
+	}
+
+	team := func(isPlayer bool, prefix string) []Character {
+		chars := make([]Character, rng.RandomInt(1, 3))
+		for i := range chars {
+			char := createTestCharacter(isPlayer, fmt.Sprintf("%s%d", prefix, i))
+			char.ID = ID(fmt.Sprintf("%d-%s%d", seed, prefix, i))
+			char.Stats = Stat{
+				HP:      rng.RandomInt(10, 40),
+				Attack:  rng.RandomInt(5, 20),
+				Defense: rng.RandomInt(0, 6),
+				Speed:   rng.RandomInt(1, 8),
+			}
+			char.Stats.MaxHP = char.Stats.HP
+			char.Position = Position{X: rng.RandomInt(-boardRadius, boardRadius), Y: rng.RandomInt(-boardRadius, boardRadius)}
+			char.MaxItems = rng.RandomInt(0, 2)
+
+			char.Weapons = []Weapon{{
+				ID:         ID(fmt.Sprintf("%s-weapon", char.ID)),
+				Name:       weapons[rng.RandomInt(0, len(weapons)-1)],
+				Damage:     rng.RandomInt(2, 10),
+				Accuracy:   rng.RandomInt(50, 100),
+				Durability: rng.RandomInt(0, 4),
+			}}
+			ability := abilities[rng.RandomInt(0, len(abilities)-1)]
+			ability.ID = ID(fmt.Sprintf("%s-ability", char.ID))
+			char.Abilities = []Ability{ability}
+			char.Items[0].ID = ID(fmt.Sprintf("%s-item", char.ID))
+			chars[i] = char
+		}
+		return chars
+	}
+
+	state := CreateInitialState(team(true, "player"), team(false, "enemy"), seed)
+	state.Rules.FriendlyFire = rng.RandomInt(0, 1) == 1
+	state.Rules.MaxDefendStacks = rng.RandomInt(1, 3)
+	for i := rng.RandomInt(0, 2); i > 0; i-- {
+		state.Obstacles = append(state.Obstacles, Position{X: rng.RandomInt(-boardRadius, boardRadius), Y: rng.RandomInt(-boardRadius, boardRadius)})
+	}
+	return state
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultCombatLogLines is how many lines State.CombatLog keeps unless configured
+const defaultCombatLogLines = 50
+
+// combatLogLimit caps State.CombatLog, dropping the oldest lines first. Set
+// from COMBAT_LOG_LINES; 0 turns the running log off.
+var combatLogLimit = defaultCombatLogLines
+
+// actorBypassLog opens every resolution's logs. It is engine bookkeeping
+// rather than something that happened in the fight, so the running log skips it.
+const actorBypassLog = "Actor bypass: Direct mutation used"
+
+// appendCombatLog adds an action's log lines to the state's running combat log
+func appendCombatLog(state *State, lines []string) {
+	for _, line := range lines {
+		if line != actorBypassLog {
+			state.CombatLog = append(state.CombatLog, line)
+		}
+	}
+	if len(state.CombatLog) > combatLogLimit {
+		state.CombatLog = append([]string(nil), state.CombatLog[len(state.CombatLog)-combatLogLimit:]...)
+	}
+}
+
+// CombatLogEntry is a single readable line of a session's combat log
+type CombatLogEntry struct {
+	Round int    `json:"round"`
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+}
+
+// BuildCombatLog renders stored events into readable log entries, resolving
+// character, ability and item names against the given state
+func BuildCombatLog(state State, events []Event) []CombatLogEntry {
+	entries := make([]CombatLogEntry, 0, len(events))
+	for _, event := range events {
+		entries = append(entries, CombatLogEntry{
+			Round: event.Round,
+			Type:  event.Type,
+			Text:  FormatEvent(state, event),
+		})
+	}
+	return entries
+}
+
+// FormatEvent describes an event using the same phrasing as Resolution.Logs
+func FormatEvent(state State, event Event) string {
+	switch event.Type {
+	case "damage":
+		if event.Source == gmSource {
+			return fmt.Sprintf("The GM deals %d damage to %s.", event.Amount, characterName(state, event.Target))
+		}
+		if event.Effect == "poison" {
+			return fmt.Sprintf("%s takes %d poison damage!", characterName(state, event.Target), event.Amount)
+		}
+		if event.Source != "" {
+			return fmt.Sprintf("%s attacks %s for %d damage!", characterName(state, event.Source), characterName(state, event.Target), event.Amount)
+		}
+		return fmt.Sprintf("%s takes %d damage!", characterName(state, event.Target), event.Amount)
+	case "miss":
+		return fmt.Sprintf("%s misses %s!", characterName(state, event.Source), characterName(state, event.Target))
+	case "death":
+		return fmt.Sprintf("%s has been defeated!", characterName(state, event.Target))
+	case "downed":
+		return fmt.Sprintf("%s is downed!", characterName(state, event.Target))
+	case "death_save_passed":
+		return fmt.Sprintf("%s passes a death save!", characterName(state, event.Target))
+	case "death_save_failed":
+		return fmt.Sprintf("%s fails a death save!", characterName(state, event.Target))
+	case "stabilized":
+		return fmt.Sprintf("%s has stabilized.", characterName(state, event.Target))
+	case "heal":
+		if event.Source == gmSource {
+			return fmt.Sprintf("The GM heals %s for %d HP.", characterName(state, event.Target), event.Amount)
+		}
+		if event.Effect == "regen" {
+			return fmt.Sprintf("%s regenerates %d HP!", characterName(state, event.Target), event.Amount)
+		}
+		return fmt.Sprintf("%s heals for %d HP!", characterName(state, event.Target), event.Amount)
+	case "ability_used":
+		return fmt.Sprintf("%s uses %s!", characterName(state, event.Actor), abilityName(state, event.Actor, event.Ability))
+	case "item_used":
+		return fmt.Sprintf("%s uses %s!", characterName(state, event.Actor), itemName(state, event.Item, "an item"))
+	case "weapon_broken":
+		return fmt.Sprintf("%s's %s breaks!", characterName(state, event.Actor), itemName(state, event.Item, "weapon"))
+	case "loot_dropped":
+		return fmt.Sprintf("%s drops %s.", characterName(state, event.Actor), itemName(state, event.Item, "an item"))
+	case "item_picked_up":
+		return fmt.Sprintf("%s picks up %s.", characterName(state, event.Actor), itemName(state, event.Item, "an item"))
+	case "item_granted":
+		return fmt.Sprintf("The GM gives %s %s.", characterName(state, event.Target), itemName(state, event.Item, "an item"))
+	case "reaction":
+		if event.Amount > 0 {
+			return fmt.Sprintf("%s reacts with %s, turning aside %d damage!", characterName(state, event.Actor), abilityName(state, event.Actor, event.Ability), event.Amount)
+		}
+		return fmt.Sprintf("%s reacts to %s's attack with %s!", characterName(state, event.Actor), characterName(state, event.Source), abilityName(state, event.Actor, event.Ability))
+	case "turn_delayed":
+		return fmt.Sprintf("%s delays their turn!", characterName(state, event.Actor))
+	case "status_added":
+		return fmt.Sprintf("The GM gives %s %s.", characterName(state, event.Target), event.Effect)
+	case "stats_set":
+		return fmt.Sprintf("The GM sets %s's stats.", characterName(state, event.Target))
+	case "combat_result":
+		if state.Winner != nil && *state.Winner == "draw" {
+			return "No one earns anything from a draw."
+		}
+		return fmt.Sprintf("Rewards: %d XP.", event.Amount)
+	case "flee":
+		return fmt.Sprintf("%s successfully flees from combat!", characterName(state, event.Actor))
+	case "concede":
+		return fmt.Sprintf("%s concedes the fight!", characterName(state, event.Actor))
+	case "action_declared":
+		return fmt.Sprintf("%s declares an action.", characterName(state, event.Actor))
+	case "turn_timeout":
+		return fmt.Sprintf("%s ran out of time!", characterName(state, event.Actor))
+	default:
+		return event.Type
+	}
+}
+
+// FormatCombatLogText renders log entries as a plain text transcript with round markers
+func FormatCombatLogText(entries []CombatLogEntry) string {
+	var text strings.Builder
+	currentRound := 0
+	for _, entry := range entries {
+		if entry.Round != currentRound {
+			if currentRound != 0 {
+				text.WriteString("\n")
+			}
+			text.WriteString(fmt.Sprintf("=== Round %d ===\n", entry.Round))
+			currentRound = entry.Round
+		}
+		text.WriteString(entry.Text + "\n")
+	}
+	return text.String()
+}
+
+func characterName(state State, id ID) string {
+	if char := GetCharacterByID(state, id); char != nil {
+		return char.Name
+	}
+	return "Someone"
+}
+
+func abilityName(state State, actorID, abilityID ID) string {
+	if char := GetCharacterByID(state, actorID); char != nil {
+		for _, ability := range char.Abilities {
+			if ability.ID == abilityID {
+				return ability.Name
+			}
+		}
+	}
+	return "an ability"
+}
+
+// itemName finds a weapon or item by ID on the characters, on the ground or in
+// the combat's loot. Broken weapons and used-up items are gone, so the
+// fallback names them instead.
+func itemName(state State, itemID ID, fallback string) string {
+	for _, char := range state.Characters {
+		for _, weapon := range char.Weapons {
+			if weapon.ID == itemID {
+				return weapon.Name
+			}
+		}
+		for _, item := range char.Items {
+			if item.ID == itemID {
+				return item.Name
+			}
+		}
+	}
+	for _, items := range state.GroundItems {
+		for _, item := range items {
+			if item.ID == itemID {
+				return item.Name
+			}
+		}
+	}
+	if state.Result != nil {
+		for _, item := range state.Result.Loot {
+			if item.ID == itemID {
+				return item.Name
+			}
+		}
+	}
+	return fallback
+}
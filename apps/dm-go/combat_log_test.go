@@ -0,0 +1,243 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPersistedEventsKeepTheRoundTheyHappenedIn(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+
+	player := createTestCharacter(true, "Hero")
+	enemy := createTestCharacter(false, "Goblin")
+	enemy.Stats.HP, enemy.Stats.MaxHP = 200, 200
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+	state.TurnOrder = []ID{enemy.ID, player.ID}
+
+	// The hero acts last, so their attack ends round 1
+	for _, action := range []Action{
+		{Kind: "Defend", Actor: enemy.ID},
+		{Kind: "Attack", Attacker: player.ID, Target: enemy.ID, Weapon: player.Weapons[0].ID},
+	} {
+		resolution := ApplyAction(state, action, 12345)
+		persistResolution("round-session", state, resolution)
+		state = resolution.State
+	}
+	if state.Round != 2 {
+		t.Fatalf("Expected the attack to end the round, got round %d", state.Round)
+	}
+
+	events, _ := eventStore.GetEvents("round-session", 0)
+	attacks := 0
+	for _, event := range events {
+		if event.Source != player.ID {
+			continue
+		}
+		attacks++
+		if event.Round != 1 {
+			t.Errorf("Expected the round-ending attack to be logged in round 1, got %s in round %d", event.Type, event.Round)
+		}
+	}
+	if attacks == 0 {
+		t.Errorf("Expected the attack's events to be stored, got %+v", events)
+	}
+}
+
+func TestBuildCombatLog(t *testing.T) {
+	player := createTestCharacter(true, "Hero")
+	enemy := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+
+	store := NewMemoryEventStore()
+	store.AppendEvents("log-test", 1, []Event{
+		{Type: "damage", Target: enemy.ID, Amount: 7, Source: player.ID},
+	})
+	store.AppendEvents("log-test", 2, []Event{
+		{Type: "damage", Target: enemy.ID, Amount: 30, Source: player.ID},
+		{Type: "death", Target: enemy.ID},
+	})
+
+	events, err := store.GetEvents("log-test", 0)
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+
+	entries := BuildCombatLog(state, events)
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 log entries, got %d", len(entries))
+	}
+
+	if entries[0].Round != 1 || entries[2].Round != 2 {
+		t.Errorf("Expected entries to carry their rounds, got %d and %d", entries[0].Round, entries[2].Round)
+	}
+	if entries[0].Text != "Hero attacks Goblin for 7 damage!" {
+		t.Errorf("Unexpected damage text: %q", entries[0].Text)
+	}
+	if entries[2].Text != "Goblin has been defeated!" {
+		t.Errorf("Unexpected death text: %q", entries[2].Text)
+	}
+
+	text := FormatCombatLogText(entries)
+	for _, expected := range []string{"=== Round 1 ===", "=== Round 2 ===", "Goblin has been defeated!"} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected transcript to contain %q", expected)
+		}
+	}
+}
+
+func TestStateCombatLog(t *testing.T) {
+	defer func(limit int) { combatLogLimit = limit }(combatLogLimit)
+	combatLogLimit = 3
+
+	hero := createTestCharacter(true, "Hero")
+	hero.Weapons[0].Accuracy = 100
+	goblin := createTestCharacter(false, "Goblin")
+	goblin.Stats.HP, goblin.Stats.MaxHP = 100, 100
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+	state.TurnOrder = []ID{hero.ID, goblin.ID}
+
+	SetDamageResolver(func(attacker, target *Character, weapon *Weapon, rng *SeededRNG) (int, bool) {
+		return 5, true
+	})
+	defer SetDamageResolver(nil)
+
+	resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: hero.ID, Target: goblin.ID, Weapon: hero.Weapons[0].ID}, 1)
+	first := resolution.State.CombatLog
+	if len(first) == 0 || first[0] != "Hero attacks Goblin with Test Weapon for 5 damage!" {
+		t.Fatalf("Expected the attack in the combat log, got %q", first)
+	}
+	for _, line := range first {
+		if line == actorBypassLog {
+			t.Error("Expected engine bookkeeping to stay out of the combat log")
+		}
+	}
+
+	resolution = ApplyAction(resolution.State, Action{Kind: "Attack", Attacker: goblin.ID, Target: hero.ID, Weapon: goblin.Weapons[0].ID}, 2)
+	second := resolution.State.CombatLog
+	if len(second) <= len(first) || second[len(second)-1] != "Goblin attacks Hero with Test Weapon for 5 damage!" {
+		t.Fatalf("Expected the log to grow with the second attack, got %q", second)
+	}
+
+	// Keep attacking until the cap kicks in; the newest lines survive
+	state = resolution.State
+	for i := 0; i < 4; i++ {
+		current := GetCurrentCharacter(state)
+		target := goblin.ID
+		if current.ID == goblin.ID {
+			target = hero.ID
+		}
+		state = ApplyAction(state, Action{Kind: "Attack", Attacker: current.ID, Target: target, Weapon: current.Weapons[0].ID}, int64(i)).State
+	}
+	if len(state.CombatLog) != 3 {
+		t.Errorf("Expected the log to be capped at 3 lines, got %d: %q", len(state.CombatLog), state.CombatLog)
+	}
+
+	// The log survives snapshots
+	store := NewMemoryEventStore()
+	store.SaveSnapshot("log-session", state.Round, state)
+	if snapshot, _ := store.GetLatestSnapshot("log-session"); snapshot == nil || len(snapshot.CombatLog) != 3 {
+		t.Errorf("Expected the combat log in the snapshot, got %+v", snapshot)
+	}
+}
+
+// emittedEventTypes collects the Type of every Event literal in the engine's source
+func emittedEventTypes(t *testing.T) map[string]bool {
+	t.Helper()
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatalf("Failed to list source files: %v", err)
+	}
+
+	types := make(map[string]bool)
+	addType := func(lit *ast.CompositeLit) {
+		for _, elt := range lit.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			key, ok := kv.Key.(*ast.Ident)
+			value, isString := kv.Value.(*ast.BasicLit)
+			if ok && key.Name == "Type" && isString && value.Kind == token.STRING {
+				eventType, _ := strconv.Unquote(value.Value)
+				types[eventType] = true
+			}
+		}
+	}
+	isEvent := func(expr ast.Expr) bool {
+		ident, ok := expr.(*ast.Ident)
+		return ok && ident.Name == "Event"
+	}
+
+	fset := token.NewFileSet()
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", name, err)
+		}
+		ast.Inspect(file, func(node ast.Node) bool {
+			lit, ok := node.(*ast.CompositeLit)
+			if !ok {
+				return true
+			}
+			if isEvent(lit.Type) {
+				addType(lit)
+			}
+			// []Event{{Type: ...}} leaves the element type implicit
+			if array, ok := lit.Type.(*ast.ArrayType); ok && isEvent(array.Elt) {
+				for _, elt := range lit.Elts {
+					if inner, ok := elt.(*ast.CompositeLit); ok && inner.Type == nil {
+						addType(inner)
+					}
+				}
+			}
+			return true
+		})
+	}
+	return types
+}
+
+func TestFormatEventCoversEmittedEvents(t *testing.T) {
+	types := emittedEventTypes(t)
+	if !types["damage"] || !types["weapon_broken"] {
+		t.Fatalf("Expected to find the engine's event types, got %v", types)
+	}
+
+	state := CreateInitialState([]Character{createTestCharacter(true, "Hero")}, []Character{createTestCharacter(false, "Goblin")}, 12345)
+	for eventType := range types {
+		if text := FormatEvent(state, Event{Type: eventType}); text == eventType {
+			t.Errorf("Expected FormatEvent to describe %q events, got the bare type", eventType)
+		}
+	}
+}
+
+func TestFormatEventNamesItems(t *testing.T) {
+	hero := createTestCharacter(true, "Hero")
+	hero.Items = []Item{{ID: "potion", Name: "Health Potion", Type: "consumable"}}
+	goblin := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+	state.GroundItems = map[string][]Item{"0,0": {{ID: "dagger", Name: "Rusty Dagger"}}}
+
+	for _, tc := range []struct {
+		event    Event
+		expected string
+	}{
+		{Event{Type: "item_picked_up", Actor: hero.ID, Item: "dagger"}, "Hero picks up Rusty Dagger."},
+		{Event{Type: "item_granted", Target: hero.ID, Item: "potion", Source: gmSource}, "The GM gives Hero Health Potion."},
+		{Event{Type: "weapon_broken", Actor: goblin.ID, Item: "gone"}, "Goblin's weapon breaks!"},
+		{Event{Type: "heal", Target: hero.ID, Amount: 3, Effect: "regen"}, "Hero regenerates 3 HP!"},
+		{Event{Type: "damage", Target: goblin.ID, Amount: 4, Source: gmSource}, "The GM deals 4 damage to Goblin."},
+	} {
+		if text := FormatEvent(state, tc.event); text != tc.expected {
+			t.Errorf("Expected %q for %s, got %q", tc.expected, tc.event.Type, text)
+		}
+	}
+}
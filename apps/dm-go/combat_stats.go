@@ -0,0 +1,99 @@
+package main
+
+import "github.com/gofiber/fiber/v2"
+
+// CharacterStats is one character's line on the post-combat scoreboard
+type CharacterStats struct {
+	Character       ID     `json:"character"`
+	Name            string `json:"name"`
+	Team            string `json:"team"`
+	DamageDealt     int    `json:"damageDealt"`
+	DamageTaken     int    `json:"damageTaken"`
+	HealingReceived int    `json:"healingReceived"`
+	Hits            int    `json:"hits"`   // Attacks and abilities that dealt damage
+	Misses          int    `json:"misses"` // Weapon attacks that missed
+	AbilitiesUsed   int    `json:"abilitiesUsed"`
+	KillingBlows    int    `json:"killingBlows"`
+	Defeated        bool   `json:"defeated"`
+}
+
+// CombatStats aggregates a session's events into a scoreboard. Stats for a
+// session still in progress cover the events so far.
+type CombatStats struct {
+	Round      int              `json:"round"`
+	Complete   bool             `json:"complete"`
+	Winner     *string          `json:"winner,omitempty"`
+	Characters []CharacterStats `json:"characters"`
+}
+
+// BuildCombatStats walks the event log and totals each character's part in
+// the fight. A killing blow goes to whoever last damaged the defeated
+// character; defeats by poison and other sourceless damage credit nobody.
+func BuildCombatStats(state State, events []Event) CombatStats {
+	stats := CombatStats{
+		Round:      state.Round,
+		Complete:   state.IsComplete,
+		Winner:     state.Winner,
+		Characters: make([]CharacterStats, len(state.Characters)),
+	}
+	byID := make(map[ID]*CharacterStats, len(state.Characters))
+	for i, char := range state.Characters {
+		stats.Characters[i] = CharacterStats{Character: char.ID, Name: char.Name, Team: CharacterTeam(char)}
+		byID[char.ID] = &stats.Characters[i]
+	}
+
+	lastDamagedBy := make(map[ID]ID)
+	for _, event := range events {
+		switch event.Type {
+		case "damage":
+			if target := byID[event.Target]; target != nil {
+				target.DamageTaken += event.Amount
+			}
+			if source := byID[event.Source]; source != nil {
+				source.DamageDealt += event.Amount
+				source.Hits++
+			}
+			lastDamagedBy[event.Target] = event.Source
+		case "miss":
+			if source := byID[event.Source]; source != nil {
+				source.Misses++
+			}
+		case "heal":
+			if target := byID[event.Target]; target != nil {
+				target.HealingReceived += event.Amount
+			}
+		case "ability_used":
+			if actor := byID[event.Actor]; actor != nil {
+				actor.AbilitiesUsed++
+			}
+		case "death":
+			if target := byID[event.Target]; target != nil {
+				target.Defeated = true
+			}
+			if killer := byID[lastDamagedBy[event.Target]]; killer != nil {
+				killer.KillingBlows++
+			}
+		}
+	}
+	return stats
+}
+
+func handleGetCombatStats(c *fiber.Ctx) error {
+	sessionID := c.Params("sessionId")
+
+	state, exists := stateManager.GetState(sessionID)
+	if !exists {
+		return c.Status(404).JSON(fiber.Map{"error": "Session not found"})
+	}
+
+	events, err := eventStore.GetEvents(sessionID, 0)
+	if err != nil {
+		sessionLogger(sessionID).Error("Failed to load events", "error", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to load combat stats"})
+	}
+
+	return c.JSON(fiber.Map{
+		"sessionId": sessionID,
+		"stats":     BuildCombatStats(state, events),
+	})
+}
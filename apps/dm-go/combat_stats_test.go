@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCombatStatsEndpoint(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	app := fiber.New()
+	setupRoutes(app)
+
+	hero := createTestCharacter(true, "Hero")
+	hero.Weapons[0].Accuracy = 100
+	hero.Stats.Attack = 25
+	goblin := createTestCharacter(false, "Goblin")
+	goblin.Weapons[0].Accuracy = 100
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+	state.TurnOrder = []ID{hero.ID, goblin.ID}
+	stateManager.SetState("stats-session", state)
+
+	getStats := func() CombatStats {
+		resp, err := app.Test(httptest.NewRequest("GET", "/sessions/stats-session/stats", nil))
+		if err != nil || resp.StatusCode != 200 {
+			t.Fatalf("Expected stats, got %v %v", resp, err)
+		}
+		var body struct {
+			Stats CombatStats `json:"stats"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("Failed to decode stats: %v", err)
+		}
+		return body.Stats
+	}
+
+	// Trade blows until the goblin falls, totalling what the engine reports
+	dealt := map[ID]int{}
+	hits := map[ID]int{}
+	for turn := 0; !state.IsComplete; turn++ {
+		if turn > 20 {
+			t.Fatal("Combat did not end")
+		}
+		attacker, target := hero, goblin
+		if turn%2 == 1 {
+			attacker, target = goblin, hero
+		}
+		resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: attacker.ID, Target: target.ID, Weapon: attacker.Weapons[0].ID}, int64(turn))
+		for _, event := range resolution.Events {
+			if event.Type == "damage" {
+				dealt[event.Source] += event.Amount
+				hits[event.Source]++
+			}
+		}
+		persistResolution("stats-session", state, resolution)
+		state = resolution.State
+
+		if turn == 0 {
+			partial := getStats()
+			if partial.Complete || partial.Characters[0].DamageDealt != dealt[hero.ID] {
+				t.Errorf("Expected partial stats for the first attack, got %+v", partial)
+			}
+		}
+	}
+
+	stats := getStats()
+	if !stats.Complete || stats.Winner == nil || *stats.Winner != "player" {
+		t.Fatalf("Expected a finished fight won by the player, got %+v", stats)
+	}
+	heroStats, goblinStats := stats.Characters[0], stats.Characters[1]
+	if heroStats.DamageDealt != dealt[hero.ID] || goblinStats.DamageTaken != dealt[hero.ID] {
+		t.Errorf("Expected the hero to deal %d damage, got dealt %d taken %d", dealt[hero.ID], heroStats.DamageDealt, goblinStats.DamageTaken)
+	}
+	if goblinStats.DamageDealt != dealt[goblin.ID] || heroStats.DamageTaken != dealt[goblin.ID] {
+		t.Errorf("Expected the goblin to deal %d damage, got dealt %d taken %d", dealt[goblin.ID], goblinStats.DamageDealt, heroStats.DamageTaken)
+	}
+	if heroStats.Hits != hits[hero.ID] || heroStats.KillingBlows != 1 || !goblinStats.Defeated || heroStats.Defeated {
+		t.Errorf("Expected the hero's hits and killing blow, got %+v and %+v", heroStats, goblinStats)
+	}
+
+	resp, _ := app.Test(httptest.NewRequest("GET", "/sessions/missing/stats", nil))
+	if resp.StatusCode != 404 {
+		t.Errorf("Expected 404 for an unknown session, got %d", resp.StatusCode)
+	}
+}
+
+func TestCombatStatsCountsMissesAndAbilities(t *testing.T) {
+	hero := createTestCharacter(true, "Hero")
+	goblin := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+
+	stats := BuildCombatStats(state, []Event{
+		{Type: "miss", Source: hero.ID, Target: goblin.ID},
+		{Type: "ability_used", Actor: goblin.ID},
+		{Type: "damage", Source: goblin.ID, Target: hero.ID, Amount: 9},
+		{Type: "heal", Target: hero.ID, Amount: 4},
+		{Type: "damage", Target: hero.ID, Amount: 30},
+		{Type: "death", Target: hero.ID},
+	})
+
+	heroStats, goblinStats := stats.Characters[0], stats.Characters[1]
+	if heroStats.Misses != 1 || heroStats.HealingReceived != 4 || heroStats.DamageTaken != 39 {
+		t.Errorf("Unexpected hero stats %+v", heroStats)
+	}
+	if goblinStats.AbilitiesUsed != 1 || goblinStats.Hits != 1 {
+		t.Errorf("Unexpected goblin stats %+v", goblinStats)
+	}
+	if goblinStats.KillingBlows != 0 {
+		t.Error("Expected a poison defeat to credit nobody with the killing blow")
+	}
+}
@@ -3,8 +3,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"log"
-	"math"
+	"log/slog"
 	"sort"
 	"strings"
 )
@@ -14,15 +13,28 @@ func CreateInitialState(players, enemies []Character, seed int64) State {
 	rng := NewSeededRNG(seed)
 	allCharacters := append(players, enemies...)
 
-	// Create turn order based on initiative (speed + d20)
+	state := State{
+		Round:       1,
+		Characters:  allCharacters,
+		TurnOrder:   rollInitiative(allCharacters, rng),
+		CurrentTurn: 0,
+		IsComplete:  false,
+		Seed:        seed,
+	}
+	reindexCharacters(&state)
+	return state
+}
+
+// rollInitiative builds a turn order from initiative rolls (speed + initiative bonus + d20)
+func rollInitiative(characters []Character, rng *SeededRNG) []ID {
 	type charWithInit struct {
 		id         ID
 		initiative int
 	}
 
-	initiatives := make([]charWithInit, len(allCharacters))
-	for i, char := range allCharacters {
-		initiative := char.Stats.Speed + rng.RollD20()
+	initiatives := make([]charWithInit, len(characters))
+	for i, char := range characters {
+		initiative := char.Stats.Speed + char.InitiativeBonus + rng.RollD20()
 		initiatives[i] = charWithInit{id: char.ID, initiative: initiative}
 	}
 
@@ -35,38 +47,167 @@ func CreateInitialState(players, enemies []Character, seed int64) State {
 	for i, init := range initiatives {
 		turnOrder[i] = init.id
 	}
+	return turnOrder
+}
 
-	return State{
-		Round:       1,
-		Characters:  allCharacters,
-		TurnOrder:   turnOrder,
-		CurrentTurn: 0,
-		IsComplete:  false,
+// rerollTurnOrder rerolls initiative for everyone still in the turn order,
+// seeded from the session seed and round so replays are deterministic
+func rerollTurnOrder(state *State) {
+	characters := make([]Character, 0, len(state.TurnOrder))
+	for _, id := range state.TurnOrder {
+		if char := GetCharacterByID(*state, id); char != nil {
+			characters = append(characters, *char)
+		}
 	}
+	state.TurnOrder = rollInitiative(characters, NewSeededRNG(state.Seed+int64(state.Round)))
+}
+
+// startSurpriseRound gives the team a round to themselves, in their usual
+// initiative order, before everyone takes turns as normal
+func startSurpriseRound(state *State, team string) {
+	var ambushers []ID
+	for _, id := range state.TurnOrder {
+		if char := GetCharacterByID(*state, id); char != nil && CharacterTeam(*char) == team {
+			ambushers = append(ambushers, id)
+		}
+	}
+	if len(ambushers) == 0 {
+		return
+	}
+	state.NextOrder = state.TurnOrder
+	state.TurnOrder = ambushers
+	state.CurrentTurn = 0
 }
 
 // GetCurrentCharacter returns the character whose turn it is
 func GetCurrentCharacter(state State) *Character {
-	if state.CurrentTurn >= len(state.TurnOrder) {
+	if state.CurrentTurn < 0 || state.CurrentTurn >= len(state.TurnOrder) {
 		return nil
 	}
-	currentID := state.TurnOrder[state.CurrentTurn]
-	for i := range state.Characters {
-		if state.Characters[i].ID == currentID {
-			return &state.Characters[i]
+	return GetCharacterByID(state, state.TurnOrder[state.CurrentTurn])
+}
+
+// GetTurnQueue returns the active characters in upcoming turn order, starting
+// with the current turn and wrapping around to the start of the round
+func GetTurnQueue(state State) []Character {
+	queue := []Character{}
+	n := len(state.TurnOrder)
+	start := state.CurrentTurn
+	if start < 0 || start >= n {
+		start = 0
+	}
+	for i := 0; i < n; i++ {
+		char := GetCharacterByID(state, state.TurnOrder[(start+i)%n])
+		if char != nil && isActive(*char) {
+			queue = append(queue, *char)
 		}
 	}
-	return nil
+	return queue
 }
 
 // GetCharacterByID finds a character by ID
 func GetCharacterByID(state State, id ID) *Character {
+	if i := characterPosition(state, id); i >= 0 {
+		return &state.Characters[i]
+	}
+	return nil
+}
+
+// GetCharacterByName finds a character by name, ignoring case. It errors when
+// no character or more than one character has the name.
+func GetCharacterByName(state State, name string) (*Character, error) {
+	var found *Character
 	for i := range state.Characters {
-		if state.Characters[i].ID == id {
-			return &state.Characters[i]
+		if !strings.EqualFold(state.Characters[i].Name, name) {
+			continue
 		}
+		if found != nil {
+			return nil, fmt.Errorf("character name %q is ambiguous", name)
+		}
+		found = &state.Characters[i]
 	}
-	return nil
+	if found == nil {
+		return nil, fmt.Errorf("character %q not found", name)
+	}
+	return found, nil
+}
+
+// WeaponByName finds one of the character's weapons by name, ignoring case.
+// It returns nil when no weapon or more than one weapon has the name.
+func (c *Character) WeaponByName(name string) *Weapon {
+	var found *Weapon
+	for i := range c.Weapons {
+		if strings.EqualFold(c.Weapons[i].Name, name) {
+			if found != nil {
+				return nil
+			}
+			found = &c.Weapons[i]
+		}
+	}
+	return found
+}
+
+// AbilityByName finds one of the character's abilities by name, ignoring case.
+// It returns nil when no ability or more than one ability has the name.
+func (c *Character) AbilityByName(name string) *Ability {
+	var found *Ability
+	for i := range c.Abilities {
+		if strings.EqualFold(c.Abilities[i].Name, name) {
+			if found != nil {
+				return nil
+			}
+			found = &c.Abilities[i]
+		}
+	}
+	return found
+}
+
+// ResolveActionNames fills in the actor and target IDs from actorName and
+// targetName when the ID fields are empty
+func ResolveActionNames(state State, action Action) (Action, error) {
+	if action.ActorName != "" && getActorID(action) == "" {
+		actor, err := GetCharacterByName(state, action.ActorName)
+		if err != nil {
+			return action, err
+		}
+		if action.Kind == "Attack" {
+			action.Attacker = actor.ID
+		} else {
+			action.Actor = actor.ID
+		}
+	}
+
+	if action.TargetName != "" && action.Target == "" {
+		target, err := GetCharacterByName(state, action.TargetName)
+		if err != nil {
+			return action, err
+		}
+		action.Target = target.ID
+	}
+
+	return action, nil
+}
+
+// RemainingCooldown returns how many turns remain before the ability can be used again
+func RemainingCooldown(char *Character, abilityID ID) int {
+	if char == nil {
+		return 0
+	}
+	return char.AbilityCooldowns[string(abilityID)]
+}
+
+// formatCooldowns lists the character's abilities that are still on cooldown
+func formatCooldowns(char *Character) string {
+	var cooldowns []string
+	for _, ability := range char.Abilities {
+		if remaining := RemainingCooldown(char, ability.ID); remaining > 0 {
+			cooldowns = append(cooldowns, fmt.Sprintf("%s: %d", ability.Name, remaining))
+		}
+	}
+	if len(cooldowns) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" [cooldowns: %s]", strings.Join(cooldowns, ", "))
 }
 
 // GetStateSummary returns a string summary of the state
@@ -80,9 +221,17 @@ func GetStateSummary(state State) string {
 	for _, char := range state.Characters {
 		if char.IsPlayer {
 			status := fmt.Sprintf("%d/%d HP", char.Stats.HP, char.Stats.MaxHP)
-			if char.Stats.HP <= 0 {
+			if isDowned(char) {
+				status = "DOWNED"
+			} else if char.Stats.HP <= 0 {
 				status = "DEFEATED"
+			} else if char.Fled {
+				status = "FLED"
+			}
+			if last, ok := state.LastAction[char.ID]; ok {
+				status += fmt.Sprintf(" (last: %s)", last)
 			}
+			status += formatCooldowns(&char)
 			summary.WriteString(fmt.Sprintf("  %s: %s\n", char.Name, status))
 		}
 	}
@@ -92,9 +241,17 @@ func GetStateSummary(state State) string {
 	for _, char := range state.Characters {
 		if !char.IsPlayer {
 			status := fmt.Sprintf("%d/%d HP", char.Stats.HP, char.Stats.MaxHP)
-			if char.Stats.HP <= 0 {
+			if isDowned(char) {
+				status = "DOWNED"
+			} else if char.Stats.HP <= 0 {
 				status = "DEFEATED"
+			} else if char.Fled {
+				status = "FLED"
+			}
+			if last, ok := state.LastAction[char.ID]; ok {
+				status += fmt.Sprintf(" (last: %s)", last)
 			}
+			status += formatCooldowns(&char)
 			summary.WriteString(fmt.Sprintf("  %s: %s\n", char.Name, status))
 		}
 	}
@@ -117,29 +274,54 @@ func GetStateSummary(state State) string {
 	return summary.String()
 }
 
+// ValidateState checks that a state is self-consistent enough to apply actions to
+func ValidateState(state State) error {
+	if state.CurrentTurn < 0 || state.CurrentTurn >= len(state.TurnOrder) {
+		return fmt.Errorf("current turn %d out of range for %d turn order entries", state.CurrentTurn, len(state.TurnOrder))
+	}
+
+	ids := make(map[ID]bool, len(state.Characters))
+	for _, char := range state.Characters {
+		if ids[char.ID] {
+			return fmt.Errorf("duplicate character ID: %s", char.ID)
+		}
+		ids[char.ID] = true
+
+		if char.AbilityCooldowns == nil {
+			return fmt.Errorf("character %s has no ability cooldowns", char.Name)
+		}
+		if err := statBounds.Check(char.Name, baseStats(char)); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range append(append([]ID(nil), state.TurnOrder...), state.NextOrder...) {
+		if !ids[id] {
+			return fmt.Errorf("turn order references unknown character: %s", id)
+		}
+	}
+
+	return checkBoard(state)
+}
+
 // ApplyAction applies an action to the state and returns the resolution
 func ApplyAction(state State, action Action, seed int64) Resolution {
 	// Stub for actor system: In full impl, send action as message to character actor goroutine
 	// For now, log bypass and proceed with direct (to highlight violation)
-	log.Printf("WARNING: Bypassing actor system for action %s", action.Kind)
-	rng := NewSeededRNG(seed)
+	logger := slog.With("action", action.Kind, "round", state.Round)
+	logger.Debug("Bypassing actor system")
+	rng := actionRNG(state.Rules, seed)
 	events := []Event{}
 	logs := []string{}
-	logs = append(logs, "Actor bypass: Direct mutation used")
+	logs = append(logs, actorBypassLog)
 
-	newState := deepCopyState(state)
-	character := GetCharacterByID(newState, getActorID(action))
-
-	if character == nil {
-		return Resolution{
-			Events: events,
-			State:  state,
-			Logs:   append(logs, "Invalid action: character not found"),
-		}
+	if err := ValidateState(state); err != nil {
+		logger.Warn("Action rejected: inconsistent state", "error", err)
+		return rejectAction(state, events, logs, RejectInvalidState, fmt.Sprintf("Invalid state: %v", err))
 	}
 
 	// Validate action kind
-	validKinds := []string{"Attack", "Defend", "Ability", "UseItem", "Flee"}
+	validKinds := []string{"Attack", "Defend", "Ability", "UseItem", "PickUp", "Flee", "Concede", "Delay"}
 	valid := false
 	for _, k := range validKinds {
 		if action.Kind == k {
@@ -148,50 +330,222 @@ func ApplyAction(state State, action Action, seed int64) Resolution {
 		}
 	}
 	if !valid {
-		return Resolution{
-			Events: events,
-			State:  state,
-			Logs:   append(logs, "Invalid action kind"),
-		}
+		logger.Warn("Action rejected: invalid action kind", "actor", action.Actor)
+		return rejectAction(state, events, logs, RejectUnknownKind, "Invalid action kind")
 	}
 
+	newState := deepCopyState(state)
+	character := GetCharacterByID(newState, getActorID(action))
+
+	if character == nil {
+		logger.Warn("Action rejected: character not found", "actor", getActorID(action))
+		return rejectAction(state, events, logs, RejectActorNotFound, "Invalid action: character not found")
+	}
+
+	var resolution Resolution
+	if simultaneousRounds(newState.Rules) && declaredInAdvance(action.Kind) {
+		resolution = declareAction(&newState, action, seed, events, logs)
+	} else {
+		resolution = dispatchAction(&newState, action, rng, events, logs)
+	}
+
+	if !actionResolved(state, resolution.State) {
+		logger.Warn("Action had no effect", "actor", character.ID, "logs", strings.Join(resolution.Logs, "; "))
+		return resolution
+	}
+
+	recordLastAction(&resolution.State, action)
+	lootEvents, lootLogs := dropLoot(&resolution.State)
+	resolution.Events = append(resolution.Events, lootEvents...)
+	resolution.Logs = append(resolution.Logs, lootLogs...)
+	appendCombatLog(&resolution.State, resolution.Logs)
+	return resolution
+}
+
+// actionRNG is the RNG an action rolls from under the session's rules
+func actionRNG(rules HouseRules, seed int64) *SeededRNG {
+	if rules.RollStreams {
+		return NewStreamedRNG(seed)
+	}
+	return NewSeededRNG(seed)
+}
+
+// dispatchAction resolves an action with the handler for its kind
+func dispatchAction(state *State, action Action, rng *SeededRNG, events []Event, logs []string) Resolution {
 	switch action.Kind {
 	case "Attack":
-		return handleAttack(&newState, action, rng, events, logs)
+		return handleAttack(state, action, rng, events, logs)
 	case "Defend":
-		return handleDefend(&newState, action, rng, events, logs)
+		return handleDefend(state, action, rng, events, logs)
 	case "Ability":
-		return handleAbility(&newState, action, rng, events, logs)
+		return handleAbility(state, action, rng, events, logs)
 	case "UseItem":
-		return handleUseItem(&newState, action, rng, events, logs)
+		return handleUseItem(state, action, rng, events, logs)
+	case "PickUp":
+		return handlePickUp(state, action, rng, events, logs)
 	case "Flee":
-		return handleFlee(&newState, action, rng, events, logs)
+		return handleFlee(state, action, rng, events, logs)
+	case "Concede":
+		return handleConcede(state, action, rng, events, logs)
+	case "Delay":
+		return handleDelay(state, action, rng, events, logs)
 	default:
-		return Resolution{
-			Events: events,
-			State:  state,
-			Logs:   append(logs, "Unknown action kind"),
+		return rejectAction(*state, events, logs, RejectUnknownKind, "Unknown action kind")
+	}
+}
+
+// ApplyActions applies actions in order, threading the state through each one.
+// Action i uses seed+i so a batch replays deterministically. It stops at the
+// first action that is rejected, returning the steps applied so far and an error.
+func ApplyActions(state State, actions []Action, seed int64) ([]Resolution, error) {
+	steps := make([]Resolution, 0, len(actions))
+	for i, action := range actions {
+		if state.IsComplete {
+			return steps, fmt.Errorf("action %d (%s): combat is already complete", i, action.Kind)
+		}
+
+		resolution := ApplyAction(state, action, seed+int64(i))
+		if !actionResolved(state, resolution.State) {
+			return steps, fmt.Errorf("action %d (%s) was rejected: %s", i, action.Kind, strings.Join(resolution.Logs, "; "))
+		}
+
+		steps = append(steps, resolution)
+		state = resolution.State
+	}
+	return steps, nil
+}
+
+// rejectAction refuses an action, leaving the state as it was and explaining why
+func rejectAction(state State, events []Event, logs []string, reason RejectReason, message string) Resolution {
+	return Resolution{Events: events, State: state, Logs: append(logs, message), RejectReason: reason}
+}
+
+// actionResolved reports whether an action actually took effect, i.e. the turn
+// moved on or combat ended
+func actionResolved(before, after State) bool {
+	return after.IsComplete || after.Round != before.Round || after.CurrentTurn != before.CurrentTurn ||
+		len(after.TurnOrder) != len(before.TurnOrder) || turnActor(after) != turnActor(before)
+}
+
+// turnActor is the ID of the character whose turn it is, or "" if there is none
+func turnActor(state State) ID {
+	if state.CurrentTurn < 0 || state.CurrentTurn >= len(state.TurnOrder) {
+		return ""
+	}
+	return state.TurnOrder[state.CurrentTurn]
+}
+
+// recordLastAction remembers what the actor did
+func recordLastAction(after *State, action Action) {
+	description := action.Kind
+	if action.Target != "" {
+		if target := GetCharacterByID(*after, action.Target); target != nil {
+			description = fmt.Sprintf("%s %s", action.Kind, target.Name)
 		}
 	}
+
+	if after.LastAction == nil {
+		after.LastAction = make(map[ID]string)
+	}
+	after.LastAction[getActorID(action)] = description
 }
 
 func getActorID(action Action) ID {
 	switch action.Kind {
 	case "Attack":
 		return action.Attacker
-	case "Defend", "Ability", "UseItem", "Flee":
+	case "Defend", "Ability", "UseItem", "PickUp", "Flee", "Concede", "Delay":
 		return action.Actor
 	default:
 		return ""
 	}
 }
 
+// DamageResolver decides whether an attack hits and how much damage it deals
+type DamageResolver func(attacker, target *Character, weapon *Weapon, rng *SeededRNG) (damage int, hit bool)
+
+// damageResolver is the ruleset used by attacks
+var damageResolver DamageResolver = DefaultDamageResolver
+
+// SetDamageResolver swaps in a custom attack ruleset; nil restores the default
+func SetDamageResolver(resolver DamageResolver) {
+	if resolver == nil {
+		resolver = DefaultDamageResolver
+	}
+	damageResolver = resolver
+}
+
+// Stats a weapon or ability's damage can scale with
+const (
+	scalingAttack = "attack"
+	scalingSpeed  = "speed"
+)
+
+// scalingKnown reports whether a weapon or ability's scaling names a stat
+func scalingKnown(scaling string) bool {
+	return scaling == "" || scaling == scalingAttack || scaling == scalingSpeed
+}
+
+// scalingBonus is the extra damage char deals from the stat a weapon or
+// ability scales with: half of it, rounded down. Unset means attack.
+func scalingBonus(char *Character, scaling string) int {
+	if scaling == scalingSpeed {
+		return char.Stats.Speed / 2
+	}
+	return char.Stats.Attack / 2
+}
+
+// DefaultDamageResolver hits when d20 + attack meets defense + 10, dealing
+// weapon damage + half the weapon's scaling stat (attack unless it says
+// speed) + d6 - defense. Armor-piercing weapons skip the defense subtraction.
+// handleAttack raises the result to the session's minimum.
+func DefaultDamageResolver(attacker, target *Character, weapon *Weapon, rng *SeededRNG) (int, bool) {
+	attackRoll := rng.Stream(rollHit).RollD20()
+	if attackRoll+attacker.Stats.Attack < target.Stats.Defense+10 {
+		return 0, false
+	}
+
+	damage := weapon.Damage + scalingBonus(attacker, weapon.Scaling) + rng.Stream(rollDamage).RollD6()
+	if !weapon.IgnoresDefense {
+		damage -= target.Stats.Defense
+	}
+	return damage, true
+}
+
+// defaultMinDamage is the least damage a hit deals when the house rules don't say
+const defaultMinDamage = 1
+
+// minimumDamage is the least damage a hit deals under the session's house rules
+func minimumDamage(rules HouseRules) int {
+	if rules.MinDamage == nil {
+		return defaultMinDamage
+	}
+	if *rules.MinDamage < 0 {
+		return 0
+	}
+	return *rules.MinDamage
+}
+
+// abilityReducedByDefense reports whether a damaging ability has the target's
+// defense subtracted. Abilities bypass defense unless they or the session's
+// rules opt in, and armor-piercing ones always do.
+func abilityReducedByDefense(rules HouseRules, ability Ability) bool {
+	return (ability.ReducedByDefense || rules.AbilityDefense) && !ability.IgnoresDefense
+}
+
 func handleAttack(state *State, action Action, rng *SeededRNG, events []Event, logs []string) Resolution {
 	attacker := GetCharacterByID(*state, action.Attacker)
 	target := GetCharacterByID(*state, action.Target)
 
-	if attacker == nil || target == nil {
-		return Resolution{Events: events, State: *state, Logs: append(logs, "Invalid attack action")}
+	if attacker == nil {
+		return rejectAction(*state, events, logs, RejectActorNotFound, "Invalid attack action")
+	}
+	if target == nil {
+		return rejectAction(*state, events, logs, RejectTargetNotFound, "Invalid attack action: target not found")
+	}
+
+	if !canTarget(*state, *attacker, *target) {
+		return rejectAction(*state, events, logs, RejectFriendlyTarget, fmt.Sprintf("%s cannot attack an ally!", attacker.Name))
 	}
 
 	// Find weapon
@@ -203,64 +557,208 @@ func handleAttack(state *State, action Action, rng *SeededRNG, events []Event, l
 		}
 	}
 
+	if weapon == nil && action.Weapon == "" && action.WeaponName != "" {
+		weapon = attacker.WeaponByName(action.WeaponName)
+	}
+
 	if weapon == nil {
 		logs = append(logs, "Weapon not found - using default")
 		weapon = &Weapon{Name: "Fist", Damage: 1, Accuracy: 0} // Default
 	}
 
-	attackRoll := rng.RollD20()
-	hit := attackRoll+attacker.Stats.Attack >= target.Stats.Defense+10
+	if isRangedWeapon(*weapon) && !HasLineOfSight(*state, attacker.Position, target.Position) {
+		return rejectAction(*state, events, logs, RejectNoLineOfSight, fmt.Sprintf("%s has no clear shot at %s!", attacker.Name, target.Name))
+	}
 
-	if hit {
-		baseDamage := weapon.Damage + (attacker.Stats.Attack / 2)
-		damageRoll := rng.RollD6()
-		totalDamage := int(math.Max(1, float64(baseDamage+damageRoll-target.Stats.Defense)))
+	totalDamage, hit := damageResolver(attacker, target, weapon, rng)
+	if hit && state.Rules.FlankingBonus > 0 {
+		if ally := flankingAlly(*state, attacker, target); ally != nil {
+			totalDamage += state.Rules.FlankingBonus
+			logs = append(logs, fmt.Sprintf("%s and %s flank %s!", attacker.Name, ally.Name, target.Name))
+		}
+	}
+	if hit && totalDamage < minimumDamage(state.Rules) {
+		totalDamage = minimumDamage(state.Rules)
+	}
+	if hit && totalDamage > 0 {
+		var reactEvents []Event
+		var reactLogs []string
+		totalDamage, reactEvents, reactLogs = react(state, target, attacker, totalDamage)
+		events, logs = append(events, reactEvents...), append(logs, reactLogs...)
+	}
+
+	switch {
+	case !hit:
+		events = append(events, Event{
+			Type:           "miss",
+			Target:         target.ID,
+			Source:         attacker.ID,
+			SourcePosition: positionOf(attacker),
+			TargetPosition: positionOf(target),
+			Effect:         weaponEffect(*weapon),
+		})
+		logs = append(logs, fmt.Sprintf("%s misses %s!", attacker.Name, target.Name))
 
-		target.Stats.HP = int(math.Max(0, float64(target.Stats.HP-totalDamage)))
+		passiveEvents, passiveLogs := triggerPassives(state, target, attacker, triggerMissed, rng.Stream(rollPassive))
+		events, logs = append(events, passiveEvents...), append(logs, passiveLogs...)
+	case totalDamage == 0:
+		logs = append(logs, fmt.Sprintf("%s hits %s with %s, but it glances off harmlessly!", attacker.Name, target.Name, weapon.Name))
+	default:
+		target.ApplyDamage(totalDamage)
+		addThreat(state, attacker.ID, totalDamage)
 
 		events = append(events, Event{
-			Type:   "damage",
-			Target: target.ID,
-			Amount: totalDamage,
-			Source: attacker.ID,
+			Type:           "damage",
+			Target:         target.ID,
+			Amount:         totalDamage,
+			Source:         attacker.ID,
+			SourcePosition: positionOf(attacker),
+			TargetPosition: positionOf(target),
+			Effect:         weaponEffect(*weapon),
 		})
 
 		logs = append(logs, fmt.Sprintf("%s attacks %s with %s for %d damage!", attacker.Name, target.Name, weapon.Name, totalDamage))
 
 		if target.Stats.HP == 0 {
+			koEvents, koLogs := knockOut(state, target)
+			events, logs = append(events, koEvents...), append(logs, koLogs...)
+		}
+
+		passiveEvents, passiveLogs := triggerPassives(state, target, attacker, triggerHit, rng.Stream(rollPassive))
+		events, logs = append(events, passiveEvents...), append(logs, passiveLogs...)
+	}
+
+	// Weapons with durability wear down on every swing; 0 means indestructible
+	if weapon.Durability > 0 {
+		weapon.Durability--
+		if weapon.Durability == 0 {
+			broken := *weapon
+			removeWeapon(attacker, broken.ID)
 			events = append(events, Event{
-				Type:   "death",
-				Target: target.ID,
+				Type:  "weapon_broken",
+				Actor: attacker.ID,
+				Item:  broken.ID,
 			})
-			logs = append(logs, fmt.Sprintf("%s has been defeated!", target.Name))
+			logs = append(logs, fmt.Sprintf("%s's %s breaks!", attacker.Name, broken.Name))
 		}
-	} else {
-		logs = append(logs, fmt.Sprintf("%s misses %s!", attacker.Name, target.Name))
 	}
 
-	updatedState := advanceTurn(*state)
-	return Resolution{Events: events, State: updatedState, Logs: logs}
+	updatedState, turnEvents, turnLogs := advanceTurn(*state)
+	return Resolution{Events: append(events, turnEvents...), State: updatedState, Logs: append(logs, turnLogs...)}
+}
+
+// Defend raises defense by defendBonus for defendDuration of the defender's turns
+const (
+	defendBonus            = 2
+	defendDuration         = 2
+	defaultMaxDefendStacks = 1
+)
+
+// maxDefendStacks is how many defensive stances a character may hold at once
+func maxDefendStacks(rules HouseRules) int {
+	if rules.MaxDefendStacks <= 0 {
+		return defaultMaxDefendStacks
+	}
+	return rules.MaxDefendStacks
 }
 
 func handleDefend(state *State, action Action, rng *SeededRNG, events []Event, logs []string) Resolution {
 	character := GetCharacterByID(*state, action.Actor)
 
 	if character == nil {
-		return Resolution{Events: events, State: *state, Logs: append(logs, "Invalid defend action")}
+		return rejectAction(*state, events, logs, RejectActorNotFound, "Invalid defend action")
 	}
 
-	character.Stats.Defense += 2
-	logs = append(logs, fmt.Sprintf("%s takes a defensive stance!", character.Name))
+	// Each stance is a defend effect that lifts defense until it expires.
+	// Stances stack up to the house-rule cap; past it, the oldest is refreshed.
+	var stances []*StatusEffect
+	for i := range character.StatusEffects {
+		if character.StatusEffects[i].Type == "defend" {
+			stances = append(stances, &character.StatusEffects[i])
+		}
+	}
+	if len(stances) < maxDefendStacks(state.Rules) {
+		character.StatusEffects = append(character.StatusEffects, StatusEffect{Type: "defend", Amount: defendBonus, Duration: defendDuration})
+		character.Stats.Defense += defendBonus
+		logs = append(logs, fmt.Sprintf("%s takes a defensive stance!", character.Name))
+	} else {
+		oldest := stances[0]
+		for _, stance := range stances[1:] {
+			if stance.Duration < oldest.Duration {
+				oldest = stance
+			}
+		}
+		oldest.Duration = defendDuration
+		logs = append(logs, fmt.Sprintf("%s holds their defensive stance!", character.Name))
+	}
+
+	updatedState, turnEvents, turnLogs := advanceTurn(*state)
+	return Resolution{Events: append(events, turnEvents...), State: updatedState, Logs: append(logs, turnLogs...)}
+}
+
+// handleDelay holds the current character's turn, moving them to a later slot
+// in this round's turn order (the end by default). The round after goes back to
+// the usual order.
+func handleDelay(state *State, action Action, rng *SeededRNG, events []Event, logs []string) Resolution {
+	character := GetCharacterByID(*state, action.Actor)
+	if character == nil || turnActor(*state) != character.ID {
+		return rejectAction(*state, events, logs, RejectNotYourTurn, "Only the character whose turn it is can delay")
+	}
+
+	current, last := state.CurrentTurn, len(state.TurnOrder)-1
+	slot := action.Slot
+	if slot == 0 {
+		slot = last
+	}
+	if slot <= current || slot > last {
+		return rejectAction(*state, events, logs, RejectInvalidSlot, fmt.Sprintf("%s can't delay to slot %d; later slots this round are %d to %d", character.Name, slot, current+1, last))
+	}
+
+	if len(state.NextOrder) == 0 {
+		state.NextOrder = append([]ID(nil), state.TurnOrder...)
+	}
+	delayedPast := GetCharacterByID(*state, state.TurnOrder[slot])
+
+	events = append(events, Event{Type: "turn_delayed", Actor: character.ID})
+	logs = append(logs, fmt.Sprintf("%s delays their turn until after %s!", character.Name, delayedPast.Name))
+
+	// Hand the turn on as usual, then slot the delayer in behind the others
+	updatedState, turnEvents, turnLogs := advanceTurn(*state)
+	if !updatedState.IsComplete && updatedState.Round == state.Round {
+		next := updatedState.CurrentTurn
+		order := make([]ID, 0, len(updatedState.TurnOrder))
+		order = append(order, updatedState.TurnOrder[:current]...)
+		order = append(order, updatedState.TurnOrder[current+1:slot+1]...)
+		order = append(order, character.ID)
+		order = append(order, updatedState.TurnOrder[slot+1:]...)
+		updatedState.TurnOrder = order
+
+		// Whoever was next moves up a place. If only defeated characters were
+		// delayed past, the delayer's turn comes straight back round.
+		if next <= slot {
+			updatedState.CurrentTurn = next - 1
+		} else {
+			updatedState.CurrentTurn = slot
+		}
+	}
+	return Resolution{Events: append(events, turnEvents...), State: updatedState, Logs: append(logs, turnLogs...)}
+}
 
-	updatedState := advanceTurn(*state)
-	return Resolution{Events: events, State: updatedState, Logs: logs}
+// removeWeapon drops a weapon from the character's inventory
+func removeWeapon(char *Character, weaponID ID) {
+	for i := range char.Weapons {
+		if char.Weapons[i].ID == weaponID {
+			char.Weapons = append(char.Weapons[:i], char.Weapons[i+1:]...)
+			return
+		}
+	}
 }
 
 func handleAbility(state *State, action Action, rng *SeededRNG, events []Event, logs []string) Resolution {
 	character := GetCharacterByID(*state, action.Actor)
 
 	if character == nil {
-		return Resolution{Events: events, State: *state, Logs: append(logs, "Invalid ability action")}
+		return rejectAction(*state, events, logs, RejectActorNotFound, "Invalid ability action")
 	}
 
 	// Find ability
@@ -272,24 +770,56 @@ func handleAbility(state *State, action Action, rng *SeededRNG, events []Event,
 		}
 	}
 
+	if ability == nil && action.Ability == "" && action.AbilityName != "" {
+		ability = character.AbilityByName(action.AbilityName)
+	}
+
 	if ability == nil {
-		return Resolution{Events: events, State: *state, Logs: append(logs, "Ability not found")}
+		return rejectAction(*state, events, logs, RejectAbilityNotFound, "Ability not found")
 	}
 
-	cooldownKey := string(ability.ID)
-	currentCooldown := character.AbilityCooldowns[cooldownKey]
+	if ability.Passive {
+		return rejectAction(*state, events, logs, RejectPassiveAbility, fmt.Sprintf("%s is passive and triggers on its own!", ability.Name))
+	}
+	if ability.Reaction {
+		return rejectAction(*state, events, logs, RejectReactionAbility, fmt.Sprintf("%s is a reaction and is used when attacked!", ability.Name))
+	}
 
-	if currentCooldown > 0 {
-		return Resolution{Events: events, State: *state, Logs: append(logs, fmt.Sprintf("%s is on cooldown!", ability.Name))}
+	if ability.Effect == "damage" {
+		if target := GetCharacterByID(*state, action.Target); target != nil && !canTarget(*state, *character, *target) {
+			return rejectAction(*state, events, logs, RejectFriendlyTarget, fmt.Sprintf("%s cannot attack an ally!", character.Name))
+		}
 	}
 
-	character.AbilityCooldowns[cooldownKey] = ability.Cooldown
+	if isSupportAbility(*ability) && action.Target != "" {
+		target := GetCharacterByID(*state, action.Target)
+		if target == nil {
+			return rejectAction(*state, events, logs, RejectTargetNotFound, "Invalid ability action: target not found")
+		}
+		if !canSupport(*character, *target) {
+			return rejectAction(*state, events, logs, RejectInvalidTarget, fmt.Sprintf("%s can't use %s on %s!", character.Name, ability.Name, target.Name))
+		}
+	}
 
+	if remaining := RemainingCooldown(character, ability.ID); remaining > 0 {
+		return rejectAction(*state, events, logs, RejectOnCooldown, fmt.Sprintf("%s is on cooldown for %d more turns!", ability.Name, remaining))
+	}
+
+	character.AbilityCooldowns[string(ability.ID)] = ability.Cooldown
+
+	abilityTarget := GetCharacterByID(*state, action.Target)
+	if abilityTarget == nil {
+		abilityTarget = character
+	}
 	events = append(events, Event{
-		Type:    "ability_used",
-		Actor:   character.ID,
-		Ability: ability.ID,
-		Target:  action.Target,
+		Type:           "ability_used",
+		Actor:          character.ID,
+		Ability:        ability.ID,
+		Target:         action.Target,
+		Cooldown:       ability.Cooldown,
+		SourcePosition: positionOf(character),
+		TargetPosition: positionOf(abilityTarget),
+		Effect:         abilityEffect(*ability),
 	})
 
 	switch ability.Effect {
@@ -297,100 +827,164 @@ func handleAbility(state *State, action Action, rng *SeededRNG, events []Event,
 		if action.Target != "" {
 			target := GetCharacterByID(*state, action.Target)
 			if target != nil {
-				damage := ability.Power + rng.RollD6()
-				target.Stats.HP = int(math.Max(0, float64(target.Stats.HP-damage)))
+				damage := ability.Power + rng.Stream(rollAbility).RollD6()
+				// Abilities only add a stat when they name one, so older ones hit as before
+				if ability.Scaling != "" {
+					damage += scalingBonus(character, ability.Scaling)
+				}
+				if abilityReducedByDefense(state.Rules, *ability) {
+					damage -= target.Stats.Defense
+				}
+				if damage < minimumDamage(state.Rules) {
+					damage = minimumDamage(state.Rules)
+				}
+				if damage == 0 {
+					logs = append(logs, fmt.Sprintf("%s uses %s on %s, but it glances off harmlessly!", character.Name, ability.Name, target.Name))
+					break
+				}
+
+				target.ApplyDamage(damage)
+				addThreat(state, character.ID, damage)
 
 				events = append(events, Event{
-					Type:   "damage",
-					Target: target.ID,
-					Amount: damage,
-					Source: character.ID,
+					Type:           "damage",
+					Target:         target.ID,
+					Amount:         damage,
+					Source:         character.ID,
+					SourcePosition: positionOf(character),
+					TargetPosition: positionOf(target),
+					Effect:         abilityEffect(*ability),
 				})
 
 				logs = append(logs, fmt.Sprintf("%s uses %s on %s for %d damage!", character.Name, ability.Name, target.Name, damage))
 
 				if target.Stats.HP == 0 {
-					events = append(events, Event{
-						Type:   "death",
-						Target: target.ID,
-					})
-					logs = append(logs, fmt.Sprintf("%s has been defeated!", target.Name))
+					koEvents, koLogs := knockOut(state, target)
+					events, logs = append(events, koEvents...), append(logs, koLogs...)
 				}
+
+				passiveEvents, passiveLogs := triggerPassives(state, target, character, triggerHit, rng.Stream(rollPassive))
+				events, logs = append(events, passiveEvents...), append(logs, passiveLogs...)
 			}
 		}
 	case "heal":
-		healAmount := ability.Power + rng.RollD6()
-		character.Stats.HP = int(math.Min(float64(character.Stats.MaxHP), float64(character.Stats.HP+healAmount)))
+		healAmount := ability.Power + rng.Stream(rollAbility).RollD6()
+		abilityTarget.ApplyHeal(healAmount)
 
 		events = append(events, Event{
-			Type:   "heal",
-			Target: character.ID,
-			Amount: healAmount,
+			Type:           "heal",
+			Target:         abilityTarget.ID,
+			Amount:         healAmount,
+			TargetPosition: positionOf(abilityTarget),
+			Effect:         "heal",
 		})
 
-		logs = append(logs, fmt.Sprintf("%s uses %s and heals for %d HP!", character.Name, ability.Name, healAmount))
+		if abilityTarget == character {
+			logs = append(logs, fmt.Sprintf("%s uses %s and heals for %d HP!", character.Name, ability.Name, healAmount))
+		} else {
+			logs = append(logs, fmt.Sprintf("%s uses %s on %s, healing %d HP!", character.Name, ability.Name, abilityTarget.Name, healAmount))
+		}
+	case "taunt":
+		logs = append(logs, applyTaunt(state, character, ability))
+	default:
+		if regen, ok := parseRegenEffect(ability.Effect); ok {
+			target := character
+			if action.Target != "" {
+				if t := GetCharacterByID(*state, action.Target); t != nil {
+					target = t
+				}
+			}
+			if regen.Amount == 0 {
+				regen.Amount = ability.Power
+			}
+			if regen.Duration == 0 {
+				regen.Duration = defaultRegenDuration
+			}
+			applyStatusEffect(target, regen)
+			logs = append(logs, fmt.Sprintf("%s uses %s; %s will regenerate %d HP for %d turns!", character.Name, ability.Name, target.Name, regen.Amount, regen.Duration))
+		}
 	}
 
-	updatedState := advanceTurn(*state)
-	return Resolution{Events: events, State: updatedState, Logs: logs}
+	updatedState, turnEvents, turnLogs := advanceTurn(*state)
+	return Resolution{Events: append(events, turnEvents...), State: updatedState, Logs: append(logs, turnLogs...)}
 }
 
 func handleUseItem(state *State, action Action, rng *SeededRNG, events []Event, logs []string) Resolution {
 	character := GetCharacterByID(*state, action.Actor)
 
 	if character == nil {
-		return Resolution{Events: events, State: *state, Logs: append(logs, "Invalid item action")}
+		return rejectAction(*state, events, logs, RejectActorNotFound, "Invalid item action")
 	}
 
-	// Find and remove item
+	// Find item
 	itemIndex := -1
-	var item *Item
 	for i := range character.Items {
 		if character.Items[i].ID == action.Item {
 			itemIndex = i
-			item = &character.Items[i]
 			break
 		}
 	}
 
-	if itemIndex == -1 || item == nil {
-		return Resolution{Events: events, State: *state, Logs: append(logs, "Item not found")}
+	if itemIndex == -1 {
+		return rejectAction(*state, events, logs, RejectItemNotFound, "Item not found")
 	}
 
-	// Remove item from inventory
-	character.Items = append(character.Items[:itemIndex], character.Items[itemIndex+1:]...)
+	// Items with charges left stay in the inventory; the last use removes them
+	item := character.Items[itemIndex]
+	remaining := 0
+	if item.Charges > 1 {
+		remaining = item.Charges - 1
+		character.Items[itemIndex].Charges = remaining
+	} else {
+		character.Items = append(character.Items[:itemIndex], character.Items[itemIndex+1:]...)
+	}
 
 	events = append(events, Event{
-		Type:  "item_used",
-		Actor: character.ID,
-		Item:  item.ID,
+		Type:    "item_used",
+		Actor:   character.ID,
+		Item:    item.ID,
+		Charges: remaining,
 	})
 
-	if strings.Contains(item.Name, "Potion") {
-		healAmount := 20 + rng.RollD6()
-		character.Stats.HP = int(math.Min(float64(character.Stats.MaxHP), float64(character.Stats.HP+healAmount)))
+	if regen, ok := parseRegenEffect(item.Effect); ok {
+		if regen.Amount == 0 {
+			regen.Amount = defaultRegenAmount
+		}
+		if regen.Duration == 0 {
+			regen.Duration = defaultRegenDuration
+		}
+		applyStatusEffect(character, regen)
+		logs = append(logs, fmt.Sprintf("%s uses %s and will regenerate %d HP for %d turns!", character.Name, item.Name, regen.Amount, regen.Duration))
+	} else if strings.Contains(item.Name, "Potion") {
+		healAmount := 20 + rng.Stream(rollItem).RollD6()
+		character.ApplyHeal(healAmount)
 
 		events = append(events, Event{
-			Type:   "heal",
-			Target: character.ID,
-			Amount: healAmount,
+			Type:           "heal",
+			Target:         character.ID,
+			Amount:         healAmount,
+			TargetPosition: positionOf(character),
+			Effect:         "heal",
 		})
 
 		logs = append(logs, fmt.Sprintf("%s uses %s and heals for %d HP!", character.Name, item.Name, healAmount))
 	}
+	if remaining > 0 {
+		logs = append(logs, fmt.Sprintf("%s has %d charges left.", item.Name, remaining))
+	}
 
-	updatedState := advanceTurn(*state)
-	return Resolution{Events: events, State: updatedState, Logs: logs}
+	updatedState, turnEvents, turnLogs := advanceTurn(*state)
+	return Resolution{Events: append(events, turnEvents...), State: updatedState, Logs: append(logs, turnLogs...)}
 }
 
 func handleFlee(state *State, action Action, rng *SeededRNG, events []Event, logs []string) Resolution {
 	character := GetCharacterByID(*state, action.Actor)
 
 	if character == nil {
-		return Resolution{Events: events, State: *state, Logs: append(logs, "Invalid flee action")}
+		return rejectAction(*state, events, logs, RejectActorNotFound, "Invalid flee action")
 	}
 
-	fleeRoll := rng.RollD20()
+	fleeRoll := rng.Stream(rollFlee).RollD20()
 	success := fleeRoll+character.Stats.Speed >= 15
 
 	if success {
@@ -401,25 +995,69 @@ func handleFlee(state *State, action Action, rng *SeededRNG, events []Event, log
 
 		logs = append(logs, fmt.Sprintf("%s successfully flees from combat!", character.Name))
 
-		winner := "player"
-		updatedState := State{
-			Round:       state.Round,
-			Characters:  state.Characters,
-			TurnOrder:   state.TurnOrder,
-			CurrentTurn: state.CurrentTurn,
-			IsComplete:  true,
-			Winner:      &winner,
-		}
-		return Resolution{Events: events, State: updatedState, Logs: logs}
+		// The fled character leaves the fight; combat only ends once their side is routed
+		character.Fled = true
+		updatedState, turnEvents, turnLogs := advanceTurn(*state)
+		updatedState = removeFromTurnOrder(updatedState, character.ID)
+		return Resolution{Events: append(events, turnEvents...), State: updatedState, Logs: append(logs, turnLogs...)}
 	} else {
 		logs = append(logs, fmt.Sprintf("%s fails to flee!", character.Name))
-		updatedState := advanceTurn(*state)
-		return Resolution{Events: events, State: updatedState, Logs: logs}
+		updatedState, turnEvents, turnLogs := advanceTurn(*state)
+		return Resolution{Events: append(events, turnEvents...), State: updatedState, Logs: append(logs, turnLogs...)}
+	}
+}
+
+func handleConcede(state *State, action Action, rng *SeededRNG, events []Event, logs []string) Resolution {
+	character := GetCharacterByID(*state, action.Actor)
+
+	if character == nil {
+		return rejectAction(*state, events, logs, RejectActorNotFound, "Invalid concede action")
+	}
+
+	events = append(events, Event{
+		Type:  "concede",
+		Actor: character.ID,
+	})
+
+	logs = append(logs, fmt.Sprintf("%s concedes the fight!", character.Name))
+
+	// The conceding character's whole team leaves the fight; anyone already
+	// defeated stays defeated rather than counting as fled
+	team := CharacterTeam(*character)
+	var conceded []ID
+	for i := range state.Characters {
+		member := state.Characters[i]
+		if CharacterTeam(member) == team && (isActive(member) || isDying(member)) {
+			state.Characters[i].Fled = true
+			conceded = append(conceded, state.Characters[i].ID)
+		}
+	}
+
+	checkCombatEnd(state)
+	if state.IsComplete {
+		rewardEvents, rewardLogs := awardCombatRewards(state)
+		return Resolution{Events: append(events, rewardEvents...), State: *state, Logs: append(logs, rewardLogs...)}
+	}
+
+	// Other teams are still fighting each other
+	updatedState, turnEvents, turnLogs := advanceTurn(*state)
+	for _, id := range conceded {
+		updatedState = removeFromTurnOrder(updatedState, id)
 	}
+	return Resolution{Events: append(events, turnEvents...), State: updatedState, Logs: append(logs, turnLogs...)}
 }
 
-func advanceTurn(state State) State {
+// advanceTurn moves play to the next character and applies the status effects
+// that tick at the start of their turn
+func advanceTurn(state State) (State, []Event, []string) {
+	// Declared actions resolve without moving the turn, see simultaneous.go
+	if state.resolving {
+		return state, nil, nil
+	}
+
 	updatedState := deepCopyState(state)
+	var events []Event
+	var logs []string
 
 	// Decrease ability cooldowns
 	for i := range updatedState.Characters {
@@ -429,57 +1067,225 @@ func advanceTurn(state State) State {
 				char.AbilityCooldowns[abilityID] = cooldown - 1
 			}
 		}
+	}
+
+	// Combat ends once at most one team has members still fighting
+	checkCombatEnd(&updatedState)
+
+	if updatedState.IsComplete {
+		events, logs = awardCombatRewards(&updatedState)
+	} else {
+		// Defeated characters keep their place in the order but are passed over,
+		// and dying ones make a death save as they are. Combat hasn't ended, so
+		// someone further along can still act.
+		for skipped := 0; skipped < len(updatedState.TurnOrder); skipped++ {
+			nextTurn(&updatedState)
+			// In simultaneous rounds, everything declared resolves as the round ends
+			if updatedState.Round > state.Round && len(updatedState.Declared) > 0 {
+				roundEvents, roundLogs := resolveDeclaredRound(&updatedState)
+				events, logs = append(events, roundEvents...), append(logs, roundLogs...)
+				if checkCombatEnd(&updatedState); updatedState.IsComplete {
+					rewardEvents, rewardLogs := awardCombatRewards(&updatedState)
+					events, logs = append(events, rewardEvents...), append(logs, rewardLogs...)
+					break
+				}
+			}
+			next := GetCurrentCharacter(updatedState)
+			if next == nil {
+				continue
+			}
+			if isActive(*next) {
+				tickEvents, tickLogs := tickStatusEffects(next)
+				events, logs = append(events, tickEvents...), append(logs, tickLogs...)
+				break
+			}
+			if isDying(*next) {
+				saveEvents, saveLogs := rollDeathSave(&updatedState, next, deathSaveRNG(updatedState))
+				events, logs = append(events, saveEvents...), append(logs, saveLogs...)
+				// A failed save can leave only one side standing
+				if checkCombatEnd(&updatedState); updatedState.IsComplete {
+					rewardEvents, rewardLogs := awardCombatRewards(&updatedState)
+					events, logs = append(events, rewardEvents...), append(logs, rewardLogs...)
+					break
+				}
+			}
+		}
+	}
+
+	// Everyone's reactions come back at the start of a new round
+	if updatedState.Round > state.Round {
+		resetReactions(&updatedState)
+	}
+
+	return updatedState, events, logs
+}
+
+// nextTurn moves the turn pointer on one place, starting a new round after the
+// last character in the order
+func nextTurn(state *State) {
+	// A turn pointer left past the end of the order by removals ends the round
+	state.CurrentTurn++
+	if state.CurrentTurn < 1 || state.CurrentTurn >= len(state.TurnOrder) {
+		state.CurrentTurn = 0
+	}
+
+	if state.CurrentTurn == 0 {
+		state.Round++
+		decayThreat(state)
+		if len(state.NextOrder) > 0 {
+			state.TurnOrder, state.NextOrder = state.NextOrder, nil
+		}
+		if state.Rules.RerollInitiative {
+			rerollTurnOrder(state)
+		}
+	}
+}
 
-		// Reset defense bonus if it was increased (assuming base defense is around 3-5)
-		if char.Stats.Defense > 5 {
-			char.Stats.Defense = int(math.Max(0, float64(char.Stats.Defense-2)))
+// CharacterTeam returns the character's team, falling back to "player" or
+// "enemy" for characters without an explicit team
+func CharacterTeam(char Character) string {
+	if char.Team != "" {
+		return char.Team
+	}
+	if char.IsPlayer {
+		return "player"
+	}
+	return "enemy"
+}
+
+// activeTeams returns the teams that still have active members, in character order
+func activeTeams(state State) []string {
+	var teams []string
+	seen := make(map[string]bool)
+	for _, char := range state.Characters {
+		team := CharacterTeam(char)
+		if (isActive(char) || isDying(char)) && !seen[team] {
+			seen[team] = true
+			teams = append(teams, team)
 		}
 	}
+	return teams
+}
+
+// checkCombatEnd completes combat when one team remains (it wins) or none do (a draw)
+func checkCombatEnd(state *State) {
+	teams := activeTeams(*state)
+	if len(teams) > 1 {
+		return
+	}
 
-	// Check for combat end
-	alivePlayers := 0
-	aliveEnemies := 0
-	for _, char := range updatedState.Characters {
-		if char.IsPlayer && char.Stats.HP > 0 {
-			alivePlayers++
-		} else if !char.IsPlayer && char.Stats.HP > 0 {
-			aliveEnemies++
+	winner := "draw"
+	if len(teams) == 1 {
+		winner = teams[0]
+	}
+	state.IsComplete = true
+	state.Winner = &winner
+}
+
+// isSupportAbility reports whether an ability helps its target rather than harming it
+func isSupportAbility(ability Ability) bool {
+	if ability.Effect == "heal" {
+		return true
+	}
+	_, ok := parseRegenEffect(ability.Effect)
+	return ok
+}
+
+// canSupport reports whether a character may use a support ability on the target:
+// themselves or an ally still in the fight or downed
+func canSupport(char, target Character) bool {
+	return CharacterTeam(char) == CharacterTeam(target) && (isActive(target) || isDowned(target))
+}
+
+// AbilityTargets lists the characters an ability may be used on: opponents for
+// damage, the caster and their allies for support abilities, and nobody for
+// abilities that don't take a target
+func AbilityTargets(state State, char Character, ability Ability) []ID {
+	targets := []ID{}
+	if triggersOnItsOwn(ability) {
+		return targets
+	}
+	for _, other := range state.Characters {
+		switch {
+		case ability.Effect == "damage":
+			if other.ID != char.ID && isActive(other) && canTarget(state, char, other) {
+				targets = append(targets, other.ID)
+			}
+		case isSupportAbility(ability):
+			if canSupport(char, other) {
+				targets = append(targets, other.ID)
+			}
 		}
 	}
+	return targets
+}
+
+// canTarget reports whether the attacker may harm the target under the session's rules
+func canTarget(state State, attacker, target Character) bool {
+	return state.Rules.FriendlyFire || CharacterTeam(attacker) != CharacterTeam(target)
+}
 
-	if alivePlayers == 0 {
-		updatedState.IsComplete = true
-		winner := "enemy"
-		updatedState.Winner = &winner
-	} else if aliveEnemies == 0 {
-		updatedState.IsComplete = true
-		winner := "player"
-		updatedState.Winner = &winner
+// removeFromTurnOrder drops a character from the initiative order, keeping
+// CurrentTurn on whoever is due to act next. If the character removed was due
+// to act, the next character in the order takes the turn, and removing the
+// last character in the order starts the next round.
+func removeFromTurnOrder(state State, id ID) State {
+	index := -1
+	for i, turnID := range state.TurnOrder {
+		if turnID == id {
+			index = i
+			break
+		}
+	}
+	state.NextOrder = withoutID(state.NextOrder, id)
+	if index == -1 {
+		return state
 	}
 
-	if !updatedState.IsComplete {
-		updatedState.CurrentTurn = (updatedState.CurrentTurn + 1) % len(updatedState.TurnOrder)
+	// Build a new slice so states sharing the old order aren't changed
+	turnOrder := make([]ID, 0, len(state.TurnOrder)-1)
+	state.TurnOrder = append(append(turnOrder, state.TurnOrder[:index]...), state.TurnOrder[index+1:]...)
+	if index < state.CurrentTurn {
+		state.CurrentTurn--
+	}
+	if state.CurrentTurn < 0 || state.CurrentTurn >= len(state.TurnOrder) {
+		state.CurrentTurn = 0
+		if !state.IsComplete {
+			state.Round++
+		}
+	}
+	return state
+}
 
-		if updatedState.CurrentTurn == 0 {
-			updatedState.Round++
+// withoutID returns a copy of ids without id, or nil if nothing is left
+func withoutID(ids []ID, id ID) []ID {
+	var remaining []ID
+	for _, other := range ids {
+		if other != id {
+			remaining = append(remaining, other)
 		}
 	}
+	return remaining
+}
 
-	return updatedState
+// isActive reports whether a character is still taking part in combat
+func isActive(char Character) bool {
+	return char.Stats.HP > 0 && !char.Fled
 }
 
 // deepCopyState creates a deep copy of the state
 func deepCopyState(state State) State {
 	stateBytes, err := json.Marshal(state)
 	if err != nil {
-		log.Printf("Deep copy failed: %v", err)
+		slog.Error("Deep copy failed", "error", err)
 		return state // Fallback to shallow
 	}
 	var newState State
 	if err := json.Unmarshal(stateBytes, &newState); err != nil {
-		log.Printf("Deep copy failed: %v", err)
+		slog.Error("Deep copy failed", "error", err)
 		return state // Fallback to shallow
 	}
+	reindexCharacters(&newState)
 	return newState
 }
 
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -77,6 +79,43 @@ func TestApplyAction_Attack(t *testing.T) {
 	}
 }
 
+func TestAttackEventAnimationHints(t *testing.T) {
+	player := createTestCharacter(true, "Player")
+	player.Position = Position{X: 0, Y: 0}
+	enemy := createTestCharacter(false, "Enemy")
+	enemy.Position = Position{X: 1, Y: 2}
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+
+	resolution := ApplyAction(state, Action{
+		Kind:     "Attack",
+		Attacker: player.ID,
+		Target:   enemy.ID,
+		Weapon:   player.Weapons[0].ID,
+	}, 12345)
+
+	var damage *Event
+	for i := range resolution.Events {
+		if resolution.Events[i].Type == "damage" {
+			damage = &resolution.Events[i]
+		}
+	}
+	if damage == nil {
+		t.Fatal("Expected a damage event")
+	}
+	if damage.SourcePosition == nil || *damage.SourcePosition != player.Position {
+		t.Errorf("Expected source position %v, got %v", player.Position, damage.SourcePosition)
+	}
+	if damage.TargetPosition == nil || *damage.TargetPosition != enemy.Position {
+		t.Errorf("Expected target position %v, got %v", enemy.Position, damage.TargetPosition)
+	}
+	if damage.Effect != "slash" {
+		t.Errorf("Expected slash effect, got %q", damage.Effect)
+	}
+	if damage.Amount <= 0 || damage.Source != player.ID || damage.Target != enemy.ID {
+		t.Errorf("Core damage fields should be unchanged, got %+v", damage)
+	}
+}
+
 func TestApplyAction_Defend(t *testing.T) {
 	player := createTestCharacter(true, "Player")
 	enemy := createTestCharacter(false, "Enemy")
@@ -176,6 +215,126 @@ func TestTurnOrder(t *testing.T) {
 	}
 }
 
+func TestTurnQueueWrapsAround(t *testing.T) {
+	hero := createTestCharacter(true, "Hero")
+	ally := createTestCharacter(true, "Ally")
+	goblin := createTestCharacter(false, "Goblin")
+	orc := createTestCharacter(false, "Orc")
+	orc.Stats.HP = 0
+
+	state := CreateInitialState([]Character{hero, ally}, []Character{goblin, orc}, 12345)
+	state.TurnOrder = []ID{hero.ID, goblin.ID, orc.ID, ally.ID}
+	state.CurrentTurn = 3 // Last slot in the round
+
+	queue := GetTurnQueue(state)
+
+	expected := []string{"Ally", "Hero", "Goblin"}
+	if len(queue) != len(expected) {
+		t.Fatalf("Expected %d characters in queue, got %d", len(expected), len(queue))
+	}
+	for i, name := range expected {
+		if queue[i].Name != name {
+			t.Errorf("Queue position %d: expected %s, got %s", i, name, queue[i].Name)
+		}
+	}
+}
+
+func TestInitiativeModes(t *testing.T) {
+	newState := func(reroll bool) State {
+		var players, enemies []Character
+		for _, name := range []string{"Fighter", "Rogue", "Wizard"} {
+			players = append(players, createTestCharacter(true, name))
+		}
+		for _, name := range []string{"Goblin", "Orc", "Troll"} {
+			enemies = append(enemies, createTestCharacter(false, name))
+		}
+		state := CreateInitialState(players, enemies, 12345)
+		state.Rules.RerollInitiative = reroll
+		return state
+	}
+
+	// Plays out the given number of rounds and records the turn order of each
+	orders := func(state State, rounds int) [][]ID {
+		history := [][]ID{state.TurnOrder}
+		for len(history) < rounds {
+			round := state.Round
+			for state.Round == round {
+				state, _, _ = advanceTurn(state)
+			}
+			history = append(history, state.TurnOrder)
+		}
+		return history
+	}
+
+	sameOrder := func(a, b []ID) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	static := orders(newState(false), 4)
+	for round, order := range static[1:] {
+		if !sameOrder(order, static[0]) {
+			t.Errorf("Static initiative changed in round %d", round+2)
+		}
+	}
+
+	state := newState(true)
+	rerolled := orders(state, 4)
+	changed := false
+	for _, order := range rerolled[1:] {
+		if len(order) != len(state.Characters) {
+			t.Fatalf("Rerolled turn order should include every character, got %d", len(order))
+		}
+		if !sameOrder(order, rerolled[0]) {
+			changed = true
+		}
+	}
+	if !changed {
+		t.Error("Expected rerolled initiative to change the turn order across rounds")
+	}
+
+	// The same seed and rounds must produce the same rerolled orders
+	replayed := orders(state, 4)
+	for round := range rerolled {
+		if !sameOrder(rerolled[round], replayed[round]) {
+			t.Errorf("Rerolled initiative is not deterministic in round %d", round+1)
+		}
+	}
+}
+
+func TestRemainingCooldownDecrements(t *testing.T) {
+	player := createTestCharacter(true, "Player")
+	enemy := createTestCharacter(false, "Enemy")
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+	ability := player.Abilities[0]
+
+	resolution := handleAbility(&state, Action{Kind: "Ability", Actor: player.ID, Ability: ability.ID, Target: enemy.ID}, NewSeededRNG(12345), nil, nil)
+
+	if len(resolution.Events) == 0 || resolution.Events[0].Type != "ability_used" || resolution.Events[0].Cooldown != ability.Cooldown {
+		t.Fatalf("Expected ability_used event with cooldown %d, got %+v", ability.Cooldown, resolution.Events)
+	}
+
+	// Using the ability ends the actor's turn, which already ticks the cooldown once
+	state = resolution.State
+	for expected := ability.Cooldown - 1; expected >= 0; expected-- {
+		if got := RemainingCooldown(GetCharacterByID(state, player.ID), ability.ID); got != expected {
+			t.Errorf("Expected %d turns of cooldown remaining, got %d", expected, got)
+		}
+		state, _, _ = advanceTurn(state)
+	}
+
+	if got := RemainingCooldown(GetCharacterByID(state, player.ID), ability.ID); got != 0 {
+		t.Errorf("Cooldown should not go below 0, got %d", got)
+	}
+}
+
 func TestDefenseReset(t *testing.T) {
 	player := createTestCharacter(true, "Player")
 	enemy := createTestCharacter(false, "Enemy")
@@ -209,7 +368,7 @@ func TestDefenseReset(t *testing.T) {
 	}
 
 	// Simulate turn advancement by calling advanceTurn again
-	resetState := advanceTurn(resolution.State)
+	resetState, _, _ := advanceTurn(resolution.State)
 
 	// Find player again
 	for i := range resetState.Characters {
@@ -224,3 +383,926 @@ func TestDefenseReset(t *testing.T) {
 		t.Errorf("Expected defense to be reset to base value, got %d", newPlayer.Stats.Defense)
 	}
 }
+
+func TestMutualKillEndsInDraw(t *testing.T) {
+	player := createTestCharacter(true, "Player")
+	enemy := createTestCharacter(false, "Enemy")
+	for _, char := range []*Character{&player, &enemy} {
+		char.Stats.HP, char.Stats.Attack = 1, 100
+	}
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+	state.TurnOrder = []ID{player.ID, enemy.ID}
+	// In simultaneous rounds both blows land together, so each fells the other
+	state.Rules.RoundMode = roundSimultaneous
+
+	declared := ApplyAction(state, Action{Kind: "Attack", Attacker: player.ID, Target: enemy.ID}, 1)
+	resolution := ApplyAction(declared.State, Action{Kind: "Attack", Attacker: enemy.ID, Target: player.ID}, 2)
+
+	if GetCharacterByID(resolution.State, player.ID).Stats.HP != 0 || GetCharacterByID(resolution.State, enemy.ID).Stats.HP != 0 {
+		t.Fatalf("Expected both sides to fall in the exchange, got %v", resolution.Logs)
+	}
+	if !resolution.State.IsComplete {
+		t.Fatal("Expected combat to be complete after mutual kill")
+	}
+	if resolution.State.Winner == nil || *resolution.State.Winner != "draw" {
+		t.Errorf("Expected winner to be draw, got %v", resolution.State.Winner)
+	}
+}
+
+func TestConcede(t *testing.T) {
+	tests := []struct {
+		name           string
+		playerConcedes bool
+		expectedWinner string
+	}{
+		{"PlayerConcedes", true, "enemy"},
+		{"EnemyConcedes", false, "player"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player := createTestCharacter(true, "Player")
+			enemy := createTestCharacter(false, "Enemy")
+			state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+
+			actor := enemy.ID
+			if tt.playerConcedes {
+				actor = player.ID
+			}
+
+			resolution := ApplyAction(state, Action{Kind: "Concede", Actor: actor}, 12345)
+
+			if !resolution.State.IsComplete {
+				t.Fatal("Expected combat to be complete after concede")
+			}
+			if resolution.State.Winner == nil || *resolution.State.Winner != tt.expectedWinner {
+				t.Errorf("Expected winner %s, got %v", tt.expectedWinner, resolution.State.Winner)
+			}
+
+			hasConcedeEvent := false
+			for _, event := range resolution.Events {
+				if event.Type == "concede" && event.Actor == actor {
+					hasConcedeEvent = true
+				}
+			}
+			if !hasConcedeEvent {
+				t.Error("Expected concede event")
+			}
+		})
+	}
+}
+
+func TestConcedeLeavesTheFallenDefeated(t *testing.T) {
+	player := createTestCharacter(true, "Player")
+	fallen := createTestCharacter(true, "Fallen")
+	fallen.Stats.HP = 0
+	enemy := createTestCharacter(false, "Enemy")
+	state := CreateInitialState([]Character{player, fallen}, []Character{enemy}, 12345)
+
+	resolution := ApplyAction(state, Action{Kind: "Concede", Actor: player.ID}, 12345)
+	if !GetCharacterByID(resolution.State, player.ID).Fled {
+		t.Error("Expected the conceding player to flee")
+	}
+	if GetCharacterByID(resolution.State, fallen.ID).Fled {
+		t.Error("Expected a character already at 0 HP to stay defeated, not fled")
+	}
+}
+
+func TestThreeTeamBattle(t *testing.T) {
+	red := createTestCharacter(true, "Red")
+	red.Team = "red"
+	blue := createTestCharacter(true, "Blue")
+	blue.Team = "blue"
+	green := createTestCharacter(false, "Green")
+	green.Team = "green"
+
+	state := CreateInitialState([]Character{red, blue}, []Character{green}, 12345)
+	state.TurnOrder = []ID{red.ID, blue.ID, green.ID}
+
+	attack := func(state State, attacker, target Character) State {
+		GetCharacterByID(state, target.ID).Stats.HP = 1
+		return ApplyAction(state, Action{
+			Kind:     "Attack",
+			Attacker: attacker.ID,
+			Target:   target.ID,
+			Weapon:   attacker.Weapons[0].ID,
+		}, 12345).State
+	}
+
+	// Red eliminates green; blue is still fighting so combat continues
+	state = attack(state, red, green)
+	if state.IsComplete {
+		t.Fatal("Combat should continue while two teams remain")
+	}
+
+	// Blue is eliminated by red on the next round
+	state.CurrentTurn = 0
+	state = attack(state, red, blue)
+	if !state.IsComplete {
+		t.Fatal("Expected combat to end with one team remaining")
+	}
+	if state.Winner == nil || *state.Winner != "red" {
+		t.Errorf("Expected red team to win, got %v", state.Winner)
+	}
+}
+
+func TestFriendlyFire(t *testing.T) {
+	hero := createTestCharacter(true, "Hero")
+	ally := createTestCharacter(true, "Ally")
+	enemy := createTestCharacter(false, "Enemy")
+
+	state := CreateInitialState([]Character{hero, ally}, []Character{enemy}, 12345)
+	state.TurnOrder = []ID{hero.ID, ally.ID, enemy.ID}
+
+	action := Action{Kind: "Attack", Attacker: hero.ID, Target: ally.ID, Weapon: hero.Weapons[0].ID}
+
+	resolution := ApplyAction(state, action, 12345)
+	if resolution.State.CurrentTurn != 0 || len(resolution.Events) != 0 {
+		t.Error("Attacking an ally should be rejected without friendly fire")
+	}
+
+	state.Rules.FriendlyFire = true
+	resolution = ApplyAction(state, action, 12345)
+	if resolution.State.CurrentTurn != 1 {
+		t.Error("Attacking an ally should be allowed with friendly fire")
+	}
+}
+
+func TestFleeWinner(t *testing.T) {
+	t.Run("PlayerFlees", func(t *testing.T) {
+		player := createTestCharacter(true, "Player")
+		player.Stats.Speed = 20 // Guarantee a successful flee
+		enemy := createTestCharacter(false, "Enemy")
+		state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+
+		resolution := ApplyAction(state, Action{Kind: "Flee", Actor: player.ID}, 12345)
+
+		if !resolution.State.IsComplete {
+			t.Fatal("Expected combat to end when the only player flees")
+		}
+		if resolution.State.Winner == nil || *resolution.State.Winner != "enemy" {
+			t.Errorf("Expected enemy to win, got %v", resolution.State.Winner)
+		}
+	})
+
+	t.Run("SingleEnemyFlees", func(t *testing.T) {
+		player := createTestCharacter(true, "Player")
+		enemy := createTestCharacter(false, "Enemy")
+		enemy.Stats.Speed = 20
+		state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+
+		resolution := ApplyAction(state, Action{Kind: "Flee", Actor: enemy.ID}, 12345)
+
+		if !resolution.State.IsComplete {
+			t.Fatal("Expected combat to end when the only enemy flees")
+		}
+		if resolution.State.Winner == nil || *resolution.State.Winner != "player" {
+			t.Errorf("Expected player to win, got %v", resolution.State.Winner)
+		}
+	})
+
+	t.Run("OneOfManyEnemiesFlees", func(t *testing.T) {
+		player := createTestCharacter(true, "Player")
+		goblin := createTestCharacter(false, "Goblin")
+		goblin.Stats.Speed = 20
+		orc := createTestCharacter(false, "Orc")
+		state := CreateInitialState([]Character{player}, []Character{goblin, orc}, 12345)
+
+		resolution := ApplyAction(state, Action{Kind: "Flee", Actor: goblin.ID}, 12345)
+
+		if resolution.State.IsComplete {
+			t.Error("Combat should continue while other enemies remain")
+		}
+	})
+}
+
+func TestFleeRemovesCharacterFromTurnOrder(t *testing.T) {
+	player := createTestCharacter(true, "Player")
+	goblin := createTestCharacter(false, "Goblin")
+	goblin.Stats.Speed = 20 // Guarantee a successful flee
+	orc := createTestCharacter(false, "Orc")
+	state := CreateInitialState([]Character{player}, []Character{goblin, orc}, 12345)
+	state.TurnOrder = []ID{goblin.ID, player.ID, orc.ID}
+	state.CurrentTurn = 0
+
+	resolution := ApplyAction(state, Action{Kind: "Flee", Actor: goblin.ID}, 12345)
+	newState := resolution.State
+
+	if newState.IsComplete {
+		t.Fatal("Combat should continue while the orc remains")
+	}
+
+	if len(newState.TurnOrder) != 2 {
+		t.Fatalf("Expected fled goblin to be removed from turn order, got %d entries", len(newState.TurnOrder))
+	}
+	for _, id := range newState.TurnOrder {
+		if id == goblin.ID {
+			t.Error("Fled goblin should not remain in turn order")
+		}
+	}
+
+	if !GetCharacterByID(newState, goblin.ID).Fled {
+		t.Error("Goblin should be marked as fled")
+	}
+
+	if current := GetCurrentCharacter(newState); current == nil || current.ID != player.ID {
+		t.Errorf("Expected player to act next, got %v", current)
+	}
+	if newState.Round != state.Round {
+		t.Errorf("Round should not advance, got %d", newState.Round)
+	}
+
+	// The orc fleeing too routs the enemy side
+	orcState := newState
+	orcState.CurrentTurn = 1
+	GetCharacterByID(orcState, orc.ID).Stats.Speed = 20
+	resolution = ApplyAction(orcState, Action{Kind: "Flee", Actor: orc.ID}, 12345)
+
+	if !resolution.State.IsComplete {
+		t.Fatal("Expected combat to end once every enemy has fled")
+	}
+	if resolution.State.Winner == nil || *resolution.State.Winner != "player" {
+		t.Errorf("Expected player to win, got %v", resolution.State.Winner)
+	}
+}
+
+func TestLastActionRecorded(t *testing.T) {
+	player := createTestCharacter(true, "Player")
+	enemy := createTestCharacter(false, "Enemy")
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+
+	action := Action{
+		Kind:     "Attack",
+		Attacker: player.ID,
+		Target:   enemy.ID,
+		Weapon:   player.Weapons[0].ID,
+	}
+
+	resolution := ApplyAction(state, action, 12345)
+
+	if got := resolution.State.LastAction[player.ID]; got != "Attack Enemy" {
+		t.Errorf("Expected last action 'Attack Enemy', got %q", got)
+	}
+
+	// The history should survive a snapshot round-trip
+	store := NewMemoryEventStore()
+	if err := store.SaveSnapshot("last-action", resolution.State.Round, resolution.State); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+	restored, err := store.GetLatestSnapshot("last-action")
+	if err != nil || restored == nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if got := restored.LastAction[player.ID]; got != "Attack Enemy" {
+		t.Errorf("Expected last action to survive snapshot, got %q", got)
+	}
+}
+
+func TestGetCharacterByName(t *testing.T) {
+	player := createTestCharacter(true, "Hero")
+	goblin := createTestCharacter(false, "Goblin")
+	twin := createTestCharacter(false, "Goblin Archer")
+	state := CreateInitialState([]Character{player}, []Character{goblin, twin}, 12345)
+
+	t.Run("Exact", func(t *testing.T) {
+		char, err := GetCharacterByName(state, "Goblin")
+		if err != nil || char == nil || char.ID != goblin.ID {
+			t.Errorf("Expected to find Goblin, got %v, %v", char, err)
+		}
+	})
+
+	t.Run("CaseInsensitive", func(t *testing.T) {
+		char, err := GetCharacterByName(state, "goblin archer")
+		if err != nil || char == nil || char.ID != twin.ID {
+			t.Errorf("Expected to find Goblin Archer, got %v, %v", char, err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		if _, err := GetCharacterByName(state, "Dragon"); err == nil {
+			t.Error("Expected error for unknown name")
+		}
+	})
+
+	t.Run("Ambiguous", func(t *testing.T) {
+		state := deepCopyState(state)
+		state.Characters[2].Name = "GOBLIN"
+		if _, err := GetCharacterByName(state, "goblin"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+			t.Errorf("Expected ambiguity error, got %v", err)
+		}
+	})
+
+	t.Run("ResolveActionNames", func(t *testing.T) {
+		action, err := ResolveActionNames(state, Action{Kind: "Attack", ActorName: "hero", TargetName: "GOBLIN"})
+		if err != nil {
+			t.Fatalf("Failed to resolve names: %v", err)
+		}
+		if action.Attacker != player.ID || action.Target != goblin.ID {
+			t.Errorf("Expected names resolved to IDs, got %+v", action)
+		}
+
+		// Explicit IDs win over names
+		action, err = ResolveActionNames(state, Action{Kind: "Ability", Actor: player.ID, ActorName: "Goblin"})
+		if err != nil || action.Actor != player.ID {
+			t.Errorf("Expected actor ID to be kept, got %+v, %v", action, err)
+		}
+	})
+}
+
+func TestWeaponAndAbilityByName(t *testing.T) {
+	player := createTestCharacter(true, "Player")
+	enemy := createTestCharacter(false, "Enemy")
+
+	if weapon := player.WeaponByName("test weapon"); weapon == nil || weapon.ID != player.Weapons[0].ID {
+		t.Errorf("Expected to find Test Weapon, got %v", weapon)
+	}
+	if ability := player.AbilityByName("TEST ABILITY"); ability == nil || ability.ID != player.Abilities[0].ID {
+		t.Errorf("Expected to find Test Ability, got %v", ability)
+	}
+
+	t.Run("NotFound", func(t *testing.T) {
+		if player.WeaponByName("Laser") != nil || player.AbilityByName("Teleport") != nil {
+			t.Error("Expected nil for unknown names")
+		}
+	})
+
+	t.Run("DuplicateName", func(t *testing.T) {
+		dup := createTestCharacter(true, "Dup")
+		dup.Weapons = append(dup.Weapons, Weapon{ID: NewID(), Name: "test weapon", Damage: 1})
+		dup.Abilities = append(dup.Abilities, Ability{ID: NewID(), Name: "Test Ability", Effect: "heal"})
+		if dup.WeaponByName("Test Weapon") != nil || dup.AbilityByName("Test Ability") != nil {
+			t.Error("Expected nil for duplicate names")
+		}
+	})
+
+	t.Run("AttackByWeaponName", func(t *testing.T) {
+		state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+		resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: player.ID, Target: enemy.ID, WeaponName: "Test Weapon"}, 12345)
+		if strings.Contains(strings.Join(resolution.Logs, "\n"), "Weapon not found") {
+			t.Errorf("Expected weapon to resolve by name, got logs %v", resolution.Logs)
+		}
+	})
+
+	t.Run("AbilityByName", func(t *testing.T) {
+		state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+		resolution := ApplyAction(state, Action{Kind: "Ability", Actor: player.ID, Target: enemy.ID, AbilityName: "test ability"}, 12345)
+		if RemainingCooldown(GetCharacterByID(resolution.State, player.ID), player.Abilities[0].ID) == 0 {
+			t.Errorf("Expected ability to resolve by name, got logs %v", resolution.Logs)
+		}
+
+		resolution = ApplyAction(state, Action{Kind: "Ability", Actor: player.ID, Target: enemy.ID, AbilityName: "Teleport"}, 12345)
+		if !strings.Contains(strings.Join(resolution.Logs, "\n"), "Ability not found") {
+			t.Errorf("Expected unknown ability name to be rejected, got logs %v", resolution.Logs)
+		}
+	})
+}
+
+func TestWeaponBreaks(t *testing.T) {
+	player := createTestCharacter(true, "Player")
+	player.Weapons[0].Durability = 2
+	enemy := createTestCharacter(false, "Enemy")
+	enemy.Stats.HP, enemy.Stats.MaxHP = 500, 500
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+	state.TurnOrder = []ID{player.ID, enemy.ID}
+
+	attack := Action{Kind: "Attack", Attacker: player.ID, Target: enemy.ID, Weapon: player.Weapons[0].ID}
+
+	resolution := ApplyAction(state, attack, 1)
+	if got := GetCharacterByID(resolution.State, player.ID).Weapons[0].Durability; got != 1 {
+		t.Fatalf("Expected durability 1 after one attack, got %d", got)
+	}
+
+	state = ApplyAction(resolution.State, Action{Kind: "Defend", Actor: enemy.ID}, 2).State
+	resolution = ApplyAction(state, attack, 3)
+
+	if weapons := GetCharacterByID(resolution.State, player.ID).Weapons; len(weapons) != 0 {
+		t.Errorf("Expected broken weapon to be removed, got %+v", weapons)
+	}
+	broken := false
+	for _, event := range resolution.Events {
+		if event.Type == "weapon_broken" && event.Actor == player.ID && event.Item == player.Weapons[0].ID {
+			broken = true
+		}
+	}
+	if !broken {
+		t.Errorf("Expected weapon_broken event, got %+v", resolution.Events)
+	}
+
+	// The next attack falls back to fists
+	state = ApplyAction(resolution.State, Action{Kind: "Defend", Actor: enemy.ID}, 4).State
+	resolution = ApplyAction(state, attack, 5)
+	if !strings.Contains(strings.Join(resolution.Logs, "\n"), "Weapon not found - using default") {
+		t.Errorf("Expected attack with fists, got logs %v", resolution.Logs)
+	}
+
+	t.Run("Indestructible", func(t *testing.T) {
+		player := createTestCharacter(true, "Player")
+		state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+		state.TurnOrder = []ID{player.ID, enemy.ID}
+		resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: player.ID, Target: enemy.ID, Weapon: player.Weapons[0].ID}, 1)
+		if weapons := GetCharacterByID(resolution.State, player.ID).Weapons; len(weapons) != 1 || weapons[0].Durability != 0 {
+			t.Errorf("Expected weapon without durability to be untouched, got %+v", weapons)
+		}
+	})
+}
+
+func TestCustomDamageResolver(t *testing.T) {
+	player := createTestCharacter(true, "Player")
+	enemy := createTestCharacter(false, "Enemy")
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+	state.TurnOrder = []ID{player.ID, enemy.ID}
+
+	var gotWeapon string
+	SetDamageResolver(func(attacker, target *Character, weapon *Weapon, rng *SeededRNG) (int, bool) {
+		gotWeapon = weapon.Name
+		return 7, true
+	})
+	defer SetDamageResolver(nil)
+
+	resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: player.ID, Target: enemy.ID, Weapon: player.Weapons[0].ID}, 12345)
+
+	if gotWeapon != "Test Weapon" {
+		t.Errorf("Expected custom resolver to receive the weapon, got %q", gotWeapon)
+	}
+	if hp := GetCharacterByID(resolution.State, enemy.ID).Stats.HP; hp != 23 {
+		t.Errorf("Expected custom resolver damage of 7, enemy HP %d", hp)
+	}
+
+	SetDamageResolver(func(attacker, target *Character, weapon *Weapon, rng *SeededRNG) (int, bool) {
+		return 0, false
+	})
+	resolution = ApplyAction(state, Action{Kind: "Attack", Attacker: player.ID, Target: enemy.ID, Weapon: player.Weapons[0].ID}, 12345)
+	if !strings.Contains(strings.Join(resolution.Logs, "\n"), "misses") {
+		t.Errorf("Expected custom resolver miss, got logs %v", resolution.Logs)
+	}
+}
+
+func TestValidateState(t *testing.T) {
+	newValidState := func() State {
+		player := createTestCharacter(true, "Player")
+		enemy := createTestCharacter(false, "Enemy")
+		return CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+	}
+
+	if err := ValidateState(newValidState()); err != nil {
+		t.Fatalf("Expected valid state, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(state *State)
+	}{
+		{"CurrentTurnPastEnd", func(state *State) { state.CurrentTurn = len(state.TurnOrder) }},
+		{"NegativeCurrentTurn", func(state *State) { state.CurrentTurn = -1 }},
+		{"UnknownTurnOrderID", func(state *State) { state.TurnOrder[0] = NewID() }},
+		{"DuplicateCharacterID", func(state *State) { state.Characters[1].ID = state.Characters[0].ID }},
+		{"NilCooldownMap", func(state *State) { state.Characters[0].AbilityCooldowns = nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := newValidState()
+			tt.mutate(&state)
+
+			if err := ValidateState(state); err == nil {
+				t.Error("Expected validation error")
+			}
+
+			// ApplyAction should refuse to touch an inconsistent state
+			resolution := ApplyAction(state, Action{Kind: "Defend", Actor: state.Characters[0].ID}, 12345)
+			if len(resolution.Events) != 0 || resolution.State.CurrentTurn != state.CurrentTurn {
+				t.Error("Expected ApplyAction to reject the inconsistent state")
+			}
+		})
+	}
+}
+
+func TestRemoveFromTurnOrderMovesTurnOn(t *testing.T) {
+	player := createTestCharacter(true, "Player")
+	goblin := createTestCharacter(false, "Goblin")
+	orc := createTestCharacter(false, "Orc")
+	state := CreateInitialState([]Character{player}, []Character{goblin, orc}, 12345)
+	state.TurnOrder = []ID{player.ID, goblin.ID, orc.ID}
+
+	t.Run("CurrentActor", func(t *testing.T) {
+		state := deepCopyState(state)
+		state.CurrentTurn = 1
+		original := append([]ID(nil), state.TurnOrder...)
+		shared := state.TurnOrder
+
+		updated := removeFromTurnOrder(state, goblin.ID)
+		if current := GetCurrentCharacter(updated); current == nil || current.ID != orc.ID {
+			t.Errorf("Expected the orc to take the goblin's turn, got %v", current)
+		}
+		if updated.Round != state.Round {
+			t.Errorf("Expected the round to continue, got round %d", updated.Round)
+		}
+		for i := range original {
+			if shared[i] != original[i] {
+				t.Fatal("Expected the original turn order to be left untouched")
+			}
+		}
+	})
+
+	t.Run("LastActor", func(t *testing.T) {
+		state := deepCopyState(state)
+		state.CurrentTurn = 2
+
+		updated := removeFromTurnOrder(state, orc.ID)
+		if current := GetCurrentCharacter(updated); current == nil || current.ID != player.ID {
+			t.Errorf("Expected the player to start the next round, got %v", current)
+		}
+		if updated.Round != state.Round+1 {
+			t.Errorf("Expected round %d, got %d", state.Round+1, updated.Round)
+		}
+	})
+}
+
+func TestAdvanceTurnToleratesOutOfRangeTurn(t *testing.T) {
+	player := createTestCharacter(true, "Player")
+	goblin := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{player}, []Character{goblin}, 12345)
+	state.TurnOrder = []ID{player.ID, goblin.ID}
+
+	for _, turn := range []int{2, 5, -1} {
+		stale := deepCopyState(state)
+		stale.CurrentTurn = turn
+		if GetCurrentCharacter(stale) != nil {
+			t.Errorf("Expected no current character at turn %d", turn)
+		}
+
+		next, _, _ := advanceTurn(stale)
+		if current := GetCurrentCharacter(next); current == nil || current.ID != player.ID {
+			t.Errorf("Turn %d: expected the player to act next, got %v", turn, current)
+		}
+		if next.Round != state.Round+1 {
+			t.Errorf("Turn %d: expected a new round, got round %d", turn, next.Round)
+		}
+		if queue := GetTurnQueue(stale); len(queue) != 2 || queue[0].ID != player.ID {
+			t.Errorf("Turn %d: expected the queue to start from the top, got %d entries", turn, len(queue))
+		}
+	}
+
+	empty := deepCopyState(state)
+	empty.TurnOrder = nil
+	if next, _, _ := advanceTurn(empty); next.CurrentTurn != 0 {
+		t.Errorf("Expected an empty turn order to be left alone, got turn %d", next.CurrentTurn)
+	}
+}
+
+func TestInitiativeBonus(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		quick := createTestCharacter(true, "Quick")
+		scout := createTestCharacter(false, "Scout")
+		scout.InitiativeBonus = 20 // Beats any d20 difference at equal speed
+
+		state := CreateInitialState([]Character{quick}, []Character{scout}, seed)
+		if state.TurnOrder[0] != scout.ID {
+			t.Fatalf("Seed %d: expected the initiative bonus to put the scout first", seed)
+		}
+	}
+}
+
+func TestSurpriseRoundGoesByTeam(t *testing.T) {
+	hero := createTestCharacter(true, "Hero")
+	goblin := createTestCharacter(false, "Goblin")
+	// A non-player on a side of their own isn't one of the ambushing enemies
+	bandit := createTestCharacter(false, "Bandit")
+	bandit.Team = "bandits"
+	// A player character fighting for the enemy is
+	turncoat := createTestCharacter(true, "Turncoat")
+	turncoat.Team = "enemy"
+	state := CreateInitialState([]Character{hero, turncoat}, []Character{goblin, bandit}, 12345)
+
+	startSurpriseRound(&state, "enemy")
+	if len(state.TurnOrder) != 2 {
+		t.Fatalf("Expected only the goblin and the turncoat to act in the surprise round, got %v", state.TurnOrder)
+	}
+	for _, id := range state.TurnOrder {
+		if id != goblin.ID && id != turncoat.ID {
+			t.Errorf("Expected only the enemy team in the surprise round, got %s", GetCharacterByID(state, id).Name)
+		}
+	}
+}
+
+func TestMinimumDamageAndArmorPiercing(t *testing.T) {
+	attack := func(rules HouseRules, ignoresDefense bool) (int, []string) {
+		player := createTestCharacter(true, "Player")
+		player.Stats.Attack = 300
+		player.Weapons[0].Damage = 1
+		player.Weapons[0].IgnoresDefense = ignoresDefense
+		tank := createTestCharacter(false, "Tank")
+		tank.Stats.Defense = 250
+		tank.Stats.HP, tank.Stats.MaxHP = 500, 500
+
+		state := CreateInitialState([]Character{player}, []Character{tank}, 12345)
+		state.TurnOrder = []ID{player.ID, tank.ID}
+		state.Rules = rules
+
+		resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: player.ID, Target: tank.ID, Weapon: player.Weapons[0].ID}, 12345)
+		return 500 - GetCharacterByID(resolution.State, tank.ID).Stats.HP, resolution.Logs
+	}
+
+	if damage, logs := attack(HouseRules{}, false); damage != 1 {
+		t.Errorf("Expected the default minimum of 1 damage, got %d (%v)", damage, logs)
+	}
+
+	noMinimum := 0
+	damage, logs := attack(HouseRules{MinDamage: &noMinimum}, false)
+	if damage != 0 || !strings.Contains(strings.Join(logs, "\n"), "glances off") {
+		t.Errorf("Expected defense to fully mitigate the hit, got %d damage (%v)", damage, logs)
+	}
+
+	// 1 weapon damage + 150 from attack + d6, with the tank's 250 defense ignored
+	if damage, logs := attack(HouseRules{MinDamage: &noMinimum}, true); damage < 152 || damage > 157 {
+		t.Errorf("Expected the armor-piercing hit to ignore defense, got %d damage (%v)", damage, logs)
+	}
+
+	// Abilities bypass defense unless they or the house rules opt in
+	player := createTestCharacter(true, "Player")
+	player.Abilities = []Ability{
+		{ID: NewID(), Name: "Jab", Effect: "damage", Power: 1},
+		{ID: NewID(), Name: "Lance", Effect: "damage", Power: 1, IgnoresDefense: true},
+		{ID: NewID(), Name: "Poke", Effect: "damage", Power: 1, ReducedByDefense: true},
+	}
+	tank := createTestCharacter(false, "Tank")
+	tank.Stats.Defense = 50
+	useAbility := func(ability int, rules HouseRules) int {
+		state := CreateInitialState([]Character{player}, []Character{tank}, 12345)
+		state.TurnOrder = []ID{player.ID, tank.ID}
+		state.Rules = rules
+		state.Rules.MinDamage = &noMinimum
+		resolution := handleAbility(&state, Action{Kind: "Ability", Actor: player.ID, Ability: player.Abilities[ability].ID, Target: tank.ID}, NewSeededRNG(12345), nil, nil)
+		return GetCharacterByID(resolution.State, tank.ID).Stats.HP
+	}
+
+	if hp := useAbility(0, HouseRules{}); hp < 23 || hp > 28 {
+		t.Errorf("Expected Jab to bypass defense by default, tank has %d HP", hp)
+	}
+	if hp := useAbility(2, HouseRules{}); hp != 30 {
+		t.Errorf("Expected Poke to be fully mitigated, tank has %d HP", hp)
+	}
+	if hp := useAbility(0, HouseRules{AbilityDefense: true}); hp != 30 {
+		t.Errorf("Expected Jab to be fully mitigated under the abilityDefense rule, tank has %d HP", hp)
+	}
+	if hp := useAbility(1, HouseRules{AbilityDefense: true}); hp < 23 || hp > 28 {
+		t.Errorf("Expected Lance to pierce the tank's defense, tank has %d HP", hp)
+	}
+}
+
+func TestDamageScaling(t *testing.T) {
+	rogue := createTestCharacter(true, "Rogue")
+	rogue.Stats.Attack, rogue.Stats.Speed = 20, 6
+	target := createTestCharacter(false, "Dummy")
+	target.Stats.Defense = -100 // Every swing hits
+
+	// The same rolls land on both weapons, so only the scaling stat differs
+	sword := Weapon{Name: "Sword", Damage: 4}
+	rapier := Weapon{Name: "Rapier", Damage: 4, Scaling: scalingSpeed}
+	for seed := int64(1); seed <= 20; seed++ {
+		swordDamage, _ := DefaultDamageResolver(&rogue, &target, &sword, NewSeededRNG(seed))
+		rapierDamage, _ := DefaultDamageResolver(&rogue, &target, &rapier, NewSeededRNG(seed))
+		if swordDamage-rapierDamage != 10-3 {
+			t.Fatalf("Expected the attack-scaled sword to out-hit the speed-scaled rapier by 7, got %d and %d", swordDamage, rapierDamage)
+		}
+	}
+
+	rogue.Stats.Attack, rogue.Stats.Speed = 4, 18
+	explicit := Weapon{Name: "Sword", Damage: 4, Scaling: scalingAttack}
+	swordDamage, _ := DefaultDamageResolver(&rogue, &target, &sword, NewSeededRNG(7))
+	explicitDamage, _ := DefaultDamageResolver(&rogue, &target, &explicit, NewSeededRNG(7))
+	rapierDamage, _ := DefaultDamageResolver(&rogue, &target, &rapier, NewSeededRNG(7))
+	if swordDamage != explicitDamage || rapierDamage-swordDamage != 9-2 {
+		t.Errorf("Expected a fast rogue to favour the rapier, got sword %d (explicit %d) and rapier %d", swordDamage, explicitDamage, rapierDamage)
+	}
+
+	// Abilities only scale when they say so
+	abilityDamage := func(scaling string) int {
+		rogue.Abilities = []Ability{{ID: NewID(), Name: "Flurry", Effect: "damage", Power: 5, Scaling: scaling}}
+		target.Stats.Defense = 0
+		state := CreateInitialState([]Character{rogue}, []Character{target}, 12345)
+		state.TurnOrder = []ID{rogue.ID, target.ID}
+		resolution := ApplyAction(state, Action{Kind: "Ability", Actor: rogue.ID, Ability: rogue.Abilities[0].ID, Target: target.ID}, 12345)
+		return 30 - GetCharacterByID(resolution.State, target.ID).Stats.HP
+	}
+	unscaled, attackScaled, speedScaled := abilityDamage(""), abilityDamage(scalingAttack), abilityDamage(scalingSpeed)
+	if attackScaled-unscaled != 2 || speedScaled-unscaled != 9 {
+		t.Errorf("Expected scaling to add half the stat to ability damage, got %d unscaled, %d attack, %d speed", unscaled, attackScaled, speedScaled)
+	}
+}
+
+func TestDefendDoesNotStackPastCap(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		maxStack int
+		maxBonus int
+	}{
+		{"DefaultRefreshes", 0, 2},
+		{"TwoStacks", 2, 4},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			player := createTestCharacter(true, "Player")
+			enemy := createTestCharacter(false, "Enemy")
+			state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+			state.TurnOrder = []ID{player.ID, enemy.ID}
+			state.Rules.MaxDefendStacks = tt.maxStack
+
+			highest := 0
+			for turn := 0; turn < 3; turn++ {
+				state = ApplyAction(state, Action{Kind: "Defend", Actor: player.ID}, int64(turn)).State
+				if defense := GetCharacterByID(state, player.ID).Stats.Defense; defense > highest {
+					highest = defense
+				}
+				state = ApplyAction(state, Action{Kind: "Defend", Actor: enemy.ID}, int64(turn)).State
+			}
+
+			if highest != player.Stats.Defense+tt.maxBonus {
+				t.Errorf("Expected defense to peak at %d, got %d", player.Stats.Defense+tt.maxBonus, highest)
+			}
+
+			// Once the player stops defending, their stances wear off
+			for turn := 0; turn < 3; turn++ {
+				state, _, _ = advanceTurn(state)
+			}
+			if defense := GetCharacterByID(state, player.ID).Stats.Defense; defense != player.Stats.Defense {
+				t.Errorf("Expected defense to return to %d, got %d", player.Stats.Defense, defense)
+			}
+		})
+	}
+}
+
+func TestDelay(t *testing.T) {
+	player := createTestCharacter(true, "Player")
+	goblin := createTestCharacter(false, "Goblin")
+	orc := createTestCharacter(false, "Orc")
+	state := CreateInitialState([]Character{player}, []Character{goblin, orc}, 12345)
+	state.TurnOrder = []ID{player.ID, goblin.ID, orc.ID}
+
+	for name, action := range map[string]Action{
+		"NotTheirTurn": {Kind: "Delay", Actor: goblin.ID},
+		"SameSlot":     {Kind: "Delay", Actor: player.ID, Slot: -1},
+		"PastTheEnd":   {Kind: "Delay", Actor: player.ID, Slot: 3},
+	} {
+		if resolution := ApplyAction(state, action, 1); actionResolved(state, resolution.State) {
+			t.Errorf("%s: expected the delay to be rejected, got logs %v", name, resolution.Logs)
+		}
+	}
+
+	// Delay past the goblin only
+	resolution := ApplyAction(state, Action{Kind: "Delay", Actor: player.ID, Slot: 1}, 1)
+	if !actionResolved(state, resolution.State) {
+		t.Fatalf("Expected the delay to be accepted, got logs %v", resolution.Logs)
+	}
+	state = resolution.State
+
+	var acted []string
+	for state.Round == 1 {
+		current := GetCurrentCharacter(state)
+		acted = append(acted, current.Name)
+		state = ApplyAction(state, Action{Kind: "Defend", Actor: current.ID}, 2).State
+	}
+	if strings.Join(acted, ",") != "Goblin,Player,Orc" {
+		t.Errorf("Expected the player to act after the goblin, got %v", acted)
+	}
+
+	// The next round goes back to the usual order
+	if state.Round != 2 || GetCurrentCharacter(state).ID != player.ID {
+		t.Errorf("Expected round 2 to start with the player, got round %d and %s", state.Round, GetCurrentCharacter(state).Name)
+	}
+	if !reflect.DeepEqual(state.TurnOrder, []ID{player.ID, goblin.ID, orc.ID}) {
+		t.Errorf("Expected the original turn order in round 2, got %v", state.TurnOrder)
+	}
+
+	// Delaying to the end (no slot) puts the character last
+	state = ApplyAction(state, Action{Kind: "Delay", Actor: player.ID}, 3).State
+	if state.TurnOrder[2] != player.ID || GetCurrentCharacter(state).ID != goblin.ID {
+		t.Errorf("Expected the player to move to the end of round 2, got %v", state.TurnOrder)
+	}
+}
+
+func TestRejectReasons(t *testing.T) {
+	player := createTestCharacter(true, "Player")
+	player.MaxItems = 1
+	ally := createTestCharacter(true, "Ally")
+	enemy := createTestCharacter(false, "Enemy")
+	player.AbilityCooldowns[string(player.Abilities[0].ID)] = 2
+	enemy.Position = Position{X: 2, Y: 0}
+	bow := Weapon{ID: NewID(), Name: "Longbow", Damage: 4}
+	player.Weapons = append(player.Weapons, bow)
+	rock := Item{ID: NewID(), Name: "Rock"}
+
+	newState := func() State {
+		state := CreateInitialState([]Character{player, ally}, []Character{enemy}, 12345)
+		state.TurnOrder = []ID{player.ID, ally.ID, enemy.ID}
+		state.Obstacles = []Position{{X: 1, Y: 0}}
+		state.GroundItems = map[string][]Item{positionKey(player.Position): {rock}}
+		return state
+	}
+
+	tests := []struct {
+		name   string
+		action Action
+		mutate func(*State)
+		reason RejectReason
+	}{
+		{"InvalidState", Action{Kind: "Defend", Actor: player.ID}, func(s *State) { s.CurrentTurn = 99 }, RejectInvalidState},
+		{"ActorNotFound", Action{Kind: "Defend", Actor: "ghost"}, nil, RejectActorNotFound},
+		{"UnknownKind", Action{Kind: "Dance", Actor: player.ID}, nil, RejectUnknownKind},
+		{"TargetNotFound", Action{Kind: "Attack", Attacker: player.ID, Target: "ghost"}, nil, RejectTargetNotFound},
+		{"FriendlyTarget", Action{Kind: "Attack", Attacker: player.ID, Target: ally.ID}, nil, RejectFriendlyTarget},
+		{"FriendlyAbilityTarget", Action{Kind: "Ability", Actor: ally.ID, Ability: ally.Abilities[0].ID, Target: player.ID}, nil, RejectFriendlyTarget},
+		{"NoLineOfSight", Action{Kind: "Attack", Attacker: player.ID, Target: enemy.ID, Weapon: bow.ID}, nil, RejectNoLineOfSight},
+		{"NotYourTurn", Action{Kind: "Delay", Actor: ally.ID}, nil, RejectNotYourTurn},
+		{"InvalidSlot", Action{Kind: "Delay", Actor: player.ID, Slot: 7}, nil, RejectInvalidSlot},
+		{"AbilityNotFound", Action{Kind: "Ability", Actor: player.ID, Ability: "ghost"}, nil, RejectAbilityNotFound},
+		{"OnCooldown", Action{Kind: "Ability", Actor: player.ID, Ability: player.Abilities[0].ID, Target: enemy.ID}, nil, RejectOnCooldown},
+		{"ItemNotFound", Action{Kind: "UseItem", Actor: player.ID, Item: "ghost"}, nil, RejectItemNotFound},
+		{"GroundItemNotFound", Action{Kind: "PickUp", Actor: player.ID, Item: "ghost"}, nil, RejectItemNotFound},
+		{"InventoryFull", Action{Kind: "PickUp", Actor: player.ID, Item: rock.ID}, nil, RejectInventoryFull},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := newState()
+			if tt.mutate != nil {
+				tt.mutate(&state)
+			}
+			resolution := ApplyAction(state, tt.action, 12345)
+			if resolution.RejectReason != tt.reason {
+				t.Errorf("Expected reason %q, got %q (logs %v)", tt.reason, resolution.RejectReason, resolution.Logs)
+			}
+			if actionResolved(state, resolution.State) {
+				t.Errorf("Expected the state to be unchanged")
+			}
+			if len(resolution.Logs) < 2 {
+				t.Errorf("Expected a human-readable log line, got %v", resolution.Logs)
+			}
+		})
+	}
+
+	// Accepted actions carry no reason
+	if resolution := ApplyAction(newState(), Action{Kind: "Defend", Actor: player.ID}, 12345); resolution.RejectReason != "" {
+		t.Errorf("Expected no reject reason for an accepted action, got %q", resolution.RejectReason)
+	}
+}
+
+func TestHealAlly(t *testing.T) {
+	cleric := createTestCharacter(true, "Cleric")
+	cleric.Stats.HP = 10
+	cleric.Abilities = []Ability{{ID: NewID(), Name: "Mend", Effect: "heal", Power: 8}}
+	fighter := createTestCharacter(true, "Fighter")
+	fighter.Stats.HP = 5
+	downed := createTestCharacter(true, "Downed")
+	downed.Stats.HP = 0
+	enemy := createTestCharacter(false, "Enemy")
+	enemy.Stats.HP = 5
+	state := CreateInitialState([]Character{cleric, fighter, downed}, []Character{enemy}, 12345)
+	state.TurnOrder = []ID{cleric.ID, fighter.ID, enemy.ID}
+
+	mend := Action{Kind: "Ability", Actor: cleric.ID, Ability: cleric.Abilities[0].ID, Target: fighter.ID}
+	resolution := ApplyAction(state, mend, 12345)
+	if resolution.RejectReason != "" {
+		t.Fatalf("Expected the heal to be accepted, got %q (%v)", resolution.RejectReason, resolution.Logs)
+	}
+	if hp := GetCharacterByID(resolution.State, fighter.ID).Stats.HP; hp < 14 || hp > 19 {
+		t.Errorf("Expected the fighter to be healed by 9-14, got %d HP", hp)
+	}
+	if hp := GetCharacterByID(resolution.State, cleric.ID).Stats.HP; hp != 10 {
+		t.Errorf("Expected the cleric's HP to be untouched, got %d", hp)
+	}
+
+	for name, target := range map[string]ID{"Enemy": enemy.ID, "Defeated": downed.ID} {
+		action := mend
+		action.Target = target
+		if resolution := ApplyAction(state, action, 12345); resolution.RejectReason != RejectInvalidTarget {
+			t.Errorf("%s: expected invalid_target, got %q (%v)", name, resolution.RejectReason, resolution.Logs)
+		}
+	}
+
+	targets := AbilityTargets(state, cleric, cleric.Abilities[0])
+	if !reflect.DeepEqual(targets, []ID{cleric.ID, fighter.ID}) {
+		t.Errorf("Expected the cleric and fighter as heal targets, got %v", targets)
+	}
+	if targets := AbilityTargets(state, cleric, Ability{Effect: "damage"}); !reflect.DeepEqual(targets, []ID{enemy.ID}) {
+		t.Errorf("Expected only the enemy as a damage target, got %v", targets)
+	}
+}
+
+func TestDefeatedCharactersLoseTheirTurns(t *testing.T) {
+	hero := createTestCharacter(true, "Hero")
+	fallen := createTestCharacter(false, "Fallen")
+	fallen.Stats.HP = 0
+	goblin := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{hero}, []Character{fallen, goblin}, 12345)
+	state.TurnOrder = []ID{hero.ID, fallen.ID, goblin.ID}
+
+	resolution := ApplyAction(state, Action{Kind: "Defend", Actor: hero.ID}, 12345)
+	if next := GetCurrentCharacter(resolution.State); next == nil || next.ID != goblin.ID {
+		t.Fatalf("Expected the turn to pass over the defeated character to Goblin, got %+v", next)
+	}
+
+	resolution = ApplyAction(resolution.State, Action{Kind: "Defend", Actor: goblin.ID}, 12345)
+	if next := GetCurrentCharacter(resolution.State); next == nil || next.ID != hero.ID || resolution.State.Round != 2 {
+		t.Errorf("Expected Hero to start round 2, got %+v in round %d", next, resolution.State.Round)
+	}
+}
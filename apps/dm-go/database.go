@@ -1,9 +1,14 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -11,9 +16,14 @@ import (
 
 // EventStore handles persistence of game events and snapshots
 type EventStore struct {
-	db *sql.DB
+	db       *sql.DB
+	compress bool // gzip event and snapshot data on write
 }
 
+// gzipPrefix marks compressed event_data/state_data values. Rows without it are
+// plain JSON, so databases written before compression was enabled still load.
+const gzipPrefix = "gz:"
+
 // Ensure EventStore implements EventStoreInterface
 var _ EventStoreInterface = (*EventStore)(nil)
 
@@ -60,8 +70,24 @@ func (es *EventStore) initSchema() error {
 			created_at INTEGER NOT NULL DEFAULT (unixepoch()),
 			updated_at INTEGER NOT NULL DEFAULT (unixepoch())
 		)`,
+		`CREATE TABLE IF NOT EXISTS actions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			session_id TEXT NOT NULL,
+			round INTEGER NOT NULL,
+			seed INTEGER NOT NULL,
+			action_data TEXT NOT NULL,
+			timestamp INTEGER NOT NULL DEFAULT (unixepoch())
+		)`,
+		`CREATE TABLE IF NOT EXISTS session_tokens (
+			session_id TEXT NOT NULL,
+			token TEXT NOT NULL,
+			character_ids TEXT NOT NULL,
+			gm INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (session_id, token)
+		)`,
 		`CREATE INDEX IF NOT EXISTS idx_events_session_round ON events(session_id, round)`,
 		`CREATE INDEX IF NOT EXISTS idx_snapshots_session_round ON snapshots(session_id, round)`,
+		`CREATE INDEX IF NOT EXISTS idx_actions_session ON actions(session_id)`,
 	}
 
 	for _, query := range queries {
@@ -73,6 +99,52 @@ func (es *EventStore) initSchema() error {
 	return nil
 }
 
+// SetCompression turns gzip compression of newly written events and snapshots
+// on or off. Existing rows are read either way.
+func (es *EventStore) SetCompression(enabled bool) {
+	es.compress = enabled
+}
+
+// encodeData prepares JSON for storage, compressing it if enabled
+func (es *EventStore) encodeData(data []byte) (string, error) {
+	if !es.compress {
+		return string(data), nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return "", fmt.Errorf("failed to compress data: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("failed to compress data: %w", err)
+	}
+	return gzipPrefix + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// decodeData returns the JSON for a stored value, compressed or not
+func decodeData(stored string) ([]byte, error) {
+	if !strings.HasPrefix(stored, gzipPrefix) {
+		return []byte(stored), nil
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(stored, gzipPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode compressed data: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress data: %w", err)
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress data: %w", err)
+	}
+	return data, nil
+}
+
 // CreateSession creates a new game session
 func (es *EventStore) CreateSession(sessionID, name string) error {
 	_, err := es.db.Exec(
@@ -101,12 +173,17 @@ func (es *EventStore) AppendEvents(sessionID string, round int, events []Event)
 	defer stmt.Close()
 
 	for _, event := range events {
-		eventData, err := json.Marshal(event)
+		event.Round = round
+		eventJSON, err := json.Marshal(event)
 		if err != nil {
 			return fmt.Errorf("failed to marshal event: %w", err)
 		}
+		eventData, err := es.encodeData(eventJSON)
+		if err != nil {
+			return err
+		}
 
-		_, err = stmt.Exec(sessionID, round, string(eventData))
+		_, err = stmt.Exec(sessionID, round, eventData)
 		if err != nil {
 			return fmt.Errorf("failed to insert event: %w", err)
 		}
@@ -117,14 +194,18 @@ func (es *EventStore) AppendEvents(sessionID string, round int, events []Event)
 
 // SaveSnapshot saves a game state snapshot
 func (es *EventStore) SaveSnapshot(sessionID string, round int, state State) error {
-	stateData, err := json.Marshal(state)
+	stateJSON, err := json.Marshal(state)
 	if err != nil {
 		return fmt.Errorf("failed to marshal state: %w", err)
 	}
+	stateData, err := es.encodeData(stateJSON)
+	if err != nil {
+		return err
+	}
 
 	_, err = es.db.Exec(
 		"INSERT INTO snapshots (session_id, round, state_data) VALUES (?, ?, ?)",
-		sessionID, round, string(stateData),
+		sessionID, round, stateData,
 	)
 	return err
 }
@@ -132,7 +213,7 @@ func (es *EventStore) SaveSnapshot(sessionID string, round int, state State) err
 // GetEvents retrieves events for a session from a given round
 func (es *EventStore) GetEvents(sessionID string, fromRound int) ([]Event, error) {
 	rows, err := es.db.Query(
-		"SELECT event_data FROM events WHERE session_id = ? AND round >= ? ORDER BY round, id",
+		"SELECT round, event_data FROM events WHERE session_id = ? AND round >= ? ORDER BY round, id",
 		sessionID, fromRound,
 	)
 	if err != nil {
@@ -142,15 +223,22 @@ func (es *EventStore) GetEvents(sessionID string, fromRound int) ([]Event, error
 
 	var events []Event
 	for rows.Next() {
+		var round int
 		var eventData string
-		if err := rows.Scan(&eventData); err != nil {
+		if err := rows.Scan(&round, &eventData); err != nil {
 			return nil, fmt.Errorf("failed to scan event: %w", err)
 		}
 
+		eventJSON, err := decodeData(eventData)
+		if err != nil {
+			return nil, err
+		}
+
 		var event Event
-		if err := json.Unmarshal([]byte(eventData), &event); err != nil {
+		if err := json.Unmarshal(eventJSON, &event); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal event: %w", err)
 		}
+		event.Round = round
 
 		events = append(events, event)
 	}
@@ -162,7 +250,7 @@ func (es *EventStore) GetEvents(sessionID string, fromRound int) ([]Event, error
 func (es *EventStore) GetLatestSnapshot(sessionID string) (*State, error) {
 	var stateData string
 	err := es.db.QueryRow(
-		"SELECT state_data FROM snapshots WHERE session_id = ? ORDER BY round DESC LIMIT 1",
+		"SELECT state_data FROM snapshots WHERE session_id = ? ORDER BY round DESC, id DESC LIMIT 1",
 		sessionID,
 	).Scan(&stateData)
 
@@ -173,12 +261,7 @@ func (es *EventStore) GetLatestSnapshot(sessionID string) (*State, error) {
 		return nil, fmt.Errorf("failed to get latest snapshot: %w", err)
 	}
 
-	var state State
-	if err := json.Unmarshal([]byte(stateData), &state); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal state: %w", err)
-	}
-
-	return &state, nil
+	return decodeState(stateData)
 }
 
 // GetSnapshotAtRound retrieves a snapshot at a specific round
@@ -196,8 +279,64 @@ func (es *EventStore) GetSnapshotAtRound(sessionID string, round int) (*State, e
 		return nil, fmt.Errorf("failed to get snapshot at round: %w", err)
 	}
 
+	return decodeState(stateData)
+}
+
+// GetSnapshots retrieves all snapshots for a session in round order
+func (es *EventStore) GetSnapshots(sessionID string) ([]State, error) {
+	rows, err := es.db.Query(
+		"SELECT state_data FROM snapshots WHERE session_id = ? ORDER BY round, id",
+		sessionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var states []State
+	for rows.Next() {
+		var stateData string
+		if err := rows.Scan(&stateData); err != nil {
+			return nil, fmt.Errorf("failed to scan snapshot: %w", err)
+		}
+
+		state, err := decodeState(stateData)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, *state)
+	}
+
+	return states, rows.Err()
+}
+
+// GetSession retrieves a session's metadata, or nil if it doesn't exist
+func (es *EventStore) GetSession(sessionID string) (*Session, error) {
+	var session Session
+	err := es.db.QueryRow(
+		"SELECT id, name, status, created_at, updated_at FROM sessions WHERE id = ?",
+		sessionID,
+	).Scan(&session.ID, &session.Name, &session.Status, &session.CreatedAt, &session.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// decodeState unmarshals a stored snapshot
+func decodeState(stateData string) (*State, error) {
+	stateJSON, err := decodeData(stateData)
+	if err != nil {
+		return nil, err
+	}
+
 	var state State
-	if err := json.Unmarshal([]byte(stateData), &state); err != nil {
+	if err := json.Unmarshal(stateJSON, &state); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal state: %w", err)
 	}
 
@@ -213,6 +352,126 @@ func (es *EventStore) UpdateSessionStatus(sessionID, status string) error {
 	return err
 }
 
+// RewindSession deletes the session's last eventCount events, its most recently
+// recorded action and any snapshots taken after the given round
+func (es *EventStore) RewindSession(sessionID string, eventCount, round int) error {
+	tx, err := es.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if eventCount > 0 {
+		_, err = tx.Exec(
+			"DELETE FROM events WHERE id IN (SELECT id FROM events WHERE session_id = ? ORDER BY id DESC LIMIT ?)",
+			sessionID, eventCount,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to delete events: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM snapshots WHERE session_id = ? AND round > ?", sessionID, round); err != nil {
+		return fmt.Errorf("failed to delete snapshots: %w", err)
+	}
+
+	_, err = tx.Exec(
+		"DELETE FROM actions WHERE id IN (SELECT id FROM actions WHERE session_id = ? ORDER BY id DESC LIMIT 1)",
+		sessionID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete action: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// RecordAction records an applied action and its seed
+func (es *EventStore) RecordAction(sessionID string, record ActionRecord) error {
+	actionJSON, err := json.Marshal(record.Action)
+	if err != nil {
+		return fmt.Errorf("failed to marshal action: %w", err)
+	}
+
+	_, err = es.db.Exec(
+		"INSERT INTO actions (session_id, round, seed, action_data) VALUES (?, ?, ?, ?)",
+		sessionID, record.Round, record.Seed, string(actionJSON),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert action: %w", err)
+	}
+	return nil
+}
+
+// GetActions retrieves a session's recorded actions in the order they were applied
+func (es *EventStore) GetActions(sessionID string) ([]ActionRecord, error) {
+	rows, err := es.db.Query("SELECT round, seed, action_data FROM actions WHERE session_id = ? ORDER BY id", sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query actions: %w", err)
+	}
+	defer rows.Close()
+
+	var records []ActionRecord
+	for rows.Next() {
+		var record ActionRecord
+		var actionData string
+		if err := rows.Scan(&record.Round, &record.Seed, &actionData); err != nil {
+			return nil, fmt.Errorf("failed to scan action: %w", err)
+		}
+		if err := json.Unmarshal([]byte(actionData), &record.Action); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal action: %w", err)
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// SaveSessionToken records a token issued for a session
+func (es *EventStore) SaveSessionToken(sessionID string, token SessionToken) error {
+	idsJSON, err := json.Marshal(token.CharacterIDs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal character IDs: %w", err)
+	}
+
+	_, err = es.db.Exec(
+		"INSERT INTO session_tokens (session_id, token, character_ids, gm) VALUES (?, ?, ?, ?)",
+		sessionID, token.Token, string(idsJSON), token.GM,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert session token: %w", err)
+	}
+	return nil
+}
+
+// GetSessionTokens retrieves the tokens issued for a session
+func (es *EventStore) GetSessionTokens(sessionID string) ([]SessionToken, error) {
+	rows, err := es.db.Query("SELECT token, character_ids, gm FROM session_tokens WHERE session_id = ?", sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []SessionToken
+	for rows.Next() {
+		var token SessionToken
+		var idsData string
+		if err := rows.Scan(&token.Token, &idsData, &token.GM); err != nil {
+			return nil, fmt.Errorf("failed to scan session token: %w", err)
+		}
+		if err := json.Unmarshal([]byte(idsData), &token.CharacterIDs); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal character IDs: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// Ping checks that the database is reachable
+func (es *EventStore) Ping() error {
+	var one int
+	return es.db.QueryRow("SELECT 1").Scan(&one)
+}
+
 // Close closes the database connection
 func (es *EventStore) Close() error {
 	return es.db.Close()
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func newTestEventStore(t *testing.T) *EventStore {
+	store, err := NewEventStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+	store.db.SetMaxOpenConns(1) // Each connection would get its own in-memory database
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestEventStoreCompression(t *testing.T) {
+	store := newTestEventStore(t)
+	store.SetCompression(true)
+
+	state := CreateInitialState([]Character{createTestCharacter(true, "Hero")}, []Character{createTestCharacter(false, "Goblin")}, 12345)
+	if err := store.SaveSnapshot("compressed", 1, state); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+	if err := store.AppendEvents("compressed", 1, []Event{{Type: "damage", Target: state.Characters[1].ID, Amount: 7}}); err != nil {
+		t.Fatalf("Failed to append events: %v", err)
+	}
+
+	var stateData, eventData string
+	store.db.QueryRow("SELECT state_data FROM snapshots WHERE session_id = ?", "compressed").Scan(&stateData)
+	store.db.QueryRow("SELECT event_data FROM events WHERE session_id = ?", "compressed").Scan(&eventData)
+	if !strings.HasPrefix(stateData, gzipPrefix) || !strings.HasPrefix(eventData, gzipPrefix) {
+		t.Fatalf("Expected compressed rows, got %.40q and %.40q", stateData, eventData)
+	}
+
+	for name, load := range map[string]func() (*State, error){
+		"latest":   func() (*State, error) { return store.GetLatestSnapshot("compressed") },
+		"at round": func() (*State, error) { return store.GetSnapshotAtRound("compressed", 1) },
+	} {
+		loaded, err := load()
+		if err != nil || loaded == nil {
+			t.Fatalf("Failed to load %s snapshot: %v", name, err)
+		}
+		if loaded.Characters[0].Name != "Hero" || len(loaded.TurnOrder) != 2 {
+			t.Errorf("Expected %s snapshot to round-trip, got %+v", name, loaded)
+		}
+	}
+
+	events, err := store.GetEvents("compressed", 0)
+	if err != nil || len(events) != 1 || events[0].Amount != 7 {
+		t.Errorf("Expected compressed event to round-trip, got %+v, %v", events, err)
+	}
+}
+
+func TestEventStoreReadsLegacyRows(t *testing.T) {
+	store := newTestEventStore(t)
+	store.SetCompression(true)
+
+	state := CreateInitialState([]Character{createTestCharacter(true, "Hero")}, []Character{createTestCharacter(false, "Goblin")}, 12345)
+	stateJSON, _ := json.Marshal(state)
+	eventJSON, _ := json.Marshal(Event{Type: "heal", Amount: 4})
+
+	// Rows written before compression existed hold plain JSON
+	if _, err := store.db.Exec("INSERT INTO snapshots (session_id, round, state_data) VALUES (?, ?, ?)", "legacy", 1, string(stateJSON)); err != nil {
+		t.Fatalf("Failed to insert legacy snapshot: %v", err)
+	}
+	if _, err := store.db.Exec("INSERT INTO events (session_id, round, event_data) VALUES (?, ?, ?)", "legacy", 1, string(eventJSON)); err != nil {
+		t.Fatalf("Failed to insert legacy event: %v", err)
+	}
+
+	loaded, err := store.GetSnapshotAtRound("legacy", 1)
+	if err != nil || loaded == nil || loaded.Characters[1].Name != "Goblin" {
+		t.Errorf("Expected legacy snapshot to load, got %+v, %v", loaded, err)
+	}
+
+	events, err := store.GetEvents("legacy", 0)
+	if err != nil || len(events) != 1 || events[0].Type != "heal" {
+		t.Errorf("Expected legacy event to load, got %+v, %v", events, err)
+	}
+}
+
+func TestEventStoreActionLog(t *testing.T) {
+	store := newTestEventStore(t)
+
+	first := ActionRecord{Round: 1, Action: Action{Kind: "Attack", Attacker: "a", Target: "b", Weapon: "w"}, Seed: 42}
+	second := ActionRecord{Round: 1, Action: Action{Kind: "Defend", Actor: "b"}, Seed: -7}
+	for _, record := range []ActionRecord{first, second} {
+		if err := store.RecordAction("logged", record); err != nil {
+			t.Fatalf("Failed to record action: %v", err)
+		}
+	}
+	store.RecordAction("other", first)
+
+	actions, err := store.GetActions("logged")
+	if err != nil || len(actions) != 2 || actions[0] != first || actions[1] != second {
+		t.Fatalf("Expected both actions in order, got %+v, %v", actions, err)
+	}
+
+	if err := store.RewindSession("logged", 0, 1); err != nil {
+		t.Fatalf("Failed to rewind: %v", err)
+	}
+	if actions, _ := store.GetActions("logged"); len(actions) != 1 || actions[0] != first {
+		t.Errorf("Expected rewind to drop the latest action, got %+v", actions)
+	}
+	if actions, _ := store.GetActions("other"); len(actions) != 1 {
+		t.Errorf("Expected other sessions to be untouched, got %+v", actions)
+	}
+}
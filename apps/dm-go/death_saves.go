@@ -0,0 +1,100 @@
+package main
+
+import "fmt"
+
+// Death save tuning: a downed character rolls d20 against the DC on each of
+// their turns, stabilizing after deathSavesNeeded successes and dying after
+// as many failures
+const (
+	defaultDeathSaveDC = 10
+	deathSavesNeeded   = 3
+)
+
+// DeathSaves tracks a downed character's death saves
+type DeathSaves struct {
+	Successes int  `json:"successes"`
+	Failures  int  `json:"failures"`
+	Stable    bool `json:"stable,omitempty"` // Out of danger but still unconscious until healed
+}
+
+// deathSaveDC is the roll a downed character needs under the session's rules
+func deathSaveDC(rules HouseRules) int {
+	if rules.DeathSaveDC <= 0 {
+		return defaultDeathSaveDC
+	}
+	return rules.DeathSaveDC
+}
+
+// isDowned reports whether a character is at 0 HP but not yet dead
+func isDowned(char Character) bool {
+	return char.Downed != nil
+}
+
+// isDying reports whether a downed character is still making death saves
+func isDying(char Character) bool {
+	return char.Downed != nil && !char.Downed.Stable
+}
+
+// knockOut resolves a character who has just been brought to 0 HP. Under the
+// deathSaves rule players are downed rather than killed, and a hit on someone
+// already downed counts as a failed death save; everyone else is defeated.
+func knockOut(state *State, target *Character) ([]Event, []string) {
+	// Declared actions leave knockouts to the end of the round, when all the
+	// damage is in
+	if state.resolving {
+		return nil, nil
+	}
+	if !state.Rules.DeathSaves || !target.IsPlayer {
+		return []Event{{Type: "death", Target: target.ID}}, []string{fmt.Sprintf("%s has been defeated!", target.Name)}
+	}
+
+	if target.Downed == nil {
+		target.Downed = &DeathSaves{}
+		return []Event{{Type: "downed", Target: target.ID}}, []string{fmt.Sprintf("%s is downed and fighting for their life!", target.Name)}
+	}
+
+	target.Downed.Stable = false
+	target.Downed.Failures++
+	logs := []string{fmt.Sprintf("The blow costs %s a death save (%d/%d failed).", target.Name, target.Downed.Failures, deathSavesNeeded)}
+	events := []Event{{Type: "death_save_failed", Target: target.ID}}
+	if target.Downed.Failures >= deathSavesNeeded {
+		target.Downed = nil
+		events = append(events, Event{Type: "death", Target: target.ID})
+		logs = append(logs, fmt.Sprintf("%s has been defeated!", target.Name))
+	}
+	return events, logs
+}
+
+// rollDeathSave makes a dying character's death save in place of their turn.
+// The save's event carries the roll.
+func rollDeathSave(state *State, char *Character, rng *SeededRNG) ([]Event, []string) {
+	roll := rng.RollD20()
+	saves := char.Downed
+	if roll >= deathSaveDC(state.Rules) {
+		saves.Successes++
+		events := []Event{{Type: "death_save_passed", Target: char.ID, Amount: roll}}
+		logs := []string{fmt.Sprintf("%s rolls %d on a death save and holds on (%d/%d).", char.Name, roll, saves.Successes, deathSavesNeeded)}
+		if saves.Successes >= deathSavesNeeded {
+			saves.Stable = true
+			events = append(events, Event{Type: "stabilized", Target: char.ID})
+			logs = append(logs, fmt.Sprintf("%s has stabilized.", char.Name))
+		}
+		return events, logs
+	}
+
+	saves.Failures++
+	events := []Event{{Type: "death_save_failed", Target: char.ID, Amount: roll}}
+	logs := []string{fmt.Sprintf("%s rolls %d on a death save and slips further (%d/%d failed).", char.Name, roll, saves.Failures, deathSavesNeeded)}
+	if saves.Failures >= deathSavesNeeded {
+		char.Downed = nil
+		events = append(events, Event{Type: "death", Target: char.ID})
+		logs = append(logs, fmt.Sprintf("%s has been defeated!", char.Name))
+	}
+	return events, logs
+}
+
+// deathSaveRNG seeds the death saves rolled while passing over downed
+// characters from the session seed and turn, so replays roll the same saves
+func deathSaveRNG(state State) *SeededRNG {
+	return turnRNG(state, "death_save")
+}
@@ -0,0 +1,102 @@
+package main
+
+import "testing"
+
+// downingState has a goblin strong enough to drop the hero in one hit, acting
+// first in a session using death saves against the given DC
+func downingState(dc int, allies ...Character) (State, Character, Character) {
+	hero := createTestCharacter(true, "Hero")
+	goblin := createTestCharacter(false, "Goblin")
+	goblin.Stats.Attack = 100
+	state := CreateInitialState(append([]Character{hero}, allies...), []Character{goblin}, 12345)
+	state.TurnOrder = []ID{goblin.ID, hero.ID}
+	for _, ally := range allies {
+		state.TurnOrder = append(state.TurnOrder, ally.ID)
+	}
+	state.Rules.DeathSaves = true
+	state.Rules.DeathSaveDC = dc
+	return state, hero, goblin
+}
+
+// hasEvent reports whether the events include one of the type for the target
+func hasEvent(events []Event, eventType string, target ID) bool {
+	for _, event := range events {
+		if event.Type == eventType && event.Target == target {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDownedPlayerStabilizes(t *testing.T) {
+	cleric := createTestCharacter(true, "Cleric")
+	cleric.Abilities[0] = Ability{ID: NewID(), Name: "Mend", Effect: "heal", Power: 10, Cooldown: 1}
+	state, hero, goblin := downingState(1, cleric)
+
+	resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: goblin.ID, Target: hero.ID, Weapon: goblin.Weapons[0].ID}, 1)
+	state = resolution.State
+	downed := GetCharacterByID(state, hero.ID)
+	if !hasEvent(resolution.Events, "downed", hero.ID) || hasEvent(resolution.Events, "death", hero.ID) || downed.Downed == nil {
+		t.Fatalf("Expected the hero to be downed rather than killed, got %v", resolution.Logs)
+	}
+	if len(downed.Items) != 1 || len(state.GroundItems) != 0 {
+		t.Errorf("Expected a downed hero to keep their items, got %+v on the ground", state.GroundItems)
+	}
+	// The hero's turn went on a death save, which a DC of 1 always passes
+	if current := GetCurrentCharacter(state); current.ID != cleric.ID || downed.Downed.Successes != 1 {
+		t.Fatalf("Expected the hero to save and pass the turn to the cleric, got %s with %+v", current.Name, downed.Downed)
+	}
+
+	for round := 0; round < 2; round++ {
+		state = ApplyAction(state, Action{Kind: "Defend", Actor: cleric.ID}, 1).State
+		resolution = ApplyAction(state, Action{Kind: "Defend", Actor: goblin.ID}, 1)
+		state = resolution.State
+	}
+	stable := GetCharacterByID(state, hero.ID)
+	if stable.Downed == nil || !stable.Downed.Stable || !hasEvent(resolution.Events, "stabilized", hero.ID) {
+		t.Fatalf("Expected the hero to stabilize after three saves, got %+v", stable.Downed)
+	}
+	if state.IsComplete || stable.Stats.HP != 0 {
+		t.Fatalf("Expected the stable hero to stay down while the cleric fights on, got %+v", stable.Stats)
+	}
+
+	// Stable heroes skip their turns without rolling, and any heal brings them back
+	resolution = ApplyAction(state, Action{Kind: "Ability", Actor: cleric.ID, Ability: cleric.Abilities[0].ID, Target: hero.ID}, 1)
+	revived := GetCharacterByID(resolution.State, hero.ID)
+	if revived.Downed != nil || revived.Stats.HP == 0 || !isActive(*revived) {
+		t.Errorf("Expected the heal to revive the hero, got %+v %+v: %v", revived.Stats, revived.Downed, resolution.Logs)
+	}
+}
+
+func TestDownedPlayerFailsDeathSaves(t *testing.T) {
+	state, hero, goblin := downingState(21)
+
+	resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: goblin.ID, Target: hero.ID, Weapon: goblin.Weapons[0].ID}, 1)
+	state = resolution.State
+	if state.IsComplete {
+		t.Fatalf("Expected combat to go on while the hero is dying, got winner %v", *state.Winner)
+	}
+	if saves := GetCharacterByID(state, hero.ID).Downed; saves == nil || saves.Failures != 1 {
+		t.Fatalf("Expected the hero to fail their first death save, got %+v", saves)
+	}
+
+	for i := 0; i < 2; i++ {
+		resolution = ApplyAction(state, Action{Kind: "Defend", Actor: goblin.ID}, 1)
+		state = resolution.State
+	}
+	dead := GetCharacterByID(state, hero.ID)
+	if dead.Downed != nil || !hasEvent(resolution.Events, "death", hero.ID) {
+		t.Fatalf("Expected the third failure to kill the hero, got %+v: %v", dead.Downed, resolution.Logs)
+	}
+	if !state.IsComplete || *state.Winner != "enemy" || len(dead.Items) != 0 {
+		t.Errorf("Expected the goblin to win and the hero's items to drop, got complete=%v items %+v", state.IsComplete, dead.Items)
+	}
+
+	// Without the rule players die outright
+	state, hero, goblin = downingState(21)
+	state.Rules.DeathSaves = false
+	resolution = ApplyAction(state, Action{Kind: "Attack", Attacker: goblin.ID, Target: hero.ID, Weapon: goblin.Weapons[0].ID}, 1)
+	if !hasEvent(resolution.Events, "death", hero.ID) || !resolution.State.IsComplete {
+		t.Errorf("Expected the hero to die without death saves, got %v", resolution.Logs)
+	}
+}
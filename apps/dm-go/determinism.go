@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Defaults for the verify subcommand: each scenario is played from this many
+// seeds, for up to this many actions each
+const (
+	verifySeeds    = 5
+	verifyMaxSteps = 200
+)
+
+// maxDiffLines caps how many differing lines a determinism failure reports
+const maxDiffLines = 8
+
+// scriptedStep is one action of a scripted run and what it did
+type scriptedStep struct {
+	Action Action   `json:"action"`
+	Events []Event  `json:"events"`
+	Logs   []string `json:"logs"`
+	State  State    `json:"state"`
+}
+
+// VerifyDeterminism plays the same seeded scripted fight twice from the
+// scenario and checks that every action, event and state matches. The script
+// picks random valid actions from the seed, so it exercises whatever the
+// scenario's characters can do. It returns a diff of the first step that
+// differs, which points at map iteration, time-based seeds or other hidden
+// inputs leaking into the engine.
+func VerifyDeterminism(scenario *Scenario, seed int64, steps int) error {
+	// Characters without IDs get fresh ones on every conversion, so both runs
+	// have to start from the same converted state
+	initial := ConvertScenarioToState(scenario, seed)
+	first := playScripted(initial, seed, steps)
+	second := playScripted(initial, seed, steps)
+
+	for i := 0; i < len(first) && i < len(second); i++ {
+		a, err := json.MarshalIndent(first[i], "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode step %d: %w", i, err)
+		}
+		b, err := json.MarshalIndent(second[i], "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode step %d: %w", i, err)
+		}
+		if string(a) != string(b) {
+			return fmt.Errorf("seed %d diverged at step %d (%s):\n%s", seed, i, first[i].Action.Kind, diffLines(string(a), string(b)))
+		}
+	}
+	if len(first) != len(second) {
+		return fmt.Errorf("seed %d diverged: the runs took %d and %d steps", seed, len(first), len(second))
+	}
+	return nil
+}
+
+// playScripted plays random valid actions drawn from seed until combat ends,
+// nobody can act or steps actions have been taken
+func playScripted(state State, seed int64, steps int) []scriptedStep {
+	rng := NewSeededRNG(seed)
+	var played []scriptedStep
+	for len(played) < steps {
+		action, ok := RandomValidAction(state, rng)
+		if !ok {
+			break
+		}
+		resolution := ApplyAction(state, action, int64(rng.RandomInt(0, 1<<30)))
+		played = append(played, scriptedStep{Action: action, Events: resolution.Events, Logs: resolution.Logs, State: resolution.State})
+		state = resolution.State
+	}
+	return played
+}
+
+// diffLines lists the lines that differ between two texts, line by line
+func diffLines(a, b string) string {
+	aLines, bLines := strings.Split(a, "\n"), strings.Split(b, "\n")
+	var diff []string
+	for i := 0; i < len(aLines) || i < len(bLines); i++ {
+		var aLine, bLine string
+		if i < len(aLines) {
+			aLine = aLines[i]
+		}
+		if i < len(bLines) {
+			bLine = bLines[i]
+		}
+		if aLine == bLine {
+			continue
+		}
+		if len(diff) == maxDiffLines*2 {
+			diff = append(diff, "...")
+			break
+		}
+		diff = append(diff, "- "+strings.TrimSpace(aLine), "+ "+strings.TrimSpace(bLine))
+	}
+	return strings.Join(diff, "\n")
+}
+
+// runVerify implements the verify subcommand: it checks every available
+// scenario replays deterministically from several seeds and returns the exit code
+func runVerify(args []string, out io.Writer) int {
+	dir := getEnv("SCENARIOS_DIR", defaultScenariosDir)
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	scenariosDir = dir
+
+	names, err := GetAvailableScenarios()
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return 1
+	}
+
+	failed := 0
+	for _, name := range names {
+		scenario, err := loadNamedScenario(name)
+		if err != nil {
+			fmt.Fprintf(out, "%s: error: %v\n", name, err)
+			failed++
+			continue
+		}
+		ok := true
+		for seed := int64(1); seed <= verifySeeds; seed++ {
+			if err := VerifyDeterminism(scenario, seed, verifyMaxSteps); err != nil {
+				fmt.Fprintf(out, "%s: %v\n", name, err)
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "%s: ok\n", name)
+	}
+	fmt.Fprintf(out, "%d scenarios verified over %d seeds, %d not deterministic\n", len(names), verifySeeds, failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyDeterminismBundledScenarios(t *testing.T) {
+	var out bytes.Buffer
+	if code := runVerify(nil, &out); code != 0 {
+		t.Fatalf("Expected the bundled scenarios to be deterministic, got exit %d:\n%s", code, out.String())
+	}
+	if !strings.Contains(out.String(), "goblin-ambush: ok") {
+		t.Errorf("Expected a line per scenario, got:\n%s", out.String())
+	}
+}
+
+func TestVerifyDeterminismFlagsTimeSeededRolls(t *testing.T) {
+	scenario, err := loadNamedScenario("goblin-ambush")
+	if err != nil {
+		t.Fatalf("Failed to load scenario: %v", err)
+	}
+
+	// Ignore the engine's RNG and seed attack rolls from the clock instead
+	SetDamageResolver(func(attacker, target *Character, weapon *Weapon, rng *SeededRNG) (int, bool) {
+		return DefaultDamageResolver(attacker, target, weapon, NewSeededRNG(time.Now().UnixNano()))
+	})
+	defer SetDamageResolver(nil)
+
+	err = VerifyDeterminism(scenario, 1, verifyMaxSteps)
+	if err == nil {
+		t.Fatal("Expected time-seeded attack rolls to be flagged")
+	}
+	if !strings.Contains(err.Error(), "diverged at step") || !strings.Contains(err.Error(), "\n- ") {
+		t.Errorf("Expected the error to point at the diverging step with a diff, got: %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultEnemyTurnDelay paces automatic enemy turns so clients have time to
+// animate each one before the next update arrives
+const defaultEnemyTurnDelay = 800 * time.Millisecond
+
+// EnemyAutoPlay takes enemy turns with the heuristic AI in sessions whose
+// rules set autoEnemies, and the turns of AI-controlled allies in any
+// session. Each turn waits delay, then is saved and broadcast
+// like any other action, so a run of enemy turns reaches clients one step at
+// a time. A zero delay plays them back to back, for tests and simulations.
+type EnemyAutoPlay struct {
+	delay time.Duration
+
+	mu      sync.Mutex
+	running map[string]*autoPlayRun
+}
+
+// autoPlayRun is one session's loop of enemy turns
+type autoPlayRun struct {
+	cancel   context.CancelFunc
+	done     chan struct{}
+	stepping bool // The loop is saving its own action, so updates it causes don't cancel it
+}
+
+// NewEnemyAutoPlay creates an auto-player that waits delay before each enemy turn
+func NewEnemyAutoPlay(delay time.Duration) *EnemyAutoPlay {
+	return &EnemyAutoPlay{
+		delay:   delay,
+		running: make(map[string]*autoPlayRun),
+	}
+}
+
+// Observe is an EventObserver. Any update the loop didn't make itself, such
+// as a player acting, an undo or combat ending, cancels the waiting loop; if
+// an enemy is then due to act, a fresh loop starts from the new state.
+func (ap *EnemyAutoPlay) Observe(sessionID string, state State, events []Event) {
+	ap.mu.Lock()
+	if run, exists := ap.running[sessionID]; exists {
+		if run.stepping {
+			ap.mu.Unlock()
+			return
+		}
+		run.cancel()
+		delete(ap.running, sessionID)
+	}
+	ap.mu.Unlock()
+
+	ap.Start(sessionID, state)
+}
+
+// Start begins taking turns if it is an auto-played character's turn and no
+// loop is already running for the session
+func (ap *EnemyAutoPlay) Start(sessionID string, state State) {
+	if !enemyAutoTurn(state) {
+		return
+	}
+
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	if _, exists := ap.running[sessionID]; exists {
+		return
+	}
+	// The loop outlives the request, whose route params are reused buffers
+	sessionID = strings.Clone(sessionID)
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &autoPlayRun{cancel: cancel, done: make(chan struct{})}
+	ap.running[sessionID] = run
+	go ap.run(ctx, sessionID, run)
+}
+
+// Wait blocks until the session's current loop, if any, has stopped
+func (ap *EnemyAutoPlay) Wait(sessionID string) {
+	ap.mu.Lock()
+	run, exists := ap.running[sessionID]
+	ap.mu.Unlock()
+	if exists {
+		<-run.done
+	}
+}
+
+// enemyAutoTurn reports whether the AI should take the current turn: an
+// enemy's under autoEnemies, or an AI-controlled ally's
+func enemyAutoTurn(state State) bool {
+	if state.IsComplete {
+		return false
+	}
+	current := GetCurrentCharacter(state)
+	if current == nil || !isActive(*current) {
+		return false
+	}
+	return current.AI || (state.Rules.AutoEnemies && !current.IsPlayer)
+}
+
+// humanControlled reports whether a character's turns wait for the player
+func humanControlled(char Character) bool {
+	return char.IsPlayer && !char.AI
+}
+
+// run takes AI turns until a human player is due to act, combat ends or the
+// loop is cancelled
+func (ap *EnemyAutoPlay) run(ctx context.Context, sessionID string, run *autoPlayRun) {
+	defer close(run.done)
+	defer func() {
+		ap.mu.Lock()
+		if ap.running[sessionID] == run {
+			delete(ap.running, sessionID)
+		}
+		ap.mu.Unlock()
+		run.cancel()
+	}()
+
+	var tick <-chan time.Time
+	if ap.delay > 0 {
+		ticker := time.NewTicker(ap.delay)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		if tick == nil {
+			if ctx.Err() != nil {
+				return
+			}
+		} else {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick:
+			}
+		}
+
+		if !ap.step(sessionID, run) {
+			return
+		}
+	}
+}
+
+// step applies the AI's choice for the current character, falling back to
+// defending if the engine refuses it. Allies use the same heuristic as
+// enemies, which picks its targets from the other team. It returns whether
+// another AI turn follows, and false if the character couldn't act at all.
+func (ap *EnemyAutoPlay) step(sessionID string, run *autoPlayRun) bool {
+	unlock := sessionLocks.Lock(sessionID)
+	defer unlock()
+	state, exists := stateManager.GetState(sessionID)
+	if !exists || !enemyAutoTurn(state) {
+		return false
+	}
+
+	actor := GetCurrentCharacter(state)
+	logger := sessionLogger(sessionID)
+
+	action := heuristicEnemyAction(state, actor)
+	seed := newSeed()
+	resolution := ApplyAction(state, action, seed)
+	if !actionResolved(state, resolution.State) {
+		logger.Warn("Enemy AI action rejected, defending instead", "actor", actor.ID, "logs", strings.Join(resolution.Logs, "; "))
+		action = Action{Kind: "Defend", Actor: actor.ID}
+		if resolution = ApplyAction(state, action, seed); !actionResolved(state, resolution.State) {
+			logger.Error("Enemy AI could not act, stopping auto-play", "actor", actor.ID)
+			return false
+		}
+	}
+
+	ap.mu.Lock()
+	run.stepping = true
+	ap.mu.Unlock()
+
+	persistResolution(sessionID, state, resolution)
+	recordAction(sessionID, state, resolution, action, seed)
+	turnTimers.Reset(sessionID, resolution.State)
+
+	ap.mu.Lock()
+	run.stepping = false
+	ap.mu.Unlock()
+
+	logger.Info("AI took its turn", "action", action.Kind, "actor", actor.ID, "round", resolution.State.Round)
+	return enemyAutoTurn(resolution.State)
+}
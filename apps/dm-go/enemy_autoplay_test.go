@@ -0,0 +1,147 @@
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// autoPlayState has two goblins acting before the hero in a session whose
+// enemies are played by the server
+func autoPlayState() State {
+	hero := createTestCharacter(true, "Hero")
+	hero.Stats.HP, hero.Stats.MaxHP = 200, 200
+	first := createTestCharacter(false, "Goblin")
+	second := createTestCharacter(false, "Hobgoblin")
+	state := CreateInitialState([]Character{hero}, []Character{first, second}, 12345)
+	state.TurnOrder = []ID{first.ID, second.ID, hero.ID}
+	state.Rules.AutoEnemies = true
+	return state
+}
+
+// waitForAutoPlay fails the test if the session's loop doesn't stop in time
+func waitForAutoPlay(t *testing.T, ap *EnemyAutoPlay, sessionID string) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		ap.Wait(sessionID)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Enemy auto-play did not finish")
+	}
+}
+
+func TestEnemyAutoPlayWithoutDelay(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	ap := NewEnemyAutoPlay(0)
+	defer eventBus.Subscribe(ap.Observe)()
+
+	state := autoPlayState()
+	hero := state.Characters[0]
+	stateManager.SetState("auto-session", state)
+
+	var updates atomic.Int32
+	defer eventBus.Subscribe(func(sessionID string, state State, events []Event) { updates.Add(1) })()
+
+	ap.Start("auto-session", state)
+	waitForAutoPlay(t, ap, "auto-session")
+
+	state, _ = stateManager.GetState("auto-session")
+	if current := GetCurrentCharacter(state); current == nil || current.ID != hero.ID {
+		t.Fatalf("Expected both goblins to act and hand the turn to the hero, got turn %d", state.CurrentTurn)
+	}
+	if updates.Load() != 2 || len(state.LastAction) != 2 {
+		t.Errorf("Expected one broadcast per enemy turn, got %d updates and last actions %v", updates.Load(), state.LastAction)
+	}
+
+	// The hero's action sets off the next round of enemy turns
+	resolution := ApplyAction(state, Action{Kind: "Defend", Actor: hero.ID}, 1)
+	persistResolution("auto-session", state, resolution)
+	waitForAutoPlay(t, ap, "auto-session")
+
+	state, _ = stateManager.GetState("auto-session")
+	if state.Round != 2 || GetCurrentCharacter(state).ID != hero.ID {
+		t.Errorf("Expected the enemies to play round 2 up to the hero's turn, got round %d turn %d", state.Round, state.CurrentTurn)
+	}
+
+	// Sessions that don't opt in are left to the DM
+	manual := autoPlayState()
+	manual.Rules.AutoEnemies = false
+	stateManager.SetState("manual-session", manual)
+	ap.Start("manual-session", manual)
+	waitForAutoPlay(t, ap, "manual-session")
+	if state, _ := stateManager.GetState("manual-session"); state.CurrentTurn != 0 {
+		t.Errorf("Expected no enemy turns without autoEnemies, got turn %d", state.CurrentTurn)
+	}
+}
+
+func TestEnemyAutoPlayCancelledByOtherUpdates(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	ap := NewEnemyAutoPlay(time.Hour)
+	defer eventBus.Subscribe(ap.Observe)()
+
+	state := autoPlayState()
+	stateManager.SetState("paced-session", state)
+	ap.Start("paced-session", state)
+
+	// Someone else hands the turn to the hero while the loop is still pausing
+	changed := state
+	changed.CurrentTurn = 2
+	stateManager.SetState("paced-session", changed)
+	eventBus.Publish("paced-session", changed, []Event{})
+	waitForAutoPlay(t, ap, "paced-session")
+
+	if state, _ := stateManager.GetState("paced-session"); state.CurrentTurn != 2 || len(state.LastAction) != 0 {
+		t.Errorf("Expected the cancelled loop not to act, got turn %d and last actions %v", state.CurrentTurn, state.LastAction)
+	}
+}
+
+func TestAIAllyTakesItsOwnTurns(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	ap := NewEnemyAutoPlay(0)
+	defer eventBus.Subscribe(ap.Observe)()
+
+	hero := createTestCharacter(true, "Hero")
+	ally := createTestCharacter(true, "Squire")
+	ally.AI = true
+	goblin := createTestCharacter(false, "Goblin")
+	goblin.Stats.HP, goblin.Stats.MaxHP = 200, 200
+	state := CreateInitialState([]Character{hero, ally}, []Character{goblin}, 12345)
+	state.TurnOrder = []ID{ally.ID, hero.ID, goblin.ID}
+	stateManager.SetState("ally-session", state)
+
+	if !enemyAutoTurn(state) || isPlayerTurn(state) {
+		t.Fatal("Expected the AI ally's turn to be played by the server, not the human")
+	}
+
+	// Enemies are left to the DM without autoEnemies, but the ally still acts
+	ap.Start("ally-session", state)
+	waitForAutoPlay(t, ap, "ally-session")
+
+	state, _ = stateManager.GetState("ally-session")
+	if current := GetCurrentCharacter(state); current == nil || current.ID != hero.ID {
+		t.Fatalf("Expected the ally to act and hand the turn to the hero, got turn %d", state.CurrentTurn)
+	}
+	if state.LastAction[ally.ID] != "Attack Goblin" {
+		t.Errorf("Expected the ally to attack, got last actions %v", state.LastAction)
+	}
+	events, _ := eventStore.GetEvents("ally-session", 0)
+	attacked := false
+	for _, event := range events {
+		if event.Source == ally.ID && event.Target == goblin.ID {
+			attacked = true
+		}
+		if event.Target == hero.ID {
+			t.Errorf("Expected the ally to leave its own side alone, got %+v", event)
+		}
+	}
+	if !attacked {
+		t.Errorf("Expected the ally to attack the goblin, got events %+v", events)
+	}
+}
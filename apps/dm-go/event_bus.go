@@ -0,0 +1,68 @@
+package main
+
+import "sync"
+
+// EventObserver is called with the events each applied action produced and the
+// state they left the session in
+type EventObserver func(sessionID string, state State, events []Event)
+
+type subscription struct {
+	id       int
+	observer EventObserver
+}
+
+// EventBus fans out applied actions to observers such as the WebSocket
+// broadcaster or third-party integrations
+type EventBus struct {
+	mu            sync.RWMutex
+	nextID        int
+	subscriptions []subscription
+}
+
+// NewEventBus creates an event bus with the given observers subscribed
+func NewEventBus(observers ...EventObserver) *EventBus {
+	bus := &EventBus{}
+	for _, observer := range observers {
+		bus.Subscribe(observer)
+	}
+	return bus
+}
+
+// Subscribe registers an observer and returns a function that removes it
+func (eb *EventBus) Subscribe(observer EventObserver) func() {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+	id := eb.nextID
+	eb.nextID++
+	eb.subscriptions = append(eb.subscriptions, subscription{id: id, observer: observer})
+
+	return func() {
+		eb.mu.Lock()
+		defer eb.mu.Unlock()
+		for i, sub := range eb.subscriptions {
+			if sub.id == id {
+				eb.subscriptions = append(eb.subscriptions[:i:i], eb.subscriptions[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish calls every observer in subscription order. A panicking observer is
+// logged and skipped so it can't break the action that triggered it.
+func (eb *EventBus) Publish(sessionID string, state State, events []Event) {
+	eb.mu.RLock()
+	subscriptions := append([]subscription(nil), eb.subscriptions...)
+	eb.mu.RUnlock()
+
+	for _, sub := range subscriptions {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					sessionLogger(sessionID).Error("Event observer panicked", "panic", r)
+				}
+			}()
+			sub.observer(sessionID, state, events)
+		}()
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestEventBusObserverReceivesDamage(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+
+	var gotSession string
+	var damage []Event
+	unsubscribe := eventBus.Subscribe(func(sessionID string, state State, events []Event) {
+		gotSession = sessionID
+		for _, event := range events {
+			if event.Type == "damage" {
+				damage = append(damage, event)
+			}
+		}
+	})
+
+	player := createTestCharacter(true, "Player")
+	player.Stats.Attack = 100
+	enemy := createTestCharacter(false, "Enemy")
+	enemy.Stats.HP, enemy.Stats.MaxHP = 500, 500
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+	state.TurnOrder = []ID{player.ID, enemy.ID}
+
+	resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: player.ID, Target: enemy.ID, Weapon: player.Weapons[0].ID}, 12345)
+	persistResolution("bus-session", state, resolution)
+
+	if gotSession != "bus-session" {
+		t.Errorf("Expected observer to get the session ID, got %q", gotSession)
+	}
+	if len(damage) != 1 || damage[0].Target != enemy.ID || damage[0].Source != player.ID {
+		t.Fatalf("Expected one damage event for the enemy, got %+v", damage)
+	}
+
+	unsubscribe()
+	persistResolution("bus-session", resolution.State, ApplyAction(resolution.State, Action{Kind: "Defend", Actor: enemy.ID}, 1))
+	if len(damage) != 1 {
+		t.Error("Expected no events after unsubscribing")
+	}
+}
+
+func TestEventBusRecoversFromPanickingObserver(t *testing.T) {
+	bus := NewEventBus()
+	called := false
+	bus.Subscribe(func(string, State, []Event) { panic("boom") })
+	bus.Subscribe(func(string, State, []Event) { called = true })
+
+	bus.Publish("panic-session", State{}, []Event{{Type: "damage"}})
+
+	if !called {
+		t.Error("Expected later observers to run after a panic")
+	}
+}
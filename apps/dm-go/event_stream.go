@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// streamBufferSize is how many updates a slow stream client may fall behind
+// before further updates are dropped for it
+const streamBufferSize = 64
+
+// streamKeepAlive is how often an idle stream sends a comment, which keeps
+// proxies from closing it and notices clients that have gone away
+var streamKeepAlive = 15 * time.Second
+
+// handleSessionStream streams a session's events and state updates as
+// Server-Sent Events, for read-only dashboards. The first frame is the current
+// state; after that each applied action sends one frame per event followed by
+// the resulting state.
+func handleSessionStream(c *fiber.Ctx) error {
+	sessionID := c.Params("sessionId")
+
+	state, exists := stateManager.GetState(sessionID)
+	if !exists {
+		return c.Status(404).JSON(fiber.Map{"error": "Session not found"})
+	}
+
+	updates := make(chan interface{}, streamBufferSize)
+	unsubscribe := eventBus.Subscribe(func(id string, state State, events []Event) {
+		if id != sessionID {
+			return
+		}
+		for _, event := range append(eventFrames(events), newStateMessage(state)) {
+			select {
+			case updates <- event:
+			default:
+				sessionLogger(sessionID).Warn("Event stream client fell behind, dropping update")
+			}
+		}
+	})
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+		logger := sessionLogger(sessionID)
+		logger.Info("Event stream connected")
+
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+
+		err := writeStreamFrame(w, newStateMessage(state))
+		for err == nil {
+			select {
+			case msg := <-updates:
+				err = writeStreamFrame(w, msg)
+			case <-keepAlive.C:
+				if _, err = w.WriteString(": keep-alive\n\n"); err == nil {
+					err = w.Flush()
+				}
+			}
+		}
+		logger.Info("Event stream disconnected", "error", err)
+	})
+	return nil
+}
+
+// eventFrames wraps each event as a stream message
+func eventFrames(events []Event) []interface{} {
+	frames := make([]interface{}, 0, len(events))
+	for _, event := range events {
+		frames = append(frames, newEventMessage(event))
+	}
+	return frames
+}
+
+// writeStreamFrame writes msg as a single SSE data line and flushes it, so a
+// write error means the client has disconnected
+func writeStreamFrame(w *bufio.Writer, msg interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+		return err
+	}
+	return w.Flush()
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSessionStream(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	sessionAuth = NewSessionAuth()
+	streamKeepAlive = 20 * time.Millisecond
+	defer func() { streamKeepAlive = 15 * time.Second }()
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	setupRoutes(app)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go app.Listener(listener)
+	defer app.Shutdown()
+	baseURL := "http://" + listener.Addr().String()
+
+	player := createTestCharacter(true, "Player")
+	enemy := createTestCharacter(false, "Enemy")
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+	state.TurnOrder = []ID{player.ID, enemy.ID}
+	stateManager.SetState("stream-session", state)
+
+	if resp, err := http.Get(baseURL + "/sessions/missing/stream"); err != nil || resp.StatusCode != 404 {
+		t.Fatalf("Expected 404 for an unknown session, got %v %v", resp, err)
+	}
+
+	eventBus.mu.RLock()
+	subscribers := len(eventBus.subscriptions)
+	eventBus.mu.RUnlock()
+
+	resp, err := http.Get(baseURL + "/sessions/stream-session/stream")
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	if got := resp.Header.Get("Content-Type"); !strings.HasPrefix(got, "text/event-stream") {
+		t.Errorf("Expected an event stream, got %q", got)
+	}
+
+	frames := make(chan map[string]json.RawMessage)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			var frame map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &frame); err != nil {
+				t.Errorf("Invalid frame %q: %v", line, err)
+			}
+			frames <- frame
+		}
+		close(frames)
+	}()
+	next := func() map[string]json.RawMessage {
+		select {
+		case frame := <-frames:
+			return frame
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for a frame")
+			return nil
+		}
+	}
+
+	if frame := next(); string(frame["type"]) != `"state"` {
+		t.Fatalf("Expected the current state first, got %v", frame)
+	}
+
+	resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: player.ID, Target: enemy.ID, Weapon: player.Weapons[0].ID}, 12345)
+	persistResolution("stream-session", state, resolution)
+	persistResolution("other-session", state, resolution)
+
+	for _, want := range resolution.Events {
+		frame := next()
+		var event Event
+		if err := json.Unmarshal(frame["event"], &event); err != nil || string(frame["type"]) != `"event"` || event.Type != want.Type {
+			t.Fatalf("Expected a %s event frame, got %v", want.Type, frame)
+		}
+	}
+	frame := next()
+	var streamed State
+	if err := json.Unmarshal(frame["state"], &streamed); err != nil || streamed.CurrentTurn != resolution.State.CurrentTurn {
+		t.Fatalf("Expected the updated state after the events, got %v", frame)
+	}
+
+	// Closing the connection removes the stream's observer
+	resp.Body.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		eventBus.mu.RLock()
+		remaining := len(eventBus.subscriptions)
+		eventBus.mu.RUnlock()
+		if remaining == subscribers {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the stream to unsubscribe after disconnecting, %d observers remain", remaining)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
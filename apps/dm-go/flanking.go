@@ -0,0 +1,26 @@
+package main
+
+// flankingAlly returns an active ally of the attacker standing directly
+// opposite them across the target, with both adjacent to it, or nil. On a
+// wrapping board the two may flank across an edge.
+func flankingAlly(state State, attacker, target *Character) *Character {
+	dx, dy := boardOffset(state.Rules, attacker.Position, target.Position)
+	if !adjacent(dx, dy) {
+		return nil
+	}
+	opposite := Position{X: target.Position.X + dx, Y: target.Position.Y + dy}
+	for i := range state.Characters {
+		ally := &state.Characters[i]
+		if ally.ID != attacker.ID && ally.ID != target.ID && isActive(*ally) &&
+			CharacterTeam(*ally) == CharacterTeam(*attacker) && sameSquare(state.Rules, ally.Position, opposite) {
+			return ally
+		}
+	}
+	return nil
+}
+
+// adjacent reports whether an offset between two different squares is one
+// step, diagonals included
+func adjacent(dx, dy int) bool {
+	return (dx != 0 || dy != 0) && dx >= -1 && dx <= 1 && dy >= -1 && dy <= 1
+}
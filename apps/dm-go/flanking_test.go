@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestFlankingBonus(t *testing.T) {
+	SetDamageResolver(func(attacker, target *Character, weapon *Weapon, rng *SeededRNG) (int, bool) {
+		return 10, true
+	})
+	defer SetDamageResolver(nil)
+
+	attack := func(allyPos Position, bonus int) int {
+		hero := createTestCharacter(true, "Hero")
+		hero.Position = Position{X: 0, Y: 1}
+		ally := createTestCharacter(true, "Ally")
+		ally.Position = allyPos
+		orc := createTestCharacter(false, "Orc")
+		orc.Stats.HP, orc.Stats.MaxHP = 100, 100
+		orc.Position = Position{X: 1, Y: 1}
+
+		state := CreateInitialState([]Character{hero, ally}, []Character{orc}, 12345)
+		state.TurnOrder = []ID{hero.ID, ally.ID, orc.ID}
+		state.Rules.FlankingBonus = bonus
+
+		resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: hero.ID, Target: orc.ID, Weapon: hero.Weapons[0].ID}, 12345)
+		return 100 - GetCharacterByID(resolution.State, orc.ID).Stats.HP
+	}
+
+	if damage := attack(Position{X: 2, Y: 1}, 3); damage != 13 {
+		t.Errorf("Expected an ally directly opposite to add the 3 flanking damage, got %d", damage)
+	}
+	if damage := attack(Position{X: 1, Y: 2}, 3); damage != 10 {
+		t.Errorf("Expected an ally beside the target not to flank, got %d", damage)
+	}
+	if damage := attack(Position{X: 3, Y: 1}, 3); damage != 10 {
+		t.Errorf("Expected an ally out of reach of the target not to flank, got %d", damage)
+	}
+	if damage := attack(Position{X: 2, Y: 1}, 0); damage != 10 {
+		t.Errorf("Expected no bonus with flanking disabled, got %d", damage)
+	}
+}
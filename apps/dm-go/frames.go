@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReplayFrame is one round of a replayed session: the board as the round
+// ended and what happened during it
+type ReplayFrame struct {
+	Round int      `json:"round"`
+	Board string   `json:"board"` // ASCII rendering, see renderBoardASCII
+	Logs  []string `json:"logs"`
+}
+
+// ReplayFrames replays recorded actions from a session's first snapshot and
+// returns a frame for every round the replay passes through, the last one
+// showing the board as it stands. Only recorded actions are replayed, so GM
+// changes and admin edits made along the way aren't in the frames.
+func ReplayFrames(initial State, actions []ActionRecord) []ReplayFrame {
+	state := initial
+	frame := ReplayFrame{Round: state.Round, Logs: []string{}}
+	var frames []ReplayFrame
+	for _, record := range actions {
+		resolution := ApplyAction(state, record.Action, record.Seed)
+		for _, line := range resolution.Logs {
+			if line != actorBypassLog {
+				frame.Logs = append(frame.Logs, line)
+			}
+		}
+		state = resolution.State
+
+		if state.Round != frame.Round {
+			frame.Board = renderBoardASCII(state)
+			frames = append(frames, frame)
+			frame = ReplayFrame{Round: state.Round, Logs: []string{}}
+		}
+	}
+	frame.Board = renderBoardASCII(state)
+	return append(frames, frame)
+}
+
+// renderBoardASCII draws the combat map as text, one row per line, followed by
+// a legend. Players are capital letters and enemies lower case, in the order
+// they appear in the state; defeated characters are x, obstacles # and empty
+// squares a dot. Characters who fled or stand off the map are only in the legend.
+func renderBoardASCII(state State) string {
+	var board, legend strings.Builder
+	symbols := make(map[Position]byte)
+	for _, obstacle := range state.Obstacles {
+		symbols[obstacle] = '#'
+	}
+
+	players, enemies := 0, 0
+	for _, char := range state.Characters {
+		symbol := byte('?')
+		if char.IsPlayer && players < 26 {
+			symbol = byte('A' + players)
+		} else if !char.IsPlayer && enemies < 26 {
+			symbol = byte('a' + enemies)
+		}
+		if char.IsPlayer {
+			players++
+		} else {
+			enemies++
+		}
+
+		note := ""
+		switch {
+		case char.Fled:
+			note = " (fled)"
+		case char.Stats.HP <= 0 && isDowned(char):
+			note = " (downed)"
+		case char.Stats.HP <= 0:
+			note = " (defeated)"
+		}
+		if char.Position.X < -boardRadius || char.Position.X > boardRadius || char.Position.Y < -boardRadius || char.Position.Y > boardRadius {
+			note += fmt.Sprintf(" (off the map at %d, %d)", char.Position.X, char.Position.Y)
+		} else if !char.Fled {
+			if char.Stats.HP <= 0 {
+				symbols[char.Position] = 'x'
+			} else {
+				symbols[char.Position] = symbol
+			}
+		}
+		legend.WriteString(fmt.Sprintf("%c %s %d/%d HP%s\n", symbol, char.Name, char.Stats.HP, char.Stats.MaxHP, note))
+	}
+
+	for y := -boardRadius; y <= boardRadius; y++ {
+		for x := -boardRadius; x <= boardRadius; x++ {
+			if x > -boardRadius {
+				board.WriteByte(' ')
+			}
+			if symbol, ok := symbols[Position{X: x, Y: y}]; ok {
+				board.WriteByte(symbol)
+			} else {
+				board.WriteByte('.')
+			}
+		}
+		board.WriteByte('\n')
+	}
+	return board.String() + "\n" + legend.String()
+}
+
+// handleGetFrames replays a session from its first snapshot and returns a
+// rendered frame per round, for turning into an animation
+func handleGetFrames(c *fiber.Ctx) error {
+	sessionID := c.Params("sessionId")
+
+	session, err := eventStore.GetSession(sessionID)
+	if err != nil {
+		sessionLogger(sessionID).Error("Failed to load session", "error", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to load session"})
+	}
+	if session == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Session not found"})
+	}
+
+	snapshots, err := eventStore.GetSnapshots(sessionID)
+	if err != nil {
+		sessionLogger(sessionID).Error("Failed to load snapshots", "error", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to load snapshots"})
+	}
+	if len(snapshots) == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Session has no snapshot to replay from"})
+	}
+
+	actions, err := eventStore.GetActions(sessionID)
+	if err != nil {
+		sessionLogger(sessionID).Error("Failed to load actions", "error", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to load actions"})
+	}
+
+	return sendJSON(c, fiber.Map{
+		"sessionId": sessionID,
+		"frames":    ReplayFrames(snapshots[0], actions),
+	})
+}
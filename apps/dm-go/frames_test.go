@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestFramesEndpointRendersOneFramePerRound(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	sessionAuth = NewSessionAuth()
+	app := fiber.New()
+	setupRoutes(app)
+
+	hero := createTestCharacter(true, "Hero")
+	hero.Position = Position{X: -1, Y: 0}
+	goblin := createTestCharacter(false, "Goblin")
+	goblin.Stats.HP, goblin.Stats.MaxHP = 200, 200
+	goblin.Position = Position{X: 1, Y: 0}
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+
+	sessionID := "frames-session"
+	stateManager.SetState(sessionID, state)
+	eventStore.CreateSession(sessionID, "Frames")
+	eventStore.SaveSnapshot(sessionID, state.Round, state)
+	for seed := int64(1); state.Round < 3; seed++ {
+		current := GetCurrentCharacter(state)
+		target := hero.ID
+		if current.IsPlayer {
+			target = goblin.ID
+		}
+		action := Action{Kind: "Attack", Attacker: current.ID, Target: target, Weapon: current.Weapons[0].ID}
+		resolution := ApplyAction(state, action, seed)
+		persistResolution(sessionID, state, resolution)
+		recordAction(sessionID, state, resolution, action, seed)
+		state = resolution.State
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/sessions/"+sessionID+"/frames", nil))
+	if err != nil || resp.StatusCode != 200 {
+		t.Fatalf("Frames request failed: %v, %v", resp.StatusCode, err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	var result struct {
+		Frames []ReplayFrame `json:"frames"`
+	}
+	json.Unmarshal(body, &result)
+
+	if len(result.Frames) != 3 {
+		t.Fatalf("Expected a frame for each of 3 rounds, got %d: %s", len(result.Frames), body)
+	}
+	for i, frame := range result.Frames {
+		if frame.Round != i+1 {
+			t.Errorf("Expected frame %d to be round %d, got %d", i, i+1, frame.Round)
+		}
+		if !strings.Contains(frame.Board, ". A . a .") || !strings.Contains(frame.Board, "a Goblin") {
+			t.Errorf("Expected frame %d to draw the board with a legend, got:\n%s", i, frame.Board)
+		}
+	}
+	for i, frame := range result.Frames[:2] {
+		if len(frame.Logs) == 0 || strings.Contains(strings.Join(frame.Logs, "\n"), actorBypassLog) {
+			t.Errorf("Expected frame %d to carry the round's log lines, got %v", i, frame.Logs)
+		}
+	}
+
+	// The last frame matches the live state
+	last := result.Frames[2]
+	if last.Board != renderBoardASCII(state) {
+		t.Errorf("Expected the last frame to show the live board\ngot:\n%s\nwant:\n%s", last.Board, renderBoardASCII(state))
+	}
+
+	if resp, _ := app.Test(httptest.NewRequest("GET", "/sessions/missing/frames", nil)); resp.StatusCode != 404 {
+		t.Errorf("Expected 404 for an unknown session, got %d", resp.StatusCode)
+	}
+}
@@ -1,8 +1,14 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/gofiber/fiber/v2"
 )
 
 // TestFullGameFlow tests a complete game from start to finish
@@ -216,11 +222,28 @@ func TestWebSocketBroadcasting(t *testing.T) {
 	}()
 
 	// This should not panic even without WebSocket clients
-	broadcastGameUpdate(sessionID, state)
+	broadcastGameUpdate(sessionID, state, nil)
 
 	t.Log("WebSocket broadcasting test completed successfully")
 }
 
+// TestSpectatorCannotDriveActions tests that spectator WebSocket connections are read-only
+func TestSpectatorCannotDriveActions(t *testing.T) {
+	actionMsg := map[string]interface{}{"type": "action", "action": "attack"}
+
+	if err := validateWebSocketMessage(wsRoleSpectator, actionMsg); err == nil {
+		t.Error("Expected spectator action message to be rejected")
+	}
+
+	if err := validateWebSocketMessage(wsRoleSpectator, map[string]interface{}{"type": "ping"}); err != nil {
+		t.Errorf("Expected spectator ping to be allowed, got %v", err)
+	}
+
+	if err := validateWebSocketMessage(wsRolePlayer, actionMsg); err != nil {
+		t.Errorf("Expected player action message to be allowed, got %v", err)
+	}
+}
+
 // TestTemplateRendering tests the new template engine
 func TestTemplateRendering(t *testing.T) {
 	te, err := NewTemplateEngine()
@@ -335,6 +358,333 @@ func TestStatePersistence(t *testing.T) {
 	t.Log("State persistence test completed successfully")
 }
 
+// TestSnapshotEndpoint tests retrieving historical snapshots over HTTP
+func TestSnapshotEndpoint(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+
+	app := fiber.New()
+	setupRoutes(app)
+
+	state := CreateInitialState(
+		[]Character{createTestCharacter(true, "Hero")},
+		[]Character{createTestCharacter(false, "Goblin")},
+		12345,
+	)
+	for _, round := range []int{1, 3, 5} {
+		state.Round = round
+		eventStore.SaveSnapshot("snapshot-test", round, state)
+	}
+
+	getSnapshot := func(path string) (int, map[string]interface{}) {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		var body map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body
+	}
+
+	status, body := getSnapshot("/sessions/snapshot-test/snapshot/4")
+	if status != 200 {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+	if body["round"] != float64(3) {
+		t.Errorf("Expected nearest earlier snapshot at round 3, got %v", body["round"])
+	}
+
+	if status, _ := getSnapshot("/sessions/snapshot-test/snapshot/0"); status != 404 {
+		t.Errorf("Expected 404 before first snapshot, got %d", status)
+	}
+	if status, _ := getSnapshot("/sessions/snapshot-test/snapshot/abc"); status != 400 {
+		t.Errorf("Expected 400 for invalid round, got %d", status)
+	}
+}
+
+// TestForkSession tests branching a new session from a past round
+func TestForkSession(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	sessionAuth = NewSessionAuth()
+
+	app := fiber.New()
+	setupRoutes(app)
+
+	player := createTestCharacter(true, "Hero")
+	enemy := createTestCharacter(false, "Goblin")
+	enemy.Stats.HP, enemy.Stats.MaxHP = 200, 200
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+
+	sessionID := "fork-source"
+	stateManager.SetState(sessionID, state)
+	eventStore.CreateSession(sessionID, "Fork source")
+	eventStore.SaveSnapshot(sessionID, state.Round, state)
+
+	// Play until round 3 so there is history to branch from
+	for seed := int64(1); state.Round < 3; seed++ {
+		current := GetCurrentCharacter(state)
+		resolution := ApplyAction(state, Action{Kind: "Defend", Actor: current.ID}, seed)
+		persistResolution(sessionID, state, resolution)
+		state = resolution.State
+	}
+
+	reconstructed, err := eventStore.GetSnapshotAtRound(sessionID, 2)
+	if err != nil || reconstructed == nil || reconstructed.Round != 2 {
+		t.Fatalf("Expected a round 2 snapshot, got %v, %v", reconstructed, err)
+	}
+
+	status, resp := postJSON(t, app, "/sessions/"+sessionID+"/fork", []byte(`{"round":2}`))
+	if status != 200 {
+		t.Fatalf("Expected 200, got %d: %s", status, resp)
+	}
+
+	var fork struct {
+		SessionID string `json:"sessionId"`
+		State     State  `json:"state"`
+	}
+	json.Unmarshal([]byte(resp), &fork)
+
+	if fork.SessionID == "" || fork.SessionID == sessionID {
+		t.Fatalf("Expected a new session ID, got %q", fork.SessionID)
+	}
+
+	forked, exists := stateManager.GetState(fork.SessionID)
+	if !exists {
+		t.Fatal("Expected forked session to be stored")
+	}
+	want, _ := json.Marshal(reconstructed)
+	for name, got := range map[string]State{"response": fork.State, "stored": forked} {
+		if data, _ := json.Marshal(got); string(data) != string(want) {
+			t.Errorf("Expected %s fork state to match round 2 snapshot\ngot:  %s\nwant: %s", name, data, want)
+		}
+	}
+
+	if original, _ := stateManager.GetState(sessionID); original.Round != 3 {
+		t.Errorf("Expected original session to stay at round 3, got %d", original.Round)
+	}
+
+	if status, _ := postJSON(t, app, "/sessions/"+sessionID+"/fork", []byte(`{"round":7}`)); status != 404 {
+		t.Errorf("Expected 404 for an unplayed round, got %d", status)
+	}
+}
+
+// TestUndoLastAction tests taking back applied actions one at a time
+func TestUndoLastAction(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	sessionAuth = NewSessionAuth()
+
+	app := fiber.New()
+	setupRoutes(app)
+
+	player := createTestCharacter(true, "Hero")
+	enemy := createTestCharacter(false, "Goblin")
+	initial := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+	initial.TurnOrder = []ID{player.ID, enemy.ID}
+
+	sessionID := "undo-session"
+	stateManager.SetState(sessionID, initial)
+	eventStore.CreateSession(sessionID, "Undo")
+	eventStore.SaveSnapshot(sessionID, initial.Round, initial)
+
+	apply := func(state State, action Action) State {
+		body, _ := json.Marshal(fiber.Map{"state": state, "action": action, "seed": 42})
+		req := httptest.NewRequest("POST", "/tools/apply_action", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("session-id", sessionID)
+		resp, err := app.Test(req)
+		if err != nil || resp.StatusCode != 200 {
+			t.Fatalf("Failed to apply %s: %v", action.Kind, err)
+		}
+		var resolution Resolution
+		json.NewDecoder(resp.Body).Decode(&resolution)
+		return resolution.State
+	}
+	undo := func() (int, State) {
+		resp, err := app.Test(httptest.NewRequest("POST", "/sessions/"+sessionID+"/undo", nil))
+		if err != nil {
+			t.Fatalf("Undo request failed: %v", err)
+		}
+		var body struct {
+			State State `json:"state"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body.State
+	}
+
+	afterAttack := apply(initial, Action{Kind: "Attack", Attacker: player.ID, Target: enemy.ID, Weapon: player.Weapons[0].ID})
+	eventsAfterAttack, _ := eventStore.GetEvents(sessionID, 0)
+	apply(afterAttack, Action{Kind: "Defend", Actor: enemy.ID})
+
+	status, restored := undo()
+	if status != 200 {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+	if restored.Round != afterAttack.Round || restored.CurrentTurn != afterAttack.CurrentTurn {
+		t.Errorf("Expected state after the attack, got round %d turn %d", restored.Round, restored.CurrentTurn)
+	}
+	if current, _ := stateManager.GetState(sessionID); GetCharacterByID(current, enemy.ID).Stats.Defense != enemy.Stats.Defense {
+		t.Error("Expected the defend to be undone in the session state")
+	}
+	if events, _ := eventStore.GetEvents(sessionID, 0); len(events) != len(eventsAfterAttack) {
+		t.Errorf("Expected %d events after undo, got %d", len(eventsAfterAttack), len(events))
+	}
+	if actions, _ := eventStore.GetActions(sessionID); len(actions) != 1 || actions[0].Action.Kind != "Attack" {
+		t.Errorf("Expected only the attack left in the action log, got %+v", actions)
+	}
+	if snapshot, _ := eventStore.GetLatestSnapshot(sessionID); snapshot == nil || snapshot.Round != 1 {
+		t.Errorf("Expected the round 2 snapshot to be removed, got %v", snapshot)
+	}
+
+	if status, _ := undo(); status != 200 {
+		t.Fatalf("Expected second undo to succeed, got %d", status)
+	}
+	if status, _ := undo(); status != 409 {
+		t.Errorf("Expected 409 undoing past the session start, got %d", status)
+	}
+
+	t.Run("Ranked", func(t *testing.T) {
+		ranked := initial
+		ranked.Rules.Ranked = true
+		stateManager.SetState(sessionID, ranked)
+		apply(ranked, Action{Kind: "Defend", Actor: player.ID})
+		if status, _ := undo(); status != 403 {
+			t.Errorf("Expected 403 for a ranked session, got %d", status)
+		}
+	})
+}
+
+// TestCreateSessionFromScenario tests starting a session from a bundled scenario
+func TestCreateSessionFromScenario(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+
+	app := fiber.New()
+	setupRoutes(app)
+
+	status, resp := postJSON(t, app, "/sessions/from-scenario", []byte(`{"scenario":"goblin-ambush","seed":123}`))
+	if status != 200 {
+		t.Fatalf("Expected 200, got %d: %s", status, resp)
+	}
+
+	var created struct {
+		SessionID string `json:"sessionId"`
+		State     State  `json:"state"`
+	}
+	if err := json.Unmarshal([]byte(resp), &created); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if created.SessionID == "" || len(created.State.Characters) == 0 {
+		t.Fatalf("Expected session ID and initial state, got %s", resp)
+	}
+	if _, exists := stateManager.GetState(created.SessionID); !exists {
+		t.Error("Expected session state to be stored")
+	}
+	if snapshot, _ := eventStore.GetLatestSnapshot(created.SessionID); snapshot == nil {
+		t.Error("Expected initial snapshot to be saved")
+	}
+
+	if status, _ := postJSON(t, app, "/sessions/from-scenario", []byte(`{"scenario":"no-such-scenario"}`)); status != 404 {
+		t.Errorf("Expected 404 for unknown scenario, got %d", status)
+	}
+	if status, _ := postJSON(t, app, "/sessions/from-scenario", []byte(`{"scenario":"../../go"}`)); status != 404 {
+		t.Errorf("Expected 404 for path-like scenario name, got %d", status)
+	}
+
+	t.Run("Difficulty", func(t *testing.T) {
+		status, resp := postJSON(t, app, "/sessions/from-scenario?difficulty=hard", []byte(`{"scenario":"goblin-ambush","seed":123}`))
+		if status != 200 {
+			t.Fatalf("Expected 200, got %d: %s", status, resp)
+		}
+		var hard struct {
+			State State `json:"state"`
+		}
+		json.Unmarshal([]byte(resp), &hard)
+
+		countEnemies := func(state State) (count, hp int) {
+			for _, char := range state.Characters {
+				if !char.IsPlayer {
+					count++
+					hp += char.Stats.HP
+				}
+			}
+			return count, hp
+		}
+		normalCount, normalHP := countEnemies(created.State)
+		hardCount, hardHP := countEnemies(hard.State)
+		if hardCount <= normalCount || hardHP <= normalHP {
+			t.Errorf("Expected hard mode to have more, tougher enemies: %d enemies/%d HP vs %d/%d", hardCount, hardHP, normalCount, normalHP)
+		}
+
+		if status, _ := postJSON(t, app, "/sessions/from-scenario?difficulty=nightmare", []byte(`{"scenario":"goblin-ambush"}`)); status != 400 {
+			t.Errorf("Expected 400 for unknown difficulty, got %d", status)
+		}
+	})
+}
+
+// TestApplyActionsBatch tests applying a sequence of actions in one call
+func TestApplyActionsBatch(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+
+	app := fiber.New()
+	setupRoutes(app)
+
+	player := createTestCharacter(true, "Player")
+	enemy := createTestCharacter(false, "Enemy")
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+	state.TurnOrder = []ID{player.ID, enemy.ID}
+
+	attack := Action{Kind: "Attack", Attacker: player.ID, Target: enemy.ID, Weapon: player.Weapons[0].ID}
+	defend := Action{Kind: "Defend", Actor: enemy.ID}
+
+	body, _ := json.Marshal(fiber.Map{"state": state, "actions": []Action{attack, defend}, "seed": 42})
+	status, resp := postJSON(t, app, "/tools/apply_actions", body)
+	if status != 200 {
+		t.Fatalf("Expected 200, got %d: %s", status, resp)
+	}
+
+	var result struct {
+		State   State    `json:"state"`
+		Events  []Event  `json:"events"`
+		Logs    []string `json:"logs"`
+		Applied int      `json:"applied"`
+	}
+	json.Unmarshal([]byte(resp), &result)
+
+	if result.Applied != 2 {
+		t.Errorf("Expected 2 actions applied, got %d", result.Applied)
+	}
+	if result.State.Round != 2 || result.State.CurrentTurn != 0 {
+		t.Errorf("Expected a full round to pass, got round %d turn %d", result.State.Round, result.State.CurrentTurn)
+	}
+	if GetCharacterByID(result.State, enemy.ID).Stats.Defense <= enemy.Stats.Defense {
+		t.Error("Expected enemy defense to increase from defend")
+	}
+	if len(result.Events) == 0 || result.Events[0].Type != "damage" {
+		t.Errorf("Expected attack damage event first, got %+v", result.Events)
+	}
+
+	// The same batch and seed must produce the same outcome
+	_, again := postJSON(t, app, "/tools/apply_actions", body)
+	var replay struct {
+		State State `json:"state"`
+	}
+	json.Unmarshal([]byte(again), &replay)
+	if GetCharacterByID(replay.State, enemy.ID).Stats.HP != GetCharacterByID(result.State, enemy.ID).Stats.HP {
+		t.Error("Expected batch to be deterministic for the same seed")
+	}
+
+	t.Run("StopsAtRejectedAction", func(t *testing.T) {
+		body, _ := json.Marshal(fiber.Map{"state": state, "actions": []Action{attack, {Kind: "Dance", Actor: enemy.ID}, defend}, "seed": 42})
+		status, resp := postJSON(t, app, "/tools/apply_actions", body)
+		if status != 422 || !strings.Contains(resp, "action 1 (Dance) was rejected") || !strings.Contains(resp, `"applied":1`) {
+			t.Errorf("Expected 422 reporting the rejected action, got %d: %s", status, resp)
+		}
+	})
+}
+
 // Helper function
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && (s[:len(substr)] == substr || s[len(s)-len(substr):] == substr || containsSubstring(s, substr)))
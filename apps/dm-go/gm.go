@@ -0,0 +1,233 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// gmSource marks events caused by the GM rather than a character
+const gmSource ID = "gm"
+
+// gmRequest is the body of the GM controls; each one reads the fields it needs
+type gmRequest struct {
+	Target string       `json:"target"` // Character ID or name
+	Amount int          `json:"amount"`
+	Item   Item         `json:"item"`
+	Effect StatusEffect `json:"effect"`
+	Stats  gmStats      `json:"stats"`
+}
+
+// gmStats are the stats a GM sets; any left out keep their value
+type gmStats struct {
+	MaxHP   *int `json:"maxHp"`
+	Attack  *int `json:"attack"`
+	Defense *int `json:"defense"`
+	Speed   *int `json:"speed"`
+}
+
+// gmChange applies a GM control to the target within state. It returns the
+// events and log lines the change produced, or a status and error refusing it.
+type gmChange func(state *State, target *Character, req gmRequest) ([]Event, []string, int, error)
+
+// requireGM only lets the session's DM token through to the GM controls
+func requireGM(c *fiber.Ctx) error {
+	if !sessionAuth.AuthorizeGM(c.Params("sessionId"), requestToken(c)) {
+		return c.Status(403).JSON(fiber.Map{"error": "Only the session's DM may use GM controls"})
+	}
+	return c.Next()
+}
+
+func handleGMHeal(c *fiber.Ctx) error {
+	return applyGMChange(c, "healed a character", gmHeal)
+}
+
+func handleGMDamage(c *fiber.Ctx) error {
+	return applyGMChange(c, "damaged a character", gmDamage)
+}
+
+func handleGMGrantItem(c *fiber.Ctx) error {
+	return applyGMChange(c, "granted an item", gmGrantItem)
+}
+
+func handleGMAddStatus(c *fiber.Ctx) error {
+	return applyGMChange(c, "added a status effect", gmAddStatus)
+}
+
+func handleGMSetStats(c *fiber.Ctx) error {
+	return applyGMChange(c, "set a character's stats", gmSetStats)
+}
+
+// applyGMChange applies a GM control to a live session. The change is saved,
+// snapshotted and broadcast like an action, but doesn't take a turn. GM
+// changes can't be undone, and since undoing an earlier action would quietly
+// revert them, the session's undo history is dropped.
+func applyGMChange(c *fiber.Ctx, verb string, change gmChange) error {
+	// Params point into fasthttp's reused buffer, and storing the state
+	// rewrites the map key, so keep a copy that outlives the request
+	sessionID := strings.Clone(c.Params("sessionId"))
+
+	unlock := sessionLocks.Lock(sessionID)
+	defer unlock()
+	state, exists := stateManager.GetState(sessionID)
+	if !exists {
+		return c.Status(404).JSON(fiber.Map{"error": "Session not found"})
+	}
+
+	var req gmRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.Target == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Missing target"})
+	}
+
+	newState := deepCopyState(state)
+	target := GetCharacterByID(newState, ID(req.Target))
+	if target == nil {
+		var err error
+		if target, err = GetCharacterByName(newState, req.Target); err != nil {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	events, logs, status, err := change(&newState, target, req)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	appendCombatLog(&newState, logs)
+
+	logger := sessionLogger(sessionID).With("round", newState.Round)
+	stateManager.SetState(sessionID, newState)
+	stateManager.ClearUndo(sessionID)
+	if err := eventStore.AppendEvents(sessionID, newState.Round, events); err != nil {
+		logger.Error("Failed to append events", "error", err)
+	}
+	if err := eventStore.SaveSnapshot(sessionID, newState.Round, newState); err != nil {
+		logger.Error("Failed to save snapshot", "error", err)
+	}
+	turnTimers.Reset(sessionID, newState)
+	eventBus.Publish(sessionID, newState, events)
+
+	logger.Info("GM "+verb, "target", target.ID)
+
+	return c.JSON(fiber.Map{
+		"success":   true,
+		"sessionId": sessionID,
+		"events":    events,
+		"logs":      logs,
+		"state":     newState,
+	})
+}
+
+func gmHeal(state *State, target *Character, req gmRequest) ([]Event, []string, int, error) {
+	if req.Amount <= 0 {
+		return nil, nil, 400, errors.New("amount must be positive")
+	}
+	if target.Stats.HP <= 0 && !isDowned(*target) {
+		return nil, nil, 409, fmt.Errorf("%s has been defeated and can't be healed", target.Name)
+	}
+
+	healed := target.ApplyHeal(req.Amount)
+	events := []Event{{Type: "heal", Target: target.ID, Amount: healed, Source: gmSource}}
+	logs := []string{fmt.Sprintf("The GM heals %s for %d HP.", target.Name, healed)}
+	return events, logs, 0, nil
+}
+
+func gmDamage(state *State, target *Character, req gmRequest) ([]Event, []string, int, error) {
+	if req.Amount <= 0 {
+		return nil, nil, 400, errors.New("amount must be positive")
+	}
+	if target.Stats.HP <= 0 && !isDowned(*target) {
+		return nil, nil, 409, fmt.Errorf("%s has already been defeated", target.Name)
+	}
+
+	dealt := target.ApplyDamage(req.Amount)
+	events := []Event{{Type: "damage", Target: target.ID, Amount: dealt, Source: gmSource}}
+	logs := []string{fmt.Sprintf("The GM deals %d damage to %s.", dealt, target.Name)}
+	if target.Stats.HP == 0 {
+		koEvents, koLogs := knockOut(state, target)
+		for i := range koEvents {
+			koEvents[i].Source = gmSource
+		}
+		events, logs = append(events, koEvents...), append(logs, koLogs...)
+		checkCombatEnd(state)
+	}
+	return events, logs, 0, nil
+}
+
+func gmGrantItem(state *State, target *Character, req gmRequest) ([]Event, []string, int, error) {
+	if strings.TrimSpace(req.Item.Name) == "" {
+		return nil, nil, 400, errors.New("item must have a name")
+	}
+	if !hasRoomForItem(target) {
+		return nil, nil, 409, fmt.Errorf("%s can't carry any more items", target.Name)
+	}
+
+	item := req.Item
+	if item.ID == "" {
+		item.ID = NewID()
+	}
+	target.Items = append(target.Items, item)
+	events := []Event{{Type: "item_granted", Target: target.ID, Item: item.ID, Source: gmSource}}
+	logs := []string{fmt.Sprintf("The GM gives %s a %s.", target.Name, item.Name)}
+	return events, logs, 0, nil
+}
+
+func gmAddStatus(state *State, target *Character, req gmRequest) ([]Event, []string, int, error) {
+	effect := req.Effect
+	if !statusEffectTypes[effect.Type] {
+		return nil, nil, 400, fmt.Errorf("unknown status effect %q", effect.Type)
+	}
+	if effect.Amount <= 0 || effect.Duration <= 0 {
+		return nil, nil, 400, errors.New("status effect amount and duration must be positive")
+	}
+
+	applyStatusEffect(target, effect)
+	events := []Event{{Type: "status_added", Target: target.ID, Amount: effect.Amount, Source: gmSource, Effect: effect.Type}}
+	logs := []string{fmt.Sprintf("The GM gives %s %s for %d turns.", target.Name, effect.Type, effect.Duration)}
+	return events, logs, 0, nil
+}
+
+// gmSetStats sets a character's max HP, attack, defense and speed. Values
+// over the configured stat bounds are lowered to them, with a log line saying
+// so. Defense is set without the bonus of any defensive stance the character
+// holds, and HP drops to a lowered max HP.
+func gmSetStats(state *State, target *Character, req gmRequest) ([]Event, []string, int, error) {
+	set := req.Stats
+	if set.MaxHP == nil && set.Attack == nil && set.Defense == nil && set.Speed == nil {
+		return nil, nil, 400, errors.New("no stats to set")
+	}
+
+	stats := baseStats(*target)
+	stanceBonus := target.Stats.Defense - stats.Defense
+	for _, field := range []struct {
+		name  string
+		value *int
+		to    *int
+	}{
+		{"maxHp", &stats.MaxHP, set.MaxHP},
+		{"attack", &stats.Attack, set.Attack},
+		{"defense", &stats.Defense, set.Defense},
+		{"speed", &stats.Speed, set.Speed},
+	} {
+		if field.to == nil {
+			continue
+		}
+		if *field.to < 0 || (field.name == "maxHp" && *field.to < 1) {
+			return nil, nil, 400, fmt.Errorf("%s can't be set to %d", field.name, *field.to)
+		}
+		*field.value = *field.to
+	}
+
+	logs := []string{fmt.Sprintf("The GM sets %s's stats.", target.Name)}
+	logs = append(logs, statBounds.Clamp(target.Name, &stats)...)
+	stats.HP = min(stats.HP, stats.MaxHP)
+	stats.Defense += stanceBonus
+	target.Stats = stats
+
+	events := []Event{{Type: "stats_set", Target: target.ID, Source: gmSource}}
+	return events, logs, 0, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestGMControls(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	sessionAuth = NewSessionAuth()
+	app := fiber.New()
+	setupRoutes(app)
+
+	hero := createTestCharacter(true, "Hero")
+	hero.Stats.HP = 10
+	hero.MaxItems = 2
+	goblin := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+	stateManager.SetState("gm-session", state)
+	playerToken, dmToken := issueSessionTokens("gm-session", state)
+
+	var broadcasts [][]Event
+	defer eventBus.Subscribe(func(sessionID string, state State, events []Event) { broadcasts = append(broadcasts, events) })()
+
+	post := func(path, token string, body interface{}) (int, State) {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", path, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(playerTokenHeader, token)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Request to %s failed: %v", path, err)
+		}
+		respBody, _ := io.ReadAll(resp.Body)
+		var result struct {
+			State State `json:"state"`
+		}
+		json.Unmarshal(respBody, &result)
+		return resp.StatusCode, result.State
+	}
+
+	heal := fiber.Map{"target": "hero", "amount": 15}
+	if status, _ := post("/sessions/gm-session/gm/heal", playerToken, heal); status != 403 {
+		t.Errorf("Expected players to be refused GM controls, got %d", status)
+	}
+	if status, _ := post("/sessions/gm-session/gm/heal", dmToken, fiber.Map{"target": "Nobody", "amount": 15}); status != 404 {
+		t.Errorf("Expected 404 for an unknown target, got %d", status)
+	}
+	if status, _ := post("/sessions/gm-session/gm/heal", dmToken, fiber.Map{"target": "Hero", "amount": 0}); status != 400 {
+		t.Errorf("Expected 400 for a zero heal, got %d", status)
+	}
+	if len(broadcasts) != 0 {
+		t.Fatalf("Expected refused changes not to be broadcast, got %v", broadcasts)
+	}
+
+	// Heals by name, capped at max HP
+	status, healed := post("/api/v1/sessions/gm-session/gm/heal", dmToken, heal)
+	if status != 200 || healed.Characters[0].Stats.HP != 25 {
+		t.Fatalf("Expected the hero to be healed to 25 HP, got %d with %+v", status, healed.Characters[0].Stats)
+	}
+	status, healed = post("/sessions/gm-session/gm/heal", dmToken, fiber.Map{"target": hero.ID, "amount": 100})
+	if status != 200 || healed.Characters[0].Stats.HP != 30 {
+		t.Errorf("Expected the heal to stop at max HP, got %d with %+v", status, healed.Characters[0].Stats)
+	}
+	if len(broadcasts) != 2 || broadcasts[0][0] != (Event{Type: "heal", Target: hero.ID, Amount: 15, Source: gmSource}) {
+		t.Errorf("Expected each heal to broadcast a GM heal event, got %v", broadcasts)
+	}
+
+	// Grants an item, up to the character's carrying limit
+	potion := fiber.Map{"target": hero.ID, "item": Item{Name: "Elixir", Type: "consumable", Effect: "heal 20"}}
+	status, granted := post("/sessions/gm-session/gm/grant-item", dmToken, potion)
+	items := granted.Characters[0].Items
+	if status != 200 || len(items) != 2 || items[1].Name != "Elixir" || items[1].ID == "" {
+		t.Fatalf("Expected the hero to receive the elixir, got %d with %+v", status, items)
+	}
+	if status, _ := post("/sessions/gm-session/gm/grant-item", dmToken, potion); status != 409 {
+		t.Errorf("Expected 409 once the hero's pack is full, got %d", status)
+	}
+	if event := broadcasts[2][0]; event.Type != "item_granted" || event.Item != items[1].ID || event.Source != gmSource {
+		t.Errorf("Expected an item granted event from the GM, got %+v", event)
+	}
+
+	// Live state, history and snapshot all reflect the changes
+	live, _ := stateManager.GetState("gm-session")
+	if live.Characters[0].Stats.HP != 30 || len(live.Characters[0].Items) != 2 || live.CurrentTurn != state.CurrentTurn {
+		t.Errorf("Expected the changes to be live without taking a turn, got %+v on turn %d", live.Characters[0], live.CurrentTurn)
+	}
+	if events, _ := eventStore.GetEvents("gm-session", 0); len(events) != 3 {
+		t.Errorf("Expected the GM events to be stored, got %v", events)
+	}
+	if snapshot, _ := eventStore.GetLatestSnapshot("gm-session"); snapshot == nil || len(snapshot.Characters[0].Items) != 2 {
+		t.Errorf("Expected the changes to be snapshotted, got %+v", snapshot)
+	}
+}
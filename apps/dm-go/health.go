@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// errSubsystemNotInitialized is reported for subsystems that were never set up
+var errSubsystemNotInitialized = errors.New("not initialized")
+
+// checkSubsystems reports the status of each subsystem the server needs to
+// serve games, and whether all of them are up
+func checkSubsystems() (fiber.Map, bool) {
+	healthy := true
+	subsystems := fiber.Map{}
+
+	report := func(name string, err error) {
+		if err != nil {
+			healthy = false
+			subsystems[name] = fiber.Map{"status": "down", "error": err.Error()}
+			return
+		}
+		subsystems[name] = fiber.Map{"status": "ok"}
+	}
+
+	if eventStore == nil {
+		report("database", errSubsystemNotInitialized)
+	} else {
+		report("database", eventStore.Ping())
+	}
+
+	// The JSON API works without templates, so missing templates only
+	// degrade the server rather than making it unhealthy
+	if templateEngine == nil {
+		subsystems["templates"] = fiber.Map{"status": "degraded", "error": errSubsystemNotInitialized.Error()}
+	} else {
+		report("templates", nil)
+	}
+
+	if stateManager == nil {
+		report("sessions", errSubsystemNotInitialized)
+	} else {
+		report("sessions", nil)
+	}
+
+	return subsystems, healthy
+}
+
+func handleHealth(c *fiber.Ctx) error {
+	subsystems, healthy := checkSubsystems()
+
+	activeSessions := 0
+	if stateManager != nil {
+		activeSessions = stateManager.GetStateCount()
+	}
+
+	response := fiber.Map{
+		"status":         "ok",
+		"timestamp":      time.Now().Format(time.RFC3339),
+		"activeSessions": activeSessions,
+		"subsystems":     subsystems,
+	}
+	if !healthy {
+		response["status"] = "unhealthy"
+		return c.Status(503).JSON(response)
+	}
+
+	return c.JSON(response)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestHealthEndpoint(t *testing.T) {
+	stateManager = NewStateManager()
+	stateManager.SetState("health-session", State{Round: 1})
+
+	te, err := NewTemplateEngine()
+	if err != nil {
+		t.Fatalf("Failed to create template engine: %v", err)
+	}
+	templateEngine = te
+
+	store, err := NewEventStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+	previousStore := eventStore
+	eventStore = store
+	t.Cleanup(func() { eventStore = previousStore })
+
+	app := fiber.New()
+	setupRoutes(app)
+
+	getHealth := func() (int, map[string]interface{}) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/health", nil))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		var body map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body
+	}
+
+	status, body := getHealth()
+	if status != 200 || body["status"] != "ok" || body["timestamp"] == nil {
+		t.Fatalf("Expected healthy response, got %d: %v", status, body)
+	}
+	if body["activeSessions"] != float64(1) {
+		t.Errorf("Expected 1 active session, got %v", body["activeSessions"])
+	}
+
+	store.Close()
+
+	status, body = getHealth()
+	if status != 503 || body["status"] != "unhealthy" {
+		t.Fatalf("Expected 503 unhealthy with a closed database, got %d: %v", status, body)
+	}
+	subsystems, _ := body["subsystems"].(map[string]interface{})
+	database, _ := subsystems["database"].(map[string]interface{})
+	if database["status"] != "down" || database["error"] == nil {
+		t.Errorf("Expected database reported down, got %v", subsystems)
+	}
+	templates, _ := subsystems["templates"].(map[string]interface{})
+	if templates["status"] != "ok" {
+		t.Errorf("Expected templates reported ok, got %v", subsystems)
+	}
+}
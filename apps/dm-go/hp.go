@@ -0,0 +1,49 @@
+package main
+
+// maxHPChange caps a single hit or heal. Client-supplied weapons and abilities
+// can carry any Power or Damage, and anything beyond this is treated as this.
+const maxHPChange = 1_000_000
+
+// clampHPChange treats negative amounts as no change and caps absurd ones
+func clampHPChange(amount int) int {
+	if amount < 0 {
+		return 0
+	}
+	if amount > maxHPChange {
+		return maxHPChange
+	}
+	return amount
+}
+
+// ApplyDamage lowers the character's HP by amount, never below 0, and returns
+// the HP actually lost
+func (c *Character) ApplyDamage(amount int) int {
+	amount = clampHPChange(amount)
+	if c.Stats.HP <= 0 {
+		c.Stats.HP = 0
+		return 0
+	}
+	if amount > c.Stats.HP {
+		amount = c.Stats.HP
+	}
+	c.Stats.HP -= amount
+	return amount
+}
+
+// ApplyHeal raises the character's HP by amount, never above MaxHP, and returns
+// the HP actually restored. Any healing brings a downed character back.
+func (c *Character) ApplyHeal(amount int) int {
+	amount = clampHPChange(amount)
+	missing := c.Stats.MaxHP - c.Stats.HP
+	if missing <= 0 {
+		return 0
+	}
+	if amount > missing {
+		amount = missing
+	}
+	c.Stats.HP += amount
+	if c.Stats.HP > 0 {
+		c.Downed = nil
+	}
+	return amount
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestApplyDamageAndHealClamp(t *testing.T) {
+	char := createTestCharacter(true, "Hero")
+	char.Stats.HP = 10
+
+	if lost := char.ApplyDamage(25); lost != 10 || char.Stats.HP != 0 {
+		t.Errorf("Expected damage to stop at 0 HP, lost %d and left %d HP", lost, char.Stats.HP)
+	}
+	if lost := char.ApplyDamage(5); lost != 0 || char.Stats.HP != 0 {
+		t.Errorf("Expected no further damage at 0 HP, lost %d and left %d HP", lost, char.Stats.HP)
+	}
+
+	char.Stats.HP = 25
+	if healed := char.ApplyHeal(20); healed != 5 || char.Stats.HP != char.Stats.MaxHP {
+		t.Errorf("Expected healing to stop at %d HP, healed %d to %d HP", char.Stats.MaxHP, healed, char.Stats.HP)
+	}
+
+	// Negative amounts must not heal through damage or hurt through healing
+	if lost := char.ApplyDamage(-10); lost != 0 || char.Stats.HP != char.Stats.MaxHP {
+		t.Errorf("Expected negative damage to do nothing, lost %d and left %d HP", lost, char.Stats.HP)
+	}
+	char.Stats.HP = 10
+	if healed := char.ApplyHeal(-10); healed != 0 || char.Stats.HP != 10 {
+		t.Errorf("Expected negative healing to do nothing, healed %d to %d HP", healed, char.Stats.HP)
+	}
+}
+
+func TestHugeDamageDoesNotOverflow(t *testing.T) {
+	char := createTestCharacter(true, "Hero")
+
+	char.ApplyDamage(math.MaxInt)
+	if char.Stats.HP != 0 {
+		t.Errorf("Expected huge damage to leave 0 HP, got %d", char.Stats.HP)
+	}
+	char.ApplyHeal(math.MaxInt)
+	if char.Stats.HP != char.Stats.MaxHP {
+		t.Errorf("Expected huge healing to stop at max HP, got %d", char.Stats.HP)
+	}
+
+	// A client-supplied ability with absurd power just knocks the target out
+	caster := createTestCharacter(true, "Caster")
+	target := createTestCharacter(false, "Target")
+	caster.Abilities = []Ability{{ID: NewID(), Name: "Meteor", Effect: "damage", Power: math.MaxInt - 10}}
+	state := CreateInitialState([]Character{caster}, []Character{target}, 42)
+	state.TurnOrder = []ID{caster.ID, target.ID}
+
+	resolution := ApplyAction(state, Action{Kind: "Ability", Actor: caster.ID, Ability: caster.Abilities[0].ID, Target: target.ID}, 42)
+	if hp := GetCharacterByID(resolution.State, target.ID).Stats.HP; hp != 0 {
+		t.Errorf("Expected the target at 0 HP, got %d (logs: %v)", hp, resolution.Logs)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import "github.com/gofiber/fiber/v2"
+
+// AbilityCooldown is how many turns an ability has left before it can be used again
+type AbilityCooldown struct {
+	Ability   ID     `json:"ability"`
+	Name      string `json:"name"`
+	Remaining int    `json:"remaining"`
+	Ready     bool   `json:"ready"`
+}
+
+// CharacterDetail is a character's full sheet plus the derived information an
+// inspect panel or LLM tool would otherwise have to work out itself
+type CharacterDetail struct {
+	Character
+	Team          string            `json:"team"`
+	Active        bool              `json:"active"`
+	CurrentTurn   bool              `json:"currentTurn"`
+	Cooldowns     []AbilityCooldown `json:"cooldowns"`
+	ActiveEffects []StatusEffect    `json:"activeEffects"`
+
+	// Characters each ability may be used on, by ability ID
+	AbilityTargets map[ID][]ID `json:"abilityTargets"`
+}
+
+// InspectCharacter returns the full detail for one character, or false if the
+// state has no character with that ID
+func InspectCharacter(state State, id ID) (CharacterDetail, bool) {
+	char := GetCharacterByID(state, id)
+	if char == nil {
+		return CharacterDetail{}, false
+	}
+
+	detail := CharacterDetail{
+		Character:      *char,
+		Team:           CharacterTeam(*char),
+		Active:         isActive(*char),
+		Cooldowns:      []AbilityCooldown{},
+		ActiveEffects:  []StatusEffect{},
+		AbilityTargets: make(map[ID][]ID),
+	}
+	if current := GetCurrentCharacter(state); current != nil && current.ID == char.ID {
+		detail.CurrentTurn = true
+	}
+	for _, ability := range char.Abilities {
+		remaining := RemainingCooldown(char, ability.ID)
+		detail.Cooldowns = append(detail.Cooldowns, AbilityCooldown{
+			Ability:   ability.ID,
+			Name:      ability.Name,
+			Remaining: remaining,
+			Ready:     remaining == 0,
+		})
+		detail.AbilityTargets[ability.ID] = AbilityTargets(state, *char, ability)
+	}
+	for _, effect := range char.StatusEffects {
+		if effect.Duration > 0 {
+			detail.ActiveEffects = append(detail.ActiveEffects, effect)
+		}
+	}
+	return detail, true
+}
+
+func handleInspectCharacter(c *fiber.Ctx) error {
+	var req struct {
+		State       State `json:"state"`
+		CharacterID ID    `json:"characterId"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if req.State.Round == 0 || req.CharacterID == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "State and characterId are required"})
+	}
+
+	if err := validateStatePayload(req.State); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	detail, found := InspectCharacter(req.State, req.CharacterID)
+	if !found {
+		return c.Status(404).JSON(fiber.Map{"error": "Character not found"})
+	}
+
+	return sendJSON(c, detail)
+}
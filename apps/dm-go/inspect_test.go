@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestInspectCharacterEndpoint(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	sessionAuth = NewSessionAuth()
+	app := fiber.New()
+	setupRoutes(app)
+
+	hero := createTestCharacter(true, "Hero")
+	hero.Position = Position{X: 2, Y: 3}
+	hero.AbilityCooldowns[string(hero.Abilities[0].ID)] = 2
+	hero.StatusEffects = []StatusEffect{{Type: "poison", Amount: 2, Duration: 3}}
+	enemy := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{hero}, []Character{enemy}, 42)
+	state.TurnOrder = []ID{hero.ID, enemy.ID}
+
+	body, _ := json.Marshal(fiber.Map{"state": state, "characterId": hero.ID})
+	status, resp := postJSON(t, app, "/tools/inspect_character", body)
+	if status != 200 {
+		t.Fatalf("Expected 200, got %d: %s", status, resp)
+	}
+
+	var detail CharacterDetail
+	if err := json.Unmarshal([]byte(resp), &detail); err != nil {
+		t.Fatalf("Failed to decode detail: %v", err)
+	}
+	if detail.ID != hero.ID || detail.Name != "Hero" || detail.Position != hero.Position {
+		t.Errorf("Expected Hero's identity and position, got %+v", detail.Character)
+	}
+	if detail.Stats != hero.Stats {
+		t.Errorf("Expected stats %+v, got %+v", hero.Stats, detail.Stats)
+	}
+	if len(detail.Weapons) != 1 || detail.Weapons[0].Name != "Test Weapon" || detail.Weapons[0].Damage != hero.Weapons[0].Damage {
+		t.Errorf("Expected the full weapon list, got %+v", detail.Weapons)
+	}
+	if len(detail.Items) != 1 || detail.Items[0].Name != "Health Potion" {
+		t.Errorf("Expected the inventory, got %+v", detail.Items)
+	}
+	if detail.Team != "player" || !detail.Active || !detail.CurrentTurn {
+		t.Errorf("Expected an active player on their turn, got team %q active %v current %v", detail.Team, detail.Active, detail.CurrentTurn)
+	}
+	if len(detail.Cooldowns) != 1 || detail.Cooldowns[0].Name != "Test Ability" || detail.Cooldowns[0].Remaining != 2 || detail.Cooldowns[0].Ready {
+		t.Errorf("Expected Test Ability with 2 turns of cooldown left, got %+v", detail.Cooldowns)
+	}
+	if len(detail.ActiveEffects) != 1 || detail.ActiveEffects[0].Type != "poison" {
+		t.Errorf("Expected the poison effect, got %+v", detail.ActiveEffects)
+	}
+
+	body, _ = json.Marshal(fiber.Map{"state": state, "characterId": enemy.ID})
+	status, resp = postJSON(t, app, "/tools/inspect_character", body)
+	if status != 200 || !contains(resp, `"currentTurn":false`) || !contains(resp, `"ready":true`) {
+		t.Errorf("Expected the enemy off-turn with its ability ready, got %d: %s", status, resp)
+	}
+
+	body, _ = json.Marshal(fiber.Map{"state": state, "characterId": "nobody"})
+	if status, resp := postJSON(t, app, "/tools/inspect_character", body); status != 404 {
+		t.Errorf("Expected 404 for an unknown character, got %d: %s", status, resp)
+	}
+}
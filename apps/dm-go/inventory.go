@@ -0,0 +1,86 @@
+package main
+
+import "fmt"
+
+// positionKey is the GroundItems key for a board position
+func positionKey(pos Position) string {
+	return fmt.Sprintf("%d,%d", pos.X, pos.Y)
+}
+
+// hasRoomForItem reports whether the character can carry another item
+func hasRoomForItem(char *Character) bool {
+	return char.MaxItems <= 0 || len(char.Items) < char.MaxItems
+}
+
+// dropLoot moves the items of defeated characters onto the ground at their position
+func dropLoot(state *State) ([]Event, []string) {
+	var events []Event
+	var logs []string
+
+	for i := range state.Characters {
+		char := &state.Characters[i]
+		if char.Stats.HP > 0 || isDowned(*char) || len(char.Items) == 0 {
+			continue
+		}
+
+		if state.GroundItems == nil {
+			state.GroundItems = make(map[string][]Item)
+		}
+		key := positionKey(char.Position)
+		state.GroundItems[key] = append(state.GroundItems[key], char.Items...)
+
+		for _, item := range char.Items {
+			events = append(events, Event{
+				Type:           "loot_dropped",
+				Actor:          char.ID,
+				Item:           item.ID,
+				TargetPosition: positionOf(char),
+			})
+			logs = append(logs, fmt.Sprintf("%s drops %s.", char.Name, item.Name))
+		}
+		char.Items = nil
+	}
+
+	return events, logs
+}
+
+// handlePickUp moves an item from the ground into the actor's inventory. There's
+// no movement on the board yet, so any ground item is within reach.
+func handlePickUp(state *State, action Action, rng *SeededRNG, events []Event, logs []string) Resolution {
+	character := GetCharacterByID(*state, action.Actor)
+
+	if character == nil {
+		return rejectAction(*state, events, logs, RejectActorNotFound, "Invalid pick up action")
+	}
+
+	for key, items := range state.GroundItems {
+		for i, item := range items {
+			if item.ID != action.Item {
+				continue
+			}
+
+			if !hasRoomForItem(character) {
+				return rejectAction(*state, events, logs, RejectInventoryFull, fmt.Sprintf("%s can't carry any more items!", character.Name))
+			}
+
+			character.Items = append(character.Items, item)
+			if remaining := append(items[:i:i], items[i+1:]...); len(remaining) > 0 {
+				state.GroundItems[key] = remaining
+			} else {
+				delete(state.GroundItems, key)
+			}
+
+			events = append(events, Event{
+				Type:  "item_picked_up",
+				Actor: character.ID,
+				Item:  item.ID,
+			})
+			logs = append(logs, fmt.Sprintf("%s picks up %s.", character.Name, item.Name))
+
+			updatedState, turnEvents, turnLogs := advanceTurn(*state)
+			return Resolution{Events: append(events, turnEvents...), State: updatedState, Logs: append(logs, turnLogs...)}
+		}
+	}
+
+	return rejectAction(*state, events, logs, RejectItemNotFound, "Item not found on the ground")
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLootDropsOnDeath(t *testing.T) {
+	player := createTestCharacter(true, "Player")
+	player.Stats.Attack = 100
+	enemy := createTestCharacter(false, "Enemy")
+	enemy.Stats.HP = 1
+	enemy.Position = Position{X: 4, Y: 2}
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+	state.TurnOrder = []ID{player.ID, enemy.ID}
+
+	resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: player.ID, Target: enemy.ID, Weapon: player.Weapons[0].ID}, 12345)
+
+	if items := GetCharacterByID(resolution.State, enemy.ID).Items; len(items) != 0 {
+		t.Errorf("Expected defeated enemy to drop their items, still has %+v", items)
+	}
+	ground := resolution.State.GroundItems["4,2"]
+	if len(ground) != 1 || ground[0].ID != enemy.Items[0].ID {
+		t.Fatalf("Expected the potion on the ground at 4,2, got %+v", resolution.State.GroundItems)
+	}
+
+	dropped := false
+	for _, event := range resolution.Events {
+		if event.Type == "loot_dropped" && event.Actor == enemy.ID && event.Item == enemy.Items[0].ID {
+			dropped = true
+		}
+	}
+	if !dropped {
+		t.Errorf("Expected loot_dropped event, got %+v", resolution.Events)
+	}
+}
+
+func TestPickUpRespectsInventoryLimit(t *testing.T) {
+	player := createTestCharacter(true, "Player")
+	ally := createTestCharacter(true, "Ally")
+	enemy := createTestCharacter(false, "Enemy")
+	state := CreateInitialState([]Character{player, ally}, []Character{enemy}, 12345)
+	state.TurnOrder = []ID{player.ID, ally.ID, enemy.ID}
+
+	loot := Item{ID: NewID(), Name: "Goblin Ear", Type: "equipment"}
+	state.GroundItems = map[string][]Item{"1,1": {loot}}
+
+	t.Run("Full", func(t *testing.T) {
+		state := deepCopyState(state)
+		GetCharacterByID(state, player.ID).MaxItems = 1 // Already carrying a potion
+
+		resolution := ApplyAction(state, Action{Kind: "PickUp", Actor: player.ID, Item: loot.ID}, 12345)
+		if !strings.Contains(strings.Join(resolution.Logs, "\n"), "can't carry any more items") {
+			t.Errorf("Expected pick up to be rejected, got logs %v", resolution.Logs)
+		}
+		if resolution.State.CurrentTurn != 0 || len(resolution.State.GroundItems["1,1"]) != 1 {
+			t.Error("Expected a rejected pick up to leave the turn and ground untouched")
+		}
+	})
+
+	t.Run("Room", func(t *testing.T) {
+		state := deepCopyState(state)
+		GetCharacterByID(state, player.ID).MaxItems = 2
+
+		resolution := ApplyAction(state, Action{Kind: "PickUp", Actor: player.ID, Item: loot.ID}, 12345)
+		items := GetCharacterByID(resolution.State, player.ID).Items
+		if len(items) != 2 || items[1].ID != loot.ID {
+			t.Errorf("Expected loot in the inventory, got %+v", items)
+		}
+		if len(resolution.State.GroundItems) != 0 {
+			t.Errorf("Expected the ground to be empty, got %+v", resolution.State.GroundItems)
+		}
+		if resolution.State.CurrentTurn != 1 {
+			t.Errorf("Expected pick up to take the turn, got turn %d", resolution.State.CurrentTurn)
+		}
+
+		// Someone else can't grab the same item again
+		resolution = ApplyAction(resolution.State, Action{Kind: "PickUp", Actor: ally.ID, Item: loot.ID}, 12345)
+		if !strings.Contains(strings.Join(resolution.Logs, "\n"), "Item not found on the ground") {
+			t.Errorf("Expected missing ground item, got logs %v", resolution.Logs)
+		}
+	})
+}
+
+func TestChargedItemUsedUntilEmpty(t *testing.T) {
+	player := createTestCharacter(true, "Player")
+	player.Items[0].Charges = 3
+	potion := player.Items[0]
+	state := CreateInitialState([]Character{player}, []Character{createTestCharacter(false, "Enemy")}, 12345)
+
+	for _, remaining := range []int{2, 1, 0} {
+		GetCharacterByID(state, player.ID).Stats.HP = 1
+		state.TurnOrder = []ID{player.ID}
+		state.CurrentTurn = 0
+		resolution := ApplyAction(state, Action{Kind: "UseItem", Actor: player.ID, Item: potion.ID}, 12345)
+		state = resolution.State
+
+		items := GetCharacterByID(state, player.ID).Items
+		if remaining > 0 && (len(items) != 1 || items[0].Charges != remaining) {
+			t.Fatalf("Expected the potion to stay with %d charges, got %+v", remaining, items)
+		}
+		if remaining == 0 && len(items) != 0 {
+			t.Fatalf("Expected the last charge to use up the potion, got %+v", items)
+		}
+		if used := resolution.Events[0]; used.Type != "item_used" || used.Item != potion.ID || used.Charges != remaining {
+			t.Errorf("Expected an item_used event with %d charges left, got %+v", remaining, used)
+		}
+		if GetCharacterByID(state, player.ID).Stats.HP <= 1 {
+			t.Errorf("Expected every charge to heal, got %v", resolution.Logs)
+		}
+	}
+
+	// Used up, the potion can't be used again
+	resolution := ApplyAction(state, Action{Kind: "UseItem", Actor: player.ID, Item: potion.ID}, 12345)
+	if resolution.RejectReason != RejectItemNotFound {
+		t.Errorf("Expected the spent potion to be gone, got %v", resolution.Logs)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+// isRangedWeapon reports whether attacks with the weapon are shots that cover can block
+func isRangedWeapon(weapon Weapon) bool {
+	return weaponEffect(weapon) == "arrow"
+}
+
+// HasLineOfSight reports whether a straight line between two squares is free
+// of obstacles. The squares at either end never block. On a wrapping board
+// the line takes the shorter way round, across an edge if need be.
+func HasLineOfSight(state State, from, to Position) bool {
+	if len(state.Obstacles) == 0 {
+		return true
+	}
+
+	blocked := make(map[Position]bool, len(state.Obstacles))
+	for _, obstacle := range state.Obstacles {
+		blocked[obstacle] = true
+	}
+
+	dx, dy := boardOffset(state.Rules, from, to)
+	for _, square := range lineBetween(from, Position{X: from.X + dx, Y: from.Y + dy}) {
+		if state.Rules.BoardMode == boardWrap {
+			square = wrapPosition(square)
+		}
+		if blocked[square] {
+			return false
+		}
+	}
+	return true
+}
+
+// lineBetween returns the squares strictly between two positions along a
+// Bresenham line
+func lineBetween(from, to Position) []Position {
+	dx, dy := abs(to.X-from.X), -abs(to.Y-from.Y)
+	stepX, stepY := 1, 1
+	if from.X > to.X {
+		stepX = -1
+	}
+	if from.Y > to.Y {
+		stepY = -1
+	}
+
+	var squares []Position
+	x, y, err := from.X, from.Y, dx+dy
+	for {
+		if x == to.X && y == to.Y {
+			return squares
+		}
+		if e2 := 2 * err; e2 >= dy {
+			err += dy
+			x += stepX
+		} else {
+			err += dx
+			y += stepY
+		}
+		if x != to.X || y != to.Y {
+			squares = append(squares, Position{X: x, Y: y})
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHasLineOfSight(t *testing.T) {
+	state := State{Obstacles: []Position{{X: 3, Y: 3}}}
+
+	tests := []struct {
+		name     string
+		from, to Position
+		want     bool
+	}{
+		{"Clear", Position{X: 0, Y: 0}, Position{X: 5, Y: 0}, true},
+		{"BlockedRow", Position{X: 0, Y: 3}, Position{X: 6, Y: 3}, false},
+		{"BlockedDiagonal", Position{X: 1, Y: 1}, Position{X: 5, Y: 5}, false},
+		{"Adjacent", Position{X: 3, Y: 2}, Position{X: 3, Y: 4}, false},
+		{"EndpointOnObstacle", Position{X: 0, Y: 3}, Position{X: 3, Y: 3}, true},
+		{"AroundTheCorner", Position{X: 2, Y: 2}, Position{X: 4, Y: 2}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasLineOfSight(state, tt.from, tt.to); got != tt.want {
+				t.Errorf("HasLineOfSight(%v, %v) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRangedAttackNeedsLineOfSight(t *testing.T) {
+	player := createTestCharacter(true, "Archer")
+	player.Weapons[0].Name = "Longbow"
+	player.Position = Position{X: 0, Y: 0}
+	enemy := createTestCharacter(false, "Goblin")
+	enemy.Position = Position{X: 4, Y: 0}
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+	state.TurnOrder = []ID{player.ID, enemy.ID}
+	state.Obstacles = []Position{{X: 2, Y: 0}}
+
+	action := Action{Kind: "Attack", Attacker: player.ID, Target: enemy.ID, Weapon: player.Weapons[0].ID}
+	resolution := ApplyAction(state, action, 12345)
+	if !strings.Contains(strings.Join(resolution.Logs, "\n"), "has no clear shot") {
+		t.Errorf("Expected blocked shot, got logs %v", resolution.Logs)
+	}
+	if resolution.State.CurrentTurn != 0 || len(resolution.Events) != 0 {
+		t.Errorf("Expected a blocked shot to leave the turn untouched, got turn %d and events %+v", resolution.State.CurrentTurn, resolution.Events)
+	}
+
+	// Melee weapons ignore cover
+	state.Characters[0].Weapons[0].Name = "Sword"
+	resolution = ApplyAction(state, action, 12345)
+	if resolution.State.CurrentTurn != 1 {
+		t.Errorf("Expected melee attack to resolve, got logs %v", resolution.Logs)
+	}
+}
+
+func TestEnemyAIWorksAroundCover(t *testing.T) {
+	hidden := createTestCharacter(true, "Hidden")
+	hidden.Stats.HP = 5 // Weakest, so the preferred target
+	hidden.Position = Position{X: 0, Y: 0}
+	exposed := createTestCharacter(true, "Exposed")
+	exposed.Position = Position{X: 4, Y: 4}
+	archer := createTestCharacter(false, "Archer")
+	archer.Weapons[0].Name = "Longbow"
+	archer.Position = Position{X: 4, Y: 0}
+	state := CreateInitialState([]Character{hidden, exposed}, []Character{archer}, 12345)
+	state.TurnOrder = []ID{archer.ID, hidden.ID, exposed.ID}
+	state.Obstacles = []Position{{X: 2, Y: 0}}
+
+	action := heuristicEnemyAction(state, GetCharacterByID(state, archer.ID))
+	if action.Kind != "Attack" || action.Target != exposed.ID {
+		t.Fatalf("Expected the archer to shoot the target it can see, got %+v", action)
+	}
+	if resolution := ApplyAction(state, action, 12345); resolution.State.CurrentTurn != 1 {
+		t.Errorf("Expected the attack to use the archer's turn, got logs %v", resolution.Logs)
+	}
+
+	// With a dagger to hand it goes for the preferred target after all
+	state.Characters[2].Weapons = append(state.Characters[2].Weapons, Weapon{ID: NewID(), Name: "Dagger", Damage: 4})
+	action = heuristicEnemyAction(state, GetCharacterByID(state, archer.ID))
+	if action.Target != hidden.ID || action.Weapon != state.Characters[2].Weapons[1].ID {
+		t.Errorf("Expected a dagger attack on the hidden target, got %+v", action)
+	}
+
+	// Nobody in sight and nothing but the bow: defend instead of a blocked shot
+	state.Characters[2].Weapons = state.Characters[2].Weapons[:1]
+	state.Obstacles = append(state.Obstacles, Position{X: 4, Y: 2})
+	if action := heuristicEnemyAction(state, GetCharacterByID(state, archer.ID)); action.Kind != "Defend" {
+		t.Errorf("Expected the archer to defend with no clear shot, got %+v", action)
+	}
+}
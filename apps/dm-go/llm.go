@@ -2,10 +2,13 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	openai "github.com/sashabaranov/go-openai"
@@ -17,6 +20,7 @@ type LLMConfig struct {
 	BaseURL     string
 	APIKey      string
 	Model       string
+	Models      []string // Narration tries these in order until one replies; empty uses Model alone
 	MaxTokens   int
 	Temperature float32
 
@@ -29,6 +33,15 @@ type LLMConfig struct {
 
 	// Model selection
 	PreferredModel string // "remote", "local", or "auto"
+
+	// Directory of custom narration prompt templates (optional)
+	PromptsDir string
+
+	// Narration prompts are trimmed to this many characters; 0 disables trimming
+	MaxPromptChars int
+
+	// Keep each session's last narration prompt and response for debugging
+	DebugPrompts bool
 }
 
 // Local model request/response structures
@@ -64,6 +77,8 @@ type LLMClient struct {
 	remoteClient *openai.Client
 	httpClient   *http.Client
 	config       LLMConfig
+	prompts      *PromptLibrary
+	promptLog    *PromptLog
 }
 
 // NewLLMClient creates a new LLM client
@@ -82,7 +97,9 @@ func NewLLMClient(config LLMConfig) *LLMClient {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		config: config,
+		config:    config,
+		prompts:   NewPromptLibrary(),
+		promptLog: NewPromptLog(),
 	}
 }
 
@@ -209,6 +226,7 @@ What should %s do?`,
 	)
 
 	if err != nil {
+		slog.Warn("LLM action suggestion failed", "enemy", enemyID, "round", state.Round, "error", err)
 		return "Attack", fmt.Errorf("LLM action suggestion failed: %w", err)
 	}
 
@@ -224,6 +242,215 @@ What should %s do?`,
 	return action, nil
 }
 
+// suggestedAction is the JSON shape the model is asked to reply with
+type suggestedAction struct {
+	Kind    string `json:"kind"`
+	Target  string `json:"target,omitempty"`
+	Ability string `json:"ability,omitempty"`
+}
+
+// SuggestEnemyActionStructured asks the model for a full action as JSON, including
+// the target and ability, and validates it against the state. Anything the model
+// gets wrong falls back to attacking the highest-threat target.
+func (llm *LLMClient) SuggestEnemyActionStructured(state State, enemyID ID, situation string) (Action, error) {
+	enemy := GetCharacterByID(state, enemyID)
+	if enemy == nil || enemy.IsPlayer {
+		return Action{}, fmt.Errorf("enemy not found or is player: %s", enemyID)
+	}
+	fallback := heuristicEnemyAction(state, enemy)
+
+	systemPrompt := `You are controlling an enemy in combat.
+Choose the most tactically sound action based on the current situation.
+Respond with only a JSON object: {"kind": "...", "target": "...", "ability": "..."}.
+"kind" is one of "Attack", "Defend", "Ability", "UseItem", or "Flee".
+"target" is the name of the character to target, required for "Attack" and damaging abilities.
+"ability" is the name of the ability to use, required for "Ability".`
+
+	userPrompt := fmt.Sprintf(`Enemy: %s
+HP: %d/%d
+Available weapons: %s
+Available abilities: %s
+Available items: %s
+
+Targets:
+%s
+
+Context: %s
+
+What should %s do?`,
+		enemy.Name,
+		enemy.Stats.HP, enemy.Stats.MaxHP,
+		formatWeapons(enemy.Weapons),
+		formatAbilities(enemy.Abilities),
+		formatItems(enemy.Items),
+		formatTargets(state.Characters),
+		situation,
+		enemy.Name)
+
+	resp, err := llm.remoteClient.CreateChatCompletion(
+		context.Background(),
+		openai.ChatCompletionRequest{
+			Model: llm.config.Model,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: systemPrompt,
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: userPrompt,
+				},
+			},
+			MaxTokens:   100,
+			Temperature: 0.3,
+			ResponseFormat: &openai.ChatCompletionResponseFormat{
+				Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+			},
+		},
+	)
+
+	if err != nil {
+		slog.Warn("LLM action suggestion failed", "enemy", enemyID, "round", state.Round, "error", err)
+		return fallback, fmt.Errorf("LLM action suggestion failed: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return fallback, nil
+	}
+
+	action, err := parseSuggestedAction(state, enemy, resp.Choices[0].Message.Content)
+	if err != nil {
+		slog.Warn("Unusable LLM action suggestion, using heuristic", "enemy", enemyID, "round", state.Round, "error", err)
+		return fallback, nil
+	}
+
+	return action, nil
+}
+
+// parseSuggestedAction turns the model's JSON reply into an action the enemy can take
+func parseSuggestedAction(state State, enemy *Character, content string) (Action, error) {
+	var suggestion suggestedAction
+	if err := json.Unmarshal([]byte(strings.TrimSpace(content)), &suggestion); err != nil {
+		return Action{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var target *Character
+	if suggestion.Target != "" {
+		target = GetCharacterByID(state, ID(suggestion.Target))
+		if target == nil {
+			var err error
+			if target, err = GetCharacterByName(state, suggestion.Target); err != nil {
+				return Action{}, err
+			}
+		}
+		if !isActive(*target) {
+			return Action{}, fmt.Errorf("target %s is out of the fight", target.Name)
+		}
+	}
+
+	switch suggestion.Kind {
+	case "Attack":
+		if target == nil || !canTarget(state, *enemy, *target) {
+			return Action{}, fmt.Errorf("attack needs an enemy target")
+		}
+		action := Action{Kind: "Attack", Attacker: enemy.ID, Target: target.ID}
+		if len(enemy.Weapons) > 0 {
+			action.Weapon = enemy.Weapons[0].ID
+		}
+		return action, nil
+
+	case "Ability":
+		ability := enemy.AbilityByName(suggestion.Ability)
+		if ability == nil {
+			return Action{}, fmt.Errorf("unknown ability %q", suggestion.Ability)
+		}
+		if triggersOnItsOwn(*ability) {
+			return Action{}, fmt.Errorf("ability %s triggers on its own", ability.Name)
+		}
+		if RemainingCooldown(enemy, ability.ID) > 0 {
+			return Action{}, fmt.Errorf("ability %s is on cooldown", ability.Name)
+		}
+		action := Action{Kind: "Ability", Actor: enemy.ID, Ability: ability.ID}
+		if ability.Effect == "damage" {
+			if target == nil || !canTarget(state, *enemy, *target) {
+				return Action{}, fmt.Errorf("ability %s needs an enemy target", ability.Name)
+			}
+		}
+		if isSupportAbility(*ability) && target != nil && !canSupport(*enemy, *target) {
+			return Action{}, fmt.Errorf("ability %s can only target allies", ability.Name)
+		}
+		if target != nil {
+			action.Target = target.ID
+		}
+		return action, nil
+
+	case "UseItem":
+		if len(enemy.Items) == 0 {
+			return Action{}, fmt.Errorf("no items to use")
+		}
+		return Action{Kind: "UseItem", Actor: enemy.ID, Item: enemy.Items[0].ID}, nil
+
+	case "Defend", "Flee":
+		return Action{Kind: suggestion.Kind, Actor: enemy.ID}, nil
+
+	default:
+		return Action{}, fmt.Errorf("unknown action kind %q", suggestion.Kind)
+	}
+}
+
+// heuristicEnemyAction attacks the highest-threat opponent it can hit,
+// defending if there is nobody to attack. A ranged weapon can't shoot through
+// cover, so the enemy switches to another weapon or the next target with a
+// clear shot rather than wasting its turn on a blocked attack. There is no
+// movement in the engine, so it can't step around the cover itself. The
+// session's AI difficulty can override this: easy enemies sometimes blunder
+// and hard ones play for kills, see ai_difficulty.go.
+func heuristicEnemyAction(state State, enemy *Character) Action {
+	switch state.Rules.AIDifficulty {
+	case aiEasy:
+		if action, ok := easyEnemyAction(state, enemy); ok {
+			return action
+		}
+	case aiHard:
+		if action, ok := hardEnemyAction(state, enemy); ok {
+			return action
+		}
+	}
+
+	target := pickAttackTarget(state, enemy)
+	if target == nil {
+		return Action{Kind: "Defend", Actor: enemy.ID}
+	}
+	if len(enemy.Weapons) == 0 {
+		// Unarmed attacks fall back to fists, which cover never blocks
+		return Action{Kind: "Attack", Attacker: enemy.ID, Target: target.ID}
+	}
+
+	if weapon, ok := weaponAgainst(state, enemy, target); ok {
+		return Action{Kind: "Attack", Attacker: enemy.ID, Target: target.ID, Weapon: weapon}
+	}
+	target = pickAttackTargetWhere(state, enemy, func(char *Character) bool {
+		_, ok := weaponAgainst(state, enemy, char)
+		return ok
+	})
+	if target == nil {
+		return Action{Kind: "Defend", Actor: enemy.ID}
+	}
+	weapon, _ := weaponAgainst(state, enemy, target)
+	return Action{Kind: "Attack", Attacker: enemy.ID, Target: target.ID, Weapon: weapon}
+}
+
+// weaponAgainst returns the attacker's first weapon that can reach the target:
+// any melee weapon, or a ranged one with line of sight
+func weaponAgainst(state State, attacker, target *Character) (ID, bool) {
+	for _, weapon := range attacker.Weapons {
+		if !isRangedWeapon(weapon) || HasLineOfSight(state, attacker.Position, target.Position) {
+			return weapon.ID, true
+		}
+	}
+	return "", false
+}
+
 // Helper functions for formatting
 func formatEvents(events []string) string {
 	if len(events) == 0 {
@@ -282,7 +509,7 @@ func formatItems(items []Item) string {
 func formatTargets(characters []Character) string {
 	var targets []string
 	for _, char := range characters {
-		if char.IsPlayer && char.Stats.HP > 0 {
+		if char.IsPlayer && isActive(char) {
 			targets = append(targets, fmt.Sprintf("%s (%d/%d HP)", char.Name, char.Stats.HP, char.Stats.MaxHP))
 		}
 	}
@@ -356,53 +583,35 @@ func (llm *LLMClient) shouldUseLocalModel() bool {
 	}
 }
 
-// GenerateNarrationWithModel generates narrative text using the appropriate model
-func (llm *LLMClient) GenerateNarrationWithModel(state State, events []string, context string, useLocal bool) (string, error) {
-	systemPrompt := `You are a master dungeon master narrating an epic fantasy combat encounter.
-Create vivid, immersive descriptions that bring the battle to life. Focus on:
-- The intensity and drama of combat actions
-- Environmental details and atmosphere
-- Character emotions and physical sensations
-- Strategic positioning and tactical elements
-- The consequences and stakes of each action
-
-Keep descriptions engaging but concise, maintaining the flow of combat while building tension and excitement.`
-
-	var userPrompt string
-	if context != "" {
-		userPrompt = fmt.Sprintf(`Current situation:
-%s
-
-Recent events:
-%s
-
-Current state:
-Round %d
-Players: %s
-Enemies: %s
-
-Create a vivid, dramatic narration of what just happened in this combat encounter:`,
-			context,
-			formatEvents(events),
-			state.Round,
-			formatCharacters(state.Characters, true),
-			formatCharacters(state.Characters, false))
-	} else {
-		userPrompt = fmt.Sprintf(`Recent events:
-%s
-
-Current state:
-Round %d
-Players: %s
-Enemies: %s
+// GenerateNarrationWithModel generates narrative text in the given prompt style using the appropriate model
+func (llm *LLMClient) GenerateNarrationWithModel(data PromptData, style string, useLocal bool) (string, error) {
+	if style == "" {
+		style = defaultPromptStyle
+	}
+	systemPrompt, userPrompt, err := llm.prompts.RenderWithin(style, data, llm.config.MaxPromptChars)
+	if err != nil {
+		return "", err
+	}
 
-Create a vivid, dramatic narration of what just happened in this combat encounter:`,
-			formatEvents(events),
-			state.Round,
-			formatCharacters(state.Characters, true),
-			formatCharacters(state.Characters, false))
+	narration, model, err := llm.completeNarration(data.State, systemPrompt, userPrompt, useLocal)
+	capture := PromptCapture{
+		Style:    style,
+		Model:    model,
+		System:   systemPrompt,
+		User:     userPrompt,
+		Response: narration,
+		At:       time.Now(),
+	}
+	if err != nil {
+		capture.Error = err.Error()
 	}
+	llm.capturePrompt(data.SessionID, capture)
+	return narration, err
+}
 
+// completeNarration sends rendered narration prompts to the local or remote
+// model and returns the reply along with the model that produced it
+func (llm *LLMClient) completeNarration(state State, systemPrompt, userPrompt string, useLocal bool) (string, string, error) {
 	// Try local model first if enabled
 	if useLocal && llm.config.LocalEnabled {
 		localMessages := []LocalChatMessage{
@@ -411,40 +620,65 @@ Create a vivid, dramatic narration of what just happened in this combat encounte
 		}
 
 		if narration, err := llm.callLocalModel(localMessages); err == nil {
-			return narration, nil
+			return narration, llm.config.LocalModel, nil
 		} else if llm.config.PreferredModel != "auto" {
-			return "", fmt.Errorf("local model failed: %w", err)
+			return "", llm.config.LocalModel, fmt.Errorf("local model failed: %w", err)
+		} else {
+			// If auto mode and local fails, fall back to remote
+			slog.Warn("Local model failed, falling back to remote", "model", llm.config.LocalModel, "round", state.Round, "error", err)
 		}
-		// If auto mode and local fails, fall back to remote
 	}
 
-	// Use remote model (OpenAI compatible)
-	resp, err := llm.remoteClient.CreateChatCompletion(
-		nil,
-		openai.ChatCompletionRequest{
-			Model: llm.config.Model,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: systemPrompt,
-				},
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: userPrompt,
+	// Use the remote models (OpenAI compatible), escalating past any that
+	// fail or reply with nothing
+	models := llm.remoteModels()
+	var lastErr error
+	for i, model := range models {
+		resp, err := llm.remoteClient.CreateChatCompletion(
+			context.Background(),
+			openai.ChatCompletionRequest{
+				Model: model,
+				Messages: []openai.ChatCompletionMessage{
+					{
+						Role:    openai.ChatMessageRoleSystem,
+						Content: systemPrompt,
+					},
+					{
+						Role:    openai.ChatMessageRoleUser,
+						Content: userPrompt,
+					},
 				},
+				MaxTokens:   llm.config.MaxTokens,
+				Temperature: llm.config.Temperature,
 			},
-			MaxTokens:   llm.config.MaxTokens,
-			Temperature: llm.config.Temperature,
-		},
-	)
-
-	if err != nil {
-		return "The battle rages on with intense combat!", fmt.Errorf("remote model failed: %w", err)
+		)
+
+		switch {
+		case err != nil:
+			lastErr = err
+			slog.Warn("Remote model failed", "model", model, "round", state.Round, "error", err)
+		case len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "":
+			lastErr = nil
+			slog.Warn("Remote model returned no narration", "model", model, "round", state.Round)
+		default:
+			if i > 0 {
+				slog.Info("Narration generated by fallback model", "model", model, "attempt", i+1, "round", state.Round)
+			}
+			return resp.Choices[0].Message.Content, model, nil
+		}
 	}
 
-	if len(resp.Choices) == 0 {
-		return "The battle rages on with intense combat!", nil
+	lastModel := models[len(models)-1]
+	if lastErr != nil {
+		return "The battle rages on with intense combat!", lastModel, fmt.Errorf("remote model failed: %w", lastErr)
 	}
+	return "The battle rages on with intense combat!", lastModel, nil
+}
 
-	return resp.Choices[0].Message.Content, nil
+// remoteModels lists the remote models narration tries, in order
+func (llm *LLMClient) remoteModels() []string {
+	if len(llm.config.Models) > 0 {
+		return llm.config.Models
+	}
+	return []string{llm.config.Model}
 }
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newStubLLMClient returns a client whose remote model always replies with content
+func newStubLLMClient(t *testing.T, content string) (*LLMClient, *map[string]interface{}) {
+	var lastRequest map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&lastRequest)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"role": "assistant", "content": content}},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return NewLLMClient(LLMConfig{BaseURL: server.URL, APIKey: "test", Model: "stub"}), &lastRequest
+}
+
+func TestSuggestEnemyActionStructured(t *testing.T) {
+	hero := createTestCharacter(true, "Hero")
+	goblin := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+
+	client, lastRequest := newStubLLMClient(t, `{"kind":"Ability","target":"hero","ability":"Test Ability"}`)
+	action, err := client.SuggestEnemyActionStructured(state, goblin.ID, "The hero is wounded")
+	if err != nil {
+		t.Fatalf("Failed to suggest action: %v", err)
+	}
+
+	expected := Action{Kind: "Ability", Actor: goblin.ID, Target: hero.ID, Ability: goblin.Abilities[0].ID}
+	if action != expected {
+		t.Errorf("Expected %+v, got %+v", expected, action)
+	}
+
+	format, _ := (*lastRequest)["response_format"].(map[string]interface{})
+	if format["type"] != "json_object" {
+		t.Errorf("Expected JSON mode request, got response_format %v", (*lastRequest)["response_format"])
+	}
+
+	t.Run("FallsBackOnBadReply", func(t *testing.T) {
+		for _, reply := range []string{
+			"Attack!",
+			`{"kind":"Dance"}`,
+			`{"kind":"Attack","target":"Dragon"}`,
+			`{"kind":"Attack","target":"Goblin"}`,
+			`{"kind":"Ability","target":"Hero","ability":"Teleport"}`,
+		} {
+			client, _ := newStubLLMClient(t, reply)
+			action, err := client.SuggestEnemyActionStructured(state, goblin.ID, "")
+			if err != nil {
+				t.Fatalf("Expected fallback without error for %q, got %v", reply, err)
+			}
+			fallback := Action{Kind: "Attack", Attacker: goblin.ID, Target: hero.ID, Weapon: goblin.Weapons[0].ID}
+			if action != fallback {
+				t.Errorf("Expected heuristic attack for %q, got %+v", reply, action)
+			}
+		}
+	})
+}
+
+func TestNarrationFallsBackAcrossModels(t *testing.T) {
+	var tried []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		tried = append(tried, req.Model)
+
+		content := ""
+		if req.Model == "strong" {
+			content = "The goblin reels from the blow."
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"role": "assistant", "content": content}},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewLLMClient(LLMConfig{BaseURL: server.URL, APIKey: "test", Models: []string{"cheap", "strong", "unused"}, DebugPrompts: true})
+	state := CreateInitialState([]Character{createTestCharacter(true, "Hero")}, []Character{createTestCharacter(false, "Goblin")}, 12345)
+	data := NewPromptData(state, []string{"Hero attacks Goblin for 12 damage!"}, "")
+	data.SessionID = "fallback-session"
+
+	narration, err := client.GenerateNarrationWithModel(data, "", false)
+	if err != nil || narration != "The goblin reels from the blow." {
+		t.Fatalf("Expected the stronger model's narration, got %q (%v)", narration, err)
+	}
+	if len(tried) != 2 || tried[0] != "cheap" || tried[1] != "strong" {
+		t.Errorf("Expected the cheap model to be tried before the strong one and no further, got %v", tried)
+	}
+	if capture, ok := client.promptLog.Last("fallback-session"); !ok || capture.Model != "strong" {
+		t.Errorf("Expected the capture to report the strong model, got %+v", capture)
+	}
+
+	// A single configured model still works, and an empty reply falls back to stock narration
+	tried = nil
+	client = NewLLMClient(LLMConfig{BaseURL: server.URL, APIKey: "test", Model: "cheap"})
+	if narration, err := client.GenerateNarrationWithModel(data, "", false); err != nil || narration == "" || len(tried) != 1 {
+		t.Errorf("Expected one request and stock narration from the single model, got %q (%v) after %v", narration, err, tried)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// logOutput is where logs go in either format, leaving stdout to the
+// subcommands that print results
+var logOutput io.Writer = os.Stderr
+
+// newLogger creates the structured logger. A "json" format emits one JSON object
+// per line for log aggregation; anything else emits readable text for local dev.
+func newLogger(format, level string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: parseLogLevel(level)}
+	if strings.EqualFold(format, "json") {
+		return slog.New(slog.NewJSONHandler(logOutput, opts))
+	}
+	return slog.New(slog.NewTextHandler(logOutput, opts))
+}
+
+// parseLogLevel maps a level name to a slog level, defaulting to info
+func parseLogLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// sessionLogger returns a logger tagged with the session ID so log lines can be
+// filtered per game
+func sessionLogger(sessionID string) *slog.Logger {
+	return slog.With("session_id", sessionID)
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLogFormatsShareOneStream(t *testing.T) {
+	previous := logOutput
+	defer func() { logOutput = previous }()
+
+	for _, format := range []string{"json", "text"} {
+		var out bytes.Buffer
+		logOutput = &out
+		newLogger(format, "info").Info("hello", "format", format)
+		if !strings.Contains(out.String(), "hello") {
+			t.Errorf("Expected %s logs on the log stream, got %q", format, out.String())
+		}
+	}
+}
@@ -1,30 +1,49 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io/fs"
+	"log/slog"
 	"os"
-	"sort"
+	"os/signal"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/websocket/v2"
 	"github.com/google/uuid"
-	"gopkg.in/yaml.v3"
 )
 
 var (
-	eventStore     EventStoreInterface
-	llmClient      *LLMClient
-	stateManager   *StateManager
-	templateEngine *TemplateEngine
-	clients        = make(map[string]*websocket.Conn)
-	clientsMutex   sync.RWMutex
+	eventStore       EventStoreInterface
+	llmClient        *LLMClient
+	stateManager     *StateManager
+	templateEngine   *TemplateEngine
+	turnTimers       = NewTurnTimerManager(realClock{})
+	allowedOrigins   = NewOriginAllowList("")
+	scenariosDir     = defaultScenariosDir
+	sessionAuth      = NewSessionAuth()
+	eventBus         = NewEventBus(broadcastGameUpdate)
+	subscribers      = NewUpdateSubscribers(eventBus)
+	enemyAutoPlay    = NewEnemyAutoPlay(0)
+	narrationTrigger = NewNarrationTrigger(narrateOff, 0)
+	llmRateLimits    = NewLLMRateLimits(defaultLLMSessionPerMinute, defaultLLMSessionBurst, defaultLLMGlobalPerMinute, defaultLLMGlobalBurst)
+	clients          = make(map[string]*lockedConn)
+	spectators       = make(map[string]map[*lockedConn]bool)
+	sessionLocks     = NewSessionLocks()
+	clientsMutex     sync.RWMutex
+)
+
+// WebSocket connection roles
+const (
+	wsRolePlayer    = "player"
+	wsRoleSpectator = "spectator"
 )
 
 // EventStoreInterface defines the interface for event stores
@@ -35,11 +54,21 @@ type EventStoreInterface interface {
 	GetEvents(sessionID string, fromRound int) ([]Event, error)
 	GetLatestSnapshot(sessionID string) (*State, error)
 	GetSnapshotAtRound(sessionID string, round int) (*State, error)
+	GetSnapshots(sessionID string) ([]State, error)
+	GetSession(sessionID string) (*Session, error)
 	UpdateSessionStatus(sessionID, status string) error
+	RewindSession(sessionID string, eventCount, round int) error
+	RecordAction(sessionID string, record ActionRecord) error
+	GetActions(sessionID string) ([]ActionRecord, error)
+	SaveSessionToken(sessionID string, token SessionToken) error
+	GetSessionTokens(sessionID string) ([]SessionToken, error)
+	Ping() error
 	Close() error
 }
 
 func main() {
+	slog.SetDefault(newLogger(getEnv("LOG_FORMAT", "text"), getEnv("LOG_LEVEL", "info")))
+
 	// Check if we should run in demo mode (no SQLite)
 	if len(os.Args) > 1 && os.Args[1] == "demo" {
 		fmt.Println("🎮 Starting SmolDungeon in DEMO mode (no SQLite required)")
@@ -47,9 +76,35 @@ func main() {
 		return
 	}
 
+	// Check scenario files without starting the server, e.g. in a pre-commit hook
+	if len(os.Args) > 1 && os.Args[1] == "lint-scenarios" {
+		os.Exit(runLintScenarios(os.Args[2:], os.Stdout))
+	}
+
+	// Check that every scenario replays identically from the same seed
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		os.Exit(runVerify(os.Args[2:], os.Stdout))
+	}
+
 	// Normal mode with SQLite
 	dbPath := getEnv("DB_PATH", "./dm-server.db")
 	port := getEnv("PORT", "3000")
+	scenariosDir = resolveScenariosDir(getEnv("SCENARIOS_DIR", defaultScenariosDir))
+	SetRosterPath(getEnv("ROSTER_PATH", defaultRosterPath))
+	adminToken = getEnv("ADMIN_TOKEN", "")
+	combatLogLimit = max(0, getEnvInt("COMBAT_LOG_LINES", defaultCombatLogLines))
+	statBounds = StatBounds{
+		MaxHP:   max(0, getEnvInt("STAT_MAX_HP", defaultMaxHPBound)),
+		Attack:  max(0, getEnvInt("STAT_MAX_ATTACK", defaultMaxStatBound)),
+		Defense: max(0, getEnvInt("STAT_MAX_DEFENSE", defaultMaxStatBound)),
+		Speed:   max(0, getEnvInt("STAT_MAX_SPEED", defaultMaxStatBound)),
+	}
+	source, err := seedSourceByName(getEnv("SEED_SOURCE", "time"))
+	if err != nil {
+		slog.Error("Invalid seed source", "error", err)
+		os.Exit(1)
+	}
+	SetSeedSource(source)
 	llmConfig := LLMConfig{
 		// Remote model settings
 		BaseURL:     getEnv("LLM_BASE_URL", ""),
@@ -67,125 +122,147 @@ func main() {
 
 		// Model selection
 		PreferredModel: getEnv("LLM_PREFERRED_MODEL", "auto"), // "remote", "local", or "auto"
+
+		PromptsDir:     getEnv("LLM_PROMPTS_DIR", ""),
+		MaxPromptChars: getEnvInt("LLM_MAX_PROMPT_CHARS", defaultMaxPromptChars),
+		DebugPrompts:   getEnvBool("LLM_DEBUG_PROMPTS", false),
+	}
+	if models := getEnvList("LLM_MODELS"); len(models) > 0 {
+		llmConfig.Models = models
+		llmConfig.Model = models[0]
 	}
 
 	// Initialize components
 	// Use SQLite database for persistence
-	var err error
-	eventStore, err = NewEventStore(dbPath)
+	store, err := NewEventStore(dbPath)
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		slog.Error("Failed to initialize database", "error", err)
+		os.Exit(1)
 	}
-	log.Printf("Using SQLite database for persistence")
+	store.SetCompression(getEnvBool("DB_COMPRESS", false))
+	eventStore = store
+	sessionAuth = NewPersistentSessionAuth(store)
+	slog.Info("Using SQLite database for persistence")
 
 	// Initialize state manager for thread-safe state access
-	stateManager = NewStateManager()
-	log.Printf("Initialized state manager")
+	stateManager = NewPersistentStateManager(store)
+	slog.Info("Initialized state manager")
 
 	// Load existing sessions from DB
 	es := eventStore.(*EventStore) // Cast to access db
 	rows, err := es.db.Query("SELECT id FROM sessions WHERE status = 'active'")
 	if err != nil {
-		log.Printf("Failed to load sessions: %v", err)
+		slog.Error("Failed to load sessions", "error", err)
 	} else {
 		defer rows.Close()
 		loadedCount := 0
 		for rows.Next() {
 			var sessionID string
 			if err := rows.Scan(&sessionID); err != nil {
-				log.Printf("Scan error: %v", err)
+				slog.Error("Scan error", "error", err)
 				continue
 			}
 			if snapshot, err := eventStore.GetLatestSnapshot(sessionID); err == nil && snapshot != nil {
 				stateManager.SetState(sessionID, *snapshot)
 				loadedCount++
 			} else {
-				log.Printf("Failed to load snapshot for session %s: %v", sessionID, err)
+				sessionLogger(sessionID).Error("Failed to load snapshot", "error", err)
 			}
 		}
-		log.Printf("Loaded %d active sessions from DB", loadedCount)
+		slog.Info("Loaded active sessions from DB", "count", loadedCount)
 	}
 
 	// Initialize template engine for Go-based web frontend
-	templateEngine, err = NewTemplateEngine()
-	if err != nil {
-		log.Fatalf("Failed to initialize template engine: %v", err)
+	// The JSON API doesn't need templates, so keep serving it without them
+	if templateEngine, err = newConfiguredTemplateEngine(); err != nil {
+		slog.Error("Failed to initialize template engine, HTML pages will show a fallback", "error", err)
+	} else {
+		slog.Info("Initialized template engine")
 	}
-	log.Printf("Initialized template engine")
+
+	llmRateLimits = NewLLMRateLimits(
+		getEnvInt("LLM_RATE_LIMIT_PER_MINUTE", defaultLLMSessionPerMinute),
+		getEnvInt("LLM_RATE_LIMIT_BURST", defaultLLMSessionBurst),
+		getEnvInt("LLM_GLOBAL_RATE_LIMIT_PER_MINUTE", defaultLLMGlobalPerMinute),
+		getEnvInt("LLM_GLOBAL_RATE_LIMIT_BURST", defaultLLMGlobalBurst),
+	)
 
 	llmClient = NewLLMClient(llmConfig)
+	if llmConfig.PromptsDir != "" {
+		if err := llmClient.prompts.LoadDir(llmConfig.PromptsDir); err != nil {
+			slog.Error("Failed to load prompt templates", "dir", llmConfig.PromptsDir, "error", err)
+			os.Exit(1)
+		}
+		slog.Info("Loaded prompt templates", "dir", llmConfig.PromptsDir)
+	}
+
+	narrationPolicy, err := narrationPolicyByName(getEnv("NARRATION_POLICY", "off"))
+	if err != nil {
+		slog.Error("Invalid narration policy", "error", err)
+		os.Exit(1)
+	}
+	enemyAutoPlay = NewEnemyAutoPlay(time.Duration(getEnvInt("ENEMY_TURN_DELAY_MS", int(defaultEnemyTurnDelay/time.Millisecond))) * time.Millisecond)
+	eventBus.Subscribe(enemyAutoPlay.Observe)
+	narrationTrigger = NewNarrationTrigger(narrationPolicy, getEnvInt("NARRATION_EVERY_N", defaultNarrationEveryN))
+	eventBus.Subscribe(narrationTrigger.Observe)
 
 	// Setup Fiber app
-	app := fiber.New(fiber.Config{
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			log.Printf("Error: %v", err)
-			return c.Status(500).JSON(fiber.Map{
-				"error": "Internal server error",
-			})
-		},
-	})
+	app := fiber.New(serverConfig())
 
 	// Middleware
+	allowedOrigins = NewOriginAllowList(getEnv("CORS_ALLOWED_ORIGINS", ""))
 	app.Use(logger.New())
-	app.Use(cors.New())
+	app.Use(corsMiddleware(allowedOrigins))
 
 	// Routes
 	setupRoutes(app)
 
-	// Health check
-	app.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status":    "ok",
-			"timestamp": time.Now().Format(time.RFC3339),
-		})
-	})
-
-	// Sessions overview
-	app.Get("/sessions", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"activeSessions": stateManager.GetStateCount(),
-			"totalSessions":  stateManager.GetStateCount(),
-		})
-	})
-
 	// Start server
-	log.Printf("DM Server starting on port %s", port)
-	log.Printf("Database: %s", dbPath)
-	log.Println("Available endpoints:")
-	log.Println("  POST /tools/get_state_summary")
-	log.Println("  POST /tools/roll_check")
-	log.Println("  POST /tools/apply_action")
-	log.Println("  POST /llm/generate_narration")
-	log.Println("  POST /llm/generate_combat_description")
-	log.Println("  GET  /health")
-	log.Println("  GET  /sessions")
-	log.Println("  POST /sessions")
-	log.Println("  GET  /sessions/:sessionId")
+	slog.Info("DM Server starting", "port", port, "database", dbPath)
+	for _, endpoint := range apiEndpoints(app) {
+		slog.Info("Available endpoint", "route", endpoint)
+	}
 
 	if llmConfig.LocalEnabled {
-		log.Printf("Local LLM Model: %s at %s", llmConfig.LocalModel, llmConfig.LocalBaseURL)
+		slog.Info("Local LLM model enabled", "model", llmConfig.LocalModel, "baseUrl", llmConfig.LocalBaseURL)
 	}
-	log.Printf("LLM Preferred Model: %s", llmConfig.PreferredModel)
+	slog.Info("LLM preferred model", "model", llmConfig.PreferredModel)
 
-	log.Fatal(app.Listen(":" + port))
+	// Stop the sweeper and drain requests on Ctrl+C or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Evicted sessions are saved first and restored from the database when next used
+	startSessionSweeper(ctx, stateManager, true)
+
+	go func() {
+		<-ctx.Done()
+		slog.Info("Shutting down")
+		if err := app.Shutdown(); err != nil {
+			slog.Error("Shutdown failed", "error", err)
+		}
+	}()
+
+	if err := app.Listen(":" + port); err != nil {
+		slog.Error("Server stopped", "error", err)
+		os.Exit(1)
+	}
 }
 
-func setupRoutes(app *fiber.App) {
-	// Tools endpoints
-	app.Post("/tools/get_state_summary", handleGetStateSummary)
-	app.Post("/tools/roll_check", handleRollCheck)
-	app.Post("/tools/apply_action", handleApplyAction)
+// apiV1Prefix is where the current version of the JSON API is served
+const apiV1Prefix = "/api/v1"
 
-	// LLM endpoints
-	app.Post("/llm/generate_narration", handleGenerateNarration)
-	app.Post("/llm/generate_combat_description", handleGenerateCombatDescription)
+func setupRoutes(app *fiber.App) {
+	// Health check
+	app.Get("/health", handleHealth)
 
-	// Session management
-	app.Post("/sessions", handleCreateSession)
-	app.Get("/sessions/:sessionId", handleGetSession)
+	// JSON API. The unversioned paths stay as aliases of /api/v1 for a
+	// deprecation window so existing clients keep working.
+	setupAPIRoutes(app.Group(apiV1Prefix))
+	setupAPIRoutes(app, deprecatedAlias)
 
 	// WebSocket endpoint for real-time game
-	app.Get("/ws/:sessionId", websocket.New(handleWebSocket))
+	app.Get("/ws/:sessionId", requireAllowedOrigin(allowedOrigins), websocket.New(handleWebSocket))
 
 	// Web routes for the game interface
 	app.Get("/", handleHomePage)
@@ -195,6 +272,91 @@ func setupRoutes(app *fiber.App) {
 	app.Post("/game/start", handleStartGame)
 }
 
+// apiEndpoints lists the health check and the versioned JSON API routes
+// registered on app, for the startup log
+func apiEndpoints(app *fiber.App) []string {
+	var endpoints []string
+	for _, route := range app.GetRoutes(true) {
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+		if route.Path == "/health" || strings.HasPrefix(route.Path, apiV1Prefix+"/") {
+			endpoints = append(endpoints, fmt.Sprintf("%-4s %s", route.Method, route.Path))
+		}
+	}
+	return endpoints
+}
+
+// setupAPIRoutes registers the JSON API on r, running middleware ahead of
+// every route
+func setupAPIRoutes(r fiber.Router, middleware ...fiber.Handler) {
+	with := func(handlers ...fiber.Handler) []fiber.Handler {
+		return append(append([]fiber.Handler(nil), middleware...), handlers...)
+	}
+
+	// Tools endpoints
+	r.Post("/tools/get_state_summary", with(handleGetStateSummary)...)
+	r.Post("/tools/roll_check", with(handleRollCheck)...)
+	r.Post("/tools/inspect_character", with(handleInspectCharacter)...)
+	r.Post("/tools/apply_action", with(handleApplyAction)...)
+	r.Post("/tools/apply_actions", with(handleApplyActions)...)
+	r.Post("/tools/invoke", with(handleInvokeTools)...)
+
+	// Scenario endpoints
+	r.Post("/scenarios/validate", with(handleValidateScenario)...)
+
+	// LLM endpoints
+	limitLLM := rateLimitLLM(llmRateLimits)
+	r.Post("/llm/generate_narration", with(limitLLM, handleGenerateNarration)...)
+	r.Post("/llm/generate_combat_description", with(limitLLM, handleGenerateCombatDescription)...)
+
+	// Session management
+	r.Get("/sessions", with(handleSessionsOverview)...)
+	r.Post("/sessions", with(handleCreateSession)...)
+	r.Post("/sessions/from-scenario", with(handleCreateSessionFromScenario)...)
+	r.Post("/sessions/from-roster", with(handleCreateSessionFromRoster)...)
+	r.Post("/sessions/:sessionId/fork", with(handleForkSession)...)
+	r.Post("/sessions/:sessionId/undo", with(handleUndo)...)
+	r.Get("/sessions/:sessionId/export", with(handleExportSession)...)
+	r.Post("/sessions/import", with(handleImportSession)...)
+	r.Get("/sessions/:sessionId", with(handleGetSession)...)
+	r.Get("/sessions/:sessionId/log", with(handleGetCombatLog)...)
+	r.Get("/sessions/:sessionId/stats", with(handleGetCombatStats)...)
+	r.Get("/sessions/:sessionId/actions", with(handleGetActions)...)
+	r.Get("/sessions/:sessionId/frames", with(handleGetFrames)...)
+	r.Get("/sessions/:sessionId/snapshot/:round", with(handleGetSnapshot)...)
+	r.Get("/sessions/:sessionId/turn-order", with(handleGetTurnOrder)...)
+	r.Get("/sessions/:sessionId/story", with(handleGetStory)...)
+	r.Get("/sessions/:sessionId/stream", with(handleSessionStream)...)
+	r.Get("/sessions/:sessionId/prompt", with(handleGetLastPrompt)...)
+
+	// GM controls for the session's DM
+	r.Post("/sessions/:sessionId/gm/heal", with(requireGM, handleGMHeal)...)
+	r.Post("/sessions/:sessionId/gm/damage", with(requireGM, handleGMDamage)...)
+	r.Post("/sessions/:sessionId/gm/grant-item", with(requireGM, handleGMGrantItem)...)
+	r.Post("/sessions/:sessionId/gm/add-status", with(requireGM, handleGMAddStatus)...)
+	r.Post("/sessions/:sessionId/gm/set-stats", with(requireGM, handleGMSetStats)...)
+
+	// Admin tools for QA and support
+	r.Put("/sessions/:sessionId/state", with(requireAdmin, handleSetState)...)
+}
+
+// deprecatedAlias marks responses from an unversioned API path as deprecated
+// and points clients at its /api/v1 successor
+func deprecatedAlias(c *fiber.Ctx) error {
+	c.Set("Deprecation", "true")
+	c.Set("Link", fmt.Sprintf("<%s%s>; rel=\"successor-version\"", apiV1Prefix, c.Path()))
+	return c.Next()
+}
+
+// handleSessionsOverview reports how many sessions are loaded
+func handleSessionsOverview(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"activeSessions": stateManager.GetStateCount(),
+		"totalSessions":  stateManager.GetStateCount(),
+	})
+}
+
 func handleGetStateSummary(c *fiber.Ctx) error {
 	var req struct {
 		State State `json:"state"`
@@ -208,6 +370,10 @@ func handleGetStateSummary(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "State is required"})
 	}
 
+	if err := validateStatePayload(req.State); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	summary := GetStateSummary(req.State)
 	return c.JSON(fiber.Map{"summary": summary})
 }
@@ -237,7 +403,7 @@ func handleRollCheck(c *fiber.Ctx) error {
 		}
 	}
 
-	seed := time.Now().UnixNano()
+	seed := newSeed()
 	rng := NewSeededRNG(seed)
 	roll := rng.RollD20()
 	total := roll + modifier
@@ -268,33 +434,178 @@ func handleApplyAction(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "State, action, and seed are required"})
 	}
 
+	if err := validateStatePayload(req.State); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	sessionID := c.Get("session-id")
+	if sessionID == "" {
+		sessionID = uuid.New().String()
+	}
+
+	unlock := sessionLocks.Lock(sessionID)
+	defer unlock()
+	req.State = sessionState(sessionID, req.State)
+
+	action, err := ResolveActionNames(req.State, req.Action)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	req.Action = action
+
+	if !sessionAuth.Authorize(sessionID, requestToken(c), getActorID(req.Action)) {
+		return c.Status(403).JSON(fiber.Map{"error": "Not authorized to act for this character"})
+	}
+
 	resolution := ApplyAction(req.State, req.Action, req.Seed)
+	if resolution.RejectReason != "" {
+		return sendJSON(c.Status(rejectStatus(resolution.RejectReason)), resolution)
+	}
+
+	persistResolution(sessionID, req.State, resolution)
+	recordAction(sessionID, req.State, resolution, req.Action, req.Seed)
+	turnTimers.Reset(sessionID, resolution.State)
+
+	return sendJSON(c, resolution)
+}
+
+// rejectStatus is the HTTP status for an action the engine refused
+func rejectStatus(reason RejectReason) int {
+	switch reason {
+	case RejectInvalidState:
+		return 400
+	case RejectNotYourTurn:
+		return 409
+	default:
+		return 422
+	}
+}
+
+func handleApplyActions(c *fiber.Ctx) error {
+	var req struct {
+		State   State    `json:"state"`
+		Actions []Action `json:"actions"`
+		Seed    int64    `json:"seed"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if req.Seed == 0 || len(req.Actions) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "State, actions, and seed are required"})
+	}
+
+	if len(req.Actions) > maxBatchActions {
+		return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("too many actions: %d (max %d)", len(req.Actions), maxBatchActions)})
+	}
+
+	if err := validateStatePayload(req.State); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
 
 	sessionID := c.Get("session-id")
 	if sessionID == "" {
 		sessionID = uuid.New().String()
 	}
 
+	unlock := sessionLocks.Lock(sessionID)
+	defer unlock()
+	req.State = sessionState(sessionID, req.State)
+
+	for i, action := range req.Actions {
+		resolved, err := ResolveActionNames(req.State, action)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("action %d: %v", i, err)})
+		}
+		req.Actions[i] = resolved
+	}
+
+	token := requestToken(c)
+	for _, action := range req.Actions {
+		if !sessionAuth.Authorize(sessionID, token, getActorID(action)) {
+			return c.Status(403).JSON(fiber.Map{"error": "Not authorized to act for this character"})
+		}
+	}
+
+	steps, err := ApplyActions(req.State, req.Actions, req.Seed)
+
+	combined := Resolution{State: req.State, Events: []Event{}, Logs: []string{}}
+	for i, step := range steps {
+		persistResolution(sessionID, combined.State, step)
+		recordAction(sessionID, combined.State, step, req.Actions[i], req.Seed+int64(i))
+		combined.State = step.State
+		combined.Events = append(combined.Events, step.Events...)
+		combined.Logs = append(combined.Logs, step.Logs...)
+	}
+	turnTimers.Reset(sessionID, combined.State)
+
+	response := fiber.Map{
+		"state":   combined.State,
+		"events":  combined.Events,
+		"logs":    combined.Logs,
+		"applied": len(steps),
+	}
+	if err != nil {
+		sessionLogger(sessionID).Warn("Batch stopped early", "applied", len(steps), "error", err)
+		response["error"] = err.Error()
+		return sendJSON(c.Status(422), response)
+	}
+
+	return sendJSON(c, response)
+}
+
+// sessionState is the state an action on the session applies to. A session the
+// server already holds plays on from its own state, so a client can't rewrite
+// it by posting one of its own; that takes the admin-only PUT
+// /sessions/:id/state. The posted state only starts sessions the server
+// doesn't know yet.
+func sessionState(sessionID string, posted State) State {
+	if live, exists := stateManager.GetState(sessionID); exists {
+		return live
+	}
+	return posted
+}
+
+// persistResolution stores the resolved state and appends its events under the
+// round the action was taken in, saving a snapshot whenever the round
+// advances, then publishes the events to observers
+func persistResolution(sessionID string, prev State, resolution Resolution) {
+	logger := sessionLogger(sessionID).With("round", resolution.State.Round)
 	stateManager.SetState(sessionID, resolution.State)
+	if actionResolved(prev, resolution.State) {
+		stateManager.PushUndo(sessionID, prev, len(resolution.Events))
+	}
 
-	// Persist to database
-	if err := eventStore.AppendEvents(sessionID, resolution.State.Round, resolution.Events); err != nil {
-		log.Printf("Failed to append events: %v", err)
+	if err := eventStore.AppendEvents(sessionID, prev.Round, resolution.Events); err != nil {
+		logger.Error("Failed to append events", "error", err)
 	}
 
-	if resolution.State.Round > req.State.Round {
+	if resolution.State.Round > prev.Round {
 		if err := eventStore.SaveSnapshot(sessionID, resolution.State.Round, resolution.State); err != nil {
-			log.Printf("Failed to save snapshot: %v", err)
+			logger.Error("Failed to save snapshot", "error", err)
 		}
 	}
 
-	return c.JSON(resolution)
+	eventBus.Publish(sessionID, resolution.State, resolution.Events)
+}
+
+// recordAction stores an applied action with its seed so the session can be
+// replayed exactly. Rejected actions changed nothing and aren't recorded.
+func recordAction(sessionID string, prev State, resolution Resolution, action Action, seed int64) {
+	if !actionResolved(prev, resolution.State) {
+		return
+	}
+	if err := eventStore.RecordAction(sessionID, ActionRecord{Round: prev.Round, Action: action, Seed: seed}); err != nil {
+		sessionLogger(sessionID).Error("Failed to record action", "error", err)
+	}
 }
 
 func handleCreateSession(c *fiber.Ctx) error {
 	var req struct {
-		SessionID string `json:"sessionId"`
-		State     State  `json:"state"`
+		SessionID        string `json:"sessionId"`
+		State            State  `json:"state"`
+		TurnTimerSeconds int    `json:"turnTimerSeconds,omitempty"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -305,20 +616,307 @@ func handleCreateSession(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Session ID and state are required"})
 	}
 
+	if err := validateStatePayload(req.State); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Recreating a session would replace its state and hand out new tokens
+	existing, err := eventStore.GetSession(req.SessionID)
+	if err != nil {
+		sessionLogger(req.SessionID).Error("Failed to look up session", "error", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create session"})
+	}
+	if _, live := stateManager.GetState(req.SessionID); live || existing != nil {
+		return c.Status(409).JSON(fiber.Map{"error": "Session already exists"})
+	}
+
 	stateManager.SetState(req.SessionID, req.State)
 
 	if err := eventStore.CreateSession(req.SessionID, "Session "+req.SessionID); err != nil {
-		log.Printf("Failed to create session: %v", err)
+		sessionLogger(req.SessionID).Error("Failed to create session", "error", err)
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to create session"})
 	}
 
 	if err := eventStore.SaveSnapshot(req.SessionID, req.State.Round, req.State); err != nil {
-		log.Printf("Failed to save initial snapshot: %v", err)
+		sessionLogger(req.SessionID).Error("Failed to save initial snapshot", "error", err)
+	}
+
+	if req.TurnTimerSeconds > 0 {
+		turnTimers.Enable(req.SessionID, time.Duration(req.TurnTimerSeconds)*time.Second)
+		turnTimers.Reset(req.SessionID, req.State)
+	}
+
+	playerToken, dmToken := issueSessionTokens(req.SessionID, req.State)
+	enemyAutoPlay.Start(req.SessionID, req.State)
+
+	return c.JSON(fiber.Map{
+		"success":     true,
+		"sessionId":   req.SessionID,
+		"playerToken": playerToken,
+		"dmToken":     dmToken,
+	})
+}
+
+func handleCreateSessionFromScenario(c *fiber.Ctx) error {
+	var req struct {
+		Scenario string `json:"scenario"`
+		Seed     int64  `json:"seed,omitempty"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if req.Scenario == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Scenario name is required"})
+	}
+
+	difficulty := c.Query("difficulty", "normal")
+	factor, ok := difficultyFactors[difficulty]
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "Unknown difficulty: " + difficulty})
+	}
+
+	scenario, err := loadNamedScenario(req.Scenario)
+	if errors.Is(err, errScenarioNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": "Scenario not found"})
+	}
+	if err != nil {
+		slog.Error("Failed to load scenario", "scenario", req.Scenario, "error", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to load scenario"})
+	}
+	ScaleScenario(scenario, factor)
+	if scenario.Rules.AIDifficulty == "" {
+		scenario.Rules.AIDifficulty = difficulty
+	}
+
+	return startScenarioSession(c, scenario, req.Seed)
+}
+
+// handleCreateSessionFromRoster creates a session with a party picked from the
+// roster, facing the enemies of a bundled scenario
+func handleCreateSessionFromRoster(c *fiber.Ctx) error {
+	var req struct {
+		Characters []string `json:"characters"`
+		Enemies    string   `json:"enemies"` // Scenario whose enemies, map and rules are used
+		Seed       int64    `json:"seed,omitempty"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if len(req.Characters) == 0 || req.Enemies == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Characters and enemies are required"})
+	}
+
+	roster, err := LoadRoster(rosterPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c.Status(404).JSON(fiber.Map{"error": "No roster configured"})
+	}
+	if err != nil {
+		slog.Error("Failed to load roster", "path", rosterPath, "error", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to load roster"})
+	}
+
+	encounter, err := loadNamedScenario(req.Enemies)
+	if errors.Is(err, errScenarioNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": "Scenario not found"})
+	}
+	if err != nil {
+		slog.Error("Failed to load scenario", "scenario", req.Enemies, "error", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to load scenario"})
+	}
+
+	scenario, err := ComposeScenario(roster, req.Characters, encounter)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return startScenarioSession(c, scenario, req.Seed)
+}
+
+// startScenarioSession creates and persists a new session from a scenario,
+// responding with its state and tokens. A zero seed uses the seed source.
+func startScenarioSession(c *fiber.Ctx, scenario *Scenario, seed int64) error {
+	if seed == 0 {
+		seed = newSeed()
+	}
+	state := ConvertScenarioToState(scenario, seed)
+
+	sessionID := uuid.New().String()
+	stateManager.SetState(sessionID, state)
+
+	if err := eventStore.CreateSession(sessionID, scenario.Name); err != nil {
+		sessionLogger(sessionID).Error("Failed to create session", "error", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create session"})
+	}
+
+	if err := eventStore.SaveSnapshot(sessionID, state.Round, state); err != nil {
+		sessionLogger(sessionID).Error("Failed to save initial snapshot", "error", err)
+	}
+
+	playerToken, dmToken := issueSessionTokens(sessionID, state)
+	enemyAutoPlay.Start(sessionID, state)
+
+	return c.JSON(fiber.Map{
+		"success":     true,
+		"sessionId":   sessionID,
+		"state":       state,
+		"playerToken": playerToken,
+		"dmToken":     dmToken,
+	})
+}
+
+// handleForkSession starts a new session from the state at the start of a
+// past round, leaving the original session untouched
+func handleForkSession(c *fiber.Ctx) error {
+	sourceID := c.Params("sessionId")
+
+	var req struct {
+		Round int   `json:"round"`
+		Seed  int64 `json:"seed,omitempty"` // Defaults to the source session's seed
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if req.Round < 1 {
+		return c.Status(400).JSON(fiber.Map{"error": "Round is required"})
+	}
+
+	snapshot, err := eventStore.GetSnapshotAtRound(sourceID, req.Round)
+	if err != nil {
+		sessionLogger(sourceID).Error("Failed to load snapshot", "round", req.Round, "error", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to load snapshot"})
+	}
+	if snapshot == nil || snapshot.Round != req.Round {
+		return c.Status(404).JSON(fiber.Map{"error": "No state recorded for round"})
+	}
+
+	state := deepCopyState(*snapshot)
+	if req.Seed != 0 {
+		state.Seed = req.Seed
+	}
+
+	sessionID := uuid.New().String()
+	stateManager.SetState(sessionID, state)
+
+	if err := eventStore.CreateSession(sessionID, fmt.Sprintf("Fork of %s at round %d", sourceID, req.Round)); err != nil {
+		sessionLogger(sessionID).Error("Failed to create session", "error", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create session"})
+	}
+
+	if err := eventStore.SaveSnapshot(sessionID, state.Round, state); err != nil {
+		sessionLogger(sessionID).Error("Failed to save initial snapshot", "error", err)
+	}
+
+	sessionLogger(sessionID).Info("Session forked", "source", sourceID, "round", req.Round)
+
+	playerToken, dmToken := issueSessionTokens(sessionID, state)
+
+	return c.JSON(fiber.Map{
+		"success":     true,
+		"sessionId":   sessionID,
+		"forkedFrom":  sourceID,
+		"round":       req.Round,
+		"state":       state,
+		"playerToken": playerToken,
+		"dmToken":     dmToken,
+	})
+}
+
+// handleUndo takes back the last applied action, restoring the state before it
+// and removing the events and snapshots it recorded
+func handleUndo(c *fiber.Ctx) error {
+	sessionID := c.Params("sessionId")
+
+	unlock := sessionLocks.Lock(sessionID)
+	defer unlock()
+	state, exists := stateManager.GetState(sessionID)
+	if !exists {
+		return c.Status(404).JSON(fiber.Map{"error": "Session not found"})
+	}
+
+	if state.Rules.Ranked {
+		return c.Status(403).JSON(fiber.Map{"error": "Undo is disabled for ranked sessions"})
+	}
+
+	prev, ok := stateManager.PeekUndo(sessionID)
+	if !ok {
+		return c.Status(409).JSON(fiber.Map{"error": "Nothing to undo"})
+	}
+
+	// Only whoever took the action may take it back
+	if actor := GetCurrentCharacter(prev); actor != nil && !sessionAuth.Authorize(sessionID, requestToken(c), actor.ID) {
+		return c.Status(403).JSON(fiber.Map{"error": "Not authorized to undo this action"})
 	}
 
+	prev, eventCount, _ := stateManager.PopUndo(sessionID)
+	if err := eventStore.RewindSession(sessionID, eventCount, prev.Round); err != nil {
+		sessionLogger(sessionID).Error("Failed to rewind session history", "error", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to undo action"})
+	}
+
+	stateManager.SetState(sessionID, prev)
+	turnTimers.Reset(sessionID, prev)
+	eventBus.Publish(sessionID, prev, []Event{})
+
+	sessionLogger(sessionID).Info("Action undone", "round", prev.Round, "events", eventCount)
+
 	return c.JSON(fiber.Map{
 		"success":   true,
-		"sessionId": req.SessionID,
+		"sessionId": sessionID,
+		"state":     prev,
+	})
+}
+
+func handleExportSession(c *fiber.Ctx) error {
+	sessionID := c.Params("sessionId")
+
+	export, err := ExportSession(eventStore, sessionID)
+	if err != nil {
+		sessionLogger(sessionID).Error("Failed to export session", "error", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to export session"})
+	}
+	if export == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Session not found"})
+	}
+	if state, exists := stateManager.GetState(sessionID); exists {
+		export.State = &state
+	}
+
+	return c.JSON(export)
+}
+
+func handleImportSession(c *fiber.Ctx) error {
+	var export SessionExport
+	if err := c.BodyParser(&export); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := validateSessionExport(export); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	sessionID, state, err := ImportSession(eventStore, export)
+	if err != nil {
+		slog.Error("Failed to import session", "session", export.Session.ID, "error", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to import session"})
+	}
+
+	stateManager.SetState(sessionID, state)
+	playerToken, dmToken := issueSessionTokens(sessionID, state)
+
+	sessionLogger(sessionID).Info("Session imported", "source", export.Session.ID, "events", len(export.Events), "snapshots", len(export.Snapshots))
+
+	return c.JSON(fiber.Map{
+		"success":     true,
+		"sessionId":   sessionID,
+		"state":       state,
+		"playerToken": playerToken,
+		"dmToken":     dmToken,
 	})
 }
 
@@ -330,17 +928,117 @@ func handleGetSession(c *fiber.Ctx) error {
 		return c.Status(404).JSON(fiber.Map{"error": "Session not found"})
 	}
 
-	return c.JSON(fiber.Map{
+	return sendJSON(c, fiber.Map{
 		"sessionId": sessionID,
 		"state":     state,
 	})
 }
 
+func handleGetTurnOrder(c *fiber.Ctx) error {
+	sessionID := c.Params("sessionId")
+
+	state, exists := stateManager.GetState(sessionID)
+	if !exists {
+		return c.Status(404).JSON(fiber.Map{"error": "Session not found"})
+	}
+
+	return c.JSON(fiber.Map{
+		"sessionId": sessionID,
+		"round":     state.Round,
+		"queue":     GetTurnQueue(state),
+	})
+}
+
+func handleGetSnapshot(c *fiber.Ctx) error {
+	sessionID := c.Params("sessionId")
+
+	round, err := c.ParamsInt("round")
+	if err != nil || round < 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid round"})
+	}
+
+	snapshot, err := eventStore.GetSnapshotAtRound(sessionID, round)
+	if err != nil {
+		sessionLogger(sessionID).Error("Failed to load snapshot", "round", round, "error", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to load snapshot"})
+	}
+	if snapshot == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "No snapshot at or before round"})
+	}
+
+	return sendJSON(c, fiber.Map{
+		"sessionId": sessionID,
+		"round":     snapshot.Round,
+		"state":     snapshot,
+	})
+}
+
+func handleGetCombatLog(c *fiber.Ctx) error {
+	sessionID := c.Params("sessionId")
+
+	state, exists := stateManager.GetState(sessionID)
+	if !exists {
+		return c.Status(404).JSON(fiber.Map{"error": "Session not found"})
+	}
+
+	events, err := eventStore.GetEvents(sessionID, 0)
+	if err != nil {
+		sessionLogger(sessionID).Error("Failed to load events", "error", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to load combat log"})
+	}
+
+	entries := BuildCombatLog(state, events)
+
+	switch c.Query("format", "json") {
+	case "json":
+		return c.JSON(fiber.Map{
+			"sessionId": sessionID,
+			"entries":   entries,
+		})
+	case "text":
+		c.Set("Content-Type", "text/plain; charset=utf-8")
+		c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="combat-log-%s.txt"`, sessionID))
+		return c.SendString(FormatCombatLogText(entries))
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "Format must be json or text"})
+	}
+}
+
+// handleGetActions returns every applied action with the seed it was resolved
+// with, so a reported session can be replayed exactly from its first snapshot
+func handleGetActions(c *fiber.Ctx) error {
+	sessionID := c.Params("sessionId")
+
+	session, err := eventStore.GetSession(sessionID)
+	if err != nil {
+		sessionLogger(sessionID).Error("Failed to load session", "error", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to load session"})
+	}
+	if session == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Session not found"})
+	}
+
+	actions, err := eventStore.GetActions(sessionID)
+	if err != nil {
+		sessionLogger(sessionID).Error("Failed to load actions", "error", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to load actions"})
+	}
+	if actions == nil {
+		actions = []ActionRecord{}
+	}
+
+	return c.JSON(fiber.Map{
+		"sessionId": sessionID,
+		"actions":   actions,
+	})
+}
+
 func handleGenerateNarration(c *fiber.Ctx) error {
 	var req struct {
 		State    State    `json:"state"`
 		Events   []string `json:"events"`
 		Context  string   `json:"context,omitempty"`
+		Style    string   `json:"style,omitempty"`
 		UseLocal bool     `json:"useLocal,omitempty"`
 	}
 
@@ -348,12 +1046,29 @@ func handleGenerateNarration(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
-	narration, err := llmClient.GenerateNarrationWithModel(req.State, req.Events, req.Context, req.UseLocal)
+	if err := validateStatePayload(req.State); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if req.Style != "" && !llmClient.prompts.Has(req.Style) {
+		return c.Status(400).JSON(fiber.Map{"error": "Unknown narration style"})
+	}
+
+	sessionID := c.Get("session-id")
+	data := NewPromptData(req.State, req.Events, req.Context)
+	data.Story = sessionStory(sessionID)
+	data.SessionID = sessionID
+
+	narration, err := llmClient.GenerateNarrationWithModel(data, req.Style, req.UseLocal)
 	if err != nil {
-		log.Printf("Narration generation failed: %v", err)
+		slog.Error("Narration generation failed", "round", req.State.Round, "error", err)
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to generate narration"})
 	}
 
+	if sessionID != "" {
+		stateManager.AppendStory(sessionID, narration)
+	}
+
 	return c.JSON(fiber.Map{
 		"narration": narration,
 		"model":     req.UseLocal && llmClient.config.LocalEnabled,
@@ -367,6 +1082,7 @@ func handleGenerateCombatDescription(c *fiber.Ctx) error {
 		Events   []string   `json:"events"`
 		Attacker *Character `json:"attacker,omitempty"`
 		Target   *Character `json:"target,omitempty"`
+		Style    string     `json:"style,omitempty"`
 		UseLocal bool       `json:"useLocal,omitempty"`
 	}
 
@@ -374,18 +1090,35 @@ func handleGenerateCombatDescription(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
+	if err := validateStatePayload(req.State); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if req.Style != "" && !llmClient.prompts.Has(req.Style) {
+		return c.Status(400).JSON(fiber.Map{"error": "Unknown narration style"})
+	}
+
 	// Create enhanced context for combat description
 	context := fmt.Sprintf("Combat Action: %s", req.Action.Kind)
 	if req.Attacker != nil && req.Target != nil {
 		context += fmt.Sprintf(" - %s attacks %s", req.Attacker.Name, req.Target.Name)
 	}
 
-	narration, err := llmClient.GenerateNarrationWithModel(req.State, req.Events, context, req.UseLocal)
+	sessionID := c.Get("session-id")
+	data := NewPromptData(req.State, req.Events, context)
+	data.Story = sessionStory(sessionID)
+	data.SessionID = sessionID
+
+	narration, err := llmClient.GenerateNarrationWithModel(data, req.Style, req.UseLocal)
 	if err != nil {
-		log.Printf("Combat description generation failed: %v", err)
+		slog.Error("Combat description generation failed", "action", req.Action.Kind, "round", req.State.Round, "error", err)
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to generate combat description"})
 	}
 
+	if sessionID != "" {
+		stateManager.AppendStory(sessionID, narration)
+	}
+
 	return c.JSON(fiber.Map{
 		"description": narration,
 		"action":      req.Action.Kind,
@@ -393,51 +1126,111 @@ func handleGenerateCombatDescription(c *fiber.Ctx) error {
 	})
 }
 
-// WebSocket handler for real-time game updates
-func handleWebSocket(c *websocket.Conn) {
+// sessionStory returns the session's narration so far as prompt context
+func sessionStory(sessionID string) string {
+	if sessionID == "" {
+		return ""
+	}
+	return strings.Join(stateManager.GetStory(sessionID), "\n")
+}
+
+func handleGetStory(c *fiber.Ctx) error {
 	sessionID := c.Params("sessionId")
 
+	if _, exists := stateManager.GetState(sessionID); !exists {
+		return c.Status(404).JSON(fiber.Map{"error": "Session not found"})
+	}
+
+	return c.JSON(fiber.Map{
+		"sessionId": sessionID,
+		"story":     stateManager.GetStory(sessionID),
+	})
+}
+
+// WebSocket handler for real-time game updates
+func handleWebSocket(ws *websocket.Conn) {
+	c := &lockedConn{Conn: ws}
+	sessionID := strings.Clone(c.Params("sessionId"))
+	role := wsRolePlayer
+	if c.Query("role") == wsRoleSpectator {
+		role = wsRoleSpectator
+	}
+
 	// Register client
 	clientsMutex.Lock()
-	clients[sessionID] = c
+	if role == wsRoleSpectator {
+		if spectators[sessionID] == nil {
+			spectators[sessionID] = make(map[*lockedConn]bool)
+		}
+		spectators[sessionID][c] = true
+	} else {
+		clients[sessionID] = c
+	}
 	clientsMutex.Unlock()
 
-	log.Printf("WebSocket client connected for session %s", sessionID)
+	logger := sessionLogger(sessionID).With("role", role)
+	logger.Info("WebSocket connected")
 
-	// Handle WebSocket messages
-	for {
-		var msg map[string]interface{}
-		err := c.ReadJSON(&msg)
-		if err != nil {
-			log.Printf("WebSocket read error: %v", err)
-			break
-		}
-
-		// Handle incoming messages (e.g., ping, etc.)
-		log.Printf("Received WebSocket message: %v", msg)
+	// Browsers can't set headers on WebSocket requests, so the player token may
+	// also come from the query string or the session cookie
+	token := c.Query("token")
+	if token == "" {
+		token = c.Headers(playerTokenHeader)
+	}
+	if token == "" {
+		token = c.Cookies(playerTokenCookie)
 	}
 
+	serveWebSocketMessages(c, sessionID, role, token)
+
 	// Clean up on disconnect
 	clientsMutex.Lock()
-	delete(clients, sessionID)
+	if role == wsRoleSpectator {
+		delete(spectators[sessionID], c)
+		if len(spectators[sessionID]) == 0 {
+			delete(spectators, sessionID)
+		}
+	} else if clients[sessionID] == c {
+		delete(clients, sessionID)
+	}
 	clientsMutex.Unlock()
 
-	log.Printf("WebSocket client disconnected for session %s", sessionID)
+	logger.Info("WebSocket disconnected")
+}
+
+// validateWebSocketMessage rejects inbound messages the connection's role may not send.
+// Spectators are read-only and may not drive actions.
+func validateWebSocketMessage(role string, msg map[string]interface{}) error {
+	if role != wsRoleSpectator {
+		return nil
+	}
+	if msgType, _ := msg["type"].(string); msgType == "action" {
+		return fmt.Errorf("spectators cannot perform actions")
+	}
+	return nil
 }
 
 // Broadcast game state update to WebSocket clients
-func broadcastGameUpdate(sessionID string, state State) {
+func broadcastGameUpdate(sessionID string, state State, events []Event) {
+	broadcastMessage(sessionID, newGameUpdateMessage(state, events))
+}
+
+// broadcastMessage sends a message to every player and spectator connected to a
+// session. msg should be one of the typed messages in messages.go.
+func broadcastMessage(sessionID string, msg interface{}) {
 	clientsMutex.RLock()
-	conn, exists := clients[sessionID]
+	var conns []*lockedConn
+	if conn, exists := clients[sessionID]; exists {
+		conns = append(conns, conn)
+	}
+	for conn := range spectators[sessionID] {
+		conns = append(conns, conn)
+	}
 	clientsMutex.RUnlock()
 
-	if exists {
-		err := conn.WriteJSON(fiber.Map{
-			"type":  "game_update",
-			"state": state,
-		})
-		if err != nil {
-			log.Printf("WebSocket broadcast error: %v", err)
+	for _, conn := range conns {
+		if err := conn.WriteJSON(msg); err != nil {
+			sessionLogger(sessionID).Error("WebSocket broadcast error", "error", err)
 		}
 	}
 }
@@ -451,13 +1244,23 @@ func handleGamePage(c *fiber.Ctx) error {
 		return c.Status(404).SendString("Session not found")
 	}
 
+	if state.IsComplete {
+		html, err := templateEngine.RenderGameOverPage(state, sessionID)
+		if err != nil {
+			sessionLogger(sessionID).Error("Template render error", "error", err)
+			return sendTemplateError(c, err)
+		}
+		c.Set("Content-Type", "text/html")
+		return c.SendString(html)
+	}
+
 	currentChar := GetCurrentCharacter(state)
-	isPlayerTurn := currentChar != nil && currentChar.IsPlayer
+	isPlayerTurn := currentChar != nil && humanControlled(*currentChar)
 
 	html, err := templateEngine.RenderGamePage(state, sessionID, isPlayerTurn)
 	if err != nil {
-		log.Printf("Template render error: %v", err)
-		return c.Status(500).SendString("Internal server error")
+		sessionLogger(sessionID).Error("Template render error", "error", err)
+		return sendTemplateError(c, err)
 	}
 
 	c.Set("Content-Type", "text/html")
@@ -499,6 +1302,8 @@ func handleGameAction(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
 	}
 
+	unlock := sessionLocks.Lock(sessionID)
+	defer unlock()
 	state, exists := stateManager.GetState(sessionID)
 	if !exists {
 		return c.Status(404).JSON(fiber.Map{"error": "Session not found"})
@@ -510,20 +1315,51 @@ func handleGameAction(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "No current character"})
 	}
 
+	if !sessionAuth.Authorize(sessionID, requestToken(c), currentChar.ID) {
+		return c.Status(403).JSON(fiber.Map{"error": "Not authorized to act for this character"})
+	}
+
 	// Create action based on request
-	var action Action
-	switch req.Action {
+	action, err := gamePageAction(state, currentChar, req.Action)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Apply the action
+	seed := newSeed()
+	resolution := ApplyAction(state, action, seed)
+	if resolution.RejectReason != "" {
+		return c.Status(rejectStatus(resolution.RejectReason)).JSON(fiber.Map{
+			"success": false,
+			"error":   resolution.Logs[len(resolution.Logs)-1],
+			"reason":  resolution.RejectReason,
+			"logs":    resolution.Logs,
+		})
+	}
+
+	// Update and persist state
+	newState := resolution.State
+	persistResolution(sessionID, state, resolution)
+	recordAction(sessionID, state, resolution, action, seed)
+	turnTimers.Reset(sessionID, newState)
+
+	sessionLogger(sessionID).Info("Applied action",
+		"action", action.Kind,
+		"actor", currentChar.ID,
+		"round", newState.Round,
+		"logs", strings.Join(resolution.Logs, "; "))
+
+	return c.JSON(fiber.Map{"success": true, "logs": resolution.Logs})
+}
+
+// gamePageAction builds the action for one of the game page's buttons
+// ("attack", "defend", "flee" or "concede") taken by the current character
+func gamePageAction(state State, currentChar *Character, name string) (Action, error) {
+	switch name {
 	case "attack":
-		// For simplicity, attack the first enemy
-		var targetID ID
-		for _, char := range state.Characters {
-			if !char.IsPlayer && char.Stats.HP > 0 {
-				targetID = char.ID
-				break
-			}
-		}
-		if targetID == "" {
-			return c.Status(400).JSON(fiber.Map{"error": "No valid target"})
+		target := SelectDefaultTarget(state, currentChar)
+		if target == nil {
+			return Action{}, fmt.Errorf("no valid target")
 		}
 
 		// Use first weapon
@@ -532,61 +1368,32 @@ func handleGameAction(c *fiber.Ctx) error {
 			weaponID = currentChar.Weapons[0].ID
 		}
 
-		action = Action{
+		return Action{
 			Kind:     "Attack",
 			Attacker: currentChar.ID,
-			Target:   targetID,
+			Target:   target.ID,
 			Weapon:   weaponID,
-		}
+		}, nil
 
 	case "defend":
-		action = Action{
-			Kind:  "Defend",
-			Actor: currentChar.ID,
-		}
+		return Action{Kind: "Defend", Actor: currentChar.ID}, nil
 
 	case "flee":
-		action = Action{
-			Kind:  "Flee",
-			Actor: currentChar.ID,
-		}
+		return Action{Kind: "Flee", Actor: currentChar.ID}, nil
 
-	default:
-		return c.Status(400).JSON(fiber.Map{"error": "Unknown action"})
-	}
-
-	// Apply the action
-	seed := time.Now().UnixNano()
-	resolution := ApplyAction(state, action, seed)
-
-	// Update state
-	newState := resolution.State
-	stateManager.SetState(sessionID, newState)
+	case "concede":
+		return Action{Kind: "Concede", Actor: currentChar.ID}, nil
 
-	// Persist to database
-	if err := eventStore.AppendEvents(sessionID, newState.Round, resolution.Events); err != nil {
-		log.Printf("Failed to append events: %v", err)
-	}
-
-	if newState.Round > state.Round {
-		if err := eventStore.SaveSnapshot(sessionID, newState.Round, newState); err != nil {
-			log.Printf("Failed to save snapshot: %v", err)
-		}
+	default:
+		return Action{}, fmt.Errorf("unknown action")
 	}
-
-	log.Printf("Applied action %s for session %s: %s", req.Action, sessionID, strings.Join(resolution.Logs, "; "))
-
-	// Broadcast update to WebSocket clients
-	broadcastGameUpdate(sessionID, newState)
-
-	return c.JSON(fiber.Map{"success": true, "logs": resolution.Logs})
 }
 
 // Home page handler - serves the home page using Go templates
 func handleHomePage(c *fiber.Ctx) error {
 	html, err := templateEngine.RenderHomePage()
 	if err != nil {
-		log.Printf("Home template render error: %v", err)
+		slog.Error("Home template render error", "error", err)
 		// Fallback to simple HTML if templates fail
 		return c.SendString(`
 <!DOCTYPE html>
@@ -617,18 +1424,35 @@ func handleHomePage(c *fiber.Ctx) error {
 	return c.SendString(html)
 }
 
+// sendTemplateError responds to a page that failed to render. Without a
+// template engine the JSON API still works, so say so instead of a bare 500.
+func sendTemplateError(c *fiber.Ctx, err error) error {
+	if !errors.Is(err, errTemplatesUnavailable) {
+		return c.Status(500).SendString("Internal server error")
+	}
+	c.Set("Content-Type", "text/html")
+	return c.Status(503).SendString(`<!DOCTYPE html>
+<html>
+<head><title>SmolDungeon - Pages Unavailable</title></head>
+<body style="font-family: Arial, sans-serif; margin: 40px;">
+    <h1>SmolDungeon Server</h1>
+    <p>The game pages failed to load, but the JSON API under <code>/tools</code>, <code>/llm</code> and <code>/sessions</code> is still running.</p>
+</body>
+</html>`)
+}
+
 // Scenarios page handler - serves the scenarios page using Go templates
 func handleScenariosPage(c *fiber.Ctx) error {
 	scenarios, err := GetAvailableScenarios()
 	if err != nil {
-		log.Printf("Failed to get scenarios: %v", err)
+		slog.Error("Failed to get scenarios", "error", err)
 		scenarios = []string{}
 	}
 
 	html, err := templateEngine.RenderScenariosPage(scenarios)
 	if err != nil {
-		log.Printf("Scenarios template render error: %v", err)
-		return c.Status(500).SendString("Internal server error")
+		slog.Error("Scenarios template render error", "error", err)
+		return sendTemplateError(c, err)
 	}
 
 	c.Set("Content-Type", "text/html")
@@ -643,15 +1467,14 @@ func handleStartGame(c *fiber.Ctx) error {
 	}
 
 	// Load scenario
-	scenarioPath := fmt.Sprintf("../../scenarios/%s.yaml", scenarioName)
-	scenario, err := LoadScenario(scenarioPath)
+	scenario, err := loadNamedScenario(scenarioName)
 	if err != nil {
-		log.Printf("Failed to load scenario %s: %v", scenarioName, err)
+		slog.Error("Failed to load scenario", "scenario", scenarioName, "error", err)
 		return c.Status(500).SendString("Failed to load scenario")
 	}
 
 	// Create initial game state
-	seed := time.Now().UnixNano()
+	seed := newSeed()
 	state := ConvertScenarioToState(scenario, seed)
 
 	// Create session
@@ -660,13 +1483,29 @@ func handleStartGame(c *fiber.Ctx) error {
 
 	// Save to database
 	if err := eventStore.CreateSession(sessionID, scenario.Name); err != nil {
-		log.Printf("Failed to create session: %v", err)
+		sessionLogger(sessionID).Error("Failed to create session", "error", err)
 	}
 
 	if err := eventStore.SaveSnapshot(sessionID, state.Round, state); err != nil {
-		log.Printf("Failed to save initial snapshot: %v", err)
+		sessionLogger(sessionID).Error("Failed to save initial snapshot", "error", err)
+	}
+
+	if seconds, err := strconv.Atoi(c.FormValue("turnTimer")); err == nil && seconds > 0 {
+		turnTimers.Enable(sessionID, time.Duration(seconds)*time.Second)
+		turnTimers.Reset(sessionID, state)
 	}
 
+	// The browser holds the player token; the DM token is not needed by the web UI.
+	// The cookie is scoped to the whole site so the /ws handshake carries it too.
+	playerToken, _ := issueSessionTokens(sessionID, state)
+	c.Cookie(&fiber.Cookie{
+		Name:     playerTokenCookie,
+		Value:    playerToken,
+		Path:     "/",
+		HTTPOnly: true,
+		SameSite: "Strict",
+	})
+
 	// Redirect to game page
 	return c.Redirect(fmt.Sprintf("/game/%s", sessionID))
 }
@@ -679,6 +1518,17 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvList reads a comma-separated list, skipping blank entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func getEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -706,25 +1556,8 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
-// LoadScenario loads a scenario from a YAML file
-func LoadScenario(filename string) (*Scenario, error) {
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read scenario file: %w", err)
-	}
-
-	var scenario Scenario
-	if err := yaml.Unmarshal(data, &scenario); err != nil {
-		return nil, fmt.Errorf("failed to parse scenario YAML: %w", err)
-	}
-
-	return &scenario, nil
-}
-
 // ConvertScenarioToState converts a scenario to an initial game state
 func ConvertScenarioToState(scenario *Scenario, seed int64) State {
-	rng := NewSeededRNG(seed)
-
 	// Convert players
 	players := make([]Character, len(scenario.Players))
 	for i, p := range scenario.Players {
@@ -737,47 +1570,35 @@ func ConvertScenarioToState(scenario *Scenario, seed int64) State {
 		enemies[i] = convertScenarioCharacterToCharacter(e, false)
 	}
 
-	// Combine all characters
-	allCharacters := append(players, enemies...)
-
-	// Create turn order based on initiative
-	type charWithInit struct {
-		id         ID
-		initiative int
+	state := CreateInitialState(players, enemies, seed)
+	state.Rules = scenario.Rules
+	state.Rewards = scenario.Rewards
+	if scenario.Round > 0 {
+		state.Round = scenario.Round
 	}
-
-	initiatives := make([]charWithInit, len(allCharacters))
-	for i, char := range allCharacters {
-		initiative := char.Stats.Speed + rng.RollD20()
-		initiatives[i] = charWithInit{id: char.ID, initiative: initiative}
+	if scenario.Surprise != "" {
+		startSurpriseRound(&state, scenario.Surprise)
 	}
-
-	// Sort by initiative descending
-	sort.Slice(initiatives, func(i, j int) bool {
-		return initiatives[i].initiative > initiatives[j].initiative
-	})
-
-	turnOrder := make([]ID, len(initiatives))
-	for i, init := range initiatives {
-		turnOrder[i] = init.id
-	}
-
-	return State{
-		Round:       1,
-		Characters:  allCharacters,
-		TurnOrder:   turnOrder,
-		CurrentTurn: 0,
-		IsComplete:  false,
+	for _, obstacle := range scenario.Obstacles {
+		state.Obstacles = append(state.Obstacles, Position{X: obstacle.X, Y: obstacle.Y})
 	}
+	return state
 }
 
 // convertScenarioCharacterToCharacter converts a scenario character to a game character
 func convertScenarioCharacterToCharacter(sc ScenarioCharacter, isPlayer bool) Character {
 	char := Character{
-		ID:               NewID(),
+		ID:               scenarioID(sc.ID),
 		Name:             sc.Name,
 		IsPlayer:         isPlayer,
 		AbilityCooldowns: make(map[string]int),
+		MaxItems:         sc.MaxItems,
+		XP:               sc.XP,
+		InitiativeBonus:  sc.InitiativeBonus,
+		AI:               isPlayer && sc.AI,
+		Sprite:           sc.Sprite,
+		Color:            sc.Color,
+		Portrait:         sc.Portrait,
 	}
 
 	// Convert stats
@@ -799,10 +1620,14 @@ func convertScenarioCharacterToCharacter(sc ScenarioCharacter, isPlayer bool) Ch
 	char.Weapons = make([]Weapon, len(sc.Weapons))
 	for i, w := range sc.Weapons {
 		char.Weapons[i] = Weapon{
-			ID:       NewID(),
-			Name:     w.Name,
-			Damage:   w.Damage,
-			Accuracy: w.Accuracy,
+			ID:         scenarioID(w.ID),
+			Name:       w.Name,
+			Damage:     w.Damage,
+			Accuracy:   w.Accuracy,
+			Durability: w.Durability,
+
+			IgnoresDefense: w.IgnoresDefense,
+			Scaling:        w.Scaling,
 		}
 	}
 
@@ -810,52 +1635,61 @@ func convertScenarioCharacterToCharacter(sc ScenarioCharacter, isPlayer bool) Ch
 	char.Abilities = make([]Ability, len(sc.Abilities))
 	for i, a := range sc.Abilities {
 		char.Abilities[i] = Ability{
-			ID:       NewID(),
+			ID:       scenarioID(a.ID),
 			Name:     a.Name,
 			Cooldown: a.Cooldown,
 			Effect:   a.Effect,
 			Power:    a.Power,
+
+			IgnoresDefense:   a.IgnoresDefense,
+			ReducedByDefense: a.ReducedByDefense,
+			Scaling:          a.Scaling,
+			Passive:          a.Passive,
+			Trigger:          a.Trigger,
+			Reaction:         a.Reaction,
+		}
+		if cooldown := sc.Cooldowns[a.Name]; cooldown > 0 {
+			char.AbilityCooldowns[string(char.Abilities[i].ID)] = cooldown
 		}
 	}
 
+	for _, effect := range sc.StatusEffects {
+		applyStatusEffect(&char, StatusEffect{Type: effect.Type, Amount: effect.Amount, Duration: effect.Duration})
+	}
+
 	// Convert items
 	char.Items = make([]Item, len(sc.Items))
 	for i, item := range sc.Items {
 		char.Items[i] = Item{
-			ID:     NewID(),
-			Name:   item.Name,
-			Type:   item.Type,
-			Effect: item.Effect,
+			ID:      scenarioID(item.ID),
+			Name:    item.Name,
+			Type:    item.Type,
+			Effect:  item.Effect,
+			Charges: item.Charges,
 		}
 	}
 
 	return char
 }
 
-// GetAvailableScenarios returns a list of available scenario files
-func GetAvailableScenarios() ([]string, error) {
-	files, err := os.ReadDir("../../scenarios")
-	if err != nil {
-		return nil, fmt.Errorf("failed to read scenarios directory: %w", err)
+// scenarioID uses a scenario's fixed ID when it has one, or generates a new one
+func scenarioID(id string) ID {
+	if id == "" {
+		return NewID()
 	}
-
-	var scenarios []string
-	for _, file := range files {
-		if !file.IsDir() && strings.HasSuffix(file.Name(), ".yaml") {
-			scenarios = append(scenarios, strings.TrimSuffix(file.Name(), ".yaml"))
-		}
-	}
-
-	return scenarios, nil
+	return ID(id)
 }
 
+// boardRadius is how far the combat map extends from (0,0) in each direction
+const boardRadius = 2
+
 // Helper functions for HTML rendering
 func renderCombatMap(state State) string {
 	var html strings.Builder
 
 	// Create 5x5 grid centered on (0,0)
-	for y := -2; y <= 2; y++ {
-		for x := -2; x <= 2; x++ {
+	for y := -boardRadius; y <= boardRadius; y++ {
+		for x := -boardRadius; x <= boardRadius; x++ {
 			html.WriteString(`<div class="character">`)
 
 			// Find character at this position
@@ -3,6 +3,8 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -11,6 +13,8 @@ type MemoryEventStore struct {
 	events    []Event
 	snapshots []Snapshot
 	sessions  []Session
+	actions   map[string][]ActionRecord
+	tokens    map[string][]SessionToken
 }
 
 // Ensure MemoryEventStore implements EventStoreInterface
@@ -40,6 +44,8 @@ func NewMemoryEventStore() *MemoryEventStore {
 		events:    []Event{},
 		snapshots: []Snapshot{},
 		sessions:  []Session{},
+		actions:   make(map[string][]ActionRecord),
+		tokens:    make(map[string][]SessionToken),
 	}
 }
 
@@ -60,6 +66,7 @@ func (mes *MemoryEventStore) CreateSession(sessionID, name string) error {
 func (mes *MemoryEventStore) AppendEvents(sessionID string, round int, events []Event) error {
 	for _, event := range events {
 		event.ID = fmt.Sprintf("%s-%d-%d", sessionID, round, len(mes.events))
+		event.Round = round
 		mes.events = append(mes.events, event)
 	}
 	return nil
@@ -94,12 +101,13 @@ func (mes *MemoryEventStore) GetEvents(sessionID string, fromRound int) ([]Event
 	return result, nil
 }
 
-// GetLatestSnapshot retrieves the most recent snapshot for a session
+// GetLatestSnapshot retrieves the most recent snapshot for a session, taking
+// the last one saved when a round has several
 func (mes *MemoryEventStore) GetLatestSnapshot(sessionID string) (*State, error) {
 	var latest *Snapshot
 	for i := range mes.snapshots {
 		if mes.snapshots[i].SessionID == sessionID {
-			if latest == nil || mes.snapshots[i].Round > latest.Round {
+			if latest == nil || mes.snapshots[i].Round >= latest.Round {
 				latest = &mes.snapshots[i]
 			}
 		}
@@ -119,13 +127,56 @@ func (mes *MemoryEventStore) GetLatestSnapshot(sessionID string) (*State, error)
 
 // GetSnapshotAtRound retrieves a snapshot at a specific round
 func (mes *MemoryEventStore) GetSnapshotAtRound(sessionID string, round int) (*State, error) {
-	for _, snapshot := range mes.snapshots {
+	var found *Snapshot
+	for i := range mes.snapshots {
+		snapshot := &mes.snapshots[i]
 		if snapshot.SessionID == sessionID && snapshot.Round <= round {
-			var state State
-			if err := json.Unmarshal([]byte(snapshot.StateData), &state); err != nil {
-				return nil, err
+			if found == nil || snapshot.Round > found.Round {
+				found = snapshot
 			}
-			return &state, nil
+		}
+	}
+
+	if found == nil {
+		return nil, nil
+	}
+
+	var state State
+	if err := json.Unmarshal([]byte(found.StateData), &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// GetSnapshots retrieves all snapshots for a session in round order
+func (mes *MemoryEventStore) GetSnapshots(sessionID string) ([]State, error) {
+	var matching []Snapshot
+	for _, snapshot := range mes.snapshots {
+		if snapshot.SessionID == sessionID {
+			matching = append(matching, snapshot)
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		return matching[i].Round < matching[j].Round
+	})
+
+	states := make([]State, 0, len(matching))
+	for _, snapshot := range matching {
+		var state State
+		if err := json.Unmarshal([]byte(snapshot.StateData), &state); err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// GetSession retrieves a session's metadata, or nil if it doesn't exist
+func (mes *MemoryEventStore) GetSession(sessionID string) (*Session, error) {
+	for i := range mes.sessions {
+		if mes.sessions[i].ID == sessionID {
+			session := mes.sessions[i]
+			return &session, nil
 		}
 	}
 	return nil, nil
@@ -143,6 +194,57 @@ func (mes *MemoryEventStore) UpdateSessionStatus(sessionID, status string) error
 	return fmt.Errorf("session not found: %s", sessionID)
 }
 
+// RewindSession deletes the session's last eventCount events, its most recently
+// recorded action and any snapshots taken after the given round
+func (mes *MemoryEventStore) RewindSession(sessionID string, eventCount, round int) error {
+	if actions := mes.actions[sessionID]; len(actions) > 0 {
+		mes.actions[sessionID] = actions[:len(actions)-1]
+	}
+
+	for i := len(mes.events) - 1; i >= 0 && eventCount > 0; i-- {
+		if strings.HasPrefix(mes.events[i].ID, sessionID+"-") {
+			mes.events = append(mes.events[:i], mes.events[i+1:]...)
+			eventCount--
+		}
+	}
+
+	snapshots := mes.snapshots[:0]
+	for _, snapshot := range mes.snapshots {
+		if snapshot.SessionID != sessionID || snapshot.Round <= round {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	mes.snapshots = snapshots
+	return nil
+}
+
+// RecordAction records an applied action and its seed
+func (mes *MemoryEventStore) RecordAction(sessionID string, record ActionRecord) error {
+	mes.actions[sessionID] = append(mes.actions[sessionID], record)
+	return nil
+}
+
+// GetActions retrieves a session's recorded actions in the order they were applied
+func (mes *MemoryEventStore) GetActions(sessionID string) ([]ActionRecord, error) {
+	return append([]ActionRecord(nil), mes.actions[sessionID]...), nil
+}
+
+// SaveSessionToken records a token issued for a session
+func (mes *MemoryEventStore) SaveSessionToken(sessionID string, token SessionToken) error {
+	mes.tokens[sessionID] = append(mes.tokens[sessionID], token)
+	return nil
+}
+
+// GetSessionTokens retrieves the tokens issued for a session
+func (mes *MemoryEventStore) GetSessionTokens(sessionID string) ([]SessionToken, error) {
+	return append([]SessionToken(nil), mes.tokens[sessionID]...), nil
+}
+
+// Ping always succeeds for memory store
+func (mes *MemoryEventStore) Ping() error {
+	return nil
+}
+
 // Close is a no-op for memory store
 func (mes *MemoryEventStore) Close() error {
 	return nil
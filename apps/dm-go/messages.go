@@ -0,0 +1,100 @@
+package main
+
+// messageVersion is stamped on every WebSocket and event stream message.
+// Bump it whenever a message's shape changes incompatibly so clients can
+// tell they are talking to a server they don't understand.
+const messageVersion = 1
+
+// Outbound message types
+const (
+	msgGameUpdate  = "game_update"
+	msgTurnTimeout = "turn_timeout"
+	msgNarration   = "narration"
+	msgEpilogue    = "combat_epilogue"
+	msgError       = "error"
+	msgEvent       = "event"
+	msgState       = "state"
+)
+
+// GameUpdateMessage carries a session's state after an action and the events it produced
+type GameUpdateMessage struct {
+	Type    string  `json:"type"`
+	Version int     `json:"version"`
+	State   State   `json:"state"`
+	Events  []Event `json:"events"`
+}
+
+// TurnTimeoutMessage announces that a character ran out of time and acted automatically
+type TurnTimeoutMessage struct {
+	Type    string `json:"type"`
+	Version int    `json:"version"`
+	Actor   ID     `json:"actor"`
+}
+
+// NarrationMessage carries narration the server generated on its own
+type NarrationMessage struct {
+	Type      string `json:"type"`
+	Version   int    `json:"version"`
+	Narration string `json:"narration"`
+	Round     int    `json:"round"`
+}
+
+// EpilogueMessage carries the narration that closes out a finished combat
+type EpilogueMessage struct {
+	Type     string `json:"type"`
+	Version  int    `json:"version"`
+	Epilogue string `json:"epilogue"`
+	Winner   string `json:"winner"`
+}
+
+// ErrorMessage tells a WebSocket client why its message was refused
+type ErrorMessage struct {
+	Type    string `json:"type"`
+	Version int    `json:"version"`
+	Error   string `json:"error"`
+}
+
+// EventMessage is an event stream frame carrying a single event
+type EventMessage struct {
+	Type    string `json:"type"`
+	Version int    `json:"version"`
+	Event   Event  `json:"event"`
+}
+
+// StateMessage is an event stream frame carrying a session's full state
+type StateMessage struct {
+	Type    string `json:"type"`
+	Version int    `json:"version"`
+	State   State  `json:"state"`
+}
+
+func newGameUpdateMessage(state State, events []Event) GameUpdateMessage {
+	if events == nil {
+		events = []Event{}
+	}
+	return GameUpdateMessage{Type: msgGameUpdate, Version: messageVersion, State: state, Events: events}
+}
+
+func newTurnTimeoutMessage(actor ID) TurnTimeoutMessage {
+	return TurnTimeoutMessage{Type: msgTurnTimeout, Version: messageVersion, Actor: actor}
+}
+
+func newNarrationMessage(narration string, round int) NarrationMessage {
+	return NarrationMessage{Type: msgNarration, Version: messageVersion, Narration: narration, Round: round}
+}
+
+func newEpilogueMessage(epilogue, winner string) EpilogueMessage {
+	return EpilogueMessage{Type: msgEpilogue, Version: messageVersion, Epilogue: epilogue, Winner: winner}
+}
+
+func newErrorMessage(err error) ErrorMessage {
+	return ErrorMessage{Type: msgError, Version: messageVersion, Error: err.Error()}
+}
+
+func newEventMessage(event Event) EventMessage {
+	return EventMessage{Type: msgEvent, Version: messageVersion, Event: event}
+}
+
+func newStateMessage(state State) StateMessage {
+	return StateMessage{Type: msgState, Version: messageVersion, State: state}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// messageKeys marshals a message and returns its top-level JSON keys, sorted
+func messageKeys(t *testing.T, msg interface{}) ([]string, map[string]json.RawMessage) {
+	t.Helper()
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Failed to marshal %T: %v", msg, err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Failed to unmarshal %T: %v", msg, err)
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, fields
+}
+
+func TestMessageShapes(t *testing.T) {
+	state := CreateInitialState([]Character{createTestCharacter(true, "Hero")}, []Character{createTestCharacter(false, "Goblin")}, 12345)
+
+	for _, tc := range []struct {
+		msg      interface{}
+		typeName string
+		keys     []string
+	}{
+		{newGameUpdateMessage(state, nil), "game_update", []string{"events", "state", "type", "version"}},
+		{newTurnTimeoutMessage("hero-1"), "turn_timeout", []string{"actor", "type", "version"}},
+		{newNarrationMessage("The goblin falls.", 3), "narration", []string{"narration", "round", "type", "version"}},
+		{newEpilogueMessage("The goblins scatter.", "player"), "combat_epilogue", []string{"epilogue", "type", "version", "winner"}},
+		{newErrorMessage(errors.New("not your turn")), "error", []string{"error", "type", "version"}},
+		{newEventMessage(Event{Type: "damage", Amount: 4}), "event", []string{"event", "type", "version"}},
+		{newStateMessage(state), "state", []string{"state", "type", "version"}},
+	} {
+		keys, fields := messageKeys(t, tc.msg)
+		if !reflect.DeepEqual(keys, tc.keys) {
+			t.Errorf("Expected %T to have keys %v, got %v", tc.msg, tc.keys, keys)
+		}
+		if string(fields["type"]) != `"`+tc.typeName+`"` || string(fields["version"]) != "1" {
+			t.Errorf("Expected %T to be a version 1 %q message, got type %s version %s", tc.msg, tc.typeName, fields["type"], fields["version"])
+		}
+	}
+
+	// Clients can always range over a game update's events
+	_, fields := messageKeys(t, newGameUpdateMessage(state, nil))
+	if string(fields["events"]) != "[]" {
+		t.Errorf("Expected an empty events list rather than null, got %s", fields["events"])
+	}
+}
+
+func TestVersionedAPIRoutes(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	app := fiber.New()
+	setupRoutes(app)
+	stateManager.SetState("v1-session", CreateInitialState([]Character{createTestCharacter(true, "Hero")}, []Character{createTestCharacter(false, "Goblin")}, 12345))
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/sessions/v1-session", nil))
+	if err != nil || resp.StatusCode != 200 {
+		t.Fatalf("Expected the versioned route to serve the session, got %v %v", resp, err)
+	}
+	if resp.Header.Get("Deprecation") != "" {
+		t.Error("Expected the versioned route not to be marked deprecated")
+	}
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/sessions/v1-session", nil))
+	if err != nil || resp.StatusCode != 200 {
+		t.Fatalf("Expected the unversioned alias to keep working, got %v %v", resp, err)
+	}
+	if resp.Header.Get("Deprecation") != "true" || resp.Header.Get("Link") != `</api/v1/sessions/v1-session>; rel="successor-version"` {
+		t.Errorf("Expected the alias to point at its successor, got %v", resp.Header)
+	}
+}
+
+func TestAPIEndpointsListRegisteredRoutes(t *testing.T) {
+	app := fiber.New()
+	setupRoutes(app)
+
+	endpoints := apiEndpoints(app)
+	listed := make(map[string]bool, len(endpoints))
+	for _, endpoint := range endpoints {
+		listed[endpoint] = true
+	}
+	for _, want := range []string{
+		"GET  /health",
+		"GET  /api/v1/sessions/:sessionId/stats",
+		"GET  /api/v1/sessions/:sessionId/stream",
+		"GET  /api/v1/sessions/:sessionId/prompt",
+		"POST /api/v1/scenarios/validate",
+		"POST /api/v1/sessions/:sessionId/gm/heal",
+		"PUT  /api/v1/sessions/:sessionId/state",
+	} {
+		if !listed[want] {
+			t.Errorf("Expected %q in the startup endpoints, got %v", want, endpoints)
+		}
+	}
+	for _, endpoint := range endpoints {
+		if strings.Contains(endpoint, "HEAD") || strings.Contains(endpoint, " /sessions") || strings.Contains(endpoint, "/ws/") {
+			t.Errorf("Expected only the health check and /api/v1 routes, got %q", endpoint)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// NarrationPolicy decides which applied actions trigger automatic narration
+type NarrationPolicy string
+
+// Narration policies
+const (
+	narrateOff         NarrationPolicy = "off"         // Only narrate when a client asks
+	narrateAction      NarrationPolicy = "action"      // After every action
+	narrateRound       NarrationPolicy = "round"       // When a round ends or combat ends
+	narrateSignificant NarrationPolicy = "significant" // On defeats, flight, round and combat end
+	narrateEveryN      NarrationPolicy = "every_n"     // Every N actions, and when combat ends
+)
+
+const defaultNarrationEveryN = 3
+
+// maxEpilogueMoments caps how many of a combat's events its epilogue is given
+const maxEpilogueMoments = 12
+
+// narrationPolicyByName parses a NARRATION_POLICY value
+func narrationPolicyByName(name string) (NarrationPolicy, error) {
+	switch policy := NarrationPolicy(strings.ToLower(strings.TrimSpace(name))); policy {
+	case "":
+		return narrateOff, nil
+	case narrateOff, narrateAction, narrateRound, narrateSignificant, narrateEveryN:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown narration policy %q (use off, action, round, significant or every_n)", name)
+}
+
+// pendingNarration is the events a session has produced since it was last narrated
+type pendingNarration struct {
+	round   int
+	actions int
+	events  []string
+}
+
+// NarrationTrigger watches the event bus and narrates sessions automatically,
+// batching the events between narrations into a single LLM call. When combat
+// ends it also writes the session an epilogue.
+type NarrationTrigger struct {
+	mu        sync.Mutex
+	policy    NarrationPolicy
+	everyN    int
+	pending   map[string]*pendingNarration
+	concluded map[string]bool // Sessions whose epilogue has been written
+	narrate   func(sessionID string, state State, events []string)
+	epilogue  func(sessionID string, state State)
+}
+
+// NewNarrationTrigger creates a trigger for the given policy. everyN is only
+// used by the every_n policy; values below 1 use the default.
+func NewNarrationTrigger(policy NarrationPolicy, everyN int) *NarrationTrigger {
+	if everyN < 1 {
+		everyN = defaultNarrationEveryN
+	}
+	nt := &NarrationTrigger{
+		policy:    policy,
+		everyN:    everyN,
+		pending:   make(map[string]*pendingNarration),
+		concluded: make(map[string]bool),
+	}
+	nt.narrate = func(sessionID string, state State, events []string) {
+		go narrateSession(sessionID, state, events)
+	}
+	nt.epilogue = func(sessionID string, state State) {
+		go narrateEpilogue(sessionID, state)
+	}
+	return nt
+}
+
+// Observe is an EventObserver that buffers a session's events and narrates
+// them once the policy says so
+func (nt *NarrationTrigger) Observe(sessionID string, state State, events []Event) {
+	if nt.policy == narrateOff {
+		return
+	}
+
+	nt.mu.Lock()
+	p, exists := nt.pending[sessionID]
+	if !exists {
+		p = &pendingNarration{round: state.Round}
+		nt.pending[sessionID] = p
+	}
+	for _, event := range events {
+		p.events = append(p.events, FormatEvent(state, event))
+	}
+	p.actions++
+
+	// Only the update that ends combat gets an epilogue; undoing past the end
+	// lets the rematch have its own
+	conclude := state.IsComplete && !nt.concluded[sessionID]
+	if state.IsComplete {
+		nt.concluded[sessionID] = true
+	} else {
+		delete(nt.concluded, sessionID)
+	}
+
+	var batch []string
+	due := nt.due(p, state)
+	if due {
+		batch = p.events
+		if state.IsComplete {
+			delete(nt.pending, sessionID)
+		} else {
+			nt.pending[sessionID] = &pendingNarration{round: state.Round}
+		}
+	}
+	nt.mu.Unlock()
+
+	// Actions without events, such as defending, count towards the cadence
+	// but leave nothing to narrate
+	if len(batch) > 0 {
+		nt.narrate(sessionID, state, batch)
+	}
+	if conclude {
+		nt.epilogue(sessionID, state)
+	}
+}
+
+// Forget drops what the trigger holds for a session, such as when the sweeper
+// evicts it. A restored session starts a fresh batch.
+func (nt *NarrationTrigger) Forget(sessionID string) {
+	nt.mu.Lock()
+	defer nt.mu.Unlock()
+	delete(nt.pending, sessionID)
+	delete(nt.concluded, sessionID)
+}
+
+// due reports whether the buffered events should be narrated now
+func (nt *NarrationTrigger) due(p *pendingNarration, state State) bool {
+	roundEnded := state.Round != p.round
+	switch nt.policy {
+	case narrateAction:
+		return true
+	case narrateRound:
+		return roundEnded || state.IsComplete
+	case narrateSignificant:
+		if roundEnded || state.IsComplete {
+			return true
+		}
+		for _, event := range p.events {
+			if isSignificantEvent(event) {
+				return true
+			}
+		}
+	case narrateEveryN:
+		return p.actions >= nt.everyN || state.IsComplete
+	}
+	return false
+}
+
+// narrateSession generates narration for a batch of events, adds it to the
+// session's story and sends it to the session's WebSocket clients
+func narrateSession(sessionID string, state State, events []string) {
+	if ok, _ := llmRateLimits.Global.Allow(""); !ok {
+		sessionLogger(sessionID).Warn("Skipped automatic narration, LLM rate limit reached")
+		return
+	}
+
+	data := NewPromptData(state, events, "")
+	data.Story = sessionStory(sessionID)
+	data.SessionID = sessionID
+	narration, err := llmClient.GenerateNarrationWithModel(data, "", false)
+	if err != nil {
+		sessionLogger(sessionID).Error("Automatic narration failed", "round", state.Round, "error", err)
+		return
+	}
+
+	stateManager.AppendStory(sessionID, narration)
+	broadcastMessage(sessionID, newNarrationMessage(narration, state.Round))
+}
+
+// narrateEpilogue writes the epilogue for a finished combat from its outcome
+// and the notable moments in its event log, adds it to the session's story
+// and sends it to the session's WebSocket clients
+func narrateEpilogue(sessionID string, state State) {
+	if ok, _ := llmRateLimits.Global.Allow(""); !ok {
+		sessionLogger(sessionID).Warn("Skipped combat epilogue, LLM rate limit reached")
+		return
+	}
+
+	events, err := eventStore.GetEvents(sessionID, 0)
+	if err != nil {
+		sessionLogger(sessionID).Warn("Failed to load events for combat epilogue", "error", err)
+	}
+
+	winner := "draw"
+	if state.Winner != nil {
+		winner = *state.Winner
+	}
+	data := NewPromptData(state, notableMoments(state, events), epilogueOutcome(winner))
+	data.Story = sessionStory(sessionID)
+	data.SessionID = sessionID
+	epilogue, err := llmClient.GenerateNarrationWithModel(data, epiloguePromptStyle, false)
+	if err != nil {
+		sessionLogger(sessionID).Error("Combat epilogue failed", "error", err)
+		return
+	}
+
+	stateManager.AppendStory(sessionID, epilogue)
+	broadcastMessage(sessionID, newEpilogueMessage(epilogue, winner))
+}
+
+// notableMoments picks the turning points out of a combat's events, falling
+// back to its last events when nothing stood out
+func notableMoments(state State, events []Event) []string {
+	var all, significant []string
+	for _, event := range events {
+		line := FormatEvent(state, event)
+		all = append(all, line)
+		if isSignificantEvent(line) {
+			significant = append(significant, line)
+		}
+	}
+	if len(significant) == 0 {
+		significant = all
+	}
+	return significant[max(len(significant)-maxEpilogueMoments, 0):]
+}
+
+// epilogueOutcome describes who won a combat for the epilogue prompt
+func epilogueOutcome(winner string) string {
+	if winner == "draw" {
+		return "The battle ended in a draw, with no side left standing."
+	}
+	return fmt.Sprintf("The %s side won.", winner)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNarrationTriggerRoundPolicy(t *testing.T) {
+	trigger := NewNarrationTrigger(narrateRound, 0)
+	var calls [][]string
+	trigger.narrate = func(sessionID string, state State, events []string) {
+		calls = append(calls, events)
+	}
+	// The hero's attacks can finish the goblin; keep the epilogue off the LLM
+	trigger.epilogue = func(string, State) {}
+
+	hero := createTestCharacter(true, "Hero")
+	enemy := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{hero}, []Character{enemy}, 42)
+	state.TurnOrder = []ID{hero.ID, enemy.ID}
+
+	// Two rounds of the hero attacking and the goblin defending: four actions,
+	// two round boundaries
+	for i := 0; i < 4; i++ {
+		action := Action{Kind: "Attack", Attacker: hero.ID, Target: enemy.ID, Weapon: hero.Weapons[0].ID}
+		if i%2 == 1 {
+			action = Action{Kind: "Defend", Actor: enemy.ID}
+		}
+		resolution := ApplyAction(state, action, 42)
+		if !actionResolved(state, resolution.State) {
+			t.Fatalf("Defend %d was rejected: %v", i, resolution.Logs)
+		}
+		state = resolution.State
+		trigger.Observe("session-1", state, resolution.Events)
+	}
+
+	if len(calls) != 2 {
+		t.Fatalf("Expected one narration per round, got %d: %v", len(calls), calls)
+	}
+	for i, events := range calls {
+		if len(events) == 0 {
+			t.Errorf("Expected narration %d to batch the round's events, got %v", i, events)
+		}
+	}
+}
+
+func TestNarrationTriggerPolicies(t *testing.T) {
+	state := State{Round: 1}
+	hit := []Event{{Type: "damage", Target: "a", Amount: 3}}
+	death := []Event{{Type: "death", Target: "a"}}
+
+	count := func(policy NarrationPolicy, everyN int, batches ...[]Event) int {
+		trigger := NewNarrationTrigger(policy, everyN)
+		calls := 0
+		trigger.narrate = func(string, State, []string) { calls++ }
+		for _, events := range batches {
+			trigger.Observe("session-1", state, events)
+		}
+		return calls
+	}
+
+	if got := count(narrateOff, 0, hit, hit, death); got != 0 {
+		t.Errorf("off: expected no narration, got %d", got)
+	}
+	if got := count(narrateAction, 0, hit, hit, death); got != 3 {
+		t.Errorf("action: expected 3 narrations, got %d", got)
+	}
+	if got := count(narrateSignificant, 0, hit, hit, death); got != 1 {
+		t.Errorf("significant: expected 1 narration for the defeat, got %d", got)
+	}
+	if got := count(narrateEveryN, 2, hit, hit, hit, hit, hit); got != 2 {
+		t.Errorf("every_n: expected 2 narrations, got %d", got)
+	}
+
+	if _, err := narrationPolicyByName("sometimes"); err == nil {
+		t.Error("Expected an unknown policy to be rejected")
+	}
+}
+
+func TestNarrationTriggerWritesOneEpilogue(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	previous := llmClient
+	stub, lastRequest := newStubLLMClient(t, "And so the goblin fell.")
+	llmClient = stub
+	defer func() { llmClient = previous }()
+
+	hero := createTestCharacter(true, "Hero")
+	goblin := createTestCharacter(false, "Goblin")
+	goblin.Stats.HP = 1
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 42)
+	state.TurnOrder = []ID{hero.ID, goblin.ID}
+	resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: hero.ID, Target: goblin.ID, Weapon: hero.Weapons[0].ID}, 42)
+	if !resolution.State.IsComplete {
+		t.Fatalf("Expected the attack to end combat: %v", resolution.Logs)
+	}
+
+	for _, policy := range []NarrationPolicy{narrateOff, narrateRound} {
+		sessionID := "session-" + string(policy)
+		stateManager.SetState(sessionID, resolution.State)
+		trigger := NewNarrationTrigger(policy, 0)
+		trigger.narrate = func(string, State, []string) {}
+		trigger.epilogue = narrateEpilogue
+
+		// The finishing update and later ones on the finished session, such as
+		// a GM edit, all arrive complete
+		trigger.Observe(sessionID, resolution.State, resolution.Events)
+		trigger.Observe(sessionID, resolution.State, nil)
+		trigger.Observe(sessionID, resolution.State, nil)
+
+		want := 1
+		if policy == narrateOff {
+			want = 0
+		}
+		if story := stateManager.GetStory(sessionID); len(story) != want {
+			t.Errorf("%s: expected %d epilogue, got %v", policy, want, story)
+		}
+	}
+
+	messages, _ := (*lastRequest)["messages"].([]interface{})
+	if len(messages) == 0 || !strings.Contains(fmt.Sprint(messages[0]), "epilogue") {
+		t.Errorf("Expected the epilogue prompt to be used, got %v", messages)
+	}
+}
+
+func TestNarrationTriggerForgetsEvictedSessions(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	stateManager.now = func() time.Time { return now }
+	defer func(previous *NarrationTrigger) { narrationTrigger = previous }(narrationTrigger)
+	narrationTrigger = NewNarrationTrigger(narrateRound, 0)
+	narrationTrigger.narrate = func(string, State, []string) {}
+	narrationTrigger.epilogue = func(string, State) {}
+
+	hero := createTestCharacter(true, "Hero")
+	goblin := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 42)
+	finished := state
+	finished.IsComplete = true
+
+	// One session is mid-round with events waiting, the other has had its epilogue
+	stateManager.SetState("waiting", state)
+	stateManager.SetState("finished", finished)
+	narrationTrigger.Observe("waiting", state, []Event{{Type: "miss", Source: hero.ID, Target: goblin.ID}})
+	narrationTrigger.Observe("finished", finished, nil)
+	if len(narrationTrigger.pending) != 1 || len(narrationTrigger.concluded) != 1 {
+		t.Fatalf("Expected the trigger to hold both sessions, got %v and %v", narrationTrigger.pending, narrationTrigger.concluded)
+	}
+
+	now = now.Add(time.Hour)
+	if evicted := NewSessionSweeper(stateManager, time.Minute, false).Sweep(); len(evicted) != 2 {
+		t.Fatalf("Expected both sessions to be evicted, got %v", evicted)
+	}
+	if len(narrationTrigger.pending) != 0 || len(narrationTrigger.concluded) != 0 {
+		t.Errorf("Expected eviction to clear the trigger, got %v and %v", narrationTrigger.pending, narrationTrigger.concluded)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+)
+
+// OriginAllowList decides which browser origins may call the API cross-origin
+type OriginAllowList struct {
+	allowAll bool
+	origins  map[string]bool
+}
+
+// NewOriginAllowList parses a comma-separated list of origins. "*" allows any
+// origin; an empty list allows none beyond same-origin requests.
+func NewOriginAllowList(spec string) *OriginAllowList {
+	allowList := &OriginAllowList{origins: make(map[string]bool)}
+	for _, origin := range strings.Split(spec, ",") {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		switch origin {
+		case "":
+			continue
+		case "*":
+			allowList.allowAll = true
+		default:
+			allowList.origins[strings.ToLower(origin)] = true
+		}
+	}
+	return allowList
+}
+
+// Allowed reports whether the origin is on the allow-list
+func (a *OriginAllowList) Allowed(origin string) bool {
+	return a.allowAll || a.origins[strings.ToLower(strings.TrimRight(origin, "/"))]
+}
+
+// corsMiddleware only grants CORS headers to allow-listed origins
+func corsMiddleware(allowList *OriginAllowList) fiber.Handler {
+	return cors.New(cors.Config{
+		AllowOriginsFunc: allowList.Allowed,
+	})
+}
+
+// requireAllowedOrigin rejects WebSocket upgrades from origins that are neither
+// allow-listed nor the server's own origin
+func requireAllowedOrigin(allowList *OriginAllowList) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		origin := c.Get(fiber.HeaderOrigin)
+		if origin != "" && !allowList.Allowed(origin) && !isSameOrigin(c, origin) {
+			return c.Status(403).JSON(fiber.Map{"error": "Origin not allowed"})
+		}
+		return c.Next()
+	}
+}
+
+func isSameOrigin(c *fiber.Ctx, origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, string(c.Request().Host()))
+}
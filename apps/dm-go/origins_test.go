@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestOriginAllowList(t *testing.T) {
+	allowList := NewOriginAllowList("https://dungeon.example.com, http://localhost:5173/")
+
+	if !allowList.Allowed("https://dungeon.example.com") {
+		t.Error("Expected listed origin to be allowed")
+	}
+	if !allowList.Allowed("http://localhost:5173") {
+		t.Error("Expected listed origin with trailing slash to be allowed")
+	}
+	if allowList.Allowed("https://evil.example.com") {
+		t.Error("Expected unlisted origin to be rejected")
+	}
+	if !NewOriginAllowList("*").Allowed("https://anywhere.example.com") {
+		t.Error("Expected wildcard to allow any origin")
+	}
+}
+
+func TestDisallowedOriginRejected(t *testing.T) {
+	allowList := NewOriginAllowList("https://dungeon.example.com")
+
+	app := fiber.New()
+	app.Use(corsMiddleware(allowList))
+	app.Get("/ws/:sessionId", requireAllowedOrigin(allowList), func(c *fiber.Ctx) error {
+		return c.SendString("upgraded")
+	})
+
+	t.Run("WebSocketFromDisallowedOrigin", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/ws/session-1", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != 403 {
+			t.Errorf("Expected 403 for disallowed origin, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("WebSocketFromAllowedOrigin", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/ws/session-1", nil)
+		req.Header.Set("Origin", "https://dungeon.example.com")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != 200 {
+			t.Errorf("Expected allowed origin to pass, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("CORSHeadersOnlyForAllowedOrigin", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/ws/session-1", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		resp, _ := app.Test(req)
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("Expected no CORS header for disallowed origin, got %q", got)
+		}
+
+		req = httptest.NewRequest("GET", "/ws/session-1", nil)
+		req.Header.Set("Origin", "https://dungeon.example.com")
+		resp, _ = app.Test(req)
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "https://dungeon.example.com" {
+			t.Errorf("Expected CORS header for allowed origin, got %q", got)
+		}
+	})
+}
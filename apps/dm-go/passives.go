@@ -0,0 +1,81 @@
+package main
+
+import "fmt"
+
+// Passive ability triggers
+const (
+	triggerHit    = "hit"    // The owner takes damage from an attack or ability
+	triggerMissed = "missed" // An attack on the owner misses
+)
+
+// Passive ability effects
+const (
+	passiveReflect = "reflect" // Deal Power damage back to whoever set it off, e.g. thorns
+	passiveCounter = "counter" // Make a free attack on whoever set it off
+)
+
+// passiveTriggerKnown reports whether a passive ability's trigger ever fires
+func passiveTriggerKnown(trigger string) bool {
+	return trigger == triggerHit || trigger == triggerMissed
+}
+
+// passiveEffectKnown reports whether triggerPassives does anything with an effect
+func passiveEffectKnown(effect string) bool {
+	return effect == passiveReflect || effect == passiveCounter
+}
+
+// triggerPassives fires the owner's passive abilities for a trigger set off by
+// other. Passives only fire while both are still in the fight, and what they
+// do never sets off further passives.
+func triggerPassives(state *State, owner, other *Character, trigger string, rng *SeededRNG) ([]Event, []string) {
+	var events []Event
+	var logs []string
+
+	for _, ability := range owner.Abilities {
+		if !ability.Passive || ability.Trigger != trigger || !isActive(*owner) || !isActive(*other) {
+			continue
+		}
+
+		var damage int
+		switch ability.Effect {
+		case passiveReflect:
+			damage = ability.Power
+			logs = append(logs, fmt.Sprintf("%s's %s reflects %d damage back at %s!", owner.Name, ability.Name, damage, other.Name))
+		case passiveCounter:
+			weapon := &Weapon{Name: "Fist", Damage: 1}
+			if len(owner.Weapons) > 0 {
+				weapon = &owner.Weapons[0]
+			}
+			var hit bool
+			if damage, hit = damageResolver(owner, other, weapon, rng); !hit {
+				logs = append(logs, fmt.Sprintf("%s's %s misses %s!", owner.Name, ability.Name, other.Name))
+				continue
+			}
+			if damage < minimumDamage(state.Rules) {
+				damage = minimumDamage(state.Rules)
+			}
+			logs = append(logs, fmt.Sprintf("%s's %s strikes %s for %d damage!", owner.Name, ability.Name, other.Name, damage))
+		default:
+			continue
+		}
+		if damage = other.ApplyDamage(damage); damage == 0 {
+			continue
+		}
+
+		events = append(events, Event{
+			Type:           "damage",
+			Target:         other.ID,
+			Amount:         damage,
+			Source:         owner.ID,
+			Ability:        ability.ID,
+			SourcePosition: positionOf(owner),
+			TargetPosition: positionOf(other),
+			Effect:         abilityEffect(ability),
+		})
+		if other.Stats.HP == 0 {
+			koEvents, koLogs := knockOut(state, other)
+			events, logs = append(events, koEvents...), append(logs, koLogs...)
+		}
+	}
+	return events, logs
+}
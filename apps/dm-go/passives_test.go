@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestThornsReflectDamage(t *testing.T) {
+	hero := createTestCharacter(true, "Hero")
+	hedgehog := createTestCharacter(false, "Hedgehog")
+	hedgehog.Stats.HP, hedgehog.Stats.MaxHP = 100, 100
+	hedgehog.Abilities = append(hedgehog.Abilities, Ability{ID: NewID(), Name: "Thorns", Effect: passiveReflect, Power: 2, Passive: true, Trigger: triggerHit})
+	state := CreateInitialState([]Character{hero}, []Character{hedgehog}, 12345)
+	state.TurnOrder = []ID{hero.ID, hedgehog.ID}
+
+	SetDamageResolver(func(attacker, target *Character, weapon *Weapon, rng *SeededRNG) (int, bool) {
+		return 10, true
+	})
+	defer SetDamageResolver(nil)
+
+	resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: hero.ID, Target: hedgehog.ID, Weapon: hero.Weapons[0].ID}, 12345)
+	if got := GetCharacterByID(resolution.State, hero.ID).Stats.HP; got != 28 {
+		t.Errorf("Expected thorns to reflect 2 damage to the attacker, leaving 28 HP, got %d", got)
+	}
+	if got := GetCharacterByID(resolution.State, hedgehog.ID).Stats.HP; got != 90 {
+		t.Errorf("Expected the attack itself to land, got %d HP", got)
+	}
+
+	var reflected *Event
+	for i, event := range resolution.Events {
+		if event.Type == "damage" && event.Target == hero.ID {
+			reflected = &resolution.Events[i]
+		}
+	}
+	if reflected == nil || reflected.Source != hedgehog.ID || reflected.Amount != 2 {
+		t.Errorf("Expected a damage event from the hedgehog for 2, got %+v", resolution.Events)
+	}
+	if !strings.Contains(strings.Join(resolution.Logs, "\n"), "Hedgehog's Thorns reflects 2 damage back at Hero!") {
+		t.Errorf("Expected the reflection to be logged, got %v", resolution.Logs)
+	}
+
+	// Damaging abilities set thorns off too
+	state.Characters[0].Stats.HP = 2
+	resolution = ApplyAction(state, Action{Kind: "Ability", Actor: hero.ID, Ability: hero.Abilities[0].ID, Target: hedgehog.ID}, 12345)
+	if got := GetCharacterByID(resolution.State, hero.ID); got.Stats.HP != 0 || !resolution.State.IsComplete {
+		t.Errorf("Expected thorns to finish off a weakened attacker and end the fight, got %d HP", got.Stats.HP)
+	}
+
+	// A blow that fells the hedgehog leaves no thorns to prick back
+	state.Characters[0].Stats.HP = 2
+	state.Characters[1].Stats.HP = 5
+	resolution = ApplyAction(state, Action{Kind: "Attack", Attacker: hero.ID, Target: hedgehog.ID, Weapon: hero.Weapons[0].ID}, 12345)
+	if got := GetCharacterByID(resolution.State, hero.ID).Stats.HP; got != 2 {
+		t.Errorf("Expected a felled owner's thorns not to fire, got %d HP for the hero", got)
+	}
+	if winner := resolution.State.Winner; winner == nil || *winner != "player" {
+		t.Errorf("Expected the hero to win, got %v", winner)
+	}
+
+	// Passives can't be used as actions
+	state.TurnOrder = []ID{hedgehog.ID, hero.ID}
+	resolution = ApplyAction(state, Action{Kind: "Ability", Actor: hedgehog.ID, Ability: hedgehog.Abilities[1].ID}, 12345)
+	if resolution.RejectReason != RejectPassiveAbility {
+		t.Errorf("Expected using a passive to be rejected, got %q: %v", resolution.RejectReason, resolution.Logs)
+	}
+}
+
+func TestCounterOnMiss(t *testing.T) {
+	hero := createTestCharacter(true, "Hero")
+	duelist := createTestCharacter(false, "Duelist")
+	duelist.Abilities = []Ability{{ID: NewID(), Name: "Riposte", Effect: passiveCounter, Passive: true, Trigger: triggerMissed}}
+	state := CreateInitialState([]Character{hero}, []Character{duelist}, 12345)
+	state.TurnOrder = []ID{hero.ID, duelist.ID}
+
+	SetDamageResolver(func(attacker, target *Character, weapon *Weapon, rng *SeededRNG) (int, bool) {
+		if attacker.Name == "Hero" {
+			return 0, false
+		}
+		return 7, true
+	})
+	defer SetDamageResolver(nil)
+
+	resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: hero.ID, Target: duelist.ID, Weapon: hero.Weapons[0].ID}, 12345)
+	if got := GetCharacterByID(resolution.State, hero.ID).Stats.HP; got != 23 {
+		t.Errorf("Expected the riposte to deal 7 damage, got %d HP left", got)
+	}
+	if resolution.State.CurrentTurn != 1 {
+		t.Errorf("Expected the counter not to use up the duelist's turn, got turn %d", resolution.State.CurrentTurn)
+	}
+}
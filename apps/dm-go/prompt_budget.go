@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultMaxPromptChars bounds narration prompts, roughly 3000 tokens at 4
+// characters per token
+const defaultMaxPromptChars = 12000
+
+// promptEventOverhead approximates what a template adds around each event
+const promptEventOverhead = 8
+
+// significantEventMarkers pick out events that are kept ahead of routine ones
+// when a prompt has to be trimmed
+var significantEventMarkers = []string{"defeated", "critical", "flees", "concedes", "breaks"}
+
+// isSignificantEvent reports whether an event is a turning point worth keeping
+func isSignificantEvent(event string) bool {
+	event = strings.ToLower(event)
+	for _, marker := range significantEventMarkers {
+		if strings.Contains(event, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderWithin renders a style like Render, trimming the oldest events and
+// story first when the prompts would exceed budget characters. Recent and
+// significant events are kept over routine ones. A budget of 0 or less renders
+// everything.
+func (pl *PromptLibrary) RenderWithin(name string, data PromptData, budget int) (string, string, error) {
+	system, user, err := pl.Render(name, data)
+	if err != nil || budget <= 0 || len(system)+len(user) <= budget {
+		return system, user, err
+	}
+
+	// Measure the prompt without any events or story
+	bare := withPromptHistory(data, nil, "")
+	system, user, err = pl.Render(name, bare)
+	if err != nil {
+		return "", "", err
+	}
+	available := budget - len(system) - len(user)
+
+	// Templates format events their own way, so the estimate can miss; shrink
+	// and retry a few times before falling back to the bare prompt
+	for attempt := 0; attempt < 4 && available > 0; attempt++ {
+		events, used := selectPromptEvents(data.Events, available)
+		story := trimStory(data.Story, available-used)
+
+		trimmed := withPromptHistory(data, events, story)
+		system, user, err = pl.Render(name, trimmed)
+		if err != nil {
+			return "", "", err
+		}
+		size := len(system) + len(user)
+		if size <= budget {
+			return system, user, nil
+		}
+		available -= size - budget
+	}
+
+	return pl.Render(name, bare)
+}
+
+// withPromptHistory returns data with its events and story replaced
+func withPromptHistory(data PromptData, events []string, story string) PromptData {
+	data.Events = events
+	data.EventsText = formatEvents(events)
+	data.Story = story
+	return data
+}
+
+// selectPromptEvents keeps as many events as fit in budget characters,
+// significant events first and newest first within each group, returned in
+// their original order. It reports the characters the kept events use.
+func selectPromptEvents(events []string, budget int) ([]string, int) {
+	keep := make([]bool, len(events))
+	used := 0
+	for _, significant := range []bool{true, false} {
+		for i := len(events) - 1; i >= 0; i-- {
+			cost := len(events[i]) + promptEventOverhead
+			if keep[i] || isSignificantEvent(events[i]) != significant || used+cost > budget {
+				continue
+			}
+			keep[i] = true
+			used += cost
+		}
+	}
+
+	var kept []string
+	omitted := 0
+	for i, event := range events {
+		if keep[i] {
+			kept = append(kept, event)
+		} else {
+			omitted++
+		}
+	}
+	if omitted > 0 {
+		note := fmt.Sprintf("(%d earlier events omitted)", omitted)
+		kept = append([]string{note}, kept...)
+		used += len(note) + promptEventOverhead
+	}
+	return kept, used
+}
+
+// trimStory keeps the most recent lines of the story that fit in budget characters
+func trimStory(story string, budget int) string {
+	if len(story) <= budget {
+		return story
+	}
+	if budget <= 0 {
+		return ""
+	}
+
+	lines := strings.Split(story, "\n")
+	start, used := len(lines), -1 // No newline before the first kept line
+	for start > 0 && used+len(lines[start-1])+1 <= budget {
+		start--
+		used += len(lines[start]) + 1
+	}
+	return strings.Join(lines[start:], "\n")
+}
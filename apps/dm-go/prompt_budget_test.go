@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRenderWithinTrimsLongHistory(t *testing.T) {
+	library := NewPromptLibrary()
+	state := CreateInitialState([]Character{createTestCharacter(true, "Hero")}, []Character{createTestCharacter(false, "Goblin")}, 12345)
+
+	events := make([]string, 5000)
+	for i := range events {
+		events[i] = fmt.Sprintf("Hero attacks Goblin for %d damage (event %d)", i%7+1, i)
+	}
+	events[10] = "Goblin Scout has been defeated!"
+	data := NewPromptData(state, events, "In the caves")
+	data.Story = strings.Repeat("Long ago the party set out.\n", 500) + "Most recently, the Hero found a map."
+
+	const budget = 4000
+	system, user, err := library.RenderWithin(defaultPromptStyle, data, budget)
+	if err != nil {
+		t.Fatalf("Failed to render prompts: %v", err)
+	}
+	if size := len(system) + len(user); size > budget {
+		t.Fatalf("Expected prompts within %d characters, got %d", budget, size)
+	}
+
+	for _, expected := range []string{"(event 4999)", "Goblin Scout has been defeated!", "earlier events omitted", "In the caves"} {
+		if !strings.Contains(user, expected) {
+			t.Errorf("Expected trimmed prompt to keep %q", expected)
+		}
+	}
+	if strings.Contains(user, "(event 0)") {
+		t.Error("Expected the oldest routine events to be dropped")
+	}
+
+	t.Run("UnderBudget", func(t *testing.T) {
+		small := NewPromptData(state, events[:3], "")
+		_, trimmed, _ := library.RenderWithin(defaultPromptStyle, small, budget)
+		_, full, _ := library.Render(defaultPromptStyle, small)
+		if trimmed != full {
+			t.Errorf("Expected a prompt under budget to be left alone\ngot:  %q\nwant: %q", trimmed, full)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		_, user, _ := library.RenderWithin(defaultPromptStyle, data, 0)
+		if !strings.Contains(user, "(event 0)") {
+			t.Error("Expected a zero budget to keep every event")
+		}
+	})
+}
+
+func TestTrimStoryKeepsRecentLines(t *testing.T) {
+	story := "first\nsecond\nthird"
+	if got := trimStory(story, 12); got != "second\nthird" {
+		t.Errorf("Expected the last two lines, got %q", got)
+	}
+	if got := trimStory(story, 0); got != "" {
+		t.Errorf("Expected an empty story, got %q", got)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PromptCapture is the exact prompt sent for a session's last narration and
+// what the model sent back, kept so operators can see why narration went wrong
+type PromptCapture struct {
+	Style    string    `json:"style"`
+	Model    string    `json:"model"`
+	System   string    `json:"system"`
+	User     string    `json:"user"`
+	Response string    `json:"response"`
+	Error    string    `json:"error,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// PromptLog keeps the last captured prompt for each session
+type PromptLog struct {
+	mu   sync.RWMutex
+	last map[string]PromptCapture
+}
+
+// NewPromptLog creates an empty prompt log
+func NewPromptLog() *PromptLog {
+	return &PromptLog{last: make(map[string]PromptCapture)}
+}
+
+// Record replaces the session's last capture
+func (pl *PromptLog) Record(sessionID string, capture PromptCapture) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	pl.last[sessionID] = capture
+}
+
+// Last returns the session's last capture, if any
+func (pl *PromptLog) Last(sessionID string) (PromptCapture, bool) {
+	pl.mu.RLock()
+	defer pl.mu.RUnlock()
+	capture, ok := pl.last[sessionID]
+	return capture, ok
+}
+
+// capturePrompt records a narration exchange when prompt debugging is on.
+// API keys are redacted in case one ends up in a prompt or an error message.
+func (llm *LLMClient) capturePrompt(sessionID string, capture PromptCapture) {
+	if !llm.config.DebugPrompts {
+		return
+	}
+
+	secrets := []string{llm.config.APIKey}
+	capture.System = redactSecrets(capture.System, secrets)
+	capture.User = redactSecrets(capture.User, secrets)
+	capture.Response = redactSecrets(capture.Response, secrets)
+	capture.Error = redactSecrets(capture.Error, secrets)
+
+	sessionLogger(sessionID).Debug("LLM narration prompt",
+		"style", capture.Style,
+		"model", capture.Model,
+		"system", capture.System,
+		"user", capture.User,
+		"response", capture.Response,
+		"error", capture.Error)
+
+	if sessionID != "" {
+		llm.promptLog.Record(sessionID, capture)
+	}
+}
+
+// redactSecrets replaces every occurrence of the secrets in text
+func redactSecrets(text string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, "[REDACTED]")
+		}
+	}
+	return text
+}
+
+func handleGetLastPrompt(c *fiber.Ctx) error {
+	if !llmClient.config.DebugPrompts {
+		return c.Status(404).JSON(fiber.Map{"error": "Prompt capture is disabled; set LLM_DEBUG_PROMPTS=true"})
+	}
+
+	sessionID := c.Params("sessionId")
+	capture, ok := llmClient.promptLog.Last(sessionID)
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"error": "No narration prompt captured for this session"})
+	}
+
+	return c.JSON(fiber.Map{
+		"sessionId": sessionID,
+		"prompt":    capture,
+	})
+}
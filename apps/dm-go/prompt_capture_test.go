@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestPromptCapture(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	previous := llmClient
+	defer func() { llmClient = previous }()
+
+	client, _ := newStubLLMClient(t, "The goblin staggers back.")
+	client.config.APIKey = "sk-very-secret"
+	llmClient = client
+	app := fiber.New()
+	setupRoutes(app)
+
+	hero := createTestCharacter(true, "Hero")
+	goblin := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+
+	narrate := func() {
+		body, _ := json.Marshal(fiber.Map{
+			"state":   state,
+			"events":  []string{"Hero attacks Goblin for 12 damage!"},
+			"context": "A torn note reads sk-very-secret",
+		})
+		req := httptest.NewRequest("POST", "/llm/generate_narration", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("session-id", "prompt-session")
+		if resp, err := app.Test(req); err != nil || resp.StatusCode != 200 {
+			t.Fatalf("Narration failed: %v %v", resp, err)
+		}
+	}
+	lastPrompt := func() (int, string) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/sessions/prompt-session/prompt", nil))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		var body strings.Builder
+		buf := make([]byte, 4096)
+		for {
+			n, err := resp.Body.Read(buf)
+			body.Write(buf[:n])
+			if err != nil {
+				break
+			}
+		}
+		return resp.StatusCode, body.String()
+	}
+
+	// Off by default
+	narrate()
+	if status, _ := lastPrompt(); status != 404 {
+		t.Fatalf("Expected 404 with prompt capture disabled, got %d", status)
+	}
+
+	client.config.DebugPrompts = true
+	narrate()
+	status, body := lastPrompt()
+	if status != 200 {
+		t.Fatalf("Expected the captured prompt, got %d: %s", status, body)
+	}
+
+	var resp struct {
+		Prompt PromptCapture `json:"prompt"`
+	}
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatalf("Failed to decode capture: %v", err)
+	}
+	capture := resp.Prompt
+	if !strings.Contains(capture.User, formatEvents([]string{"Hero attacks Goblin for 12 damage!"})) {
+		t.Errorf("Expected the prompt to contain the formatted events, got %q", capture.User)
+	}
+	if capture.System == "" || capture.Style != defaultPromptStyle || capture.Model != "stub" {
+		t.Errorf("Expected the system prompt, style and model, got %+v", capture)
+	}
+	if capture.Response != "The goblin staggers back." {
+		t.Errorf("Expected the raw response, got %q", capture.Response)
+	}
+	if strings.Contains(body, "sk-very-secret") || !strings.Contains(capture.User, "[REDACTED]") {
+		t.Errorf("Expected the API key to be redacted, got %q", capture.User)
+	}
+}
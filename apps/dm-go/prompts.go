@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// defaultPromptStyle is the narration style used when a request doesn't pick one
+const defaultPromptStyle = "epic"
+
+// Shared user prompt for the built-in styles
+const defaultUserPrompt = `{{if .Story}}Story so far:
+{{.Story}}
+
+{{end}}{{if .Context}}Current situation:
+{{.Context}}
+
+{{end}}Recent events:
+{{.EventsText}}
+
+Current state:
+Round {{.State.Round}}
+Players: {{.Players}}
+Enemies: {{.Enemies}}
+
+Create a vivid, dramatic narration of what just happened in this combat encounter:`
+
+// defaultPromptStyles are the built-in narration presets: style -> system prompt
+var defaultPromptStyles = map[string]string{
+	"epic": `You are a master dungeon master narrating an epic fantasy combat encounter.
+Create vivid, immersive descriptions that bring the battle to life. Focus on:
+- The intensity and drama of combat actions
+- Environmental details and atmosphere
+- Character emotions and physical sensations
+- Strategic positioning and tactical elements
+- The consequences and stakes of each action
+
+Keep descriptions engaging but concise, maintaining the flow of combat while building tension and excitement.`,
+	"gritty": `You are a dungeon master narrating a grim, low-fantasy skirmish.
+Describe the combat as brutal, desperate and exhausting: mud, blood, fear and ragged breathing.
+Keep it grounded and terse. Never make decisions for the players.`,
+	"comedic": `You are a dungeon master narrating a combat encounter for laughs.
+Lean into slapstick, clumsy villains and absurd coincidences while keeping the events accurate.
+Keep it short and punchy. Never make decisions for the players.`,
+	"pg": `You are a dungeon master narrating a family-friendly adventure.
+Keep the action exciting but avoid gore and graphic injuries; foes are defeated, not killed.
+Keep it short and upbeat. Never make decisions for the players.`,
+}
+
+// epiloguePromptStyle is the style used to close out a finished combat. Like
+// any style it can be replaced from PROMPTS_DIR.
+const epiloguePromptStyle = "epilogue"
+
+const epilogueSystemPrompt = `You are a dungeon master closing out a combat encounter that has just ended.
+Write a short epilogue: who prevailed, the turning points that decided it, and the aftermath as the dust settles.
+Stay true to the events you are given. Never make decisions for the players.`
+
+const epilogueUserPrompt = `{{if .Story}}Story so far:
+{{.Story}}
+
+{{end}}Outcome:
+{{.Context}}
+
+Notable moments:
+{{.EventsText}}
+
+Survivors after {{.State.Round}} rounds:
+Players: {{.Players}}
+Enemies: {{.Enemies}}
+
+Write the epilogue to this battle:`
+
+// PromptData is what narration prompt templates are rendered with
+type PromptData struct {
+	State      State
+	Events     []string
+	EventsText string
+	Context    string
+	Players    string
+	Enemies    string
+	Story      string // Earlier narration in the session, if any
+	SessionID  string // Session the narration is for, used to capture prompts for debugging
+}
+
+// NewPromptData builds template data from the narration inputs
+func NewPromptData(state State, events []string, context string) PromptData {
+	return PromptData{
+		State:      state,
+		Events:     events,
+		EventsText: formatEvents(events),
+		Context:    context,
+		Players:    formatCharacters(state.Characters, true),
+		Enemies:    formatCharacters(state.Characters, false),
+	}
+}
+
+type promptStyle struct {
+	system *template.Template
+	user   *template.Template
+}
+
+// PromptLibrary holds the named narration prompt styles
+type PromptLibrary struct {
+	mu     sync.RWMutex
+	styles map[string]promptStyle
+}
+
+// NewPromptLibrary creates a library with the built-in styles
+func NewPromptLibrary() *PromptLibrary {
+	library := &PromptLibrary{styles: make(map[string]promptStyle)}
+	for name, system := range defaultPromptStyles {
+		if err := library.Add(name, system, defaultUserPrompt); err != nil {
+			panic(fmt.Sprintf("invalid built-in prompt style %s: %v", name, err))
+		}
+	}
+	if err := library.Add(epiloguePromptStyle, epilogueSystemPrompt, epilogueUserPrompt); err != nil {
+		panic(fmt.Sprintf("invalid built-in prompt style %s: %v", epiloguePromptStyle, err))
+	}
+	return library
+}
+
+// Add registers or replaces a style from system and user template text
+func (pl *PromptLibrary) Add(name, systemText, userText string) error {
+	system, err := template.New(name + ".system").Parse(systemText)
+	if err != nil {
+		return fmt.Errorf("failed to parse system prompt for %s: %w", name, err)
+	}
+	user, err := template.New(name + ".user").Parse(userText)
+	if err != nil {
+		return fmt.Errorf("failed to parse user prompt for %s: %w", name, err)
+	}
+
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	pl.styles[name] = promptStyle{system: system, user: user}
+	return nil
+}
+
+// LoadDir loads styles from <style>.system.tmpl files in dir. A matching
+// <style>.user.tmpl is optional; the default user prompt is used without one.
+func (pl *PromptLibrary) LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.system.tmpl"))
+	if err != nil {
+		return fmt.Errorf("failed to list prompt templates: %w", err)
+	}
+
+	for _, systemFile := range files {
+		name := strings.TrimSuffix(filepath.Base(systemFile), ".system.tmpl")
+
+		systemText, err := os.ReadFile(systemFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt template: %w", err)
+		}
+
+		userText := []byte(defaultUserPrompt)
+		userFile := filepath.Join(dir, name+".user.tmpl")
+		if data, err := os.ReadFile(userFile); err == nil {
+			userText = data
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read prompt template: %w", err)
+		}
+
+		if err := pl.Add(name, string(systemText), string(userText)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Has reports whether the style exists
+func (pl *PromptLibrary) Has(name string) bool {
+	pl.mu.RLock()
+	defer pl.mu.RUnlock()
+	_, ok := pl.styles[name]
+	return ok
+}
+
+// Render renders the system and user prompts for a style
+func (pl *PromptLibrary) Render(name string, data PromptData) (string, string, error) {
+	pl.mu.RLock()
+	style, ok := pl.styles[name]
+	pl.mu.RUnlock()
+	if !ok {
+		return "", "", fmt.Errorf("unknown prompt style: %s", name)
+	}
+
+	var system, user bytes.Buffer
+	if err := style.system.Execute(&system, data); err != nil {
+		return "", "", fmt.Errorf("failed to render system prompt: %w", err)
+	}
+	if err := style.user.Execute(&user, data); err != nil {
+		return "", "", fmt.Errorf("failed to render user prompt: %w", err)
+	}
+	return system.String(), user.String(), nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPromptLibraryCustomStyle(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"pirate.system.tmpl": "Ye be a pirate narrator. Round {{.State.Round}}.",
+		"pirate.user.tmpl":   "Arr! {{.Context}} Events: {{range .Events}}[{{.}}]{{end}} Crew: {{.Players}}",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	library := NewPromptLibrary()
+	if err := library.LoadDir(dir); err != nil {
+		t.Fatalf("Failed to load prompt templates: %v", err)
+	}
+	if !library.Has("pirate") || !library.Has(defaultPromptStyle) {
+		t.Fatal("Expected custom style alongside the built-in styles")
+	}
+
+	state := CreateInitialState([]Character{createTestCharacter(true, "Hero")}, []Character{createTestCharacter(false, "Goblin")}, 12345)
+	state.Round = 3
+
+	system, user, err := library.Render("pirate", NewPromptData(state, []string{"Hero attacks Goblin"}, "On the deck"))
+	if err != nil {
+		t.Fatalf("Failed to render prompts: %v", err)
+	}
+	if system != "Ye be a pirate narrator. Round 3." {
+		t.Errorf("Unexpected system prompt: %q", system)
+	}
+	for _, expected := range []string{"On the deck", "[Hero attacks Goblin]", "Hero (30/30 HP)"} {
+		if !strings.Contains(user, expected) {
+			t.Errorf("Expected user prompt to contain %q, got %q", expected, user)
+		}
+	}
+
+	if _, _, err := library.Render("no-such-style", PromptData{}); err == nil {
+		t.Error("Expected error for unknown style")
+	}
+}
+
+func TestDefaultUserPromptOmitsEmptyContext(t *testing.T) {
+	library := NewPromptLibrary()
+	state := CreateInitialState([]Character{createTestCharacter(true, "Hero")}, []Character{createTestCharacter(false, "Goblin")}, 12345)
+
+	_, user, err := library.Render(defaultPromptStyle, NewPromptData(state, nil, ""))
+	if err != nil {
+		t.Fatalf("Failed to render prompts: %v", err)
+	}
+	if strings.Contains(user, "Current situation") {
+		t.Errorf("Expected no situation section without context, got %q", user)
+	}
+	if !strings.HasPrefix(user, "Recent events:\nNo recent events") {
+		t.Errorf("Unexpected user prompt: %q", user)
+	}
+}
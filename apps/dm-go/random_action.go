@@ -0,0 +1,83 @@
+package main
+
+import "sort"
+
+// RandomValidAction picks a random action the current character could legally
+// take, for driving the engine with seeded random play in tests and
+// simulations. It returns false when combat is over or nobody can act.
+// Fleeing and conceding end a fight quickly, so they are rare picks.
+func RandomValidAction(state State, rng *SeededRNG) (Action, bool) {
+	if state.IsComplete {
+		return Action{}, false
+	}
+	char := GetCurrentCharacter(state)
+	if char == nil || !isActive(*char) {
+		return Action{}, false
+	}
+
+	switch roll := rng.RollD100(); {
+	case roll <= 3:
+		return Action{Kind: "Flee", Actor: char.ID}, true
+	case roll == 4:
+		return Action{Kind: "Concede", Actor: char.ID}, true
+	}
+
+	candidates := []Action{{Kind: "Defend", Actor: char.ID}}
+
+	// Attacks are the bulk of a real fight, so weight them up
+	for i := range state.Characters {
+		target := &state.Characters[i]
+		if target.ID == char.ID || !isActive(*target) || !canTarget(state, *char, *target) {
+			continue
+		}
+		if len(char.Weapons) == 0 {
+			candidates = append(candidates, Action{Kind: "Attack", Attacker: char.ID, Target: target.ID})
+			continue
+		}
+		for _, weapon := range char.Weapons {
+			if !isRangedWeapon(weapon) || HasLineOfSight(state, char.Position, target.Position) {
+				attack := Action{Kind: "Attack", Attacker: char.ID, Target: target.ID, Weapon: weapon.ID}
+				candidates = append(candidates, attack, attack)
+			}
+		}
+	}
+
+	for _, ability := range char.Abilities {
+		if triggersOnItsOwn(ability) || RemainingCooldown(char, ability.ID) > 0 {
+			continue
+		}
+		action := Action{Kind: "Ability", Actor: char.ID, Ability: ability.ID}
+		if ability.Effect == "damage" || isSupportAbility(ability) {
+			targets := AbilityTargets(state, *char, ability)
+			if len(targets) == 0 {
+				continue
+			}
+			action.Target = targets[rng.RandomInt(0, len(targets)-1)]
+		}
+		candidates = append(candidates, action)
+	}
+
+	for _, item := range char.Items {
+		candidates = append(candidates, Action{Kind: "UseItem", Actor: char.ID, Item: item.ID})
+	}
+
+	if hasRoomForItem(char) {
+		// Walk the ground in a fixed order so a seed always picks the same item
+		squares := make([]string, 0, len(state.GroundItems))
+		for square := range state.GroundItems {
+			squares = append(squares, square)
+		}
+		sort.Strings(squares)
+		for _, square := range squares {
+			for _, item := range state.GroundItems[square] {
+				candidates = append(candidates, Action{Kind: "PickUp", Actor: char.ID, Item: item.ID})
+			}
+		}
+	}
+
+	if last := len(state.TurnOrder) - 1; state.CurrentTurn < last {
+		candidates = append(candidates, Action{Kind: "Delay", Actor: char.ID, Slot: rng.RandomInt(state.CurrentTurn+1, last)})
+	}
+
+	return candidates[rng.RandomInt(0, len(candidates)-1)], true
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Default LLM request limits, per session and across all sessions
+const (
+	defaultLLMSessionPerMinute = 10
+	defaultLLMSessionBurst     = 5
+	defaultLLMGlobalPerMinute  = 60
+	defaultLLMGlobalBurst      = 20
+)
+
+// maxIdleBuckets is how many buckets a limiter keeps before dropping full ones
+const maxIdleBuckets = 10000
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter is a token bucket per key: each key may make burst requests at
+// once, refilling at perMinute requests per minute
+type RateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // Tokens per second
+	burst   float64
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+}
+
+// NewRateLimiter creates a limiter. A perMinute of 0 or less disables it.
+func NewRateLimiter(perMinute, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token for the key, or reports how long until one is available
+func (rl *RateLimiter) Allow(key string) (bool, time.Duration) {
+	if rl == nil || rl.rate <= 0 {
+		return true, 0
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := rl.now()
+
+	bucket, ok := rl.buckets[key]
+	if !ok {
+		if len(rl.buckets) >= maxIdleBuckets {
+			rl.dropFullBuckets(now)
+		}
+		bucket = &tokenBucket{tokens: rl.burst, last: now}
+		rl.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(rl.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*rl.rate)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / rl.rate * float64(time.Second))
+		return false, wait
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// dropFullBuckets forgets keys that have refilled completely, since a new
+// bucket would be identical
+func (rl *RateLimiter) dropFullBuckets(now time.Time) {
+	for key, bucket := range rl.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*rl.rate >= rl.burst {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+// LLMRateLimits throttles the LLM endpoints per session and globally
+type LLMRateLimits struct {
+	Session *RateLimiter
+	Global  *RateLimiter
+}
+
+// NewLLMRateLimits creates per-session and global limiters
+func NewLLMRateLimits(sessionPerMinute, sessionBurst, globalPerMinute, globalBurst int) *LLMRateLimits {
+	return &LLMRateLimits{
+		Session: NewRateLimiter(sessionPerMinute, sessionBurst),
+		Global:  NewRateLimiter(globalPerMinute, globalBurst),
+	}
+}
+
+// rateLimitLLM rejects LLM requests over the limits with 429 and Retry-After.
+// Requests without a session-id header are limited by client IP.
+func rateLimitLLM(limits *LLMRateLimits) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get("session-id")
+		if key == "" {
+			key = "ip:" + c.IP()
+		}
+
+		if ok, wait := limits.Session.Allow(key); !ok {
+			return tooManyRequests(c, wait, "Too many narration requests for this session")
+		}
+		if ok, wait := limits.Global.Allow(""); !ok {
+			return tooManyRequests(c, wait, "Narration is busy, try again shortly")
+		}
+		return c.Next()
+	}
+}
+
+func tooManyRequests(c *fiber.Ctx, wait time.Duration, message string) error {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Set(fiber.HeaderRetryAfter, fmt.Sprint(seconds))
+	return c.Status(429).JSON(fiber.Map{"error": message, "retryAfter": seconds})
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRateLimiterRefills(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := NewRateLimiter(60, 2) // One token a second
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.Allow("a"); !ok {
+			t.Fatalf("Expected request %d within the burst to be allowed", i+1)
+		}
+	}
+	ok, wait := limiter.Allow("a")
+	if ok || wait != time.Second {
+		t.Errorf("Expected the bucket to be empty with a 1s wait, got %v, %v", ok, wait)
+	}
+	if ok, _ := limiter.Allow("b"); !ok {
+		t.Error("Expected other keys to have their own bucket")
+	}
+
+	now = now.Add(time.Second)
+	if ok, _ := limiter.Allow("a"); !ok {
+		t.Error("Expected a token after refilling for a second")
+	}
+}
+
+func TestLLMRateLimitReturns429(t *testing.T) {
+	previous := llmRateLimits
+	llmRateLimits = NewLLMRateLimits(1, 2, 100, 100)
+	defer func() { llmRateLimits = previous }()
+
+	app := fiber.New()
+	setupRoutes(app)
+
+	narrate := func(sessionID string) (int, string) {
+		req := httptest.NewRequest("POST", "/llm/generate_narration", nil)
+		req.Header.Set("session-id", sessionID)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp.StatusCode, resp.Header.Get(fiber.HeaderRetryAfter)
+	}
+
+	// The handler rejects the empty body, but the limiter runs first
+	for i := 0; i < 2; i++ {
+		if status, _ := narrate("limited"); status == 429 {
+			t.Fatalf("Expected request %d within the burst to pass the limiter", i+1)
+		}
+	}
+
+	status, retryAfter := narrate("limited")
+	if status != 429 {
+		t.Fatalf("Expected 429 once the bucket is exhausted, got %d", status)
+	}
+	if retryAfter != "60" {
+		t.Errorf("Expected Retry-After of 60 seconds, got %q", retryAfter)
+	}
+
+	if status, _ := narrate("other"); status == 429 {
+		t.Error("Expected other sessions to be unaffected")
+	}
+
+	t.Run("Global", func(t *testing.T) {
+		llmRateLimits = NewLLMRateLimits(100, 100, 1, 1)
+		app := fiber.New()
+		setupRoutes(app)
+
+		req := httptest.NewRequest("POST", "/llm/generate_combat_description", nil)
+		app.Test(req)
+		resp, _ := app.Test(httptest.NewRequest("POST", "/llm/generate_combat_description", nil))
+		if resp.StatusCode != 429 {
+			t.Errorf("Expected the global limit to apply across sessions, got %d", resp.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package main
+
+import "fmt"
+
+// Reaction ability effects
+const (
+	reactionParry  = "parry"  // Turn aside Power points of an incoming hit
+	reactionShield = "shield" // Block an incoming hit entirely
+)
+
+// defaultReactionsPerRound is how many reactions a character may use each
+// round when the house rules don't say
+const defaultReactionsPerRound = 1
+
+// reactionEffectKnown reports whether react does anything with an effect
+func reactionEffectKnown(effect string) bool {
+	return effect == reactionParry || effect == reactionShield
+}
+
+// reactionsPerRound is each character's reaction budget under the session's rules
+func reactionsPerRound(rules HouseRules) int {
+	if rules.ReactionsPerRound <= 0 {
+		return defaultReactionsPerRound
+	}
+	return rules.ReactionsPerRound
+}
+
+// triggersOnItsOwn reports whether an ability fires by itself rather than
+// being used as an action
+func triggersOnItsOwn(ability Ability) bool {
+	return ability.Passive || ability.Reaction
+}
+
+// react lets the target of a hit use their first ready reaction before the
+// damage lands, if they still have one left this round. It returns the damage
+// that gets through.
+func react(state *State, target, attacker *Character, damage int) (int, []Event, []string) {
+	if !isActive(*target) || target.ReactionsUsed >= reactionsPerRound(state.Rules) {
+		return damage, nil, nil
+	}
+
+	for _, ability := range target.Abilities {
+		if !ability.Reaction || !reactionEffectKnown(ability.Effect) || RemainingCooldown(target, ability.ID) > 0 {
+			continue
+		}
+
+		prevented := damage
+		var log string
+		switch ability.Effect {
+		case reactionParry:
+			prevented = min(max(ability.Power, 0), damage)
+			log = fmt.Sprintf("%s parries with %s, turning aside %d damage!", target.Name, ability.Name, prevented)
+		case reactionShield:
+			log = fmt.Sprintf("%s blocks %s's attack with %s!", target.Name, attacker.Name, ability.Name)
+		}
+
+		target.ReactionsUsed++
+		target.AbilityCooldowns[string(ability.ID)] = ability.Cooldown
+		event := Event{
+			Type:     "reaction",
+			Actor:    target.ID,
+			Source:   attacker.ID,
+			Ability:  ability.ID,
+			Amount:   prevented,
+			Cooldown: ability.Cooldown,
+			Effect:   abilityEffect(ability),
+		}
+		return damage - prevented, []Event{event}, []string{log}
+	}
+	return damage, nil, nil
+}
+
+// resetReactions restores everyone's reaction budget at the start of a round
+func resetReactions(state *State) {
+	for i := range state.Characters {
+		state.Characters[i].ReactionsUsed = 0
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import "testing"
+
+// reactionState has two orcs attacking a knight who can parry, in that order
+func reactionState(reactions ...Ability) (State, Character, Character, Character) {
+	knight := createTestCharacter(true, "Knight")
+	knight.Stats.HP, knight.Stats.MaxHP = 100, 100
+	knight.Abilities = append(knight.Abilities, reactions...)
+	first := createTestCharacter(false, "Orc")
+	second := createTestCharacter(false, "Orc Brute")
+	state := CreateInitialState([]Character{knight}, []Character{first, second}, 12345)
+	state.TurnOrder = []ID{first.ID, second.ID, knight.ID}
+	return state, knight, first, second
+}
+
+func TestParryReducesIncomingDamage(t *testing.T) {
+	parry := Ability{ID: NewID(), Name: "Parry", Effect: reactionParry, Power: 4, Cooldown: 2, Reaction: true}
+	state, knight, orc, _ := reactionState(parry)
+
+	SetDamageResolver(func(attacker, target *Character, weapon *Weapon, rng *SeededRNG) (int, bool) {
+		return 10, true
+	})
+	defer SetDamageResolver(nil)
+
+	resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: orc.ID, Target: knight.ID, Weapon: orc.Weapons[0].ID}, 1)
+	parried := GetCharacterByID(resolution.State, knight.ID)
+	if parried.Stats.HP != 94 {
+		t.Errorf("Expected the parry to turn aside 4 of 10 damage, leaving 94 HP, got %d", parried.Stats.HP)
+	}
+	// The cooldown starts ticking as the orc's turn ends
+	if parried.ReactionsUsed != 1 || RemainingCooldown(parried, parry.ID) != 1 {
+		t.Errorf("Expected the parry to use the knight's reaction and go on cooldown, got %d used and cooldown %d", parried.ReactionsUsed, RemainingCooldown(parried, parry.ID))
+	}
+	if event := resolution.Events[0]; event.Type != "reaction" || event.Actor != knight.ID || event.Ability != parry.ID || event.Amount != 4 {
+		t.Errorf("Expected a reaction event before the damage, got %+v", resolution.Events)
+	}
+	if event := resolution.Events[1]; event.Type != "damage" || event.Amount != 6 {
+		t.Errorf("Expected the damage event to carry the parried damage, got %+v", event)
+	}
+
+	// Reactions aren't actions
+	state = resolution.State
+	state.CurrentTurn = 2
+	if resolution := ApplyAction(state, Action{Kind: "Ability", Actor: knight.ID, Ability: parry.ID, Target: knight.ID}, 1); resolution.RejectReason != RejectReactionAbility {
+		t.Errorf("Expected using a reaction as an action to be rejected, got %q", resolution.RejectReason)
+	}
+}
+
+func TestReactionBudgetExhausted(t *testing.T) {
+	parry := Ability{ID: NewID(), Name: "Parry", Effect: reactionParry, Power: 4, Reaction: true}
+	shield := Ability{ID: NewID(), Name: "Shield", Effect: reactionShield, Reaction: true}
+	state, knight, orc, brute := reactionState(parry, shield)
+
+	SetDamageResolver(func(attacker, target *Character, weapon *Weapon, rng *SeededRNG) (int, bool) {
+		return 10, true
+	})
+	defer SetDamageResolver(nil)
+
+	attack := func(attacker Character) Resolution {
+		t.Helper()
+		resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: attacker.ID, Target: knight.ID, Weapon: attacker.Weapons[0].ID}, 1)
+		if resolution.RejectReason != "" {
+			t.Fatalf("Attack rejected: %v", resolution.Logs)
+		}
+		state = resolution.State
+		return resolution
+	}
+
+	attack(orc)
+	if hp := GetCharacterByID(state, knight.ID).Stats.HP; hp != 94 {
+		t.Fatalf("Expected the first hit to be parried, got %d HP", hp)
+	}
+
+	// The shield is ready, but the knight has no reaction left this round
+	resolution := attack(brute)
+	if hp := GetCharacterByID(state, knight.ID).Stats.HP; hp != 84 || hasEvent(resolution.Events, "reaction", "") {
+		t.Errorf("Expected the second hit to land in full, got %d HP and events %+v", hp, resolution.Events)
+	}
+
+	// A new round restores the budget
+	state = ApplyAction(state, Action{Kind: "Defend", Actor: knight.ID}, 1).State
+	if state.Round != 2 || GetCharacterByID(state, knight.ID).ReactionsUsed != 0 {
+		t.Fatalf("Expected the knight's reactions to reset in round 2, got round %d with %d used", state.Round, GetCharacterByID(state, knight.ID).ReactionsUsed)
+	}
+	attack(orc)
+	if hp := GetCharacterByID(state, knight.ID).Stats.HP; hp != 78 {
+		t.Errorf("Expected the round 2 hit to be parried again, got %d HP", hp)
+	}
+}
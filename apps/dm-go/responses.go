@@ -0,0 +1,26 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// prettyQuery is the query parameter asking for indented JSON, e.g. ?pretty=true
+const prettyQuery = "pretty"
+
+// sendJSON writes body as JSON with the status already set on c. Requests
+// with ?pretty=true get it indented for reading while debugging; everyone
+// else gets fiber's compact encoding, which is smaller and faster.
+func sendJSON(c *fiber.Ctx, body interface{}) error {
+	if !c.QueryBool(prettyQuery) {
+		return c.JSON(body)
+	}
+
+	data, err := json.MarshalIndent(body, "", "  ")
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	return c.Send(data)
+}
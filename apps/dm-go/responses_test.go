@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestPrettyJSONResponses(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	sessionAuth = NewSessionAuth()
+	app := fiber.New()
+	setupRoutes(app)
+
+	hero := createTestCharacter(true, "Hero")
+	goblin := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 42)
+	attack := Action{Kind: "Attack", Attacker: state.TurnOrder[0], Target: goblin.ID}
+	if attack.Attacker == goblin.ID {
+		attack.Target = hero.ID
+	}
+	body, _ := json.Marshal(fiber.Map{"state": state, "action": attack, "seed": 7})
+
+	status, compact := postJSON(t, app, "/tools/apply_action", body)
+	if status != 200 || strings.Contains(compact, "\n") {
+		t.Fatalf("Expected a compact resolution by default, got %d: %s", status, compact)
+	}
+	status, pretty := postJSON(t, app, "/tools/apply_action?pretty=true", body)
+	if status != 200 || !strings.Contains(pretty, "\n  \"") {
+		t.Fatalf("Expected an indented resolution, got %d: %s", status, pretty)
+	}
+	var a, b Resolution
+	if err := json.Unmarshal([]byte(compact), &a); err != nil {
+		t.Fatalf("Failed to decode compact resolution: %v", err)
+	}
+	if err := json.Unmarshal([]byte(pretty), &b); err != nil {
+		t.Fatalf("Failed to decode pretty resolution: %v", err)
+	}
+	if a.State.Round != b.State.Round || len(a.Events) != len(b.Events) {
+		t.Errorf("Expected the same resolution either way, got %+v and %+v", a.Events, b.Events)
+	}
+
+	stateManager.SetState("pretty-session", state)
+	resp, err := app.Test(httptest.NewRequest("GET", "/sessions/pretty-session?pretty=true", nil))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 || !strings.Contains(string(data), "\n  \"state\": {") {
+		t.Errorf("Expected an indented session state, got %d: %s", resp.StatusCode, data)
+	}
+	if contentType := resp.Header.Get(fiber.HeaderContentType); !strings.HasPrefix(contentType, fiber.MIMEApplicationJSON) {
+		t.Errorf("Expected a JSON content type, got %q", contentType)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Rewards used when a session doesn't configure its own
+const (
+	defaultXPPerEnemy     = 10
+	defaultVictoryHPBonus = 5
+)
+
+// rewardRules returns the session's reward rules, or the defaults
+func rewardRules(state State) RewardRules {
+	if state.Rewards != nil {
+		return *state.Rewards
+	}
+	return RewardRules{XPPerEnemy: defaultXPPerEnemy, VictoryHPBonus: defaultVictoryHPBonus}
+}
+
+// awardCombatRewards records the winning team's rewards on a completed combat:
+// XP for each defeated opponent, a bonus for each surviving winner scaled by
+// their remaining HP, and the loot left on the board. A draw earns nothing.
+func awardCombatRewards(state *State) ([]Event, []string) {
+	if !state.IsComplete || state.Result != nil || state.Winner == nil {
+		return nil, nil
+	}
+
+	rules := rewardRules(*state)
+	result := &CombatResult{Winner: *state.Winner, Defeated: []ID{}, Loot: []Item{}}
+	state.Result = result
+	if result.Winner == "draw" {
+		return []Event{{Type: "combat_result"}}, []string{"No one earns anything from a draw."}
+	}
+
+	for _, char := range state.Characters {
+		switch {
+		case CharacterTeam(char) == result.Winner:
+			if isActive(char) && char.Stats.MaxHP > 0 {
+				result.HPBonus += rules.VictoryHPBonus * char.Stats.HP / char.Stats.MaxHP
+			}
+		case char.Stats.HP == 0:
+			result.Defeated = append(result.Defeated, char.ID)
+			if char.XP > 0 {
+				result.XP += char.XP
+			} else {
+				result.XP += rules.XPPerEnemy
+			}
+			// The final blow lands before loot is dropped
+			result.Loot = append(result.Loot, char.Items...)
+		}
+	}
+
+	keys := make([]string, 0, len(state.GroundItems))
+	for key := range state.GroundItems {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		result.Loot = append(result.Loot, state.GroundItems[key]...)
+	}
+
+	result.TotalXP = result.XP + result.HPBonus
+	event := Event{Type: "combat_result", Amount: result.TotalXP}
+	log := fmt.Sprintf("Rewards: %d XP (%d from defeated foes, %d survival bonus) and %d item(s) of loot.", result.TotalXP, result.XP, result.HPBonus, len(result.Loot))
+	return []Event{event}, []string{log}
+}
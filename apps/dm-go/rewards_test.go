@@ -0,0 +1,72 @@
+package main
+
+import "testing"
+
+func TestCombatRewards(t *testing.T) {
+	player := createTestCharacter(true, "Hero")
+	player.Stats.Attack = 100
+	player.Stats.HP = 15 // Half health
+	goblin := createTestCharacter(false, "Goblin")
+	goblin.Stats.HP = 1
+	goblin.Position = Position{X: 2, Y: 0}
+	chief := createTestCharacter(false, "Goblin Chief")
+	chief.Stats.HP = 1
+	chief.XP = 40
+	chief.Items = append(chief.Items, Item{ID: NewID(), Name: "Crown", Type: "equipment"})
+	state := CreateInitialState([]Character{player}, []Character{goblin, chief}, 12345)
+	state.TurnOrder = []ID{player.ID, goblin.ID, chief.ID}
+	state.Rewards = &RewardRules{XPPerEnemy: 25, VictoryHPBonus: 10}
+
+	attack := func(state State, target ID) Resolution {
+		state.CurrentTurn = 0
+		return ApplyAction(state, Action{Kind: "Attack", Attacker: player.ID, Target: target, Weapon: player.Weapons[0].ID}, 12345)
+	}
+
+	resolution := attack(state, goblin.ID)
+	if resolution.State.IsComplete || resolution.State.Result != nil {
+		t.Fatal("Expected combat to continue after the first kill")
+	}
+	resolution = attack(resolution.State, chief.ID)
+
+	result := resolution.State.Result
+	if !resolution.State.IsComplete || result == nil {
+		t.Fatalf("Expected a combat result, got %+v", resolution.State)
+	}
+	if result.Winner != "player" || len(result.Defeated) != 2 {
+		t.Errorf("Expected the player to defeat both goblins, got %+v", result)
+	}
+	if result.XP != 65 || result.HPBonus != 5 || result.TotalXP != 70 {
+		t.Errorf("Expected 25 + 40 XP and a 5 XP survival bonus, got %+v", result)
+	}
+
+	loot := map[string]bool{}
+	for _, item := range result.Loot {
+		loot[item.Name] = true
+	}
+	if len(result.Loot) != 3 || !loot["Health Potion"] || !loot["Crown"] {
+		t.Errorf("Expected both potions and the crown as loot, got %+v", result.Loot)
+	}
+
+	found := false
+	for _, event := range resolution.Events {
+		if event.Type == "combat_result" && event.Amount == 70 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected combat_result event, got %+v", resolution.Events)
+	}
+}
+
+func TestCombatRewardsDraw(t *testing.T) {
+	state := CreateInitialState([]Character{createTestCharacter(true, "Hero")}, []Character{createTestCharacter(false, "Goblin")}, 12345)
+	for i := range state.Characters {
+		state.Characters[i].Stats.HP = 0
+	}
+	checkCombatEnd(&state)
+	awardCombatRewards(&state)
+
+	if state.Result == nil || state.Result.Winner != "draw" || state.Result.TotalXP != 0 {
+		t.Errorf("Expected an empty draw result, got %+v", state.Result)
+	}
+}
@@ -7,6 +7,10 @@ import (
 // SeededRNG provides seeded random number generation
 type SeededRNG struct {
 	rng *rand.Rand
+
+	// Set for streamed RNGs, see Stream
+	seed    int64
+	streams map[string]*SeededRNG
 }
 
 // NewSeededRNG creates a new seeded RNG
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// Categories of rolls that get their own stream under the rollStreams rule
+const (
+	rollHit     = "hit"     // Attack rolls
+	rollDamage  = "damage"  // Weapon damage dice
+	rollPassive = "passive" // Passives set off by an attack or ability
+	rollAbility = "ability" // Ability damage and healing dice
+	rollItem    = "item"    // Healing dice of potions
+	rollFlee    = "flee"    // Flee checks
+)
+
+// NewStreamedRNG creates an RNG that rolls each category of dice from its own
+// sequence derived from seed. Adding rolls of a new category then leaves the
+// results of every existing category alone, so saved replays keep resolving
+// the same way.
+func NewStreamedRNG(seed int64) *SeededRNG {
+	rng := NewSeededRNG(seed)
+	rng.seed = seed
+	rng.streams = make(map[string]*SeededRNG)
+	return rng
+}
+
+// Stream returns the RNG to roll a category of dice from. A plain seeded RNG
+// rolls everything from its one sequence and returns itself; a streamed RNG
+// derives the category's sequence from its seed the first time and keeps
+// rolling from it after that.
+func (s *SeededRNG) Stream(category string) *SeededRNG {
+	if s.streams == nil {
+		return s
+	}
+	if stream, exists := s.streams[category]; exists {
+		return stream
+	}
+	stream := NewSeededRNG(streamSeed(s.seed, category))
+	s.streams[category] = stream
+	return stream
+}
+
+// turnRNG seeds rolls made outside any action, such as the AI's choices, from
+// the session seed and whose turn it is. The salt names what the rolls are
+// for, so each purpose gets its own sequence rather than repeating another's.
+func turnRNG(state State, salt string) *SeededRNG {
+	return NewSeededRNG(streamSeed(state.Seed+int64(state.Round)*1000+int64(state.CurrentTurn), salt))
+}
+
+// streamSeed derives a category's seed from the base seed
+func streamSeed(seed int64, category string) int64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d:%s", seed, category)
+	return int64(h.Sum64())
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRollStreamsIsolateCategories(t *testing.T) {
+	attacker := createTestCharacter(true, "Hero")
+	target := createTestCharacter(false, "Goblin")
+	weapon := attacker.Weapons[0]
+
+	// An extra category of roll made before the attack, like a future accuracy check
+	withExtraRoll := func(rng *SeededRNG) (int, bool) {
+		rng.Stream("accuracy").RollD100()
+		return DefaultDamageResolver(&attacker, &target, &weapon, rng)
+	}
+
+	shifted := 0
+	for seed := int64(1); seed <= 50; seed++ {
+		damage, hit := DefaultDamageResolver(&attacker, &target, &weapon, NewStreamedRNG(seed))
+		extraDamage, extraHit := withExtraRoll(NewStreamedRNG(seed))
+		if damage != extraDamage || hit != extraHit {
+			t.Fatalf("Seed %d: expected the extra roll to leave the attack alone, got %d/%v then %d/%v", seed, damage, hit, extraDamage, extraHit)
+		}
+
+		damage, hit = DefaultDamageResolver(&attacker, &target, &weapon, NewSeededRNG(seed))
+		if extraDamage, extraHit := withExtraRoll(NewSeededRNG(seed)); damage != extraDamage || hit != extraHit {
+			shifted++
+		}
+	}
+	if shifted == 0 {
+		t.Error("Expected an extra roll to shift attacks sharing one sequence, so the test means something")
+	}
+}
+
+func TestRollStreamsRule(t *testing.T) {
+	hero := createTestCharacter(true, "Hero")
+	goblin := createTestCharacter(false, "Goblin")
+	goblin.Stats.HP, goblin.Stats.MaxHP = 200, 200
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+	state.TurnOrder = []ID{hero.ID, goblin.ID}
+	state.Rules.RollStreams = true
+	attack := Action{Kind: "Attack", Attacker: hero.ID, Target: goblin.ID, Weapon: hero.Weapons[0].ID}
+
+	for seed := int64(1); seed <= 20; seed++ {
+		want := ApplyAction(state, attack, seed)
+
+		SetDamageResolver(func(attacker, target *Character, weapon *Weapon, rng *SeededRNG) (int, bool) {
+			rng.Stream("accuracy").RollD20()
+			return DefaultDamageResolver(attacker, target, weapon, rng)
+		})
+		got := ApplyAction(state, attack, seed)
+		SetDamageResolver(nil)
+
+		if !reflect.DeepEqual(want.Events, got.Events) || !reflect.DeepEqual(want.State, got.State) {
+			t.Fatalf("Seed %d: expected the same attack with an extra category of roll, got %+v and %+v", seed, want.Events, got.Events)
+		}
+	}
+
+	// Sessions without the rule roll from one sequence, exactly as before
+	state.Rules.RollStreams = false
+	plain := ApplyAction(state, attack, 7)
+	rng := NewSeededRNG(7)
+	damage, hit := DefaultDamageResolver(&hero, &goblin, &hero.Weapons[0], rng)
+	if hit != (plain.Events[0].Type == "damage") || (hit && plain.Events[0].Amount != damage) {
+		t.Errorf("Expected the shared sequence without rollStreams, got %+v for %d/%v", plain.Events[0], damage, hit)
+	}
+}
+
+func TestRollStreamsCoverAbilitiesItemsAndFleeing(t *testing.T) {
+	hero := createTestCharacter(true, "Hero")
+	hero.Items = []Item{{ID: NewID(), Name: "Health Potion", Type: "consumable"}}
+	hero.Stats.HP, hero.Stats.MaxHP = 10, 100
+	goblin := createTestCharacter(false, "Goblin")
+	goblin.Stats.HP, goblin.Stats.MaxHP = 200, 200
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+	state.TurnOrder = []ID{hero.ID, goblin.ID}
+	state.Rules.RollStreams = true
+
+	const seed = 9
+	roll := func(category string) *SeededRNG { return NewSeededRNG(streamSeed(seed, category)) }
+
+	ability := ApplyAction(state, Action{Kind: "Ability", Actor: hero.ID, Ability: hero.Abilities[0].ID, Target: goblin.ID}, seed)
+	if want := hero.Abilities[0].Power + roll(rollAbility).RollD6(); !hasEvent(ability.Events, "damage", goblin.ID) || 200-GetCharacterByID(ability.State, goblin.ID).Stats.HP != want {
+		t.Errorf("Expected the ability to roll %d from its own stream, got %+v", want, ability.Events)
+	}
+
+	potion := ApplyAction(state, Action{Kind: "UseItem", Actor: hero.ID, Item: hero.Items[0].ID}, seed)
+	if want := 10 + 20 + roll(rollItem).RollD6(); GetCharacterByID(potion.State, hero.ID).Stats.HP != want {
+		t.Errorf("Expected the potion to heal from its own stream to %d HP, got %d", want, GetCharacterByID(potion.State, hero.ID).Stats.HP)
+	}
+
+	for fleeSeed := int64(1); fleeSeed <= 20; fleeSeed++ {
+		flee := ApplyAction(state, Action{Kind: "Flee", Actor: hero.ID}, fleeSeed)
+		want := NewSeededRNG(streamSeed(fleeSeed, rollFlee)).RollD20()+hero.Stats.Speed >= 15
+		if fled := GetCharacterByID(flee.State, hero.ID).Fled; fled != want {
+			t.Fatalf("Seed %d: expected the flee check to roll from its own stream (success %v), got %v", fleeSeed, want, fled)
+		}
+	}
+}
+
+func TestTurnRNGSaltsEachPurpose(t *testing.T) {
+	state := CreateInitialState([]Character{createTestCharacter(true, "Hero")}, []Character{createTestCharacter(false, "Goblin")}, 12345)
+
+	rolls := func(rng *SeededRNG) []int {
+		var out []int
+		for i := 0; i < 8; i++ {
+			out = append(out, rng.RollD100())
+		}
+		return out
+	}
+	if !reflect.DeepEqual(rolls(aiRNG(state)), rolls(aiRNG(state))) {
+		t.Error("Expected the same turn to seed the same AI rolls")
+	}
+	if reflect.DeepEqual(rolls(aiRNG(state)), rolls(deathSaveRNG(state))) {
+		t.Error("Expected the AI and death saves to roll from different sequences on the same turn")
+	}
+}
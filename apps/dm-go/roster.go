@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultRosterPath is where the server looks for the character roster
+const defaultRosterPath = "./roster.yaml"
+
+// rosterPath is the roster file POST /sessions/from-roster reads
+var rosterPath = defaultRosterPath
+
+// SetRosterPath points the server at a roster file; an empty path restores the default
+func SetRosterPath(path string) {
+	if path == "" {
+		path = defaultRosterPath
+	}
+	rosterPath = path
+}
+
+// Roster is a collection of pre-built characters that can be brought into any scenario
+type Roster struct {
+	Characters []ScenarioCharacter `yaml:"characters"`
+}
+
+// LoadRoster loads a roster from a YAML or JSON file
+func LoadRoster(filename string) (*Roster, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read roster file: %w", err)
+	}
+	return parseRoster(data)
+}
+
+// parseRoster parses a roster. JSON is valid YAML, so both formats are accepted.
+func parseRoster(data []byte) (*Roster, error) {
+	var roster Roster
+	if err := yaml.Unmarshal(data, &roster); err != nil {
+		return nil, fmt.Errorf("failed to parse roster: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, char := range roster.Characters {
+		key := strings.ToLower(char.Name)
+		if key == "" {
+			return nil, fmt.Errorf("roster character is missing a name")
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("roster character %q is listed more than once", char.Name)
+		}
+		seen[key] = true
+
+		if err := validateScenarioCharacter(char); err != nil {
+			return nil, fmt.Errorf("invalid roster: %w", err)
+		}
+	}
+	if err := validateScenarioIDs(roster.Characters); err != nil {
+		return nil, fmt.Errorf("invalid roster: %w", err)
+	}
+	return &roster, nil
+}
+
+// Character finds a roster entry by name, ignoring case
+func (r *Roster) Character(name string) (ScenarioCharacter, bool) {
+	for _, char := range r.Characters {
+		if strings.EqualFold(char.Name, name) {
+			return char, true
+		}
+	}
+	return ScenarioCharacter{}, false
+}
+
+// ComposeScenario builds a scenario with the chosen roster characters as the
+// party, facing the enemies, map and rules of the given scenario
+func ComposeScenario(roster *Roster, names []string, encounter *Scenario) (*Scenario, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("at least one roster character is required")
+	}
+
+	scenario := *encounter
+	scenario.Players = make([]ScenarioCharacter, 0, len(names))
+	chosen := make(map[string]bool)
+	for _, name := range names {
+		char, ok := roster.Character(name)
+		if !ok {
+			return nil, fmt.Errorf("roster character %q not found", name)
+		}
+		if chosen[strings.ToLower(char.Name)] {
+			return nil, fmt.Errorf("roster character %q chosen more than once", char.Name)
+		}
+		chosen[strings.ToLower(char.Name)] = true
+		scenario.Players = append(scenario.Players, char)
+	}
+
+	// Fixed IDs in the roster must not clash with the encounter's
+	if err := validateScenarioIDs(append(append([]ScenarioCharacter(nil), scenario.Players...), scenario.Enemies...)); err != nil {
+		return nil, err
+	}
+	return &scenario, nil
+}
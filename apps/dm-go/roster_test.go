@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const testRosterJSON = `{"characters": [
+	{"name": "Paladin", "stats": {"hp": 28, "maxHp": 28, "attack": 5, "defense": 5, "speed": 2},
+	 "weapons": [{"name": "Warhammer", "damage": 7, "accuracy": 80}]},
+	{"name": "Rogue", "stats": {"hp": 18, "maxHp": 18, "attack": 6, "defense": 2, "speed": 6},
+	 "weapons": [{"name": "Dagger", "damage": 5, "accuracy": 90}]}
+]}`
+
+func TestLoadRoster(t *testing.T) {
+	roster, err := LoadRoster("roster.yaml")
+	if err != nil {
+		t.Fatalf("Failed to load bundled roster: %v", err)
+	}
+	if ranger, ok := roster.Character("ranger"); !ok || ranger.Weapons[0].Name != "Longbow" {
+		t.Errorf("Expected to find the ranger by name, got %+v", ranger)
+	}
+
+	path := filepath.Join(t.TempDir(), "roster.json")
+	os.WriteFile(path, []byte(testRosterJSON), 0o644)
+	roster, err = LoadRoster(path)
+	if err != nil || len(roster.Characters) != 2 || roster.Characters[1].Stats.Speed != 6 {
+		t.Errorf("Expected JSON roster to load, got %+v, %v", roster, err)
+	}
+
+	if _, err := parseRoster([]byte("characters:\n  - name: Rogue\n  - name: rogue\n")); err == nil {
+		t.Error("Expected duplicate roster names to be rejected")
+	}
+}
+
+func TestCreateSessionFromRoster(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "roster.json")
+	if err := os.WriteFile(path, []byte(testRosterJSON), 0o644); err != nil {
+		t.Fatalf("Failed to write roster: %v", err)
+	}
+	SetRosterPath(path)
+	defer SetRosterPath("")
+
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	app := fiber.New()
+	setupRoutes(app)
+
+	status, resp := postJSON(t, app, "/sessions/from-roster", []byte(`{"characters":["rogue","Paladin"],"enemies":"goblin-ambush","seed":7}`))
+	if status != 200 {
+		t.Fatalf("Expected 200, got %d: %s", status, resp)
+	}
+	var created struct {
+		SessionID string `json:"sessionId"`
+		State     State  `json:"state"`
+	}
+	json.Unmarshal([]byte(resp), &created)
+
+	var players, enemies []string
+	for _, char := range created.State.Characters {
+		if char.IsPlayer {
+			players = append(players, char.Name)
+		} else {
+			enemies = append(enemies, char.Name)
+		}
+	}
+	if len(players) != 2 || players[0] != "Rogue" || players[1] != "Paladin" {
+		t.Errorf("Expected the chosen roster party, got %v", players)
+	}
+	if len(enemies) == 0 {
+		t.Error("Expected the scenario's enemies")
+	}
+	if _, exists := stateManager.GetState(created.SessionID); !exists {
+		t.Error("Expected session state to be stored")
+	}
+
+	for name, body := range map[string]string{
+		"UnknownCharacter": `{"characters":["Wizard"],"enemies":"goblin-ambush"}`,
+		"DuplicateChoice":  `{"characters":["Rogue","rogue"],"enemies":"goblin-ambush"}`,
+		"NoCharacters":     `{"characters":[],"enemies":"goblin-ambush"}`,
+	} {
+		if status, resp := postJSON(t, app, "/sessions/from-roster", []byte(body)); status != 400 {
+			t.Errorf("%s: expected 400, got %d: %s", name, status, resp)
+		}
+	}
+	if status, _ := postJSON(t, app, "/sessions/from-roster", []byte(`{"characters":["Rogue"],"enemies":"no-such-scenario"}`)); status != 404 {
+		t.Errorf("Expected 404 for unknown enemy set, got %d", status)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// maxTeamHPRatio is how many times one team's total HP may exceed the other's
+// before the linter warns that the teams are unbalanced
+const maxTeamHPRatio = 3
+
+// ScenarioLint is the outcome of linting one scenario file. Errors make the
+// scenario unusable; warnings point at content that won't work as written.
+type ScenarioLint struct {
+	File     string
+	Errors   []string
+	Warnings []string
+}
+
+// lintScenario checks a scenario file's YAML: everything parseScenario
+// validates, plus problems that load fine but won't play as intended
+func lintScenario(data []byte) (errs, warnings []string) {
+	var scenario Scenario
+	if err := yaml.Unmarshal(data, &scenario); err != nil {
+		return []string{fmt.Sprintf("failed to parse YAML: %v", err)}, nil
+	}
+	if err := validateScenario(&scenario); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if len(scenario.Players) == 0 {
+		errs = append(errs, "scenario has no players")
+	}
+	if len(scenario.Enemies) == 0 {
+		errs = append(errs, "scenario has no enemies")
+	}
+
+	playerHP, enemyHP := teamHP(scenario.Players), teamHP(scenario.Enemies)
+	if playerHP > 0 && enemyHP > 0 && (playerHP > enemyHP*maxTeamHPRatio || enemyHP > playerHP*maxTeamHPRatio) {
+		warnings = append(warnings, fmt.Sprintf("unbalanced teams: players have %d total HP, enemies have %d", playerHP, enemyHP))
+	}
+
+	if !roundModeKnown(scenario.Rules.RoundMode) {
+		warnings = append(warnings, fmt.Sprintf("unknown round mode %q, rounds will be sequential", scenario.Rules.RoundMode))
+	}
+
+	if !aiDifficultyKnown(scenario.Rules.AIDifficulty) {
+		warnings = append(warnings, fmt.Sprintf("unknown AI difficulty %q, enemies will play at normal", scenario.Rules.AIDifficulty))
+	}
+
+	for _, obstacle := range scenario.Obstacles {
+		if offBoard(obstacle) && !boardLimited(scenario.Rules) {
+			warnings = append(warnings, fmt.Sprintf("obstacle at (%d, %d) is off the board", obstacle.X, obstacle.Y))
+		}
+	}
+
+	for _, char := range append(append([]ScenarioCharacter(nil), scenario.Players...), scenario.Enemies...) {
+		if offBoard(char.Position) && !boardLimited(scenario.Rules) {
+			warnings = append(warnings, fmt.Sprintf("%s at (%d, %d) is off the board", char.Name, char.Position.X, char.Position.Y))
+		}
+		for _, ability := range char.Abilities {
+			if ability.Passive {
+				if !passiveTriggerKnown(ability.Trigger) || !passiveEffectKnown(ability.Effect) {
+					warnings = append(warnings, fmt.Sprintf("%s's passive ability %q (trigger %q, effect %q) never does anything", char.Name, ability.Name, ability.Trigger, ability.Effect))
+				}
+				continue
+			}
+			if ability.Reaction {
+				if !reactionEffectKnown(ability.Effect) {
+					warnings = append(warnings, fmt.Sprintf("%s's reaction %q has effect %q, which does nothing", char.Name, ability.Name, ability.Effect))
+				}
+				continue
+			}
+			if !abilityEffectKnown(ability.Effect) {
+				warnings = append(warnings, fmt.Sprintf("%s's ability %q has effect %q, which does nothing", char.Name, ability.Name, ability.Effect))
+			}
+		}
+		for _, item := range char.Items {
+			if warning := lintItemEffect(item); warning != "" {
+				warnings = append(warnings, fmt.Sprintf("%s's item %q %s", char.Name, item.Name, warning))
+			}
+		}
+	}
+	return errs, warnings
+}
+
+// teamHP is the total starting HP of a team
+func teamHP(characters []ScenarioCharacter) int {
+	total := 0
+	for _, char := range characters {
+		total += char.Stats.HP
+	}
+	return total
+}
+
+// offBoard reports whether a position falls outside the combat map
+func offBoard(pos ScenarioPosition) bool {
+	return !onBoard(Position{X: pos.X, Y: pos.Y})
+}
+
+// abilityEffectKnown reports whether handleAbility does anything with an effect
+func abilityEffectKnown(effect string) bool {
+	switch effect {
+	case "damage", "heal", "taunt":
+		return true
+	}
+	_, ok := parseRegenEffect(effect)
+	return ok
+}
+
+// lintItemEffect describes what's wrong with an item's effect, or returns ""
+func lintItemEffect(item ScenarioItem) string {
+	if regen, ok := parseRegenEffect(item.Effect); ok {
+		if strings.TrimSpace(strings.ToLower(item.Effect)) != "regen" && (regen.Amount == 0 || regen.Duration == 0) {
+			return fmt.Sprintf("has effect %q, which couldn't be fully parsed; defaults will be used", item.Effect)
+		}
+		return ""
+	}
+	if strings.Contains(item.Name, "Potion") {
+		return ""
+	}
+	return fmt.Sprintf("has effect %q, which couldn't be parsed and does nothing", item.Effect)
+}
+
+// lintScenarios lints every scenario YAML in dir, writing a report to out, and
+// returns the number of invalid scenarios
+func lintScenarios(dir string, out io.Writer) (int, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read scenarios directory: %w", err)
+	}
+
+	var results []ScenarioLint
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".yaml") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return 0, fmt.Errorf("failed to read %s: %w", file.Name(), err)
+		}
+		errs, warnings := lintScenario(data)
+		results = append(results, ScenarioLint{File: file.Name(), Errors: errs, Warnings: warnings})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].File < results[j].File })
+
+	invalid := 0
+	for _, result := range results {
+		if len(result.Errors) > 0 {
+			invalid++
+		}
+		if len(result.Errors) == 0 && len(result.Warnings) == 0 {
+			fmt.Fprintf(out, "%s: ok\n", result.File)
+			continue
+		}
+		for _, msg := range result.Errors {
+			fmt.Fprintf(out, "%s: error: %s\n", result.File, msg)
+		}
+		for _, msg := range result.Warnings {
+			fmt.Fprintf(out, "%s: warning: %s\n", result.File, msg)
+		}
+	}
+	fmt.Fprintf(out, "%d scenarios checked, %d invalid\n", len(results), invalid)
+	return invalid, nil
+}
+
+// runLintScenarios implements the lint-scenarios subcommand and returns the exit code
+func runLintScenarios(args []string, out io.Writer) int {
+	dir := getEnv("SCENARIOS_DIR", defaultScenariosDir)
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	invalid, err := lintScenarios(dir, out)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return 1
+	}
+	if invalid > 0 {
+		return 1
+	}
+	return 0
+}
+
+// handleValidateScenario lints a scenario posted as YAML or JSON (which YAML
+// parses too) for editors that want feedback as the author types. Valid
+// scenarios also get a preview of the initial state they convert to, rolled
+// from ?seed= (default 1). Nothing is saved.
+func handleValidateScenario(c *fiber.Ctx) error {
+	body := c.Body()
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Missing scenario"})
+	}
+
+	errs, warnings := lintScenario(body)
+	response := fiber.Map{
+		"valid":    len(errs) == 0,
+		"errors":   append([]string{}, errs...),
+		"warnings": append([]string{}, warnings...),
+	}
+	if len(errs) == 0 {
+		var scenario Scenario
+		if err := yaml.Unmarshal(body, &scenario); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("failed to parse YAML: %v", err)})
+		}
+		response["state"] = ConvertScenarioToState(&scenario, int64(c.QueryInt("seed", 1)))
+	}
+	return sendJSON(c, response)
+}
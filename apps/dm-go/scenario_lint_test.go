@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestLintScenarios(t *testing.T) {
+	dir := t.TempDir()
+	good := `
+name: Good
+players:
+  - name: Hero
+    stats: {hp: 20, maxHp: 20, attack: 5, defense: 3, speed: 5}
+    abilities: [{name: Smite, effect: damage, power: 5}]
+    items: [{name: Health Potion, type: consumable, effect: heal 20 HP}]
+enemies:
+  - name: Rat
+    position: {x: 1, y: 0}
+    stats: {hp: 10, maxHp: 10, attack: 2, defense: 1, speed: 6}
+`
+	bad := `
+name: Bad
+rules: {aiDifficulty: brutal}
+players:
+  - name: Hero
+    position: {x: 9, y: 0}
+    stats: {hp: 30, maxHp: 20}
+    abilities: [{name: Shimmer, effect: sparkle}]
+    items: [{name: Odd Rock, type: consumable, effect: glows faintly}]
+`
+	os.WriteFile(filepath.Join(dir, "good.yaml"), []byte(good), 0o644)
+	os.WriteFile(filepath.Join(dir, "bad.yaml"), []byte(bad), 0o644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a scenario"), 0o644)
+
+	var out strings.Builder
+	invalid, err := lintScenarios(dir, &out)
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	if invalid != 1 {
+		t.Errorf("Expected 1 invalid scenario, got %d:\n%s", invalid, out.String())
+	}
+
+	report := out.String()
+	for _, expected := range []string{
+		"good.yaml: ok",
+		"bad.yaml: error: Hero has 30 HP",
+		"bad.yaml: error: scenario has no enemies",
+		`bad.yaml: warning: unknown AI difficulty "brutal"`,
+		"bad.yaml: warning: Hero at (9, 0) is off the board",
+		`bad.yaml: warning: Hero's ability "Shimmer" has effect "sparkle"`,
+		`bad.yaml: warning: Hero's item "Odd Rock" has effect "glows faintly"`,
+		"2 scenarios checked, 1 invalid",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected report to contain %q, got:\n%s", expected, report)
+		}
+	}
+
+	if code := runLintScenarios([]string{dir}, &out); code != 1 {
+		t.Errorf("Expected exit code 1 with an invalid scenario, got %d", code)
+	}
+	os.Remove(filepath.Join(dir, "bad.yaml"))
+	if code := runLintScenarios([]string{dir}, &out); code != 0 {
+		t.Errorf("Expected exit code 0 once every scenario is valid, got %d", code)
+	}
+}
+
+func TestValidateScenarioEndpoint(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	sessionAuth = NewSessionAuth()
+	app := fiber.New()
+	setupRoutes(app)
+
+	type validation struct {
+		Valid    bool     `json:"valid"`
+		Errors   []string `json:"errors"`
+		Warnings []string `json:"warnings"`
+		State    *State   `json:"state"`
+	}
+	validate := func(body string) validation {
+		t.Helper()
+		status, resp := postJSON(t, app, "/api/v1/scenarios/validate?seed=7", []byte(body))
+		if status != 200 {
+			t.Fatalf("Expected 200, got %d: %s", status, resp)
+		}
+		var result validation
+		if err := json.Unmarshal([]byte(resp), &result); err != nil {
+			t.Fatalf("Failed to decode validation: %v", err)
+		}
+		return result
+	}
+
+	// YAML, with a warning but no errors
+	result := validate(testScenarioYAML)
+	if !result.Valid || len(result.Errors) != 0 || result.State == nil {
+		t.Fatalf("Expected a valid scenario with a state preview, got %+v", result)
+	}
+	if result.State.Seed != 7 || len(result.State.Characters) != 2 || len(result.State.TurnOrder) != 2 {
+		t.Errorf("Expected the preview to be the converted initial state, got %+v", result.State)
+	}
+
+	// JSON works too
+	result = validate(`{"name": "Duel", "players": [{"name": "Hero", "stats": {"hp": 10, "maxHp": 10}}], "enemies": [{"name": "Rat", "stats": {"hp": 5, "maxHp": 5}}]}`)
+	if !result.Valid || result.State == nil || result.State.Characters[1].Name != "Rat" {
+		t.Errorf("Expected a JSON scenario to validate, got %+v", result)
+	}
+
+	result = validate("name: Empty\nplayers:\n  - name: Hero\n    stats: {hp: 0, maxHp: 10}\n")
+	if result.Valid || len(result.Errors) == 0 || result.State != nil {
+		t.Errorf("Expected an invalid scenario to list its errors without a preview, got %+v", result)
+	}
+	if result.Warnings == nil {
+		t.Error("Expected warnings to be an empty list rather than null")
+	}
+
+	if count := stateManager.GetStateCount(); count != 0 {
+		t.Errorf("Expected validation not to start any sessions, got %d", count)
+	}
+}
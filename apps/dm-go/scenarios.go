@@ -0,0 +1,295 @@
+package main
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Built-in scenarios so a deployed binary works without a scenarios directory
+//
+//go:embed scenarios/*.yaml
+var embeddedScenariosFS embed.FS
+
+// defaultScenariosDir is where the bundled scenarios live relative to apps/dm-go.
+// They are the same files the binary embeds, so edits show up without a rebuild.
+const defaultScenariosDir = "scenarios"
+
+// errScenarioNotFound is returned when a scenario name isn't one of the available scenarios
+var errScenarioNotFound = errors.New("scenario not found")
+
+// resolveScenariosDir turns the configured scenarios directory into an absolute
+// path so scenario loading doesn't depend on the working directory later on
+func resolveScenariosDir(dir string) string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		slog.Warn("Failed to resolve scenarios directory", "dir", dir, "error", err)
+		return dir
+	}
+	if info, err := os.Stat(absDir); err != nil || !info.IsDir() {
+		slog.Warn("Scenarios directory not found, using built-in scenarios only", "dir", absDir)
+	}
+	return absDir
+}
+
+// scenarioPath returns the file path of a named scenario in the scenarios directory
+func scenarioPath(name string) string {
+	return filepath.Join(scenariosDir, fmt.Sprintf("%s.yaml", name))
+}
+
+// LoadScenario loads a scenario from a YAML file
+func LoadScenario(filename string) (*Scenario, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario file: %w", err)
+	}
+	return parseScenario(data)
+}
+
+func parseScenario(data []byte) (*Scenario, error) {
+	var scenario Scenario
+	if err := yaml.Unmarshal(data, &scenario); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario YAML: %w", err)
+	}
+	if err := validateScenario(&scenario); err != nil {
+		return nil, fmt.Errorf("invalid scenario: %w", err)
+	}
+	return &scenario, nil
+}
+
+// validateScenario checks the starting conditions a scenario sets up
+func validateScenario(scenario *Scenario) error {
+	if scenario.Round < 0 {
+		return fmt.Errorf("starting round %d is negative", scenario.Round)
+	}
+	if scenario.Surprise != "" && scenario.Surprise != "player" && scenario.Surprise != "enemy" {
+		return fmt.Errorf("surprise must be player or enemy, got %q", scenario.Surprise)
+	}
+	if !boardModeKnown(scenario.Rules.BoardMode) {
+		return fmt.Errorf("unknown board mode %q", scenario.Rules.BoardMode)
+	}
+	characters := append(append([]ScenarioCharacter(nil), scenario.Players...), scenario.Enemies...)
+	for _, char := range characters {
+		if err := validateScenarioCharacter(char); err != nil {
+			return err
+		}
+		if boardLimited(scenario.Rules) && offBoard(char.Position) {
+			return fmt.Errorf("%s at (%d, %d) is off the %s board", char.Name, char.Position.X, char.Position.Y, scenario.Rules.BoardMode)
+		}
+	}
+	for _, obstacle := range scenario.Obstacles {
+		if boardLimited(scenario.Rules) && offBoard(obstacle) {
+			return fmt.Errorf("obstacle at (%d, %d) is off the %s board", obstacle.X, obstacle.Y, scenario.Rules.BoardMode)
+		}
+	}
+	return validateScenarioIDs(characters)
+}
+
+// validateScenarioIDs checks that fixed character, weapon, ability and item IDs
+// are unique across the whole scenario
+func validateScenarioIDs(characters []ScenarioCharacter) error {
+	seen := make(map[string]bool)
+	check := func(id string) error {
+		if id == "" {
+			return nil
+		}
+		if seen[id] {
+			return fmt.Errorf("duplicate id %q", id)
+		}
+		seen[id] = true
+		return nil
+	}
+
+	for _, char := range characters {
+		ids := []string{char.ID}
+		for _, weapon := range char.Weapons {
+			ids = append(ids, weapon.ID)
+		}
+		for _, ability := range char.Abilities {
+			ids = append(ids, ability.ID)
+		}
+		for _, item := range char.Items {
+			ids = append(ids, item.ID)
+		}
+		for _, id := range ids {
+			if err := check(id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateScenarioCharacter checks a character's HP, stats, weapon and ability
+// scaling, starting cooldowns and status effects
+func validateScenarioCharacter(char ScenarioCharacter) error {
+	if char.Stats.HP > char.Stats.MaxHP {
+		return fmt.Errorf("%s has %d HP, more than their max of %d", char.Name, char.Stats.HP, char.Stats.MaxHP)
+	}
+	stats := Stat{HP: char.Stats.HP, MaxHP: char.Stats.MaxHP, Attack: char.Stats.Attack, Defense: char.Stats.Defense, Speed: char.Stats.Speed}
+	if err := statBounds.Check(char.Name, stats); err != nil {
+		return err
+	}
+
+	for _, weapon := range char.Weapons {
+		if !scalingKnown(weapon.Scaling) {
+			return fmt.Errorf("%s's weapon %q scales with unknown stat %q", char.Name, weapon.Name, weapon.Scaling)
+		}
+	}
+	for _, ability := range char.Abilities {
+		if !scalingKnown(ability.Scaling) {
+			return fmt.Errorf("%s's ability %q scales with unknown stat %q", char.Name, ability.Name, ability.Scaling)
+		}
+	}
+
+	for name := range char.Cooldowns {
+		found := false
+		for _, ability := range char.Abilities {
+			found = found || ability.Name == name
+		}
+		if !found {
+			return fmt.Errorf("%s has a cooldown for unknown ability %q", char.Name, name)
+		}
+	}
+
+	for _, effect := range char.StatusEffects {
+		if !statusEffectTypes[effect.Type] {
+			return fmt.Errorf("%s has unknown status effect %q", char.Name, effect.Type)
+		}
+		if effect.Duration < 1 {
+			return fmt.Errorf("%s's %s effect needs a duration", char.Name, effect.Type)
+		}
+	}
+	return nil
+}
+
+// loadNamedScenario loads one of the available scenarios by name, preferring
+// the scenarios directory over the built-in copy. Only listed scenarios can be
+// loaded, so names can't be used to reach arbitrary files.
+func loadNamedScenario(name string) (*Scenario, error) {
+	available, err := GetAvailableScenarios()
+	if err != nil {
+		return nil, err
+	}
+	for _, scenario := range available {
+		if scenario != name {
+			continue
+		}
+
+		data, err := os.ReadFile(scenarioPath(name))
+		if errors.Is(err, fs.ErrNotExist) {
+			data, err = embeddedScenariosFS.ReadFile("scenarios/" + name + ".yaml")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read scenario file: %w", err)
+		}
+		return parseScenario(data)
+	}
+	return nil, errScenarioNotFound
+}
+
+// GetAvailableScenarios returns the built-in scenarios merged with any in the scenarios directory
+func GetAvailableScenarios() ([]string, error) {
+	seen := make(map[string]bool)
+
+	embedded, err := fs.ReadDir(embeddedScenariosFS, "scenarios")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read built-in scenarios: %w", err)
+	}
+
+	files, err := os.ReadDir(scenariosDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read scenarios directory: %w", err)
+	}
+
+	for _, file := range append(embedded, files...) {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), ".yaml") {
+			seen[strings.TrimSuffix(file.Name(), ".yaml")] = true
+		}
+	}
+
+	scenarios := make([]string, 0, len(seen))
+	for name := range seen {
+		scenarios = append(scenarios, name)
+	}
+	sort.Strings(scenarios)
+
+	return scenarios, nil
+}
+
+// Difficulty presets for ScaleScenario
+var difficultyFactors = map[string]float64{
+	"easy":   0.75,
+	"normal": 1.0,
+	"hard":   1.5,
+}
+
+// ScaleScenario makes a scenario's enemies tougher or weaker: HP and attack are
+// multiplied by factor, and so is the number of enemies. Extra enemies are
+// numbered copies of the originals; stats never drop below 1 and at least one
+// enemy always remains.
+func ScaleScenario(s *Scenario, factor float64) {
+	if factor <= 0 || len(s.Enemies) == 0 {
+		return
+	}
+
+	scaleStat := func(value int) int {
+		return int(math.Max(1, math.Round(float64(value)*factor)))
+	}
+	for i := range s.Enemies {
+		stats := &s.Enemies[i].Stats
+		stats.HP = scaleStat(stats.HP)
+		stats.MaxHP = scaleStat(stats.MaxHP)
+		stats.Attack = scaleStat(stats.Attack)
+	}
+
+	originals := s.Enemies
+	count := int(math.Max(1, math.Round(float64(len(originals))*factor)))
+	if count <= len(originals) {
+		s.Enemies = originals[:count]
+		return
+	}
+
+	for i := len(originals); i < count; i++ {
+		copyNumber := i/len(originals) + 1
+		enemy := originals[i%len(originals)]
+		enemy.Name = fmt.Sprintf("%s %d", enemy.Name, copyNumber)
+		enemy.Position.Y += copyNumber - 1
+		suffixScenarioIDs(&enemy, fmt.Sprintf("-%d", copyNumber))
+		s.Enemies = append(s.Enemies, enemy)
+	}
+}
+
+// suffixScenarioIDs appends a suffix to a copied character's fixed IDs so the
+// copy doesn't share them with the original
+func suffixScenarioIDs(char *ScenarioCharacter, suffix string) {
+	suffixed := func(id string) string {
+		if id == "" {
+			return ""
+		}
+		return id + suffix
+	}
+
+	char.ID = suffixed(char.ID)
+	char.Weapons = append([]ScenarioWeapon(nil), char.Weapons...)
+	for i := range char.Weapons {
+		char.Weapons[i].ID = suffixed(char.Weapons[i].ID)
+	}
+	char.Abilities = append([]ScenarioAbility(nil), char.Abilities...)
+	for i := range char.Abilities {
+		char.Abilities[i].ID = suffixed(char.Abilities[i].ID)
+	}
+	char.Items = append([]ScenarioItem(nil), char.Items...)
+	for i := range char.Items {
+		char.Items[i].ID = suffixed(char.Items[i].ID)
+	}
+}
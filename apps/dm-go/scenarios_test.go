@@ -0,0 +1,379 @@
+package main
+
+import (
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const testScenarioYAML = `name: Cellar Rats
+description: Rats in the cellar
+players:
+  - name: Hero
+    stats: {hp: 20, maxHp: 20, attack: 5, defense: 3, speed: 4}
+    weapons:
+      - {name: Rusty Sword, damage: 4, accuracy: 0, durability: 2}
+enemies:
+  - name: Rat
+    stats: {hp: 5, maxHp: 5, attack: 2, defense: 1, speed: 6}
+obstacles:
+  - {x: 2, y: 1}
+`
+
+func TestScenariosDirConfigurable(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cellar-rats.yaml"), []byte(testScenarioYAML), 0o644); err != nil {
+		t.Fatalf("Failed to write scenario: %v", err)
+	}
+
+	previous := scenariosDir
+	scenariosDir = resolveScenariosDir(dir)
+	defer func() { scenariosDir = previous }()
+
+	if !filepath.IsAbs(scenariosDir) {
+		t.Errorf("Expected scenarios dir to be absolute, got %s", scenariosDir)
+	}
+
+	scenarios, err := GetAvailableScenarios()
+	if err != nil {
+		t.Fatalf("Failed to list scenarios: %v", err)
+	}
+	if !containsScenario(scenarios, "cellar-rats") {
+		t.Errorf("Expected cellar-rats to be listed, got %v", scenarios)
+	}
+
+	scenario, err := loadNamedScenario("cellar-rats")
+	if err != nil {
+		t.Fatalf("Failed to load scenario: %v", err)
+	}
+	if scenario.Name != "Cellar Rats" || len(scenario.Enemies) != 1 {
+		t.Errorf("Unexpected scenario contents: %+v", scenario)
+	}
+
+	state := ConvertScenarioToState(scenario, 12345)
+	hero, _ := GetCharacterByName(state, "Hero")
+	if hero == nil || len(hero.Weapons) != 1 || hero.Weapons[0].Durability != 2 {
+		t.Errorf("Expected weapon durability to be loaded, got %+v", hero)
+	}
+	if len(state.Obstacles) != 1 || state.Obstacles[0] != (Position{X: 2, Y: 1}) {
+		t.Errorf("Expected obstacles to be loaded, got %+v", state.Obstacles)
+	}
+}
+
+func TestBuiltInScenariosWithoutDirectory(t *testing.T) {
+	previous := scenariosDir
+	scenariosDir = filepath.Join(t.TempDir(), "missing")
+	defer func() { scenariosDir = previous }()
+
+	scenarios, err := GetAvailableScenarios()
+	if err != nil {
+		t.Fatalf("Failed to list scenarios: %v", err)
+	}
+	if !containsScenario(scenarios, "goblin-ambush") {
+		t.Fatalf("Expected built-in scenarios to be listed, got %v", scenarios)
+	}
+
+	scenario, err := loadNamedScenario("goblin-ambush")
+	if err != nil {
+		t.Fatalf("Failed to load built-in scenario: %v", err)
+	}
+	if len(scenario.Players) == 0 || len(scenario.Enemies) == 0 {
+		t.Errorf("Expected built-in scenario to have characters, got %+v", scenario)
+	}
+}
+
+func containsScenario(scenarios []string, name string) bool {
+	for _, scenario := range scenarios {
+		if scenario == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestScaleScenario(t *testing.T) {
+	load := func() *Scenario {
+		scenario, err := parseScenario([]byte(testScenarioYAML))
+		if err != nil {
+			t.Fatalf("Failed to parse scenario: %v", err)
+		}
+		scenario.Enemies = append(scenario.Enemies, ScenarioCharacter{
+			Name:  "Big Rat",
+			Stats: ScenarioStats{HP: 9, MaxHP: 9, Attack: 3, Defense: 1, Speed: 3},
+		})
+		return scenario
+	}
+
+	hard := load()
+	ScaleScenario(hard, difficultyFactors["hard"])
+
+	if len(hard.Enemies) != 3 {
+		t.Fatalf("Expected hard mode to add an enemy, got %d", len(hard.Enemies))
+	}
+	if rat := hard.Enemies[0].Stats; rat.HP != 8 || rat.MaxHP != 8 || rat.Attack != 3 {
+		t.Errorf("Expected rat stats scaled by 1.5, got %+v", rat)
+	}
+	if extra := hard.Enemies[2]; extra.Name != "Rat 2" || extra.Stats.HP != 8 || extra.Position.Y != 1 {
+		t.Errorf("Expected a numbered, scaled copy of the rat, got %+v", extra)
+	}
+	if hero := hard.Players[0].Stats; hero.HP != 20 || hero.Attack != 5 {
+		t.Errorf("Expected players to be untouched, got %+v", hero)
+	}
+
+	// Same scenario, factor and seed give the same encounter
+	again := load()
+	ScaleScenario(again, difficultyFactors["hard"])
+	first, second := ConvertScenarioToState(hard, 99), ConvertScenarioToState(again, 99)
+	for i := range first.Characters {
+		if first.Characters[i].Name != second.Characters[i].Name || first.Characters[i].Stats != second.Characters[i].Stats {
+			t.Errorf("Expected hard mode to be deterministic, got %+v and %+v", first.Characters[i], second.Characters[i])
+		}
+	}
+
+	t.Run("ClampsToMinimums", func(t *testing.T) {
+		tiny := load()
+		ScaleScenario(tiny, 0.01)
+		if len(tiny.Enemies) != 1 {
+			t.Errorf("Expected at least one enemy, got %d", len(tiny.Enemies))
+		}
+		if stats := tiny.Enemies[0].Stats; stats.HP != 1 || stats.MaxHP != 1 || stats.Attack != 1 {
+			t.Errorf("Expected stats clamped to 1, got %+v", stats)
+		}
+	})
+}
+
+const midBattleScenarioYAML = `
+name: Ambush Aftermath
+round: 3
+players:
+  - name: Hero
+    stats: {hp: 12, maxHp: 20, attack: 5, defense: 3, speed: 4}
+    abilities:
+      - {name: Second Wind, cooldown: 4, effect: heal, power: 8}
+    cooldowns: {Second Wind: 2}
+    statusEffects:
+      - {type: poison, amount: 3, duration: 2}
+enemies:
+  - name: Rat
+    stats: {hp: 5, maxHp: 5, attack: 2, defense: 1, speed: 6}
+`
+
+func TestMidBattleScenario(t *testing.T) {
+	scenario, err := parseScenario([]byte(midBattleScenarioYAML))
+	if err != nil {
+		t.Fatalf("Failed to parse scenario: %v", err)
+	}
+
+	state := ConvertScenarioToState(scenario, 12345)
+	if state.Round != 3 {
+		t.Errorf("Expected combat to start in round 3, got %d", state.Round)
+	}
+	hero, _ := GetCharacterByName(state, "Hero")
+	if hero.Stats.HP != 12 {
+		t.Errorf("Expected the hero to start wounded, got %d HP", hero.Stats.HP)
+	}
+	if RemainingCooldown(hero, hero.Abilities[0].ID) != 2 {
+		t.Errorf("Expected Second Wind to start on cooldown, got %v", hero.AbilityCooldowns)
+	}
+	if len(hero.StatusEffects) != 1 || hero.StatusEffects[0].Type != "poison" {
+		t.Fatalf("Expected the hero to start poisoned, got %+v", hero.StatusEffects)
+	}
+
+	events, _ := tickStatusEffects(hero)
+	if hero.Stats.HP != 9 || len(events) != 1 || events[0].Effect != "poison" {
+		t.Errorf("Expected poison to deal 3 damage, got HP %d and events %+v", hero.Stats.HP, events)
+	}
+
+	for name, yaml := range map[string]string{
+		"Overhealed":      "players:\n  - name: Hero\n    stats: {hp: 25, maxHp: 20}\n",
+		"UnknownCooldown": "players:\n  - name: Hero\n    stats: {hp: 5, maxHp: 20}\n    cooldowns: {Fireball: 2}\n",
+		"UnknownEffect":   "players:\n  - name: Hero\n    stats: {hp: 5, maxHp: 20}\n    statusEffects: [{type: frozen, duration: 2}]\n",
+		"NegativeRound":   "round: -1\n",
+		"WeaponScaling":   "players:\n  - name: Hero\n    stats: {hp: 5, maxHp: 20}\n    weapons: [{name: Lucky Dagger, damage: 3, scaling: luck}]\n",
+		"AbilityScaling":  "players:\n  - name: Hero\n    stats: {hp: 5, maxHp: 20}\n    abilities: [{name: Smite, effect: damage, scaling: luck}]\n",
+	} {
+		if _, err := parseScenario([]byte(yaml)); err == nil {
+			t.Errorf("%s: expected scenario to be rejected", name)
+		}
+	}
+}
+
+const ambushScenarioYAML = `
+name: Ambush
+surprise: enemy
+players:
+  - name: Hero
+    stats: {hp: 20, maxHp: 20, attack: 5, defense: 3, speed: 30}
+  - name: Squire
+    stats: {hp: 20, maxHp: 20, attack: 5, defense: 3, speed: 20}
+enemies:
+  - name: Goblin
+    stats: {hp: 5, maxHp: 5, attack: 2, defense: 1, speed: 1}
+  - name: Goblin Archer
+    stats: {hp: 5, maxHp: 5, attack: 2, defense: 1, speed: 1}
+    initiativeBonus: 50
+`
+
+func TestSurpriseRound(t *testing.T) {
+	scenario, err := parseScenario([]byte(ambushScenarioYAML))
+	if err != nil {
+		t.Fatalf("Failed to parse scenario: %v", err)
+	}
+	state := ConvertScenarioToState(scenario, 12345)
+
+	names := func(order []ID) []string {
+		var result []string
+		for _, id := range order {
+			result = append(result, GetCharacterByID(state, id).Name)
+		}
+		return result
+	}
+
+	if got := names(state.TurnOrder); len(got) != 2 || got[0] != "Goblin Archer" || got[1] != "Goblin" {
+		t.Fatalf("Expected only the goblins to act in the surprise round, archer first, got %v", got)
+	}
+	if len(state.NextOrder) != 4 {
+		t.Fatalf("Expected everyone in the following round's order, got %v", names(state.NextOrder))
+	}
+
+	state, _, _ = advanceTurn(state)
+	state, _, _ = advanceTurn(state)
+	if state.Round != 2 || state.NextOrder != nil {
+		t.Fatalf("Expected normal initiative from round 2, got round %d with pending order %v", state.Round, state.NextOrder)
+	}
+	// The archer's bonus keeps them first; the hero's speed beats the rest
+	if got := names(state.TurnOrder); len(got) != 4 || got[0] != "Goblin Archer" || got[1] != "Hero" {
+		t.Errorf("Expected normal initiative order, got %v", got)
+	}
+
+	if _, err := parseScenario([]byte("surprise: dragons\n")); err == nil {
+		t.Error("Expected an unknown surprise team to be rejected")
+	}
+}
+
+const fixedIDScenarioYAML = `
+name: Fixed IDs
+players:
+  - id: hero
+    name: Hero
+    stats: {hp: 20, maxHp: 20, attack: 5, defense: 3, speed: 5}
+    weapons: [{id: hero-sword, name: Sword, damage: 6, accuracy: 80}]
+    abilities: [{id: hero-rally, name: Rally, cooldown: 2, effect: heal, power: 4}]
+    items: [{id: hero-potion, name: Potion, type: consumable, effect: heal 5}]
+enemies:
+  - id: rat
+    name: Rat
+    stats: {hp: 5, maxHp: 5, attack: 2, defense: 1, speed: 6}
+  - name: Bat
+    stats: {hp: 5, maxHp: 5, attack: 2, defense: 1, speed: 6}
+`
+
+func TestScenarioFixedIDs(t *testing.T) {
+	load := func() State {
+		scenario, err := parseScenario([]byte(fixedIDScenarioYAML))
+		if err != nil {
+			t.Fatalf("Failed to parse scenario: %v", err)
+		}
+		return ConvertScenarioToState(scenario, 12345)
+	}
+
+	first, second := load(), load()
+	for _, name := range []string{"Hero", "Rat"} {
+		a, _ := GetCharacterByName(first, name)
+		b, _ := GetCharacterByName(second, name)
+		if a.ID != b.ID {
+			t.Errorf("Expected %s to get the same ID on every load, got %s and %s", name, a.ID, b.ID)
+		}
+	}
+
+	hero, _ := GetCharacterByName(first, "Hero")
+	if hero.ID != "hero" || hero.Weapons[0].ID != "hero-sword" || hero.Abilities[0].ID != "hero-rally" || hero.Items[0].ID != "hero-potion" {
+		t.Errorf("Expected the scenario's fixed IDs, got %s %s %s %s", hero.ID, hero.Weapons[0].ID, hero.Abilities[0].ID, hero.Items[0].ID)
+	}
+
+	// Characters without an id still get a fresh one
+	batA, _ := GetCharacterByName(first, "Bat")
+	batB, _ := GetCharacterByName(second, "Bat")
+	if batA.ID == "" || batA.ID == batB.ID {
+		t.Errorf("Expected generated IDs for Bat, got %q and %q", batA.ID, batB.ID)
+	}
+
+	// Copies made by difficulty scaling get their own IDs
+	scenario, _ := parseScenario([]byte(fixedIDScenarioYAML))
+	ScaleScenario(scenario, 2)
+	if err := validateScenario(scenario); err != nil {
+		t.Errorf("Expected scaled copies to have unique IDs, got %v", err)
+	}
+	if scenario.Enemies[2].ID != "rat-2" {
+		t.Errorf("Expected the copied rat to be rat-2, got %q", scenario.Enemies[2].ID)
+	}
+
+	duplicate := "players:\n  - {id: hero, name: Hero, stats: {hp: 5, maxHp: 5}}\nenemies:\n  - {id: hero, name: Rat, stats: {hp: 5, maxHp: 5}}\n"
+	if _, err := parseScenario([]byte(duplicate)); err == nil {
+		t.Error("Expected duplicate IDs to be rejected")
+	}
+}
+
+const displayScenarioYAML = `
+name: Painted
+players:
+  - name: Hero
+    stats: {hp: 20, maxHp: 20, attack: 5, defense: 3, speed: 30}
+    color: "#8a2be2"
+    sprite: knight-blue
+    portrait: /static/hero.png
+enemies:
+  - name: Goblin
+    stats: {hp: 5, maxHp: 5, attack: 2, defense: 1, speed: 1}
+`
+
+func TestScenarioDisplayMetadata(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	sessionAuth = NewSessionAuth()
+	app := fiber.New()
+	setupRoutes(app)
+
+	scenario, err := parseScenario([]byte(displayScenarioYAML))
+	if err != nil {
+		t.Fatalf("Failed to parse scenario: %v", err)
+	}
+	state := ConvertScenarioToState(scenario, 12345)
+	hero, _ := GetCharacterByName(state, "Hero")
+	if hero.Color != "#8a2be2" || hero.Sprite != "knight-blue" || hero.Portrait != "/static/hero.png" {
+		t.Fatalf("Expected the display metadata to be converted, got %+v", hero)
+	}
+	stateManager.SetState("painted-session", state)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/sessions/painted-session", nil))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `"color":"#8a2be2"`) || !strings.Contains(string(body), `"sprite":"knight-blue"`) {
+		t.Errorf("Expected the color and sprite in the session JSON, got %s", body)
+	}
+	goblin, _ := GetCharacterByName(state, "Goblin")
+	if _, fields := messageKeys(t, *goblin); fields["color"] != nil {
+		t.Error("Expected characters without a color to leave it out of the JSON")
+	}
+
+	te, err := NewTemplateEngine()
+	if err != nil {
+		t.Fatalf("Failed to create template engine: %v", err)
+	}
+	html, err := te.RenderGamePage(state, "painted-session", true)
+	if err != nil {
+		t.Fatalf("Failed to render game page: %v", err)
+	}
+	for _, want := range []string{`border-color: #8a2be2`, `data-sprite="knight-blue"`, `src="/static/hero.png"`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected the game page to contain %q", want)
+		}
+	}
+}
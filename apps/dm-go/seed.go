@@ -0,0 +1,65 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// SeedSource produces the seeds for new sessions and server-side rolls. Seeds
+// are never 0, which the API treats as "no seed given".
+type SeedSource func() int64
+
+// seedSource is where the server gets its seeds
+var seedSource SeedSource = TimeSeedSource
+
+// SetSeedSource swaps in a different seed source; nil restores the default
+func SetSeedSource(source SeedSource) {
+	if source == nil {
+		source = TimeSeedSource
+	}
+	seedSource = source
+}
+
+// newSeed returns a seed from the configured source
+func newSeed() int64 {
+	return seedSource()
+}
+
+// TimeSeedSource seeds from the clock. Seeds are guessable, and calls in the
+// same nanosecond get the same seed.
+func TimeSeedSource() int64 {
+	if seed := time.Now().UnixNano(); seed != 0 {
+		return seed
+	}
+	return 1
+}
+
+// CryptoSeedSource seeds from crypto/rand so clients can't predict rolls. It
+// falls back to the clock if the system's randomness is unavailable.
+func CryptoSeedSource() int64 {
+	var buf [8]byte
+	for {
+		if _, err := rand.Read(buf[:]); err != nil {
+			slog.Error("Crypto seed unavailable, falling back to time", "error", err)
+			return TimeSeedSource()
+		}
+		if seed := int64(binary.LittleEndian.Uint64(buf[:])); seed != 0 {
+			return seed
+		}
+	}
+}
+
+// seedSourceByName maps the SEED_SOURCE setting to a seed source
+func seedSourceByName(name string) (SeedSource, error) {
+	switch name {
+	case "", "time":
+		return TimeSeedSource, nil
+	case "crypto":
+		return CryptoSeedSource, nil
+	default:
+		return nil, fmt.Errorf("unknown seed source %q (expected time or crypto)", name)
+	}
+}
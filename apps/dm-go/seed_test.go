@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCryptoSeedSourceIsDistinct(t *testing.T) {
+	seen := make(map[int64]bool)
+	for i := 0; i < 10000; i++ {
+		seed := CryptoSeedSource()
+		if seed == 0 {
+			t.Fatal("Expected a non-zero seed")
+		}
+		if seen[seed] {
+			t.Fatalf("Expected distinct seeds, got %d twice after %d calls", seed, i)
+		}
+		seen[seed] = true
+	}
+}
+
+func TestSeedSourceByName(t *testing.T) {
+	for _, name := range []string{"", "time", "crypto"} {
+		if source, err := seedSourceByName(name); err != nil || source == nil {
+			t.Errorf("Expected seed source %q to be known, got %v", name, err)
+		}
+	}
+	if _, err := seedSourceByName("dice"); err == nil {
+		t.Error("Expected an error for an unknown seed source")
+	}
+}
+
+func TestSessionRecordsSourceSeed(t *testing.T) {
+	SetSeedSource(func() int64 { return 4242 })
+	defer SetSeedSource(nil)
+
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	app := fiber.New()
+	setupRoutes(app)
+
+	status, resp := postJSON(t, app, "/sessions/from-scenario", []byte(`{"scenario":"goblin-ambush"}`))
+	if status != 200 {
+		t.Fatalf("Expected 200, got %d: %s", status, resp)
+	}
+	var created struct {
+		SessionID string `json:"sessionId"`
+	}
+	json.Unmarshal([]byte(resp), &created)
+
+	state, _ := stateManager.GetState(created.SessionID)
+	if state.Seed != 4242 {
+		t.Errorf("Expected the session to record the source's seed, got %d", state.Seed)
+	}
+	if snapshot, _ := eventStore.GetLatestSnapshot(created.SessionID); snapshot == nil || snapshot.Seed != 4242 {
+		t.Errorf("Expected the seed in the saved snapshot, got %+v", snapshot)
+	}
+}
@@ -1,12 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 )
 
@@ -22,9 +23,9 @@ func DemoServer() {
 	stateManager = NewStateManager()
 
 	var err error
-	templateEngine, err = NewTemplateEngine()
+	templateEngine, err = newConfiguredTemplateEngine()
 	if err != nil {
-		log.Fatalf("Failed to create template engine: %v", err)
+		slog.Error("Failed to create template engine, HTML pages will show a fallback", "error", err)
 	}
 
 	llmClient = NewLLMClient(LLMConfig{
@@ -35,18 +36,13 @@ func DemoServer() {
 	})
 
 	// Setup Fiber app
-	app := fiber.New(fiber.Config{
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			log.Printf("Error: %v", err)
-			return c.Status(500).JSON(fiber.Map{
-				"error": "Internal server error",
-			})
-		},
-	})
+	app := fiber.New(serverConfig())
 
 	// Middleware
+	allowedOrigins = NewOriginAllowList(getEnv("CORS_ALLOWED_ORIGINS", "*"))
+	scenariosDir = resolveScenariosDir(getEnv("SCENARIOS_DIR", defaultScenariosDir))
 	app.Use(logger.New())
-	app.Use(cors.New())
+	app.Use(corsMiddleware(allowedOrigins))
 
 	// Routes
 	app.Get("/health", func(c *fiber.Ctx) error {
@@ -66,11 +62,17 @@ func DemoServer() {
 	// Create a demo session on startup
 	createDemoSession()
 
-	log.Println("✅ Demo server initialized successfully!")
-	log.Println("🌐 Opening your browser to http://localhost:3000")
+	// Nothing outlives the demo server, so idle sessions are dropped unsaved
+	startSessionSweeper(context.Background(), stateManager, false)
+
+	slog.Info("✅ Demo server initialized successfully!")
+	slog.Info("🌐 Opening your browser to http://localhost:3000")
 
 	// Start server
-	log.Fatal(app.Listen(":3000"))
+	if err := app.Listen(":3000"); err != nil {
+		slog.Error("Server stopped", "error", err)
+		os.Exit(1)
+	}
 }
 
 // createDemoSession creates a sample game for immediate testing
@@ -115,15 +117,15 @@ func createDemoSession() {
 	}
 
 	// Create initial state
-	seed := time.Now().UnixNano()
+	seed := newSeed()
 	state := CreateInitialState([]Character{player}, []Character{goblin}, seed)
 
 	// Create demo session
 	sessionID := "demo-session"
 	stateManager.SetState(sessionID, state)
 
-	log.Printf("✅ Created demo session: %s", sessionID)
-	log.Printf("🎮 Game ready with Hero vs Goblin")
+	sessionLogger(sessionID).Info("✅ Created demo session")
+	slog.Info("🎮 Game ready with Hero vs Goblin")
 }
 
 // handleStartGameDemo handles game start for demo
@@ -134,16 +136,15 @@ func handleStartGameDemo(c *fiber.Ctx) error {
 	}
 
 	// Load scenario
-	scenarioPath := fmt.Sprintf("../../scenarios/%s.yaml", scenarioName)
-	scenario, err := LoadScenario(scenarioPath)
+	scenario, err := loadNamedScenario(scenarioName)
 	if err != nil {
-		log.Printf("Failed to load scenario %s: %v", scenarioName, err)
+		slog.Error("Failed to load scenario", "scenario", scenarioName, "error", err)
 		// Use demo scenario instead
 		return c.Redirect("/game/demo-session")
 	}
 
 	// Create initial game state
-	seed := time.Now().UnixNano()
+	seed := newSeed()
 	state := ConvertScenarioToState(scenario, seed)
 
 	// Create session
@@ -152,13 +153,13 @@ func handleStartGameDemo(c *fiber.Ctx) error {
 
 	// Save to memory store
 	if err := eventStore.CreateSession(sessionID, scenario.Name); err != nil {
-		log.Printf("Failed to create session: %v", err)
+		sessionLogger(sessionID).Error("Failed to create session", "error", err)
 	}
 
 	if err := eventStore.SaveSnapshot(sessionID, state.Round, state); err != nil {
-		log.Printf("Failed to save initial snapshot: %v", err)
+		sessionLogger(sessionID).Error("Failed to save initial snapshot", "error", err)
 	}
 
-	log.Printf("🎯 Started new game: %s (%s)", sessionID, scenario.Name)
+	sessionLogger(sessionID).Info("🎯 Started new game", "scenario", scenario.Name)
 	return c.Redirect(fmt.Sprintf("/game/%s", sessionID))
 }
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// SessionExport is a whole session in one document, for backup and sharing
+type SessionExport struct {
+	Session   Session            `json:"session"`
+	Events    []Event            `json:"events"`
+	Snapshots []ExportedSnapshot `json:"snapshots"`
+	Actions   []ActionRecord     `json:"actions,omitempty"` // Applied actions and their seeds, for replay
+	State     *State             `json:"state,omitempty"`   // The session as it stood when exported, which may be mid-round
+}
+
+// ExportedSnapshot is a state snapshot taken at the start of a round
+type ExportedSnapshot struct {
+	Round int   `json:"round"`
+	State State `json:"state"`
+}
+
+// ExportSession gathers a session's metadata, events and snapshots from the
+// store. It returns nil if the session doesn't exist.
+func ExportSession(store EventStoreInterface, sessionID string) (*SessionExport, error) {
+	session, err := store.GetSession(sessionID)
+	if err != nil || session == nil {
+		return nil, err
+	}
+
+	events, err := store.GetEvents(sessionID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	states, err := store.GetSnapshots(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	actions, err := store.GetActions(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &SessionExport{
+		Session:   *session,
+		Events:    events,
+		Snapshots: make([]ExportedSnapshot, len(states)),
+		Actions:   actions,
+	}
+	if export.Events == nil {
+		export.Events = []Event{}
+	}
+	for i, state := range states {
+		export.Snapshots[i] = ExportedSnapshot{Round: state.Round, State: state}
+	}
+	return export, nil
+}
+
+// validateSessionExport checks every snapshot in an export before anything is written
+func validateSessionExport(export SessionExport) error {
+	if len(export.Snapshots) == 0 {
+		return fmt.Errorf("export has no snapshots")
+	}
+	for _, snapshot := range export.Snapshots {
+		if err := validateStatePayload(snapshot.State); err != nil {
+			return fmt.Errorf("snapshot at round %d: %w", snapshot.Round, err)
+		}
+		if err := ValidateState(snapshot.State); err != nil {
+			return fmt.Errorf("snapshot at round %d: %w", snapshot.Round, err)
+		}
+	}
+	if export.State != nil {
+		if err := validateStatePayload(*export.State); err != nil {
+			return fmt.Errorf("current state: %w", err)
+		}
+		if err := ValidateState(*export.State); err != nil {
+			return fmt.Errorf("current state: %w", err)
+		}
+	}
+	return nil
+}
+
+// ImportSession recreates an exported session in the store, keeping its ID
+// unless that is already taken. It returns the session ID and the exported
+// current state, saved as the latest snapshot, or the latest snapshot for
+// exports without one.
+func ImportSession(store EventStoreInterface, export SessionExport) (string, State, error) {
+	if err := validateSessionExport(export); err != nil {
+		return "", State{}, err
+	}
+
+	sessionID := export.Session.ID
+	existing, err := store.GetSession(sessionID)
+	if err != nil {
+		return "", State{}, err
+	}
+	if sessionID == "" || existing != nil {
+		sessionID = uuid.New().String()
+	}
+
+	name := export.Session.Name
+	if name == "" {
+		name = "Imported session"
+	}
+	if err := store.CreateSession(sessionID, name); err != nil {
+		return "", State{}, fmt.Errorf("failed to create session: %w", err)
+	}
+	if export.Session.Status != "" && export.Session.Status != "active" {
+		if err := store.UpdateSessionStatus(sessionID, export.Session.Status); err != nil {
+			return "", State{}, fmt.Errorf("failed to set session status: %w", err)
+		}
+	}
+
+	latest := export.Snapshots[0]
+	for _, snapshot := range export.Snapshots {
+		if err := store.SaveSnapshot(sessionID, snapshot.Round, snapshot.State); err != nil {
+			return "", State{}, fmt.Errorf("failed to save snapshot: %w", err)
+		}
+		if snapshot.Round >= latest.Round {
+			latest = snapshot
+		}
+	}
+	if export.State != nil {
+		// Actions since the round began are only in the current state
+		latest = ExportedSnapshot{Round: export.State.Round, State: *export.State}
+		if err := store.SaveSnapshot(sessionID, latest.Round, latest.State); err != nil {
+			return "", State{}, fmt.Errorf("failed to save current state: %w", err)
+		}
+	}
+
+	// Events are stored a round at a time
+	for start := 0; start < len(export.Events); {
+		round := export.Events[start].Round
+		end := start
+		for end < len(export.Events) && export.Events[end].Round == round {
+			end++
+		}
+		if err := store.AppendEvents(sessionID, round, export.Events[start:end]); err != nil {
+			return "", State{}, fmt.Errorf("failed to append events: %w", err)
+		}
+		start = end
+	}
+
+	for _, record := range export.Actions {
+		if err := store.RecordAction(sessionID, record); err != nil {
+			return "", State{}, fmt.Errorf("failed to record action: %w", err)
+		}
+	}
+
+	return sessionID, latest.State, nil
+}
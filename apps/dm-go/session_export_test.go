@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSessionExportImportRoundTrip(t *testing.T) {
+	source := NewMemoryEventStore()
+	eventStore = source
+	stateManager = NewStateManager()
+	sessionAuth = NewSessionAuth()
+
+	app := fiber.New()
+	setupRoutes(app)
+
+	player := createTestCharacter(true, "Hero")
+	enemy := createTestCharacter(false, "Goblin")
+	enemy.Stats.HP, enemy.Stats.MaxHP = 200, 200
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+
+	sessionID := "export-session"
+	stateManager.SetState(sessionID, state)
+	source.CreateSession(sessionID, "Export me")
+	source.SaveSnapshot(sessionID, state.Round, state)
+	for seed := int64(1); state.Round < 3; seed++ {
+		current := GetCurrentCharacter(state)
+		target := player.ID
+		if current.IsPlayer {
+			target = enemy.ID
+		}
+		resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: current.ID, Target: target, Weapon: current.Weapons[0].ID}, seed)
+		persistResolution(sessionID, state, resolution)
+		state = resolution.State
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/sessions/"+sessionID+"/export", nil))
+	if err != nil || resp.StatusCode != 200 {
+		t.Fatalf("Export failed: %v, %v", resp.StatusCode, err)
+	}
+	exported, _ := io.ReadAll(resp.Body)
+
+	var export SessionExport
+	json.Unmarshal(exported, &export)
+	if export.Session.Name != "Export me" || len(export.Snapshots) != 3 || len(export.Events) == 0 {
+		t.Fatalf("Unexpected export: session %+v, %d snapshots, %d events", export.Session, len(export.Snapshots), len(export.Events))
+	}
+
+	// Import into a fresh store
+	target := NewMemoryEventStore()
+	eventStore = target
+	status, body := postJSON(t, app, "/sessions/import", exported)
+	if status != 200 {
+		t.Fatalf("Import failed with %d: %s", status, body)
+	}
+	var imported struct {
+		SessionID string `json:"sessionId"`
+	}
+	json.Unmarshal([]byte(body), &imported)
+	if imported.SessionID != sessionID {
+		t.Errorf("Expected the original session ID to be kept, got %q", imported.SessionID)
+	}
+
+	want, _ := source.GetLatestSnapshot(sessionID)
+	got, _ := target.GetLatestSnapshot(imported.SessionID)
+	wantJSON, _ := json.Marshal(want)
+	gotJSON, _ := json.Marshal(got)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("Expected latest snapshot to match\ngot:  %s\nwant: %s", gotJSON, wantJSON)
+	}
+
+	sourceEvents, _ := source.GetEvents(sessionID, 0)
+	targetEvents, _ := target.GetEvents(imported.SessionID, 0)
+	if len(targetEvents) != len(sourceEvents) {
+		t.Errorf("Expected %d imported events, got %d", len(sourceEvents), len(targetEvents))
+	}
+
+	t.Run("ConflictGetsNewID", func(t *testing.T) {
+		status, body := postJSON(t, app, "/sessions/import", exported)
+		var again struct {
+			SessionID string `json:"sessionId"`
+		}
+		json.Unmarshal([]byte(body), &again)
+		if status != 200 || again.SessionID == "" || again.SessionID == sessionID {
+			t.Errorf("Expected import under a new ID, got %d: %s", status, body)
+		}
+	})
+
+	t.Run("MidRound", func(t *testing.T) {
+		// Take an action into round 3 so the state has moved on from its snapshot
+		eventStore = source
+		current := GetCurrentCharacter(state)
+		target := player.ID
+		if current.IsPlayer {
+			target = enemy.ID
+		}
+		resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: current.ID, Target: target, Weapon: current.Weapons[0].ID}, 99)
+		persistResolution(sessionID, state, resolution)
+		if resolution.State.Round != 3 || resolution.State.CurrentTurn == state.CurrentTurn {
+			t.Fatalf("Expected a mid-round state, got round %d turn %d", resolution.State.Round, resolution.State.CurrentTurn)
+		}
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/sessions/"+sessionID+"/export", nil))
+		if err != nil || resp.StatusCode != 200 {
+			t.Fatalf("Export failed: %v, %v", resp.StatusCode, err)
+		}
+		midRound, _ := io.ReadAll(resp.Body)
+
+		restored := NewMemoryEventStore()
+		eventStore = restored
+		stateManager = NewStateManager()
+		status, body := postJSON(t, app, "/sessions/import", midRound)
+		if status != 200 {
+			t.Fatalf("Import failed with %d: %s", status, body)
+		}
+
+		wantJSON, _ := json.Marshal(resolution.State)
+		got, _ := stateManager.GetState(sessionID)
+		gotJSON, _ := json.Marshal(got)
+		if string(gotJSON) != string(wantJSON) {
+			t.Errorf("Expected the imported session to resume mid-round\ngot:  %s\nwant: %s", gotJSON, wantJSON)
+		}
+		if latest, _ := restored.GetLatestSnapshot(sessionID); latest == nil || latest.CurrentTurn != resolution.State.CurrentTurn {
+			t.Errorf("Expected the current state to be saved as the latest snapshot, got %+v", latest)
+		}
+	})
+
+	t.Run("InvalidState", func(t *testing.T) {
+		bad := export
+		bad.Snapshots = []ExportedSnapshot{{Round: 1, State: State{Round: 1, Characters: state.Characters, TurnOrder: []ID{NewID()}}}}
+		data, _ := json.Marshal(bad)
+		if status, body := postJSON(t, app, "/sessions/import", data); status != 400 {
+			t.Errorf("Expected 400 for an invalid state, got %d: %s", status, body)
+		}
+	})
+
+	if resp, _ := app.Test(httptest.NewRequest("GET", "/sessions/missing/export", nil)); resp.StatusCode != 404 {
+		t.Errorf("Expected 404 exporting an unknown session, got %d", resp.StatusCode)
+	}
+}
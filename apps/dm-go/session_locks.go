@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"sync"
+)
+
+// SessionLocks serialises changes to each session, so two writers, such as a
+// player's action and an expiring turn timer, can't both read the same state
+// and save conflicting results. Each lock is dropped once nobody holds or
+// waits on it, so idle sessions leave nothing behind.
+type SessionLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sessionLock
+}
+
+type sessionLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// NewSessionLocks creates an empty set of session locks
+func NewSessionLocks() *SessionLocks {
+	return &SessionLocks{locks: make(map[string]*sessionLock)}
+}
+
+// Lock blocks until the caller holds the session's lock and returns the
+// function that releases it. Hold it from reading the session's state until
+// the new state is saved.
+func (sl *SessionLocks) Lock(sessionID string) func() {
+	// Route params point into reused request buffers
+	sessionID = strings.Clone(sessionID)
+
+	sl.mu.Lock()
+	lock, exists := sl.locks[sessionID]
+	if !exists {
+		lock = &sessionLock{}
+		sl.locks[sessionID] = lock
+	}
+	lock.refs++
+	sl.mu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+		sl.mu.Lock()
+		if lock.refs--; lock.refs == 0 {
+			delete(sl.locks, sessionID)
+		}
+		sl.mu.Unlock()
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSessionLocksSerialiseWriters(t *testing.T) {
+	locks := NewSessionLocks()
+	// Each session has its own counter; only writers to the same one contend
+	counts := map[string]*int{"a": new(int), "b": new(int)}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		for _, sessionID := range []string{"a", "b"} {
+			wg.Add(1)
+			go func(sessionID string) {
+				defer wg.Done()
+				unlock := locks.Lock(sessionID)
+				defer unlock()
+				// Read, wait, write: without the lock, writers overlap and lose updates
+				count := *counts[sessionID]
+				time.Sleep(time.Millisecond)
+				*counts[sessionID] = count + 1
+			}(sessionID)
+		}
+	}
+	wg.Wait()
+
+	if *counts["a"] != 20 || *counts["b"] != 20 {
+		t.Errorf("Expected every writer's update to land, got %d and %d", *counts["a"], *counts["b"])
+	}
+	if len(locks.locks) != 0 {
+		t.Errorf("Expected released locks to be dropped, got %d left", len(locks.locks))
+	}
+}
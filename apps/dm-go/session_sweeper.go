@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// defaultSweepInterval is how often the sweeper looks for idle sessions
+const defaultSweepInterval = time.Minute
+
+// SessionSweeper evicts sessions nobody has acted in for longer than a TTL,
+// so abandoned games don't hold memory forever
+type SessionSweeper struct {
+	states  *StateManager
+	ttl     time.Duration
+	persist bool // Save a final snapshot before evicting, so the session can be restored; off in demo mode
+}
+
+// NewSessionSweeper creates a sweeper for states. A zero TTL disables it.
+func NewSessionSweeper(states *StateManager, ttl time.Duration, persist bool) *SessionSweeper {
+	return &SessionSweeper{states: states, ttl: ttl, persist: persist}
+}
+
+// Sweep evicts every session idle for longer than the TTL and returns their IDs
+func (s *SessionSweeper) Sweep() []string {
+	if s.ttl <= 0 {
+		return nil
+	}
+	cutoff := s.states.now().Add(-s.ttl)
+
+	var evicted []string
+	for sessionID, state := range s.states.IdleStates(cutoff) {
+		logger := sessionLogger(sessionID)
+		if s.persist {
+			if err := eventStore.SaveSnapshot(sessionID, state.Round, state); err != nil {
+				// Keep the session in memory rather than lose its latest state
+				logger.Error("Failed to save snapshot before eviction", "error", err)
+				continue
+			}
+		}
+		if !s.states.EvictIfIdle(sessionID, cutoff) {
+			continue
+		}
+		turnTimers.Disable(sessionID)
+		narrationTrigger.Forget(sessionID)
+		logger.Info("Evicted idle session", "ttl", s.ttl)
+		evicted = append(evicted, sessionID)
+	}
+	return evicted
+}
+
+// startSessionSweeper runs a sweeper in the background when SESSION_TTL_MINUTES
+// is set, until ctx is cancelled
+func startSessionSweeper(ctx context.Context, states *StateManager, persist bool) {
+	ttl := time.Duration(getEnvInt("SESSION_TTL_MINUTES", 0)) * time.Minute
+	if ttl <= 0 {
+		return
+	}
+	interval := time.Duration(getEnvInt("SESSION_SWEEP_INTERVAL_SECONDS", int(defaultSweepInterval/time.Second))) * time.Second
+	go NewSessionSweeper(states, ttl, persist).Run(ctx, interval)
+	slog.Info("Evicting idle sessions", "ttl", ttl, "persist", persist)
+}
+
+// Run sweeps every interval until ctx is cancelled
+func (s *SessionSweeper) Run(ctx context.Context, interval time.Duration) {
+	if s.ttl <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Sweep()
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSweeperEvictsIdleSessions(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	stateManager.now = func() time.Time { return now }
+
+	hero := createTestCharacter(true, "Hero")
+	goblin := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+	state.TurnOrder = []ID{hero.ID, goblin.ID}
+	stateManager.SetState("idle", state)
+	stateManager.SetState("active", state)
+
+	sweeper := NewSessionSweeper(stateManager, 10*time.Minute, true)
+
+	// Keep one session busy while the other sits untouched
+	now = now.Add(6 * time.Minute)
+	resolution := ApplyAction(state, Action{Kind: "Defend", Actor: hero.ID}, 12345)
+	persistResolution("active", state, resolution)
+	now = now.Add(6 * time.Minute)
+
+	evicted := sweeper.Sweep()
+	if len(evicted) != 1 || evicted[0] != "idle" {
+		t.Fatalf("Expected only the idle session to be evicted, got %v", evicted)
+	}
+	if _, exists := stateManager.GetState("idle"); exists {
+		t.Error("Expected the idle session to be gone")
+	}
+	if _, exists := stateManager.GetState("active"); !exists {
+		t.Error("Expected the active session to be kept")
+	}
+	if snapshot, err := eventStore.GetLatestSnapshot("idle"); err != nil || snapshot == nil || len(snapshot.Characters) != 2 {
+		t.Errorf("Expected a final snapshot of the evicted session, got %v %v", snapshot, err)
+	}
+
+	now = now.Add(5 * time.Minute)
+	if evicted := sweeper.Sweep(); len(evicted) != 1 || evicted[0] != "active" {
+		t.Errorf("Expected the once-active session to be evicted after going idle, got %v", evicted)
+	}
+}
+
+func TestSweeperDisabledAndCancelable(t *testing.T) {
+	stateManager = NewStateManager()
+	stateManager.SetState("old", State{})
+	stateManager.now = func() time.Time { return time.Now().Add(time.Hour) }
+
+	if evicted := NewSessionSweeper(stateManager, 0, false).Sweep(); len(evicted) != 0 {
+		t.Errorf("Expected a zero TTL to disable sweeping, got %v", evicted)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewSessionSweeper(stateManager, time.Minute, false).Run(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	deadline := time.After(time.Second)
+	for stateManager.GetStateCount() > 0 {
+		select {
+		case <-deadline:
+			t.Fatal("Expected the running sweeper to evict the old session")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the sweeper to stop once cancelled")
+	}
+}
+
+func TestEvictedSessionsAreRestored(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewPersistentStateManager(eventStore)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	stateManager.now = func() time.Time { return now }
+
+	hero := createTestCharacter(true, "Hero")
+	goblin := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+	state.TurnOrder = []ID{hero.ID, goblin.ID}
+	stateManager.SetState("resting", state)
+
+	// The mid-round action is only saved by the sweeper's final snapshot
+	resolution := ApplyAction(state, Action{Kind: "Defend", Actor: hero.ID}, 12345)
+	persistResolution("resting", state, resolution)
+
+	now = now.Add(time.Hour)
+	if evicted := NewSessionSweeper(stateManager, time.Minute, true).Sweep(); len(evicted) != 1 {
+		t.Fatalf("Expected the session to be evicted, got %v", evicted)
+	}
+	if stateManager.GetStateCount() != 0 {
+		t.Fatal("Expected no sessions left in memory")
+	}
+
+	restored, exists := stateManager.GetState("resting")
+	if !exists || restored.CurrentTurn != resolution.State.CurrentTurn || GetCharacterByID(restored, hero.ID) == nil {
+		t.Fatalf("Expected the session to be restored as it was evicted, got %v %+v", exists, restored)
+	}
+	if stateManager.GetStateCount() != 1 {
+		t.Error("Expected the restored session to be held in memory again")
+	}
+	if _, exists := stateManager.GetState("never-created"); exists {
+		t.Error("Expected a session without snapshots to stay missing")
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Round modes
+const (
+	roundSequential   = "sequential"   // Each action resolves on its turn
+	roundSimultaneous = "simultaneous" // Actions are declared in turn and resolve together as the round ends
+)
+
+// Declaration is an action declared in a simultaneous round, with the seed it
+// will be resolved with
+type Declaration struct {
+	Action Action `json:"action"`
+	Seed   int64  `json:"seed"`
+}
+
+// roundModeKnown reports whether a round mode is one the engine plays
+func roundModeKnown(mode string) bool {
+	return mode == "" || mode == roundSequential || mode == roundSimultaneous
+}
+
+// simultaneousRounds reports whether the session resolves rounds simultaneously
+func simultaneousRounds(rules HouseRules) bool {
+	return rules.RoundMode == roundSimultaneous
+}
+
+// declaredInAdvance reports whether an action kind is declared rather than
+// resolved on the spot in simultaneous rounds. Fleeing, conceding, delaying
+// and picking up loot still happen at once.
+func declaredInAdvance(kind string) bool {
+	switch kind {
+	case "Attack", "Defend", "Ability", "UseItem":
+		return true
+	}
+	return false
+}
+
+// declareAction records the current character's action for the end of the
+// round and passes the turn on. The action is checked against the state now,
+// so one that could never resolve is refused rather than fizzling later.
+func declareAction(state *State, action Action, seed int64, events []Event, logs []string) Resolution {
+	actor := GetCharacterByID(*state, getActorID(action))
+	if turnActor(*state) != actor.ID {
+		return rejectAction(*state, events, logs, RejectNotYourTurn, fmt.Sprintf("%s can only declare an action on their turn", actor.Name))
+	}
+
+	trial := deepCopyState(*state)
+	trial.resolving = true
+	if check := dispatchAction(&trial, action, actionRNG(state.Rules, seed), events, logs); check.RejectReason != "" {
+		check.State = *state
+		return check
+	}
+
+	state.Declared = append(state.Declared, Declaration{Action: action, Seed: seed})
+	events = append(events, Event{Type: "action_declared", Actor: actor.ID})
+	logs = append(logs, fmt.Sprintf("%s declares %s.", actor.Name, declaredVerb(action.Kind)))
+
+	updatedState, turnEvents, turnLogs := advanceTurn(*state)
+	return Resolution{Events: append(events, turnEvents...), State: updatedState, Logs: append(logs, turnLogs...)}
+}
+
+// declaredVerb describes a declared action without giving away its target
+func declaredVerb(kind string) string {
+	switch kind {
+	case "Attack":
+		return "an attack"
+	case "Defend":
+		return "a defense"
+	case "Ability":
+		return "an ability"
+	case "UseItem":
+		return "an item"
+	}
+	return strings.ToLower(kind)
+}
+
+// resolveDeclaredRound resolves every action declared this round against the
+// state as it stood when the round ended, so nobody's action is cut short by
+// an earlier one: a character defeated this round still gets their action,
+// and two characters can defeat each other. Each action is resolved on its
+// own copy of that state; HP and threat changes are added up, other changes
+// to a character (cooldowns, items, status effects) are taken from the
+// action that made them, later ones winning, and knockouts are applied once
+// everything has landed.
+func resolveDeclaredRound(state *State) ([]Event, []string) {
+	declared := state.Declared
+	state.Declared = nil
+	start := deepCopyState(*state)
+
+	var events []Event
+	logs := []string{"All declared actions resolve at once!"}
+	hpChange := make(map[ID]int)
+	for _, declaration := range declared {
+		trial := deepCopyState(start)
+		trial.resolving = true
+		resolution := dispatchAction(&trial, declaration.Action, actionRNG(start.Rules, declaration.Seed), nil, nil)
+		if resolution.RejectReason != "" {
+			// Declared actions were valid when declared; anything refused now,
+			// like a target who fled, just fizzles
+			logs = append(logs, resolution.Logs...)
+			continue
+		}
+		events, logs = append(events, resolution.Events...), append(logs, resolution.Logs...)
+
+		for i := range start.Characters {
+			before := &start.Characters[i]
+			after := GetCharacterByID(resolution.State, before.ID)
+			merged := GetCharacterByID(*state, before.ID)
+			if after == nil || merged == nil {
+				continue
+			}
+			hpChange[before.ID] += after.Stats.HP - before.Stats.HP
+			mergeCharacterChanges(merged, before, after)
+		}
+		for id, threat := range resolution.State.Threat {
+			if delta := threat - start.Threat[id]; delta != 0 {
+				addThreat(state, id, delta)
+			}
+		}
+	}
+
+	for i := range state.Characters {
+		char := &state.Characters[i]
+		if hpChange[char.ID] == 0 {
+			continue
+		}
+		startHP := char.Stats.HP
+		char.Stats.HP = min(max(startHP+hpChange[char.ID], 0), char.Stats.MaxHP)
+		if char.Stats.HP > 0 {
+			char.Downed = nil
+		}
+		if startHP > 0 && char.Stats.HP == 0 {
+			koEvents, koLogs := knockOut(state, char)
+			events, logs = append(events, koEvents...), append(logs, koLogs...)
+		}
+	}
+	return events, logs
+}
+
+// mergeCharacterChanges copies into merged whatever one declared action
+// changed about a character, apart from HP, which is added up separately.
+// Other stat changes, like a defensive stance's bonus, are added on.
+func mergeCharacterChanges(merged, before, after *Character) {
+	merged.Stats.MaxHP += after.Stats.MaxHP - before.Stats.MaxHP
+	merged.Stats.Attack += after.Stats.Attack - before.Stats.Attack
+	merged.Stats.Defense += after.Stats.Defense - before.Stats.Defense
+	merged.Stats.Speed += after.Stats.Speed - before.Stats.Speed
+	if !reflect.DeepEqual(after.AbilityCooldowns, before.AbilityCooldowns) {
+		merged.AbilityCooldowns = after.AbilityCooldowns
+	}
+	if !reflect.DeepEqual(after.StatusEffects, before.StatusEffects) {
+		merged.StatusEffects = after.StatusEffects
+	}
+	if !reflect.DeepEqual(after.Items, before.Items) {
+		merged.Items = after.Items
+	}
+	if !reflect.DeepEqual(after.Weapons, before.Weapons) {
+		merged.Weapons = after.Weapons
+	}
+	if after.ReactionsUsed != before.ReactionsUsed {
+		merged.ReactionsUsed = after.ReactionsUsed
+	}
+}
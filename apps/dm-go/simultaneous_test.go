@@ -0,0 +1,109 @@
+package main
+
+import "testing"
+
+// lethalExchange has a hero and a goblin who each defeat the other with one hit
+func lethalExchange(mode string) (State, Character, Character) {
+	hero := createTestCharacter(true, "Hero")
+	goblin := createTestCharacter(false, "Goblin")
+	for _, char := range []*Character{&hero, &goblin} {
+		char.Stats.HP, char.Stats.Attack = 5, 100
+	}
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+	state.TurnOrder = []ID{hero.ID, goblin.ID}
+	state.Rules.RoundMode = mode
+	return state, hero, goblin
+}
+
+func TestSequentialLethalExchange(t *testing.T) {
+	state, hero, goblin := lethalExchange("")
+
+	resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: hero.ID, Target: goblin.ID}, 1)
+	if !resolution.State.IsComplete || *resolution.State.Winner != "player" {
+		t.Fatalf("Expected the faster hero to win before the goblin acts, got winner %v", resolution.State.Winner)
+	}
+	if GetCharacterByID(resolution.State, hero.ID).Stats.HP != 5 {
+		t.Error("Expected the goblin never to strike back")
+	}
+}
+
+func TestSimultaneousLethalExchange(t *testing.T) {
+	state, hero, goblin := lethalExchange(roundSimultaneous)
+
+	// Declaring takes the turn but changes nothing yet
+	declared := ApplyAction(state, Action{Kind: "Attack", Attacker: hero.ID, Target: goblin.ID}, 1)
+	if declared.RejectReason != "" || len(declared.State.Declared) != 1 {
+		t.Fatalf("Expected the hero's attack to be declared, got %+v", declared)
+	}
+	if GetCharacterByID(declared.State, goblin.ID).Stats.HP != 5 || turnActor(declared.State) != goblin.ID {
+		t.Fatalf("Expected the goblin unhurt and up next, got %+v on turn %d", GetCharacterByID(declared.State, goblin.ID).Stats, declared.State.CurrentTurn)
+	}
+	if !hasEvent(declared.Events, "action_declared", "") {
+		t.Errorf("Expected an action declared event, got %+v", declared.Events)
+	}
+
+	// The goblin can still answer, and both attacks land together
+	resolution := ApplyAction(declared.State, Action{Kind: "Attack", Attacker: goblin.ID, Target: hero.ID}, 2)
+	final := resolution.State
+	if !final.IsComplete || final.Winner == nil || *final.Winner != "draw" {
+		t.Fatalf("Expected a draw, got complete=%v winner %v", final.IsComplete, final.Winner)
+	}
+	if GetCharacterByID(final, hero.ID).Stats.HP != 0 || GetCharacterByID(final, goblin.ID).Stats.HP != 0 {
+		t.Errorf("Expected both to fall, got %+v and %+v", GetCharacterByID(final, hero.ID).Stats, GetCharacterByID(final, goblin.ID).Stats)
+	}
+	if !hasEvent(resolution.Events, "death", hero.ID) || !hasEvent(resolution.Events, "death", goblin.ID) {
+		t.Errorf("Expected a death event for each, got %+v", resolution.Events)
+	}
+	if len(final.Declared) != 0 {
+		t.Errorf("Expected the declarations to be used up, got %+v", final.Declared)
+	}
+}
+
+func TestSimultaneousDeclarationsAreChecked(t *testing.T) {
+	state, hero, goblin := lethalExchange(roundSimultaneous)
+	goblin.Stats.HP, goblin.Stats.MaxHP = 200, 200
+	state.Characters[1] = goblin
+
+	if resolution := ApplyAction(state, Action{Kind: "Attack", Attacker: goblin.ID, Target: hero.ID}, 1); resolution.RejectReason != RejectNotYourTurn {
+		t.Errorf("Expected declaring out of turn to be refused, got %q", resolution.RejectReason)
+	}
+	if resolution := ApplyAction(state, Action{Kind: "Ability", Actor: hero.ID, Ability: "nope", Target: goblin.ID}, 1); resolution.RejectReason != RejectAbilityNotFound || len(resolution.State.Declared) != 0 {
+		t.Errorf("Expected an invalid declaration to be refused up front, got %q", resolution.RejectReason)
+	}
+
+	// Damage that doesn't finish anyone carries over into the next round
+	declared := ApplyAction(state, Action{Kind: "Attack", Attacker: hero.ID, Target: goblin.ID}, 1)
+	resolution := ApplyAction(declared.State, Action{Kind: "Defend", Actor: goblin.ID}, 2)
+	final := resolution.State
+	if final.IsComplete || final.Round != 2 || turnActor(final) != hero.ID {
+		t.Fatalf("Expected round 2 to start with the hero, got round %d turn %d complete=%v", final.Round, final.CurrentTurn, final.IsComplete)
+	}
+	if hp := GetCharacterByID(final, goblin.ID).Stats.HP; hp >= 200 {
+		t.Errorf("Expected the hero's attack to land at the end of the round, goblin has %d HP", hp)
+	}
+}
+
+func TestSimultaneousDefendExpires(t *testing.T) {
+	state, hero, goblin := lethalExchange(roundSimultaneous)
+	for i := range state.Characters {
+		state.Characters[i].Stats.HP, state.Characters[i].Stats.MaxHP = 200, 200
+		state.Characters[i].Stats.Attack = 1
+	}
+	base := hero.Stats.Defense
+
+	declared := ApplyAction(state, Action{Kind: "Defend", Actor: hero.ID}, 1)
+	state = ApplyAction(declared.State, Action{Kind: "Attack", Attacker: goblin.ID, Target: hero.ID}, 2).State
+	if defense := GetCharacterByID(state, hero.ID).Stats.Defense; defense != base+defendBonus {
+		t.Fatalf("Expected the declared stance to raise defense to %d, got %d", base+defendBonus, defense)
+	}
+
+	// Once the stance wears off, defense is back where it started
+	for seed := int64(3); seed < 3+2*defendDuration+2; seed += 2 {
+		declared := ApplyAction(state, Action{Kind: "Attack", Attacker: hero.ID, Target: goblin.ID}, seed)
+		state = ApplyAction(declared.State, Action{Kind: "Attack", Attacker: goblin.ID, Target: hero.ID}, seed+1).State
+	}
+	final := GetCharacterByID(state, hero.ID)
+	if len(final.StatusEffects) != 0 || final.Stats.Defense != base {
+		t.Errorf("Expected defense back at %d with no stance, got %d with %+v", base, final.Stats.Defense, final.StatusEffects)
+	}
+}
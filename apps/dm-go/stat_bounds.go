@@ -0,0 +1,80 @@
+package main
+
+import "fmt"
+
+// Default stat caps, far above anything the bundled scenarios use but low
+// enough that damage arithmetic stays sane
+const (
+	defaultMaxHPBound   = 10000
+	defaultMaxStatBound = 1000
+)
+
+// StatBounds are the highest values each character stat may take. A bound of
+// 0 leaves that stat uncapped.
+type StatBounds struct {
+	MaxHP   int `json:"maxHp"`
+	Attack  int `json:"attack"`
+	Defense int `json:"defense"`
+	Speed   int `json:"speed"`
+}
+
+// statBounds are the caps enforced on states, scenarios and GM changes,
+// configured with the STAT_MAX_* environment variables
+var statBounds = StatBounds{
+	MaxHP:   defaultMaxHPBound,
+	Attack:  defaultMaxStatBound,
+	Defense: defaultMaxStatBound,
+	Speed:   defaultMaxStatBound,
+}
+
+// boundedStat is one stat's value paired with its cap
+type boundedStat struct {
+	name  string
+	value *int
+	bound int
+}
+
+// fields lists the stats with their caps. HP shares the max HP cap.
+func (b StatBounds) fields(stats *Stat) []boundedStat {
+	return []boundedStat{
+		{"max HP", &stats.MaxHP, b.MaxHP},
+		{"HP", &stats.HP, b.MaxHP},
+		{"attack", &stats.Attack, b.Attack},
+		{"defense", &stats.Defense, b.Defense},
+		{"speed", &stats.Speed, b.Speed},
+	}
+}
+
+// Check returns an error naming the first of a character's stats over its cap
+func (b StatBounds) Check(name string, stats Stat) error {
+	for _, field := range b.fields(&stats) {
+		if field.bound > 0 && *field.value > field.bound {
+			return fmt.Errorf("%s's %s of %d is over the limit of %d", name, field.name, *field.value, field.bound)
+		}
+	}
+	return nil
+}
+
+// Clamp lowers any stats over their caps and describes each one it lowered
+func (b StatBounds) Clamp(name string, stats *Stat) []string {
+	var clamped []string
+	for _, field := range b.fields(stats) {
+		if field.bound > 0 && *field.value > field.bound {
+			clamped = append(clamped, fmt.Sprintf("%s's %s of %d was lowered to the limit of %d.", name, field.name, *field.value, field.bound))
+			*field.value = field.bound
+		}
+	}
+	return clamped
+}
+
+// baseStats are a character's stats without the defense their defensive
+// stances add, so a character at the cap can still defend
+func baseStats(char Character) Stat {
+	stats := char.Stats
+	for _, effect := range char.StatusEffects {
+		if effect.Type == "defend" {
+			stats.Defense -= effect.Amount
+		}
+	}
+	return stats
+}
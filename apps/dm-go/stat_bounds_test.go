@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestImportRejectsStatsOverBounds(t *testing.T) {
+	previous := statBounds
+	defer func() { statBounds = previous }()
+
+	hero := createTestCharacter(true, "Hero")
+	hero.Stats.Attack = 500
+	goblin := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+	export, _ := json.Marshal(SessionExport{
+		Session:   Session{ID: "bounded", Name: "Bounded"},
+		Snapshots: []ExportedSnapshot{{Round: 1, State: state}},
+	})
+
+	for _, tc := range []struct {
+		bounds StatBounds
+		status int
+	}{
+		{StatBounds{Attack: 100}, 400},
+		{StatBounds{Attack: 500}, 200},
+		{StatBounds{}, 200}, // Uncapped
+	} {
+		eventStore = NewMemoryEventStore()
+		stateManager = NewStateManager()
+		sessionAuth = NewSessionAuth()
+		statBounds = tc.bounds
+		app := fiber.New()
+		setupRoutes(app)
+
+		status, body := postJSON(t, app, "/sessions/import", export)
+		if status != tc.status {
+			t.Errorf("Attack bound %d: expected %d, got %d: %s", tc.bounds.Attack, tc.status, status, body)
+		}
+		if status == 400 && !strings.Contains(body, "Hero's attack of 500 is over the limit of 100") {
+			t.Errorf("Expected the rejection to name the stat and limit, got %s", body)
+		}
+	}
+}
+
+func TestGMSetStatsClampsToBounds(t *testing.T) {
+	previous := statBounds
+	defer func() { statBounds = previous }()
+
+	for _, bounds := range []StatBounds{
+		{MaxHP: 50, Attack: 20, Defense: 10, Speed: 5},
+		{MaxHP: 200, Attack: 80, Defense: 40, Speed: 15},
+	} {
+		eventStore = NewMemoryEventStore()
+		stateManager = NewStateManager()
+		sessionAuth = NewSessionAuth()
+		statBounds = bounds
+		app := fiber.New()
+		setupRoutes(app)
+
+		hero := createTestCharacter(true, "Hero")
+		goblin := createTestCharacter(false, "Goblin")
+		state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+		stateManager.SetState("gm-bounds", state)
+		_, dmToken := issueSessionTokens("gm-bounds", state)
+
+		data, _ := json.Marshal(fiber.Map{"target": "Hero", "stats": fiber.Map{"maxHp": 10000, "attack": 10000, "defense": 10000, "speed": 10000}})
+		req := httptest.NewRequest("POST", "/sessions/gm-bounds/gm/set-stats", bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(playerTokenHeader, dmToken)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		respBody, _ := io.ReadAll(resp.Body)
+		status, body := resp.StatusCode, string(respBody)
+		if status != 200 {
+			t.Fatalf("Bounds %+v: expected the stats to be set, got %d: %s", bounds, status, body)
+		}
+
+		live, _ := stateManager.GetState("gm-bounds")
+		got := GetCharacterByID(live, hero.ID).Stats
+		want := Stat{HP: 30, MaxHP: bounds.MaxHP, Attack: bounds.Attack, Defense: bounds.Defense, Speed: bounds.Speed}
+		if got != want {
+			t.Errorf("Bounds %+v: expected stats clamped to %+v, got %+v", bounds, want, got)
+		}
+		if want := fmt.Sprintf("Hero's attack of 10000 was lowered to the limit of %d.", bounds.Attack); !strings.Contains(body, want) {
+			t.Errorf("Expected the clamp to be logged as %q, got %s", want, body)
+		}
+		if err := ValidateState(live); err != nil {
+			t.Errorf("Expected the clamped state to be valid, got %v", err)
+		}
+	}
+}
@@ -2,34 +2,106 @@ package main
 
 import (
 	"sync"
+	"time"
 )
 
+// maxStoryChars bounds the running narration kept per session so it fits in prompts
+const maxStoryChars = 2000
+
+// maxUndoHistory bounds how many actions can be undone per session
+const maxUndoHistory = 20
+
+// undoEntry is the state before an applied action and how many events it recorded
+type undoEntry struct {
+	state  State
+	events int
+}
+
 // StateManager provides thread-safe access to game states
 type StateManager struct {
-	mu     sync.RWMutex
-	states map[string]State
+	mu      sync.RWMutex
+	states  map[string]State
+	stories map[string][]string // Narration so far, oldest first
+	undo    map[string][]undoEntry
+
+	lastActive map[string]time.Time // When each session last changed
+	now        func() time.Time
+
+	snapshots SnapshotLoader // Restores sessions missing from memory; nil for none
+}
+
+// SnapshotLoader loads a session's latest saved state, or nil if it has none
+type SnapshotLoader interface {
+	GetLatestSnapshot(sessionID string) (*State, error)
 }
 
 // NewStateManager creates a new state manager
 func NewStateManager() *StateManager {
 	return &StateManager{
-		states: make(map[string]State),
+		states:  make(map[string]State),
+		stories: make(map[string][]string),
+		undo:    make(map[string][]undoEntry),
+
+		lastActive: make(map[string]time.Time),
+		now:        time.Now,
 	}
 }
 
+// NewPersistentStateManager creates a state manager that reloads sessions it
+// doesn't hold, such as ones the sweeper evicted, from their latest snapshot
+func NewPersistentStateManager(snapshots SnapshotLoader) *StateManager {
+	sm := NewStateManager()
+	sm.snapshots = snapshots
+	return sm
+}
+
 // GetState retrieves a state by session ID
 func (sm *StateManager) GetState(sessionID string) (State, bool) {
 	sm.mu.RLock()
-	defer sm.mu.RUnlock()
 	state, exists := sm.states[sessionID]
-	return state, exists
+	sm.mu.RUnlock()
+	if exists || sm.snapshots == nil {
+		return state, exists
+	}
+	return sm.restore(sessionID)
+}
+
+// restore loads a session that isn't in memory from its latest snapshot
+func (sm *StateManager) restore(sessionID string) (State, bool) {
+	snapshot, err := sm.snapshots.GetLatestSnapshot(sessionID)
+	if err != nil {
+		sessionLogger(sessionID).Error("Failed to restore session", "error", err)
+		return State{}, false
+	}
+	if snapshot == nil {
+		return State{}, false
+	}
+	state := *snapshot
+	reindexCharacters(&state)
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	// Another request may have restored or replaced it in the meantime
+	if current, exists := sm.states[sessionID]; exists {
+		return current, true
+	}
+	sm.states[sessionID] = state
+	sm.lastActive[sessionID] = sm.now()
+	sessionLogger(sessionID).Info("Restored session from snapshot", "round", state.Round)
+	return state, true
 }
 
-// SetState sets a state for a session ID
+// SetState sets a state for a session ID, indexing its characters if the
+// state came from JSON
 func (sm *StateManager) SetState(sessionID string, state State) {
+	if state.index == nil {
+		reindexCharacters(&state)
+	}
+
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 	sm.states[sessionID] = state
+	sm.lastActive[sessionID] = sm.now()
 }
 
 // DeleteState removes a state by session ID
@@ -37,6 +109,111 @@ func (sm *StateManager) DeleteState(sessionID string) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 	delete(sm.states, sessionID)
+	delete(sm.stories, sessionID)
+	delete(sm.undo, sessionID)
+	delete(sm.lastActive, sessionID)
+}
+
+// IdleStates returns the sessions that haven't changed since before cutoff
+func (sm *StateManager) IdleStates(cutoff time.Time) map[string]State {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	idle := make(map[string]State)
+	for sessionID, state := range sm.states {
+		if sm.lastActive[sessionID].Before(cutoff) {
+			idle[sessionID] = state
+		}
+	}
+	return idle
+}
+
+// EvictIfIdle removes a session unless it has changed since cutoff, so a
+// session that picks up an action while being swept is kept
+func (sm *StateManager) EvictIfIdle(sessionID string, cutoff time.Time) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if _, exists := sm.states[sessionID]; !exists || !sm.lastActive[sessionID].Before(cutoff) {
+		return false
+	}
+	delete(sm.states, sessionID)
+	delete(sm.stories, sessionID)
+	delete(sm.undo, sessionID)
+	delete(sm.lastActive, sessionID)
+	return true
+}
+
+// AppendStory adds a narration to the session's story, dropping the oldest
+// entries once the story grows past maxStoryChars
+func (sm *StateManager) AppendStory(sessionID, narration string) {
+	if len(narration) > maxStoryChars {
+		narration = narration[len(narration)-maxStoryChars:]
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	story := append(sm.stories[sessionID], narration)
+
+	total := 0
+	for _, entry := range story {
+		total += len(entry)
+	}
+	for total > maxStoryChars {
+		total -= len(story[0])
+		story = story[1:]
+	}
+	sm.stories[sessionID] = story
+}
+
+// GetStory returns a copy of the session's story so far
+func (sm *StateManager) GetStory(sessionID string) []string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return append([]string(nil), sm.stories[sessionID]...)
+}
+
+// PushUndo records the state before an action so it can be undone, dropping
+// the oldest entry past maxUndoHistory
+func (sm *StateManager) PushUndo(sessionID string, prev State, eventCount int) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	history := append(sm.undo[sessionID], undoEntry{state: prev, events: eventCount})
+	if len(history) > maxUndoHistory {
+		history = history[len(history)-maxUndoHistory:]
+	}
+	sm.undo[sessionID] = history
+}
+
+// PeekUndo returns the state before the last action without removing it
+func (sm *StateManager) PeekUndo(sessionID string) (State, bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	history := sm.undo[sessionID]
+	if len(history) == 0 {
+		return State{}, false
+	}
+	return history[len(history)-1].state, true
+}
+
+// PopUndo removes the most recent undo entry, returning the state before the
+// last action and the number of events that action recorded
+func (sm *StateManager) PopUndo(sessionID string) (State, int, bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	history := sm.undo[sessionID]
+	if len(history) == 0 {
+		return State{}, 0, false
+	}
+	last := history[len(history)-1]
+	sm.undo[sessionID] = history[:len(history)-1]
+	return last.state, last.events, true
+}
+
+// ClearUndo drops a session's undo history, e.g. once its state is replaced
+// outright and earlier actions no longer lead to it
+func (sm *StateManager) ClearUndo(sessionID string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	delete(sm.undo, sessionID)
 }
 
 // GetAllStates returns a copy of all states
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 )
@@ -110,3 +111,49 @@ func TestStateManager_GetAllStates(t *testing.T) {
 		}
 	}
 }
+
+func TestStateManager_Story(t *testing.T) {
+	sm := NewStateManager()
+	sessionID := "story-session"
+
+	sm.AppendStory(sessionID, "The goblin falls to Aria's blade.")
+	sm.AppendStory(sessionID, "Borin shrugs off an arrow.")
+
+	story := sm.GetStory(sessionID)
+	if len(story) != 2 || story[0] != "The goblin falls to Aria's blade." {
+		t.Fatalf("Expected story to grow in order, got %v", story)
+	}
+
+	// The story so far is fed into the next narration prompt
+	stateManager = sm
+	data := NewPromptData(State{Round: 2}, []string{"Borin attacks"}, "")
+	data.Story = sessionStory(sessionID)
+	_, user, err := NewPromptLibrary().Render(defaultPromptStyle, data)
+	if err != nil {
+		t.Fatalf("Failed to render prompt: %v", err)
+	}
+	if !strings.Contains(user, "Story so far:\nThe goblin falls to Aria's blade.\nBorin shrugs off an arrow.") {
+		t.Errorf("Expected prompt to include the story so far, got %q", user)
+	}
+
+	// The story is bounded, keeping the most recent narration
+	for i := 0; i < 100; i++ {
+		sm.AppendStory(sessionID, fmt.Sprintf("Narration %d: %s", i, strings.Repeat("x", 50)))
+	}
+	story = sm.GetStory(sessionID)
+	total := 0
+	for _, entry := range story {
+		total += len(entry)
+	}
+	if total > maxStoryChars {
+		t.Errorf("Expected story to be bounded to %d chars, got %d", maxStoryChars, total)
+	}
+	if !strings.HasPrefix(story[len(story)-1], "Narration 99:") {
+		t.Errorf("Expected latest narration to be kept, got %q", story[len(story)-1])
+	}
+
+	sm.DeleteState(sessionID)
+	if len(sm.GetStory(sessionID)) != 0 {
+		t.Error("Expected story to be removed with the session")
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Defaults for regen effects that don't spell out their strength
+const (
+	defaultRegenAmount   = 3
+	defaultRegenDuration = 3
+)
+
+// statusEffectTypes are the status effects scenarios may start characters with.
+// Defend effects are only created by handleDefend, which applies their bonus.
+var statusEffectTypes = map[string]bool{"regen": true, "poison": true}
+
+// parseRegenEffect parses effects such as "regen" or "regen 5 HP for 3 turns".
+// Missing values are left at zero for the caller to fill in.
+func parseRegenEffect(effect string) (StatusEffect, bool) {
+	effect = strings.ToLower(strings.TrimSpace(effect))
+	if !strings.HasPrefix(effect, "regen") {
+		return StatusEffect{}, false
+	}
+
+	regen := StatusEffect{Type: "regen"}
+	fmt.Sscanf(effect, "regen %d hp for %d turns", &regen.Amount, &regen.Duration)
+	return regen, true
+}
+
+// applyStatusEffect adds an effect to the character, refreshing any existing
+// effect of the same type rather than stacking it
+func applyStatusEffect(char *Character, effect StatusEffect) {
+	for i := range char.StatusEffects {
+		if char.StatusEffects[i].Type == effect.Type {
+			char.StatusEffects[i] = effect
+			return
+		}
+	}
+	char.StatusEffects = append(char.StatusEffects, effect)
+}
+
+// tickStatusEffects applies the character's effects at the start of their turn
+// and drops any that have expired
+func tickStatusEffects(char *Character) ([]Event, []string) {
+	var events []Event
+	var logs []string
+
+	remaining := char.StatusEffects[:0]
+	for _, effect := range char.StatusEffects {
+		switch effect.Type {
+		case "regen":
+			if healed := char.ApplyHeal(effect.Amount); healed > 0 {
+				events = append(events, Event{
+					Type:           "heal",
+					Target:         char.ID,
+					Amount:         healed,
+					TargetPosition: positionOf(char),
+					Effect:         "regen",
+				})
+				logs = append(logs, fmt.Sprintf("%s regenerates %d HP!", char.Name, healed))
+			}
+		case "poison":
+			// Poison wears a character down but never finishes them off
+			damage := clampHPChange(effect.Amount)
+			if damage > char.Stats.HP-1 {
+				damage = char.Stats.HP - 1
+			}
+			if damage > 0 {
+				char.ApplyDamage(damage)
+				events = append(events, Event{
+					Type:           "damage",
+					Target:         char.ID,
+					Amount:         damage,
+					TargetPosition: positionOf(char),
+					Effect:         "poison",
+				})
+				logs = append(logs, fmt.Sprintf("%s takes %d poison damage!", char.Name, damage))
+			}
+		}
+
+		effect.Duration--
+		if effect.Duration > 0 {
+			remaining = append(remaining, effect)
+		} else if effect.Type == "defend" {
+			// A defensive stance gives back the defense it added
+			char.Stats.Defense -= effect.Amount
+			if char.Stats.Defense < 0 {
+				char.Stats.Defense = 0
+			}
+		}
+	}
+
+	if len(remaining) == 0 {
+		remaining = nil
+	}
+	char.StatusEffects = remaining
+	return events, logs
+}
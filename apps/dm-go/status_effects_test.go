@@ -0,0 +1,80 @@
+package main
+
+import "testing"
+
+func TestRegenHealsEachTurnUntilExpiry(t *testing.T) {
+	player := createTestCharacter(true, "Player")
+	enemy := createTestCharacter(false, "Enemy")
+	player.Stats.HP = 10
+	player.Items = []Item{{ID: NewID(), Name: "Troll Salve", Type: "consumable", Effect: "regen 4 HP for 3 turns"}}
+
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+	state.TurnOrder = []ID{player.ID, enemy.ID}
+
+	resolution := handleUseItem(&state, Action{Kind: "UseItem", Actor: player.ID, Item: player.Items[0].ID}, NewSeededRNG(12345), nil, nil)
+	state = resolution.State
+
+	heals := 0
+	for i := 0; i < 10; i++ {
+		var events []Event
+		state, events, _ = advanceTurn(state)
+		for _, event := range events {
+			if event.Type == "heal" && event.Target == player.ID {
+				heals++
+				if event.Amount != 4 {
+					t.Errorf("Expected regen tick of 4, got %d", event.Amount)
+				}
+			}
+		}
+	}
+
+	if heals != 3 {
+		t.Errorf("Expected 3 regen ticks, got %d", heals)
+	}
+	if hp := GetCharacterByID(state, player.ID).Stats.HP; hp != 22 {
+		t.Errorf("Expected HP 22 after regen, got %d", hp)
+	}
+	if effects := GetCharacterByID(state, player.ID).StatusEffects; len(effects) != 0 {
+		t.Errorf("Expected regen to expire, got %+v", effects)
+	}
+}
+
+func TestRegenDoesNotOverheal(t *testing.T) {
+	player := createTestCharacter(true, "Player")
+	player.Stats.HP = player.Stats.MaxHP - 2
+	player.StatusEffects = []StatusEffect{{Type: "regen", Amount: 5, Duration: 2}}
+
+	events, _ := tickStatusEffects(&player)
+
+	if player.Stats.HP != player.Stats.MaxHP {
+		t.Errorf("Expected HP clamped to %d, got %d", player.Stats.MaxHP, player.Stats.HP)
+	}
+	if len(events) != 1 || events[0].Amount != 2 {
+		t.Errorf("Expected a single heal event for 2 HP, got %+v", events)
+	}
+
+	// At full health the effect still counts down but heals nothing
+	events, _ = tickStatusEffects(&player)
+	if len(events) != 0 || player.Stats.HP != player.Stats.MaxHP {
+		t.Errorf("Expected no healing at full HP, got %+v (HP %d)", events, player.Stats.HP)
+	}
+	if len(player.StatusEffects) != 0 {
+		t.Errorf("Expected regen to expire, got %+v", player.StatusEffects)
+	}
+}
+
+func TestPoisonNeverKills(t *testing.T) {
+	player := createTestCharacter(true, "Player")
+	player.Stats.HP = 4
+	player.StatusEffects = []StatusEffect{{Type: "poison", Amount: 3, Duration: 3}}
+
+	tickStatusEffects(&player)
+	if player.Stats.HP != 1 {
+		t.Errorf("Expected poison to deal 3 damage, got HP %d", player.Stats.HP)
+	}
+
+	events, _ := tickStatusEffects(&player)
+	if player.Stats.HP != 1 || len(events) != 0 {
+		t.Errorf("Expected poison to stop at 1 HP, got HP %d and events %+v", player.Stats.HP, events)
+	}
+}
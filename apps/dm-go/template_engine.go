@@ -4,21 +4,78 @@ import (
 	"bytes"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
+	"io/fs"
+	"log/slog"
+	"os"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 //go:embed templates/*.html
 var templatesFS embed.FS
 
+// errTemplatesUnavailable is returned when rendering without a template
+// engine, which happens when the templates failed to load at startup
+var errTemplatesUnavailable = errors.New("templates are unavailable")
+
 // TemplateEngine handles HTML template rendering
 type TemplateEngine struct {
 	templates *template.Template
+	fsys      fs.FS
+	reload    bool // Re-parse templates on every render (development mode)
 }
 
-// NewTemplateEngine creates a new template engine
+// NewTemplateEngine creates a new template engine from the embedded templates
 func NewTemplateEngine() (*TemplateEngine, error) {
+	fsys, err := fs.Sub(templatesFS, "templates")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedded templates: %w", err)
+	}
+	return newTemplateEngine(fsys, false)
+}
+
+// NewDevTemplateEngine creates a template engine that re-reads templates from
+// dir on every render, so template edits show up without rebuilding
+func NewDevTemplateEngine(dir string) (*TemplateEngine, error) {
+	return newTemplateEngine(os.DirFS(dir), true)
+}
+
+// newConfiguredTemplateEngine uses live templates from TEMPLATE_DIR when
+// TEMPLATE_DEV_MODE is set, and the embedded templates otherwise
+func newConfiguredTemplateEngine() (*TemplateEngine, error) {
+	if getEnvBool("TEMPLATE_DEV_MODE", false) {
+		dir := getEnv("TEMPLATE_DIR", "./templates")
+		slog.Info("Template hot reload enabled", "dir", dir)
+		return NewDevTemplateEngine(dir)
+	}
+	return NewTemplateEngine()
+}
+
+func newTemplateEngine(fsys fs.FS, reload bool) (*TemplateEngine, error) {
+	tmpl, err := parseTemplates(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &TemplateEngine{templates: tmpl, fsys: fsys, reload: reload}, nil
+}
+
+// current returns the templates to render with, re-parsing them in development
+// mode. A nil engine has no templates to render.
+func (te *TemplateEngine) current() (*template.Template, error) {
+	if te == nil {
+		return nil, errTemplatesUnavailable
+	}
+	if !te.reload {
+		return te.templates, nil
+	}
+	return parseTemplates(te.fsys)
+}
+
+func parseTemplates(fsys fs.FS) (*template.Template, error) {
 	tmpl, err := template.New("").Funcs(template.FuncMap{
 		"formatHealth":   formatHealth,
 		"getHealthColor": getHealthColor,
@@ -26,6 +83,7 @@ func NewTemplateEngine() (*TemplateEngine, error) {
 		"isPlayerTurn": func(state State) bool {
 			return isPlayerTurn(state)
 		},
+		"remainingCooldown":    RemainingCooldown,
 		"renderCharacterClass": renderCharacterClass,
 		"renderHealthBar":      renderHealthBar,
 		"characterAt": func(characters []Character, x, y int) *Character {
@@ -49,18 +107,26 @@ func NewTemplateEngine() (*TemplateEngine, error) {
 			}
 			return (hp * 100) / maxHp
 		},
-		"json": func(v interface{}) string {
-			// Simple JSON encoding for template
-			data, _ := json.Marshal(v)
-			return string(data)
-		},
-	}).ParseFS(templatesFS, "templates/*.html")
+		"json": templateJSON,
+	}).ParseFS(fsys, "*.html")
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse templates: %w", err)
 	}
 
-	return &TemplateEngine{templates: tmpl}, nil
+	return tmpl, nil
+}
+
+// templateJSON encodes v for a <script> block. json.Marshal escapes <, > and &
+// inside strings, so content like "</script>" can't end the block early, and
+// returning template.JS embeds the result as an object rather than a quoted
+// string. An error aborts the render instead of leaving an empty value.
+func templateJSON(v interface{}) (template.JS, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode template JSON: %w", err)
+	}
+	return template.JS(data), nil
 }
 
 // RenderGamePage renders the main game page
@@ -70,15 +136,22 @@ func (te *TemplateEngine) RenderGamePage(state State, sessionID string, isPlayer
 		SessionID    string
 		IsPlayerTurn bool
 		CurrentChar  *Character
+		TurnQueue    []Character
 	}{
 		State:        state,
 		SessionID:    sessionID,
 		IsPlayerTurn: isPlayerTurn,
 		CurrentChar:  GetCurrentCharacter(state),
+		TurnQueue:    GetTurnQueue(state),
+	}
+
+	tmpl, err := te.current()
+	if err != nil {
+		return "", err
 	}
 
 	var buf bytes.Buffer
-	err := te.templates.ExecuteTemplate(&buf, "game.html", data)
+	err = tmpl.ExecuteTemplate(&buf, "game.html", data)
 	if err != nil {
 		return "", fmt.Errorf("failed to execute game template: %w", err)
 	}
@@ -86,10 +159,57 @@ func (te *TemplateEngine) RenderGamePage(state State, sessionID string, isPlayer
 	return buf.String(), nil
 }
 
+// RenderGameOverPage renders the combat-complete screen
+func (te *TemplateEngine) RenderGameOverPage(state State, sessionID string) (string, error) {
+	winner := "Draw"
+	headline := "🤝 It's a Draw"
+	if state.Winner != nil && *state.Winner != "draw" {
+		winner = capitalize(*state.Winner)
+		switch *state.Winner {
+		case "player":
+			headline = "🏆 Victory!"
+		case "enemy":
+			headline = "💀 Defeat"
+		default:
+			headline = fmt.Sprintf("🏆 %s Wins!", winner)
+		}
+	}
+
+	data := struct {
+		State     State
+		SessionID string
+		Winner    string
+		Headline  string
+	}{
+		State:     state,
+		SessionID: sessionID,
+		Winner:    winner,
+		Headline:  headline,
+	}
+
+	tmpl, err := te.current()
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.ExecuteTemplate(&buf, "gameover.html", data)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute game over template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
 // RenderHomePage renders the home page
 func (te *TemplateEngine) RenderHomePage() (string, error) {
+	tmpl, err := te.current()
+	if err != nil {
+		return "", err
+	}
+
 	var buf bytes.Buffer
-	err := te.templates.ExecuteTemplate(&buf, "home.html", nil)
+	err = tmpl.ExecuteTemplate(&buf, "home.html", nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to execute home template: %w", err)
 	}
@@ -112,8 +232,13 @@ func (te *TemplateEngine) RenderScenariosPage(scenarios []string) (string, error
 		}
 	}
 
+	tmpl, err := te.current()
+	if err != nil {
+		return "", err
+	}
+
 	var buf bytes.Buffer
-	err := te.templates.ExecuteTemplate(&buf, "scenarios.html", data)
+	err = tmpl.ExecuteTemplate(&buf, "scenarios.html", data)
 	if err != nil {
 		return "", fmt.Errorf("failed to execute scenarios template: %w", err)
 	}
@@ -142,7 +267,7 @@ func formatPosition(pos Position) string {
 
 func isPlayerTurn(state State) bool {
 	currentChar := GetCurrentCharacter(state)
-	return currentChar != nil && currentChar.IsPlayer
+	return currentChar != nil && humanControlled(*currentChar)
 }
 
 func renderCharacterClass(char Character, isCurrent bool) string {
@@ -158,7 +283,9 @@ func renderCharacterClass(char Character, isCurrent bool) string {
 		classes = append(classes, "current-turn")
 	}
 
-	if char.Stats.HP == 0 {
+	if isDowned(char) {
+		classes = append(classes, "downed")
+	} else if char.Stats.HP == 0 {
 		classes = append(classes, "dead")
 	} else if char.Stats.HP < char.Stats.MaxHP/3 {
 		classes = append(classes, "low-health")
@@ -176,6 +303,15 @@ func renderHealthBar(hp, maxHp int) string {
 	</div>`, percentage, color)
 }
 
+// capitalize upper-cases the first letter of s, such as a team name
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	first, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(first)) + s[size:]
+}
+
 func formatScenarioName(name string) string {
 	return strings.Title(strings.ReplaceAll(name, "-", " "))
 }
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestDevTemplateEngineReloads(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"game.html", "gameover.html", "home.html", "scenarios.html"} {
+		data, err := templatesFS.ReadFile("templates/" + name)
+		if err != nil {
+			t.Fatalf("Failed to read embedded %s: %v", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	te, err := NewDevTemplateEngine(dir)
+	if err != nil {
+		t.Fatalf("Failed to create dev template engine: %v", err)
+	}
+
+	html, err := te.RenderHomePage()
+	if err != nil {
+		t.Fatalf("Failed to render home page: %v", err)
+	}
+	if strings.Contains(html, "Edited Home Page") {
+		t.Fatal("Home page should not contain the edit yet")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "home.html"), []byte("<h1>Edited Home Page</h1>"), 0o644); err != nil {
+		t.Fatalf("Failed to edit home.html: %v", err)
+	}
+
+	html, err = te.RenderHomePage()
+	if err != nil {
+		t.Fatalf("Failed to render edited home page: %v", err)
+	}
+	if !strings.Contains(html, "Edited Home Page") {
+		t.Errorf("Expected dev mode to pick up the edited template, got %q", html)
+	}
+}
+
+func TestRenderGameOverPage(t *testing.T) {
+	te, err := NewTemplateEngine()
+	if err != nil {
+		t.Fatalf("Failed to create template engine: %v", err)
+	}
+
+	hero := createTestCharacter(true, "Hero")
+	goblin := createTestCharacter(false, "Goblin")
+	goblin.Stats.HP = 0
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+	state.Round = 4
+	state.IsComplete = true
+	winner := "player"
+	state.Winner = &winner
+	awardCombatRewards(&state)
+	state.CombatLog = []string{"Hero attacks Goblin for 30 damage!", "Goblin has been defeated!"}
+
+	html, err := te.RenderGameOverPage(state, "game-over-session")
+	if err != nil {
+		t.Fatalf("Failed to render game over page: %v", err)
+	}
+
+	for _, expected := range []string{"Victory!", "Winner: Player", "4 rounds", "Hero", "Goblin", "30/30", "0/30", "15 XP", "Loot: Health Potion", "Combat Log", "Goblin has been defeated!", `href="/scenarios"`} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("Expected game over page to contain %q", expected)
+		}
+	}
+}
+
+func TestServerWorksWithoutTemplates(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	sessionAuth = NewSessionAuth()
+	previous := templateEngine
+	templateEngine = nil
+	defer func() { templateEngine = previous }()
+
+	app := fiber.New()
+	setupRoutes(app)
+
+	hero := createTestCharacter(true, "Hero")
+	goblin := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+	state.TurnOrder = []ID{hero.ID, goblin.ID}
+
+	// The JSON API is unaffected
+	body, _ := json.Marshal(fiber.Map{"state": state, "action": Action{Kind: "Attack", Attacker: hero.ID, Target: goblin.ID, Weapon: hero.Weapons[0].ID}, "seed": 12345})
+	if status, resp := postJSON(t, app, "/tools/apply_action", body); status != 200 {
+		t.Errorf("Expected apply_action to work without templates, got %d: %s", status, resp)
+	}
+	body, _ = json.Marshal(fiber.Map{"sessionId": "no-templates", "state": state})
+	if status, resp := postJSON(t, app, "/sessions", body); status != 200 {
+		t.Fatalf("Expected session creation to work without templates, got %d: %s", status, resp)
+	}
+
+	get := func(path string) (int, string) {
+		res, err := app.Test(httptest.NewRequest("GET", path, nil))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		var buf strings.Builder
+		data := make([]byte, 4096)
+		for {
+			n, err := res.Body.Read(data)
+			buf.Write(data[:n])
+			if err != nil {
+				break
+			}
+		}
+		return res.StatusCode, buf.String()
+	}
+
+	if status, resp := get("/sessions/no-templates"); status != 200 {
+		t.Errorf("Expected the session to load without templates, got %d: %s", status, resp)
+	}
+	if status, resp := get("/health"); status != 200 || !strings.Contains(resp, `"degraded"`) {
+		t.Errorf("Expected a healthy server with degraded templates, got %d: %s", status, resp)
+	}
+
+	// HTML pages fall back to a minimal page
+	if status, resp := get("/"); status != 200 || !strings.Contains(resp, "server is running") {
+		t.Errorf("Expected the home page fallback, got %d: %s", status, resp)
+	}
+	for _, path := range []string{"/scenarios", "/game/no-templates"} {
+		if status, resp := get(path); status != 503 || !strings.Contains(resp, "JSON API") {
+			t.Errorf("Expected the fallback page for %s, got %d: %s", path, status, resp)
+		}
+	}
+}
+
+func TestGamePageEmbedsStateSafely(t *testing.T) {
+	te, err := NewTemplateEngine()
+	if err != nil {
+		t.Fatalf("Failed to create template engine: %v", err)
+	}
+
+	name := `</script><script>alert("pwned")</script>`
+	hero := createTestCharacter(true, "Hero")
+	villain := createTestCharacter(false, name)
+	state := CreateInitialState([]Character{hero}, []Character{villain}, 12345)
+
+	html, err := te.RenderGamePage(state, "escape-session", true)
+	if err != nil {
+		t.Fatalf("Failed to render game page: %v", err)
+	}
+	if strings.Contains(html, `<script>alert(`) {
+		t.Fatal("Expected the character name not to open a script block")
+	}
+
+	const prefix = "let currentState = "
+	start := strings.Index(html, prefix)
+	if start < 0 {
+		t.Fatal("Expected the page to embed the current state")
+	}
+	line := html[start+len(prefix):]
+	line = line[:strings.Index(line, "\n")]
+	line = strings.TrimSuffix(strings.TrimSpace(line), ";")
+	if strings.Contains(line, "</") || !strings.Contains(line, `\u003c/script\u003e`) {
+		t.Errorf("Expected the name to be escaped in the embedded JSON, got %s", line)
+	}
+
+	var embedded State
+	if err := json.Unmarshal([]byte(line), &embedded); err != nil {
+		t.Fatalf("Expected the embedded state to be a JSON object, got %v: %s", err, line)
+	}
+	if got, _ := GetCharacterByName(embedded, name); got == nil {
+		t.Errorf("Expected the escaped name to decode back to %q", name)
+	}
+
+	if _, err := templateJSON(make(chan int)); err == nil {
+		t.Error("Expected values that can't be encoded to fail the render")
+	}
+}
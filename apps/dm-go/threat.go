@@ -0,0 +1,95 @@
+package main
+
+import "fmt"
+
+// tauntThreat is the threat a "taunt" ability adds on top of its power
+const tauntThreat = 25
+
+// addThreat raises a character's threat, drawing the enemy AI's attention
+func addThreat(state *State, id ID, amount int) {
+	if amount <= 0 {
+		return
+	}
+	if state.Threat == nil {
+		state.Threat = make(map[ID]int)
+	}
+	state.Threat[id] += amount
+}
+
+// decayThreat halves everyone's threat at the start of a round so old
+// aggro fades unless it is kept up
+func decayThreat(state *State) {
+	for id, threat := range state.Threat {
+		if threat/2 > 0 {
+			state.Threat[id] = threat / 2
+		} else {
+			delete(state.Threat, id)
+		}
+	}
+}
+
+// applyTaunt makes the character the enemy AI's preferred target
+func applyTaunt(state *State, char *Character, ability *Ability) string {
+	addThreat(state, char.ID, tauntThreat+ability.Power)
+	return fmt.Sprintf("%s uses %s and draws the enemy's attention!", char.Name, ability.Name)
+}
+
+// pickAttackTarget chooses who an AI-controlled character attacks: the active
+// opponent with the most threat, falling back to SelectDefaultTarget's order on ties
+func pickAttackTarget(state State, attacker *Character) *Character {
+	return pickAttackTargetWhere(state, attacker, func(*Character) bool { return true })
+}
+
+// pickAttackTargetWhere is pickAttackTarget limited to opponents that pass ok
+func pickAttackTargetWhere(state State, attacker *Character, ok func(*Character) bool) *Character {
+	var best *Character
+	for i := range state.Characters {
+		char := &state.Characters[i]
+		if !isActive(*char) || CharacterTeam(*char) == CharacterTeam(*attacker) || !ok(char) {
+			continue
+		}
+		if best == nil {
+			best = char
+			continue
+		}
+		threat, bestThreat := state.Threat[char.ID], state.Threat[best.ID]
+		if threat > bestThreat || (threat == bestThreat && preferredTarget(*attacker, *char, *best)) {
+			best = char
+		}
+	}
+	return best
+}
+
+// SelectDefaultTarget picks who an attack goes to when nobody was chosen: the
+// nearest active opponent, then the one with the least HP, then the lowest ID,
+// so the pick doesn't depend on the order characters were added in
+func SelectDefaultTarget(state State, attacker *Character) *Character {
+	var best *Character
+	for i := range state.Characters {
+		char := &state.Characters[i]
+		if !isActive(*char) || CharacterTeam(*char) == CharacterTeam(*attacker) {
+			continue
+		}
+		if best == nil || preferredTarget(*attacker, *char, *best) {
+			best = char
+		}
+	}
+	return best
+}
+
+// preferredTarget reports whether the attacker should go for a over b: the
+// nearer, then the weaker, then the lower ID
+func preferredTarget(attacker, a, b Character) bool {
+	if da, db := gridDistance(attacker.Position, a.Position), gridDistance(attacker.Position, b.Position); da != db {
+		return da < db
+	}
+	if a.Stats.HP != b.Stats.HP {
+		return a.Stats.HP < b.Stats.HP
+	}
+	return a.ID < b.ID
+}
+
+// gridDistance counts the steps between two squares moving along rows and columns
+func gridDistance(from, to Position) int {
+	return abs(to.X-from.X) + abs(to.Y-from.Y)
+}
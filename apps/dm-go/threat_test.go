@@ -0,0 +1,95 @@
+package main
+
+import "testing"
+
+func TestTauntPullsEnemyAttack(t *testing.T) {
+	tank := createTestCharacter(true, "Tank")
+	tank.Abilities = []Ability{{ID: NewID(), Name: "Shield Taunt", Cooldown: 2, Effect: "taunt", Power: 5}}
+	mage := createTestCharacter(true, "Mage")
+	mage.Stats.HP = 8 // Fragile, so the weakest target
+	goblin := createTestCharacter(false, "Goblin")
+
+	state := CreateInitialState([]Character{tank, mage}, []Character{goblin}, 12345)
+	state.TurnOrder = []ID{tank.ID, mage.ID, goblin.ID}
+
+	if action := heuristicEnemyAction(state, GetCharacterByID(state, goblin.ID)); action.Target != mage.ID {
+		t.Fatalf("Expected the goblin to go for the fragile mage without threat, got %+v", action)
+	}
+
+	resolution := ApplyAction(state, Action{Kind: "Ability", Actor: tank.ID, Ability: tank.Abilities[0].ID}, 12345)
+	if got := resolution.State.Threat[tank.ID]; got != tauntThreat+5 {
+		t.Errorf("Expected taunt to add %d threat, got %d", tauntThreat+5, got)
+	}
+
+	// The mage's damage adds threat too, but not enough to outweigh the taunt
+	resolution = ApplyAction(resolution.State, Action{Kind: "Attack", Attacker: mage.ID, Target: goblin.ID, Weapon: mage.Weapons[0].ID}, 12345)
+	if resolution.State.Threat[mage.ID] == 0 {
+		t.Error("Expected dealing damage to add threat")
+	}
+
+	action := heuristicEnemyAction(resolution.State, GetCharacterByID(resolution.State, goblin.ID))
+	if action.Kind != "Attack" || action.Target != tank.ID {
+		t.Errorf("Expected the taunting tank to draw the goblin's attack, got %+v", action)
+	}
+}
+
+func TestThreatDecaysEachRound(t *testing.T) {
+	player := createTestCharacter(true, "Player")
+	enemy := createTestCharacter(false, "Enemy")
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+	state.TurnOrder = []ID{player.ID, enemy.ID}
+	state.Threat = map[ID]int{player.ID: 40, enemy.ID: 1}
+
+	state, _, _ = advanceTurn(state)
+	if state.Threat[player.ID] != 40 {
+		t.Errorf("Expected threat to hold within a round, got %d", state.Threat[player.ID])
+	}
+
+	state, _, _ = advanceTurn(state)
+	if state.Threat[player.ID] != 20 {
+		t.Errorf("Expected threat to halve at the new round, got %d", state.Threat[player.ID])
+	}
+	if _, ok := state.Threat[enemy.ID]; ok {
+		t.Error("Expected threat that decays to zero to be dropped")
+	}
+}
+
+func TestSelectDefaultTargetPrefersNearestThenWeakest(t *testing.T) {
+	hero := createTestCharacter(true, "Hero")
+	ally := createTestCharacter(true, "Ally")
+	ally.Position = Position{X: 9, Y: 9}
+	ally.Stats.HP = 5
+	far := createTestCharacter(false, "Far Goblin")
+	far.Position = Position{X: 5, Y: 5}
+	far.Stats.HP = 1 // Weakest, but out of the way
+	near := createTestCharacter(false, "Near Goblin")
+	near.Position = Position{X: 1, Y: 0}
+	nearWounded := createTestCharacter(false, "Wounded Goblin")
+	nearWounded.Position = Position{X: 0, Y: 1}
+	nearWounded.Stats.HP = 10
+
+	// The far goblin comes first, so the old first-enemy pick chose it
+	state := CreateInitialState([]Character{hero, ally}, []Character{far, near, nearWounded}, 12345)
+	attacker := GetCharacterByID(state, hero.ID)
+	if target := SelectDefaultTarget(state, attacker); target == nil || target.ID != nearWounded.ID {
+		t.Fatalf("Expected the nearest, most wounded goblin, got %+v", target)
+	}
+
+	action, err := gamePageAction(state, attacker, "attack")
+	if err != nil || action.Target != nearWounded.ID {
+		t.Errorf("Expected the game page's attack to pick the same target, got %+v (%v)", action, err)
+	}
+	// Without threat the AI uses the same order, so the goblin goes for the
+	// hero beside it rather than the weaker ally across the board
+	if action := heuristicEnemyAction(state, GetCharacterByID(state, near.ID)); action.Target != hero.ID {
+		t.Errorf("Expected the goblin to attack the nearby hero, got %+v", action)
+	}
+
+	// Equally near and equally hurt, the lower ID wins whatever the order
+	twin := GetCharacterByID(state, near.ID)
+	twin.Position, twin.Stats.HP = nearWounded.Position, nearWounded.Stats.HP
+	want := min(near.ID, nearWounded.ID)
+	if target := SelectDefaultTarget(state, attacker); target.ID != want {
+		t.Errorf("Expected the tie to go to the lower ID %s, got %s", want, target.ID)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// toolHandlers are the tools reachable through POST /tools/invoke, by name
+var toolHandlers = map[string]fiber.Handler{
+	"get_state_summary": handleGetStateSummary,
+	"roll_check":        handleRollCheck,
+	"inspect_character": handleInspectCharacter,
+	"apply_action":      handleApplyAction,
+	"apply_actions":     handleApplyActions,
+}
+
+// ToolInvocation names a tool and the request body to call it with
+type ToolInvocation struct {
+	Tool string          `json:"tool"`
+	Args json.RawMessage `json:"args"`
+}
+
+// ToolResult is a tool's HTTP status and response body. Error repeats the
+// tool's error message when the status isn't 2xx.
+type ToolResult struct {
+	Tool   string          `json:"tool"`
+	Status int             `json:"status"`
+	Result json.RawMessage `json:"result"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// handleInvokeTools gives agents one entrypoint for every tool. The body is a
+// single invocation, answered with its ToolResult, or an array of invocations
+// run in order and answered with {"results": [...]}. Tools see the request's
+// headers, so session-id and X-Player-Token apply to every invocation.
+func handleInvokeTools(c *fiber.Ctx) error {
+	body := bytes.TrimSpace(c.Body())
+	batch := len(body) > 0 && body[0] == '['
+
+	var calls []ToolInvocation
+	if batch {
+		if err := json.Unmarshal(body, &calls); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+		if len(calls) == 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "At least one tool invocation is required"})
+		}
+		if len(calls) > maxToolInvocations {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("too many tool invocations: %d (max %d)", len(calls), maxToolInvocations)})
+		}
+	} else {
+		var call ToolInvocation
+		if err := json.Unmarshal(body, &call); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+		calls = []ToolInvocation{call}
+	}
+
+	// Reject unknown tools before anything runs so a batch isn't half applied
+	for i, call := range calls {
+		if _, ok := toolHandlers[call.Tool]; !ok {
+			message := fmt.Sprintf("unknown tool %q (available: %s)", call.Tool, strings.Join(toolNames(), ", "))
+			if batch {
+				message = fmt.Sprintf("invocation %d: %s", i, message)
+			}
+			return c.Status(400).JSON(fiber.Map{"error": message})
+		}
+	}
+
+	results := make([]ToolResult, len(calls))
+	for i, call := range calls {
+		results[i] = invokeTool(c, call)
+	}
+
+	c.Response().ResetBody()
+	c.Status(200)
+	if batch {
+		return sendJSON(c, fiber.Map{"results": results})
+	}
+	return sendJSON(c, results[0])
+}
+
+// invokeTool runs a tool handler with the invocation's args as the request body
+// and captures what it responded with
+func invokeTool(c *fiber.Ctx, call ToolInvocation) ToolResult {
+	args := call.Args
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+
+	c.Request().SetBody(args)
+	c.Response().ResetBody()
+	c.Status(200)
+
+	result := ToolResult{Tool: call.Tool}
+	if err := toolHandlers[call.Tool](c); err != nil {
+		result.Status = 500
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Status = c.Response().StatusCode()
+	result.Result = append(json.RawMessage(nil), c.Response().Body()...)
+	if result.Status >= 300 {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		json.Unmarshal(result.Result, &failure)
+		result.Error = failure.Error
+	}
+	return result
+}
+
+// toolNames lists the invokable tools in alphabetical order
+func toolNames() []string {
+	names := make([]string, 0, len(toolHandlers))
+	for name := range toolHandlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
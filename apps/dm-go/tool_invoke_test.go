@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestInvokeTools(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	sessionAuth = NewSessionAuth()
+
+	app := fiber.New()
+	setupRoutes(app)
+
+	player := createTestCharacter(true, "Hero")
+	enemy := createTestCharacter(false, "Goblin")
+	enemy.Stats.HP, enemy.Stats.MaxHP = 200, 200
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+	state.TurnOrder = []ID{player.ID, enemy.ID}
+	stateJSON, _ := json.Marshal(state)
+	attack := fmt.Sprintf(`{"kind":"Attack","attacker":%q,"target":%q,"weapon":%q}`, player.ID, enemy.ID, player.Weapons[0].ID)
+
+	invoke := func(t *testing.T, body string) (int, ToolResult) {
+		t.Helper()
+		status, resp := postJSON(t, app, "/tools/invoke", []byte(body))
+		var result ToolResult
+		json.Unmarshal([]byte(resp), &result)
+		return status, result
+	}
+
+	t.Run("EachTool", func(t *testing.T) {
+		calls := map[string]string{
+			"get_state_summary": fmt.Sprintf(`{"state":%s}`, stateJSON),
+			"roll_check":        `{"actor":"","type":"skill","dc":1}`,
+			"inspect_character": fmt.Sprintf(`{"state":%s,"characterId":%q}`, stateJSON, player.ID),
+			"apply_action":      fmt.Sprintf(`{"state":%s,"action":%s,"seed":42}`, stateJSON, attack),
+			"apply_actions":     fmt.Sprintf(`{"state":%s,"actions":[%s],"seed":42}`, stateJSON, attack),
+		}
+		for tool, args := range calls {
+			status, result := invoke(t, fmt.Sprintf(`{"tool":%q,"args":%s}`, tool, args))
+			if status != 200 || result.Tool != tool || result.Status != 200 || len(result.Result) == 0 {
+				t.Errorf("%s: expected a successful result, got %d %+v", tool, status, result)
+			}
+		}
+		if len(calls) != len(toolHandlers) {
+			t.Errorf("Expected every tool to be covered, have %d of %d", len(calls), len(toolHandlers))
+		}
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		body := fmt.Sprintf(`[{"tool":"roll_check","args":{"type":"skill","dc":1}},{"tool":"apply_action","args":{"state":%s,"action":%s}}]`, stateJSON, attack)
+		status, resp := postJSON(t, app, "/tools/invoke", []byte(body))
+		var batch struct {
+			Results []ToolResult `json:"results"`
+		}
+		json.Unmarshal([]byte(resp), &batch)
+		if status != 200 || len(batch.Results) != 2 {
+			t.Fatalf("Expected two results, got %d: %s", status, resp)
+		}
+
+		var roll RollResult
+		json.Unmarshal(batch.Results[0].Result, &roll)
+		if batch.Results[0].Status != 200 || !roll.Success {
+			t.Errorf("Expected the roll check to succeed, got %+v", batch.Results[0])
+		}
+		// The second call has no seed, so the tool's own validation error comes back
+		if batch.Results[1].Status != 400 || !strings.Contains(batch.Results[1].Error, "seed are required") {
+			t.Errorf("Expected apply_action's 400 to be passed through, got %+v", batch.Results[1])
+		}
+	})
+
+	t.Run("KeepsMiddlewareHeaders", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/tools/invoke", strings.NewReader(`{"tool":"roll_check","args":{"type":"skill","dc":1}}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != 200 || resp.Header.Get("Deprecation") != "true" {
+			t.Errorf("Expected the deprecation header to survive the tool call, got %d %v", resp.StatusCode, resp.Header)
+		}
+	})
+
+	t.Run("UnknownTool", func(t *testing.T) {
+		status, resp := postJSON(t, app, "/tools/invoke", []byte(`{"tool":"summon_dragon","args":{}}`))
+		if status != 400 || !strings.Contains(resp, `unknown tool \"summon_dragon\"`) || !strings.Contains(resp, "roll_check") {
+			t.Errorf("Expected 400 naming the unknown tool and the available ones, got %d: %s", status, resp)
+		}
+
+		// Nothing in a batch runs when one of its tools is unknown
+		body := fmt.Sprintf(`[{"tool":"apply_action","args":{"state":%s,"action":%s,"seed":42}},{"tool":"nope"}]`, stateJSON, attack)
+		if status, resp := postJSON(t, app, "/tools/invoke", []byte(body)); status != 400 || !strings.Contains(resp, "invocation 1") {
+			t.Errorf("Expected the batch to be rejected, got %d: %s", status, resp)
+		}
+	})
+
+	if status, _ := postJSON(t, app, "/tools/invoke", []byte(`[]`)); status != 400 {
+		t.Errorf("Expected 400 for an empty batch, got %d", status)
+	}
+}
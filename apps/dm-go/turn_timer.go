@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Clock abstracts timer scheduling so turn timers can be driven by a fake clock in tests
+type Clock interface {
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a scheduled callback that can be cancelled
+type Timer interface {
+	Stop() bool
+}
+
+// realClock schedules callbacks using the standard library
+type realClock struct{}
+
+// AfterFunc schedules f to run after d
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// TurnTimerManager runs optional per-session turn timers that auto-pass idle players
+type TurnTimerManager struct {
+	mu        sync.Mutex
+	clock     Clock
+	durations map[string]time.Duration
+	timers    map[string]Timer
+	onTimeout func(sessionID string, round, turn int)
+}
+
+// NewTurnTimerManager creates a turn timer manager using the given clock
+func NewTurnTimerManager(clock Clock) *TurnTimerManager {
+	tm := &TurnTimerManager{
+		clock:     clock,
+		durations: make(map[string]time.Duration),
+		timers:    make(map[string]Timer),
+	}
+	tm.onTimeout = tm.handleTimeout
+	return tm
+}
+
+// Enable turns on the turn timer for a session
+func (tm *TurnTimerManager) Enable(sessionID string, d time.Duration) {
+	// The ID is kept as a map key; Fiber reuses the buffers behind request values
+	sessionID = strings.Clone(sessionID)
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.durations[sessionID] = d
+}
+
+// Disable stops and removes the turn timer for a session
+func (tm *TurnTimerManager) Disable(sessionID string) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	if timer, exists := tm.timers[sessionID]; exists {
+		timer.Stop()
+		delete(tm.timers, sessionID)
+	}
+	delete(tm.durations, sessionID)
+}
+
+// Reset restarts the session's timer for the current turn. It is a no-op for
+// sessions without a timer, finished combats, and non-player turns.
+func (tm *TurnTimerManager) Reset(sessionID string, state State) {
+	// The timer outlives the request that may have passed the ID
+	sessionID = strings.Clone(sessionID)
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if timer, exists := tm.timers[sessionID]; exists {
+		timer.Stop()
+		delete(tm.timers, sessionID)
+	}
+
+	d, enabled := tm.durations[sessionID]
+	if !enabled || state.IsComplete {
+		return
+	}
+
+	currentChar := GetCurrentCharacter(state)
+	if currentChar == nil || !humanControlled(*currentChar) {
+		return
+	}
+
+	round, turn := state.Round, state.CurrentTurn
+	tm.timers[sessionID] = tm.clock.AfterFunc(d, func() {
+		tm.onTimeout(sessionID, round, turn)
+	})
+}
+
+// handleTimeout auto-applies a Defend for the idle player and advances the turn
+func (tm *TurnTimerManager) handleTimeout(sessionID string, round, turn int) {
+	unlock := sessionLocks.Lock(sessionID)
+	defer unlock()
+	state, exists := stateManager.GetState(sessionID)
+	if !exists {
+		return
+	}
+
+	// Ignore stale timers for turns that have already been taken
+	if state.IsComplete || state.Round != round || state.CurrentTurn != turn {
+		return
+	}
+
+	currentChar := GetCurrentCharacter(state)
+	if currentChar == nil {
+		return
+	}
+
+	action := Action{
+		Kind:  "Defend",
+		Actor: currentChar.ID,
+	}
+
+	seed := newSeed()
+	resolution := ApplyAction(state, action, seed)
+	resolution.Events = append([]Event{{
+		Type:  "turn_timeout",
+		Actor: currentChar.ID,
+	}}, resolution.Events...)
+	timeout := fmt.Sprintf("%s ran out of time!", currentChar.Name)
+	resolution.Logs = append(resolution.Logs, timeout)
+	appendCombatLog(&resolution.State, []string{timeout})
+
+	sessionLogger(sessionID).Info("Turn timer expired", "actor", currentChar.ID, "round", state.Round)
+
+	broadcastMessage(sessionID, newTurnTimeoutMessage(currentChar.ID))
+	persistResolution(sessionID, state, resolution)
+	recordAction(sessionID, state, resolution, action, seed)
+
+	tm.Reset(sessionID, resolution.State)
+}
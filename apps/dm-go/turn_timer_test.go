@@ -0,0 +1,122 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock fires scheduled callbacks only when advanced manually
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Duration
+	pending []*fakeTimer
+}
+
+type fakeTimer struct {
+	at      time.Duration
+	f       func()
+	stopped bool
+}
+
+func (t *fakeTimer) Stop() bool {
+	wasActive := !t.stopped
+	t.stopped = true
+	return wasActive
+}
+
+func (fc *fakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	timer := &fakeTimer{at: fc.now + d, f: f}
+	fc.pending = append(fc.pending, timer)
+	return timer
+}
+
+// Advance moves the clock forward and runs any callbacks that became due
+func (fc *fakeClock) Advance(d time.Duration) {
+	fc.mu.Lock()
+	fc.now += d
+	var due []*fakeTimer
+	var remaining []*fakeTimer
+	for _, timer := range fc.pending {
+		if !timer.stopped && timer.at <= fc.now {
+			due = append(due, timer)
+		} else if !timer.stopped {
+			remaining = append(remaining, timer)
+		}
+	}
+	fc.pending = remaining
+	fc.mu.Unlock()
+
+	for _, timer := range due {
+		timer.stopped = true
+		timer.f()
+	}
+}
+
+func TestTurnTimerAutoPassesOnTimeout(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+
+	player := createTestCharacter(true, "Player")
+	enemy := createTestCharacter(false, "Enemy")
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+
+	state.TurnOrder = []ID{player.ID, enemy.ID} // Player acts first
+
+	sessionID := "turn-timer-test"
+	stateManager.SetState(sessionID, state)
+
+	clock := &fakeClock{}
+	timers := NewTurnTimerManager(clock)
+	timers.Enable(sessionID, 5*time.Second)
+	timers.Reset(sessionID, state)
+
+	clock.Advance(4 * time.Second)
+	if current, _ := stateManager.GetState(sessionID); current.CurrentTurn != state.CurrentTurn {
+		t.Fatal("Turn should not advance before the timer expires")
+	}
+
+	clock.Advance(2 * time.Second)
+	updated, _ := stateManager.GetState(sessionID)
+	if updated.CurrentTurn == state.CurrentTurn {
+		t.Fatal("Expected turn to advance after timeout")
+	}
+	if GetCurrentCharacter(updated).ID != enemy.ID {
+		t.Errorf("Expected enemy turn after timeout, got %s", GetCurrentCharacter(updated).Name)
+	}
+
+	events, _ := eventStore.GetEvents(sessionID, 0)
+	hasTimeout := false
+	for _, event := range events {
+		if event.Type == "turn_timeout" && event.Actor == player.ID {
+			hasTimeout = true
+		}
+	}
+	if !hasTimeout {
+		t.Error("Expected a turn_timeout event to be recorded")
+	}
+}
+
+func TestTurnTimerIgnoresSessionsWithoutTimer(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+
+	player := createTestCharacter(true, "Player")
+	enemy := createTestCharacter(false, "Enemy")
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+	state.TurnOrder = []ID{player.ID, enemy.ID}
+
+	sessionID := "no-timer-test"
+	stateManager.SetState(sessionID, state)
+
+	clock := &fakeClock{}
+	timers := NewTurnTimerManager(clock)
+	timers.Reset(sessionID, state)
+
+	clock.Advance(time.Hour)
+	if updated, _ := stateManager.GetState(sessionID); updated.CurrentTurn != state.CurrentTurn {
+		t.Error("Turn should not advance when no timer is configured")
+	}
+}
@@ -29,10 +29,14 @@ type Position struct {
 
 // Weapon represents a weapon
 type Weapon struct {
-	ID       ID     `json:"id"`
-	Name     string `json:"name"`
-	Damage   int    `json:"damage"`
-	Accuracy int    `json:"accuracy"`
+	ID         ID     `json:"id"`
+	Name       string `json:"name"`
+	Damage     int    `json:"damage"`
+	Accuracy   int    `json:"accuracy"`
+	Durability int    `json:"durability,omitempty"` // Attacks left before it breaks; 0 is indestructible
+
+	IgnoresDefense bool   `json:"ignoresDefense,omitempty"` // Armor-piercing: the target's defense isn't subtracted
+	Scaling        string `json:"scaling,omitempty"`        // Stat adding half its value to damage: "attack" (default) or "speed"
 }
 
 // Ability represents an ability
@@ -40,16 +44,37 @@ type Ability struct {
 	ID       ID     `json:"id"`
 	Name     string `json:"name"`
 	Cooldown int    `json:"cooldown"`
-	Effect   string `json:"effect"` // "damage", "heal", "buff", "debuff"
+	Effect   string `json:"effect"` // "damage", "heal", "buff", "debuff", "regen"
 	Power    int    `json:"power"`
+
+	IgnoresDefense   bool   `json:"ignoresDefense,omitempty"`   // Armor-piercing: the target's defense isn't subtracted, even under the abilityDefense rule
+	ReducedByDefense bool   `json:"reducedByDefense,omitempty"` // The target's defense is subtracted, as for weapon hits
+	Scaling          string `json:"scaling,omitempty"`          // Stat adding half its value to damage: "attack" or "speed"; none by default
+
+	// Passive abilities can't be used; they fire on their own when Trigger
+	// ("hit" or "missed") happens to the owner. Effect is "reflect" or "counter".
+	Passive bool   `json:"passive,omitempty"`
+	Trigger string `json:"trigger,omitempty"`
+
+	// Reaction abilities can't be used either; the owner uses one when hit by
+	// an attack, before the damage lands. Effect is "parry" or "shield".
+	Reaction bool `json:"reaction,omitempty"`
 }
 
 // Item represents an item
 type Item struct {
-	ID     ID     `json:"id"`
-	Name   string `json:"name"`
-	Type   string `json:"type"` // "consumable", "equipment"
-	Effect string `json:"effect"`
+	ID      ID     `json:"id"`
+	Name    string `json:"name"`
+	Type    string `json:"type"` // "consumable", "equipment"
+	Effect  string `json:"effect"`
+	Charges int    `json:"charges,omitempty"` // Uses left; 0 or 1 is used up in one go
+}
+
+// StatusEffect represents a lingering effect that ticks at the start of the bearer's turn
+type StatusEffect struct {
+	Type     string `json:"type"` // "regen", "poison" or "defend"
+	Amount   int    `json:"amount"`
+	Duration int    `json:"duration"` // Turns remaining
 }
 
 // Character represents a game character
@@ -63,6 +88,20 @@ type Character struct {
 	Items            []Item         `json:"items"`
 	AbilityCooldowns map[string]int `json:"abilityCooldowns"`
 	IsPlayer         bool           `json:"isPlayer"`
+	Team             string         `json:"team,omitempty"` // Defaults to "player" or "enemy" from IsPlayer
+	Fled             bool           `json:"fled,omitempty"`
+	StatusEffects    []StatusEffect `json:"statusEffects,omitempty"`
+	MaxItems         int            `json:"maxItems,omitempty"`        // Inventory limit; 0 is unlimited
+	XP               int            `json:"xp,omitempty"`              // XP for defeating this character; 0 uses the session's reward rules
+	InitiativeBonus  int            `json:"initiativeBonus,omitempty"` // Added to every initiative roll
+	Downed           *DeathSaves    `json:"downed,omitempty"`          // Set while at 0 HP but not dead, under the deathSaves rule
+	ReactionsUsed    int            `json:"reactionsUsed,omitempty"`   // Reactions used this round
+	AI               bool           `json:"ai,omitempty"`              // A player-side ally the server plays with the enemy AI
+
+	// Optional display hints for frontends; the engine ignores them
+	Sprite   string `json:"sprite,omitempty"`   // Sprite sheet key or image URL
+	Color    string `json:"color,omitempty"`    // CSS color for the character's tile
+	Portrait string `json:"portrait,omitempty"` // Portrait image URL
 }
 
 // Action represents a game action
@@ -74,37 +113,137 @@ type Action struct {
 	Actor    ID     `json:"actor,omitempty"`
 	Ability  ID     `json:"ability,omitempty"`
 	Item     ID     `json:"item,omitempty"`
+	Slot     int    `json:"slot,omitempty"` // Delay: turn order index to move to; 0 is the end of the round
+
+	// Name-based alternatives, resolved to IDs when the ID fields are empty
+	ActorName   string `json:"actorName,omitempty"`
+	TargetName  string `json:"targetName,omitempty"`
+	WeaponName  string `json:"weaponName,omitempty"`
+	AbilityName string `json:"abilityName,omitempty"`
 }
 
 // Event represents a game event
 type Event struct {
-	ID      string `json:"id,omitempty"`
-	Type    string `json:"type"`
-	Target  ID     `json:"target,omitempty"`
-	Amount  int    `json:"amount,omitempty"`
-	Source  ID     `json:"source,omitempty"`
-	Actor   ID     `json:"actor,omitempty"`
-	Ability ID     `json:"ability,omitempty"`
-	Item    ID     `json:"item,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type"`
+	Target   ID     `json:"target,omitempty"`
+	Amount   int    `json:"amount,omitempty"`
+	Source   ID     `json:"source,omitempty"`
+	Actor    ID     `json:"actor,omitempty"`
+	Ability  ID     `json:"ability,omitempty"`
+	Item     ID     `json:"item,omitempty"`
+	Cooldown int    `json:"cooldown,omitempty"`
+	Charges  int    `json:"charges,omitempty"` // Uses left on an item_used event's item
+	Round    int    `json:"round,omitempty"`
+
+	// Animation hints for the frontend
+	SourcePosition *Position `json:"sourcePosition,omitempty"`
+	TargetPosition *Position `json:"targetPosition,omitempty"`
+	Effect         string    `json:"effect,omitempty"` // e.g. "slash", "fireball", "heal"
+}
+
+// ActionRecord is an applied action with the seed it was resolved with, enough
+// to replay it exactly
+type ActionRecord struct {
+	Round  int    `json:"round"` // Round the action was taken in
+	Action Action `json:"action"`
+	Seed   int64  `json:"seed"`
 }
 
 // State represents the game state
 type State struct {
-	Round       int         `json:"round"`
-	Characters  []Character `json:"characters"`
-	TurnOrder   []ID        `json:"turnOrder"`
-	CurrentTurn int         `json:"currentTurn"`
-	IsComplete  bool        `json:"isComplete"`
-	Winner      *string     `json:"winner,omitempty"` // "player", "enemy", "draw"
+	Round       int               `json:"round"`
+	Characters  []Character       `json:"characters"`
+	TurnOrder   []ID              `json:"turnOrder"`
+	CurrentTurn int               `json:"currentTurn"`
+	IsComplete  bool              `json:"isComplete"`
+	Winner      *string           `json:"winner,omitempty"` // Winning team or "draw"
+	LastAction  map[ID]string     `json:"lastAction,omitempty"`
+	Rules       HouseRules        `json:"rules"`
+	Seed        int64             `json:"seed,omitempty"`        // Initial seed, used to reroll initiative deterministically
+	GroundItems map[string][]Item `json:"groundItems,omitempty"` // Loot on the board, keyed by "x,y" position
+	Threat      map[ID]int        `json:"threat,omitempty"`      // Aggro per character; the enemy AI attacks the highest
+	Obstacles   []Position        `json:"obstacles,omitempty"`   // Squares that block ranged attacks
+	NextOrder   []ID              `json:"nextOrder,omitempty"`   // Replaces TurnOrder when the round ends, e.g. after a surprise round
+	Rewards     *RewardRules      `json:"rewards,omitempty"`     // Nil uses the default rewards
+	Result      *CombatResult     `json:"result,omitempty"`      // Reward summary, set when combat ends
+	CombatLog   []string          `json:"combatLog,omitempty"`   // Readable log lines across actions, oldest first, capped at combatLogLimit
+	Declared    []Declaration     `json:"declared,omitempty"`    // Actions waiting for the end of a simultaneous round
+
+	index     characterIndex // Character positions by ID, rebuilt by reindexCharacters
+	resolving bool           // Resolving a declared action against the start of the round
+}
+
+// RewardRules configure the rewards handed out when combat ends
+type RewardRules struct {
+	XPPerEnemy     int `json:"xpPerEnemy" yaml:"xpPerEnemy"`         // XP for each defeated enemy without its own XP value
+	VictoryHPBonus int `json:"victoryHpBonus" yaml:"victoryHpBonus"` // Bonus XP per surviving winner at full HP, scaled by HP remaining
+}
+
+// CombatResult summarises what the winning team earned
+type CombatResult struct {
+	Winner   string `json:"winner"`
+	Defeated []ID   `json:"defeated"`
+	XP       int    `json:"xp"`      // From defeated enemies
+	HPBonus  int    `json:"hpBonus"` // From the winners' remaining HP
+	TotalXP  int    `json:"totalXp"`
+	Loot     []Item `json:"loot"`
+}
+
+// HouseRules are optional rule variations for a session
+type HouseRules struct {
+	FriendlyFire     bool `json:"friendlyFire,omitempty" yaml:"friendlyFire"`         // Allow attacking members of your own team
+	RerollInitiative bool `json:"rerollInitiative,omitempty" yaml:"rerollInitiative"` // Reroll turn order at the start of each round
+	Ranked           bool `json:"ranked,omitempty" yaml:"ranked"`                     // Lock the session against undoing actions
+	MinDamage        *int `json:"minDamage,omitempty" yaml:"minDamage"`               // Least damage a hit deals after defense; defaults to 1, and 0 lets defense negate weak hits
+	MaxDefendStacks  int  `json:"maxDefendStacks,omitempty" yaml:"maxDefendStacks"`   // Defensive stances a character may hold at once; defaults to 1
+	FlankingBonus    int  `json:"flankingBonus,omitempty" yaml:"flankingBonus"`       // Extra damage for hitting a target with an ally directly opposite; 0 disables flanking
+	AbilityDefense   bool `json:"abilityDefense,omitempty" yaml:"abilityDefense"`     // Subtract defense from every damaging ability, not only those that opt in
+
+	AIDifficulty string `json:"aiDifficulty,omitempty" yaml:"aiDifficulty"` // Enemy AI tier: "easy", "normal" (default) or "hard"
+	AutoEnemies  bool   `json:"autoEnemies,omitempty" yaml:"autoEnemies"`   // The server plays enemy turns itself with the AI
+
+	DeathSaves  bool `json:"deathSaves,omitempty" yaml:"deathSaves"`   // Players at 0 HP are downed and make death saves instead of dying
+	DeathSaveDC int  `json:"deathSaveDC,omitempty" yaml:"deathSaveDC"` // d20 roll a death save needs; defaults to 10
+
+	ReactionsPerRound int `json:"reactionsPerRound,omitempty" yaml:"reactionsPerRound"` // Reactions each character may use per round; defaults to 1
+
+	RollStreams bool `json:"rollStreams,omitempty" yaml:"rollStreams"` // Roll each category of dice from its own seeded stream, see roll_streams.go
+
+	RoundMode string `json:"roundMode,omitempty" yaml:"roundMode"` // "sequential" (default) or "simultaneous", where the round's actions resolve together
+
+	BoardMode string `json:"boardMode,omitempty" yaml:"boardMode"` // "infinite" (default), "bounded" or "wrap", see board.go
 }
 
 // Resolution represents the result of applying an action
 type Resolution struct {
-	Events []Event  `json:"events"`
-	State  State    `json:"state"`
-	Logs   []string `json:"logs"`
+	Events       []Event      `json:"events"`
+	State        State        `json:"state"`
+	Logs         []string     `json:"logs"`
+	RejectReason RejectReason `json:"rejectReason,omitempty"` // Set when the action was refused and the state is unchanged
 }
 
+// RejectReason says why the engine refused an action
+type RejectReason string
+
+const (
+	RejectInvalidState    RejectReason = "invalid_state"
+	RejectActorNotFound   RejectReason = "actor_not_found"
+	RejectUnknownKind     RejectReason = "unknown_kind"
+	RejectTargetNotFound  RejectReason = "target_not_found"
+	RejectFriendlyTarget  RejectReason = "friendly_target"
+	RejectInvalidTarget   RejectReason = "invalid_target"
+	RejectNoLineOfSight   RejectReason = "no_line_of_sight"
+	RejectNotYourTurn     RejectReason = "not_your_turn"
+	RejectInvalidSlot     RejectReason = "invalid_slot"
+	RejectAbilityNotFound RejectReason = "ability_not_found"
+	RejectOnCooldown      RejectReason = "on_cooldown"
+	RejectPassiveAbility  RejectReason = "passive_ability"
+	RejectReactionAbility RejectReason = "reaction_ability"
+	RejectItemNotFound    RejectReason = "item_not_found"
+	RejectInventoryFull   RejectReason = "inventory_full"
+)
+
 // RollCheck represents a roll check request
 type RollCheck struct {
 	Actor ID     `json:"actor"`
@@ -127,16 +266,36 @@ type Scenario struct {
 	Context     string              `yaml:"context"`
 	Players     []ScenarioCharacter `yaml:"players"`
 	Enemies     []ScenarioCharacter `yaml:"enemies"`
+	Rules       HouseRules          `yaml:"rules"`
+	Obstacles   []ScenarioPosition  `yaml:"obstacles"`
+	Rewards     *RewardRules        `yaml:"rewards"`
+	Round       int                 `yaml:"round"`    // Starting round for battles already in progress; defaults to 1
+	Surprise    string              `yaml:"surprise"` // Team ("player" or "enemy") that acts alone in a surprise round
 }
 
 // ScenarioCharacter represents a character in a scenario
 type ScenarioCharacter struct {
+	ID        string            `yaml:"id"` // Optional fixed ID for stable references; generated when empty
 	Name      string            `yaml:"name"`
 	Position  ScenarioPosition  `yaml:"position"`
 	Stats     ScenarioStats     `yaml:"stats"`
 	Weapons   []ScenarioWeapon  `yaml:"weapons"`
 	Abilities []ScenarioAbility `yaml:"abilities"`
 	Items     []ScenarioItem    `yaml:"items"`
+	MaxItems  int               `yaml:"maxItems"`
+	XP        int               `yaml:"xp"`
+
+	InitiativeBonus int  `yaml:"initiativeBonus"` // Added to every initiative roll
+	AI              bool `yaml:"ai"`              // Players only: an ally the server plays rather than the human
+
+	// Display hints passed through to the state for frontends
+	Sprite   string `yaml:"sprite"`
+	Color    string `yaml:"color"`
+	Portrait string `yaml:"portrait"`
+
+	// Starting conditions for battles already in progress
+	StatusEffects []ScenarioStatusEffect `yaml:"statusEffects"`
+	Cooldowns     map[string]int         `yaml:"cooldowns"` // Turns left by ability name
 }
 
 // ScenarioPosition represents a position in the scenario
@@ -156,22 +315,46 @@ type ScenarioStats struct {
 
 // ScenarioWeapon represents a weapon in a scenario
 type ScenarioWeapon struct {
-	Name     string `yaml:"name"`
-	Damage   int    `yaml:"damage"`
-	Accuracy int    `yaml:"accuracy"`
+	ID         string `yaml:"id"`
+	Name       string `yaml:"name"`
+	Damage     int    `yaml:"damage"`
+	Accuracy   int    `yaml:"accuracy"`
+	Durability int    `yaml:"durability"`
+
+	IgnoresDefense bool   `yaml:"ignoresDefense"`
+	Scaling        string `yaml:"scaling"`
 }
 
 // ScenarioAbility represents an ability in a scenario
 type ScenarioAbility struct {
+	ID       string `yaml:"id"`
 	Name     string `yaml:"name"`
 	Cooldown int    `yaml:"cooldown"`
 	Effect   string `yaml:"effect"`
 	Power    int    `yaml:"power"`
+
+	IgnoresDefense   bool   `yaml:"ignoresDefense"`
+	ReducedByDefense bool   `yaml:"reducedByDefense"`
+	Scaling          string `yaml:"scaling"`
+
+	Passive bool   `yaml:"passive"`
+	Trigger string `yaml:"trigger"`
+
+	Reaction bool `yaml:"reaction"`
+}
+
+// ScenarioStatusEffect represents a status effect a scenario character starts with
+type ScenarioStatusEffect struct {
+	Type     string `yaml:"type"`
+	Amount   int    `yaml:"amount"`
+	Duration int    `yaml:"duration"`
 }
 
 // ScenarioItem represents an item in a scenario
 type ScenarioItem struct {
-	Name   string `yaml:"name"`
-	Type   string `yaml:"type"`
-	Effect string `yaml:"effect"`
+	ID      string `yaml:"id"`
+	Name    string `yaml:"name"`
+	Type    string `yaml:"type"`
+	Effect  string `yaml:"effect"`
+	Charges int    `yaml:"charges"`
 }
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// fullState has every State, Character, Weapon, Ability, Item and StatusEffect
+// field set, so a round trip shows any field that doesn't survive JSON
+func fullState() State {
+	minDamage := 0
+	winner := "player"
+	hero := Character{
+		ID:               "hero",
+		Name:             "Hero",
+		Stats:            Stat{HP: 0, MaxHP: 30, Attack: 15, Defense: 3, Speed: 4},
+		Position:         Position{X: 1, Y: 2},
+		Weapons:          []Weapon{{ID: "sword", Name: "Sword", Damage: 6, Accuracy: 85, Durability: 9, IgnoresDefense: true, Scaling: scalingSpeed}},
+		Abilities:        []Ability{{ID: "thorns", Name: "Thorns", Cooldown: 2, Effect: "reflect", Power: 3, IgnoresDefense: true, ReducedByDefense: true, Scaling: scalingAttack, Passive: true, Trigger: triggerHit, Reaction: true}},
+		Items:            []Item{{ID: "wand", Name: "Wand", Type: "consumable", Effect: "regen", Charges: 3}},
+		AbilityCooldowns: map[string]int{"thorns": 0, "fireball": 2},
+		IsPlayer:         true,
+		Team:             "heroes",
+		Fled:             true,
+		StatusEffects:    []StatusEffect{{Type: "poison", Amount: 3, Duration: 2}},
+		MaxItems:         4,
+		XP:               25,
+		InitiativeBonus:  5,
+		Downed:           &DeathSaves{Successes: 2, Failures: 1, Stable: true},
+		ReactionsUsed:    1,
+		AI:               true,
+		Sprite:           "knight",
+		Color:            "#3366ff",
+		Portrait:         "/portraits/hero.png",
+	}
+	return State{
+		Round:       3,
+		Characters:  []Character{hero},
+		TurnOrder:   []ID{"hero"},
+		CurrentTurn: 0,
+		IsComplete:  true,
+		Winner:      &winner,
+		LastAction:  map[ID]string{"hero": "Attack"},
+		Rules: HouseRules{
+			FriendlyFire: true, RerollInitiative: true, Ranked: true, MinDamage: &minDamage, MaxDefendStacks: 2, FlankingBonus: 3, AbilityDefense: true,
+			AIDifficulty: aiHard, AutoEnemies: true, DeathSaves: true, DeathSaveDC: 12, ReactionsPerRound: 2, RollStreams: true, RoundMode: roundSimultaneous, BoardMode: boardWrap,
+		},
+		Seed:        42,
+		GroundItems: map[string][]Item{"1,2": {{ID: "potion", Name: "Health Potion", Type: "consumable", Effect: "heal 20 HP"}}},
+		Threat:      map[ID]int{"hero": 10},
+		Obstacles:   []Position{{X: 2, Y: 2}},
+		NextOrder:   []ID{"hero"},
+		Rewards:     &RewardRules{XPPerEnemy: 10, VictoryHPBonus: 5},
+		Result:      &CombatResult{Winner: winner, Defeated: []ID{"goblin"}, XP: 10, HPBonus: 2, TotalXP: 12, Loot: []Item{}},
+		CombatLog:   []string{"Hero attacks Goblin for 9 damage!"},
+		Declared:    []Declaration{{Action: Action{Kind: "Defend", Actor: "hero"}, Seed: 7}},
+	}
+}
+
+func TestJSONFieldNames(t *testing.T) {
+	state := fullState()
+	hero := state.Characters[0]
+	position := Position{X: 1, Y: 1}
+
+	for _, tc := range []struct {
+		value interface{}
+		keys  []string
+	}{
+		{state, []string{"characters", "combatLog", "currentTurn", "declared", "groundItems", "isComplete", "lastAction", "nextOrder", "obstacles", "result", "rewards", "round", "rules", "seed", "threat", "turnOrder", "winner"}},
+		{hero, []string{"abilities", "abilityCooldowns", "ai", "color", "downed", "fled", "id", "initiativeBonus", "isPlayer", "items", "maxItems", "name", "portrait", "position", "reactionsUsed", "sprite", "stats", "statusEffects", "team", "weapons", "xp"}},
+		{hero.Stats, []string{"attack", "defense", "hp", "maxHp", "speed"}},
+		{hero.Weapons[0], []string{"accuracy", "damage", "durability", "id", "ignoresDefense", "name", "scaling"}},
+		{hero.Abilities[0], []string{"cooldown", "effect", "id", "ignoresDefense", "name", "passive", "power", "reaction", "reducedByDefense", "scaling", "trigger"}},
+		{hero.Items[0], []string{"charges", "effect", "id", "name", "type"}},
+		{hero.StatusEffects[0], []string{"amount", "duration", "type"}},
+		{*hero.Downed, []string{"failures", "stable", "successes"}},
+		{state.Rules, []string{"abilityDefense", "aiDifficulty", "autoEnemies", "boardMode", "deathSaveDC", "deathSaves", "flankingBonus", "friendlyFire", "maxDefendStacks", "minDamage", "ranked", "reactionsPerRound", "rerollInitiative", "rollStreams", "roundMode"}},
+		{*state.Result, []string{"defeated", "hpBonus", "loot", "totalXp", "winner", "xp"}},
+		{
+			Action{Kind: "Attack", Attacker: "a", Target: "t", Weapon: "w", Actor: "a", Ability: "ab", Item: "i", Slot: 1, ActorName: "A", TargetName: "T", WeaponName: "W", AbilityName: "AB"},
+			[]string{"ability", "abilityName", "actor", "actorName", "attacker", "item", "kind", "slot", "target", "targetName", "weapon", "weaponName"},
+		},
+		{
+			Event{ID: "e", Type: "damage", Target: "t", Amount: 1, Source: "s", Actor: "a", Ability: "ab", Item: "i", Cooldown: 1, Charges: 1, Round: 1, SourcePosition: &position, TargetPosition: &position, Effect: "slash"},
+			[]string{"ability", "actor", "amount", "charges", "cooldown", "effect", "id", "item", "round", "source", "sourcePosition", "target", "targetPosition", "type"},
+		},
+		{ActionRecord{Round: 1, Action: Action{Kind: "Defend"}, Seed: 7}, []string{"action", "round", "seed"}},
+	} {
+		if keys, _ := messageKeys(t, tc.value); !reflect.DeepEqual(keys, tc.keys) {
+			t.Errorf("Expected %T to marshal with keys %v, got %v", tc.value, tc.keys, keys)
+		}
+	}
+}
+
+func TestJSONOmitsEmptyFields(t *testing.T) {
+	for _, tc := range []struct {
+		value interface{}
+		keys  []string
+	}{
+		// A nil Winner is absent rather than null, and so are the optional extras
+		{State{}, []string{"characters", "currentTurn", "isComplete", "round", "rules", "turnOrder"}},
+		// Collections a client ranges over are always present
+		{Character{}, []string{"abilities", "abilityCooldowns", "id", "isPlayer", "items", "name", "position", "stats", "weapons"}},
+		{Weapon{}, []string{"accuracy", "damage", "id", "name"}},
+		{Ability{}, []string{"cooldown", "effect", "id", "name", "power"}},
+		{Item{}, []string{"effect", "id", "name", "type"}},
+		{HouseRules{}, []string{}},
+		{Action{Kind: "Defend"}, []string{"kind"}},
+		{Event{Type: "miss"}, []string{"type"}},
+	} {
+		if keys, _ := messageKeys(t, tc.value); !reflect.DeepEqual(keys, tc.keys) {
+			t.Errorf("Expected an empty %T to marshal with keys %v, got %v", tc.value, tc.keys, keys)
+		}
+	}
+
+	// A minimum damage of 0 is a setting, not an absence
+	minDamage := 0
+	if _, fields := messageKeys(t, HouseRules{MinDamage: &minDamage}); string(fields["minDamage"]) != "0" {
+		t.Errorf("Expected minDamage 0 to be kept, got %s", fields["minDamage"])
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	state := fullState()
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("Failed to marshal state: %v", err)
+	}
+	var decoded State
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal state: %v", err)
+	}
+	// The character index isn't serialized, so rebuild it before comparing
+	reindexCharacters(&state)
+	reindexCharacters(&decoded)
+	if !reflect.DeepEqual(decoded, state) {
+		t.Errorf("Expected the state to survive a round trip\nwant %+v\ngot  %+v", state, decoded)
+	}
+	if cooldown, ok := decoded.Characters[0].AbilityCooldowns["thorns"]; !ok || cooldown != 0 {
+		t.Error("Expected ready abilities to keep their zero cooldown entry")
+	}
+
+	for _, action := range []Action{
+		{Kind: "Attack", Attacker: "hero", Target: "goblin", Weapon: "sword"},
+		{Kind: "Delay", Actor: "hero", Slot: 2},
+		{Kind: "Ability", ActorName: "Hero", TargetName: "Goblin", AbilityName: "Fireball"},
+	} {
+		data, _ := json.Marshal(action)
+		var decoded Action
+		if err := json.Unmarshal(data, &decoded); err != nil || decoded != action {
+			t.Errorf("Expected %+v to survive a round trip, got %+v (%v)", action, decoded, err)
+		}
+	}
+}
+
+func TestDeepCopyStateIsIndependent(t *testing.T) {
+	state := fullState()
+	reindexCharacters(&state)
+	copied := deepCopyState(state)
+	if !reflect.DeepEqual(copied, state) {
+		t.Fatalf("Expected the deep copy to equal the original\nwant %+v\ngot  %+v", state, copied)
+	}
+
+	// Changing anything reachable from the copy leaves the original alone
+	hero := &copied.Characters[0]
+	hero.AbilityCooldowns["thorns"] = 5
+	hero.Items[0].Charges = 1
+	hero.StatusEffects[0].Duration = 9
+	hero.Downed.Failures = 3
+	*copied.Winner = "enemy"
+	*copied.Rules.MinDamage = 4
+	copied.LastAction["hero"] = "Defend"
+	copied.GroundItems["1,2"][0].Name = "Empty Flask"
+	copied.Result.Defeated[0] = "orc"
+
+	state.index = nil
+	if !reflect.DeepEqual(state, fullState()) {
+		t.Errorf("Expected the original to be untouched by changes to its copy, got %+v", state)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import "sync"
+
+// subscriberBufferSize is how many game updates an in-process subscriber may
+// fall behind before further updates are dropped for it
+const subscriberBufferSize = 16
+
+// UpdateSubscribers hands a session's game updates to in-process listeners
+// over channels, alongside the WebSocket connections. Tests and integrations
+// can wait for the next update with a receive instead of opening a socket.
+// Each channel is fed by its own observer on the event bus.
+type UpdateSubscribers struct {
+	bus *EventBus
+}
+
+// NewUpdateSubscribers creates subscribers that listen on bus
+func NewUpdateSubscribers(bus *EventBus) *UpdateSubscribers {
+	return &UpdateSubscribers{bus: bus}
+}
+
+// Subscribe returns a channel receiving every game update broadcast to the
+// session, and a function that unsubscribes and closes it
+func (us *UpdateSubscribers) Subscribe(sessionID string) (<-chan GameUpdateMessage, func()) {
+	updates := make(chan GameUpdateMessage, subscriberBufferSize)
+
+	// The bus may still be calling the observer when it's removed, so sends
+	// and the close take turns
+	var mu sync.Mutex
+	closed := false
+	unsubscribe := us.bus.Subscribe(func(id string, state State, events []Event) {
+		if id != sessionID {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+		select {
+		case updates <- newGameUpdateMessage(state, events):
+		default:
+			sessionLogger(sessionID).Warn("Update subscriber fell behind, dropping update")
+		}
+	})
+
+	var once sync.Once
+	return updates, func() {
+		once.Do(func() {
+			unsubscribe()
+			mu.Lock()
+			defer mu.Unlock()
+			closed = true
+			close(updates)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSubscriberReceivesGameUpdates(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	sessionAuth = NewSessionAuth()
+	app := fiber.New()
+	setupRoutes(app)
+
+	hero := createTestCharacter(true, "Hero")
+	goblin := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{hero}, []Character{goblin}, 12345)
+	state.TurnOrder = []ID{hero.ID, goblin.ID}
+	stateManager.SetState("subscribed-session", state)
+
+	updates, unsubscribe := subscribers.Subscribe("subscribed-session")
+	other, unsubscribeOther := subscribers.Subscribe("other-session")
+	defer unsubscribeOther()
+
+	body, _ := json.Marshal(fiber.Map{"state": state, "action": Action{Kind: "Defend", Actor: hero.ID}, "seed": 7})
+	req := httptest.NewRequest("POST", "/tools/apply_action", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("session-id", "subscribed-session")
+	if resp, err := app.Test(req); err != nil || resp.StatusCode != 200 {
+		t.Fatalf("Failed to apply action: %v", err)
+	}
+
+	select {
+	case update := <-updates:
+		if update.Type != msgGameUpdate || GetCurrentCharacter(update.State).ID != goblin.ID {
+			t.Errorf("Expected the update to hand the turn to the goblin, got %+v", update)
+		}
+		if update.State.LastAction[hero.ID] != "Defend" {
+			t.Errorf("Expected the update to carry the hero's defend, got %v", update.State.LastAction)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a game update on the subscriber channel")
+	}
+
+	select {
+	case update := <-other:
+		t.Errorf("Expected other sessions' subscribers to hear nothing, got %+v", update)
+	default:
+	}
+
+	unsubscribe()
+	if _, open := <-updates; open {
+		t.Error("Expected unsubscribing to close the channel")
+	}
+	unsubscribe()
+	eventBus.Publish("subscribed-session", state, nil)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Request limits for endpoints that accept client-supplied game state
+const (
+	maxRequestBodyBytes = 1 << 20 // 1 MiB
+	maxStateCharacters  = 64
+	maxBatchActions     = 100
+	maxToolInvocations  = 50
+)
+
+// serverConfig is the Fiber configuration both servers run with. The body
+// limit makes fasthttp refuse oversized requests while reading them, before
+// they are held in memory.
+func serverConfig() fiber.Config {
+	return fiber.Config{
+		BodyLimit:    maxRequestBodyBytes,
+		ErrorHandler: handleServerError,
+	}
+}
+
+// handleServerError answers requests that failed outside a handler's own
+// responses, such as oversized bodies and handler errors. Oversized bodies
+// get a 400 like any other rejected payload.
+func handleServerError(c *fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusRequestEntityTooLarge {
+		return c.Status(400).JSON(fiber.Map{
+			"error": fmt.Sprintf("Request body too large (max %d bytes)", maxRequestBodyBytes),
+		})
+	}
+	slog.Error("Request failed", "path", c.Path(), "error", err)
+	return c.Status(500).JSON(fiber.Map{
+		"error": "Internal server error",
+	})
+}
+
+// validateStatePayload checks that a client-supplied state is within size limits
+// and that its turn order only references characters in the state
+func validateStatePayload(state State) error {
+	if len(state.Characters) > maxStateCharacters {
+		return fmt.Errorf("too many characters: %d (max %d)", len(state.Characters), maxStateCharacters)
+	}
+	if len(state.TurnOrder) > maxStateCharacters {
+		return fmt.Errorf("turn order too long: %d (max %d)", len(state.TurnOrder), maxStateCharacters)
+	}
+
+	known := make(map[ID]bool, len(state.Characters))
+	for _, char := range state.Characters {
+		known[char.ID] = true
+	}
+	for _, id := range state.TurnOrder {
+		if !known[id] {
+			return fmt.Errorf("turn order references unknown character: %s", id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func postJSON(t *testing.T, app *fiber.App, path string, body []byte) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest("POST", path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Request to %s failed: %v", path, err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(respBody)
+}
+
+func TestRequestValidationRejections(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+
+	app := fiber.New()
+	setupRoutes(app)
+
+	player := createTestCharacter(true, "Player")
+	enemy := createTestCharacter(false, "Enemy")
+	validState := CreateInitialState([]Character{player}, []Character{enemy}, 12345)
+
+	t.Run("OversizedBody", func(t *testing.T) {
+		// The limit is enforced by the server while it reads the body, so this
+		// needs a real connection rather than app.Test
+		limited := fiber.New(serverConfig())
+		setupRoutes(limited)
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		go limited.Listener(listener)
+		defer limited.Shutdown()
+
+		body := []byte(`{"padding":"` + strings.Repeat("x", maxRequestBodyBytes) + `"}`)
+		resp, err := http.Post("http://"+listener.Addr().String()+"/tools/apply_action", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != 400 || !strings.Contains(string(respBody), "too large") {
+			t.Errorf("Expected 400 body too large, got %d: %s", resp.StatusCode, respBody)
+		}
+	})
+
+	t.Run("TooManyCharacters", func(t *testing.T) {
+		state := validState
+		state.Characters = make([]Character, maxStateCharacters+1)
+		state.TurnOrder = nil
+		for i := range state.Characters {
+			state.Characters[i] = Character{ID: NewID(), Name: "Mob"}
+		}
+		body, _ := json.Marshal(fiber.Map{"state": state, "action": Action{Kind: "Defend"}, "seed": 1})
+		status, resp := postJSON(t, app, "/tools/apply_action", body)
+		if status != 400 || !strings.Contains(resp, "too many characters") {
+			t.Errorf("Expected 400 too many characters, got %d: %s", status, resp)
+		}
+	})
+
+	t.Run("TurnOrderTooLong", func(t *testing.T) {
+		state := validState
+		state.TurnOrder = make([]ID, maxStateCharacters+1)
+		for i := range state.TurnOrder {
+			state.TurnOrder[i] = player.ID
+		}
+		body, _ := json.Marshal(fiber.Map{"sessionId": "too-long", "state": state})
+		status, resp := postJSON(t, app, "/sessions", body)
+		if status != 400 || !strings.Contains(resp, "turn order too long") {
+			t.Errorf("Expected 400 turn order too long, got %d: %s", status, resp)
+		}
+	})
+
+	t.Run("UnknownTurnOrderID", func(t *testing.T) {
+		state := validState
+		state.TurnOrder = []ID{player.ID, NewID()}
+		body, _ := json.Marshal(fiber.Map{"state": state})
+		status, resp := postJSON(t, app, "/tools/get_state_summary", body)
+		if status != 400 || !strings.Contains(resp, "unknown character") {
+			t.Errorf("Expected 400 unknown character, got %d: %s", status, resp)
+		}
+	})
+
+	t.Run("ValidState", func(t *testing.T) {
+		body, _ := json.Marshal(fiber.Map{"state": validState})
+		status, resp := postJSON(t, app, "/tools/get_state_summary", body)
+		if status != 200 {
+			t.Errorf("Expected valid state to be accepted, got %d: %s", status, resp)
+		}
+	})
+
+	t.Run("UnknownActionName", func(t *testing.T) {
+		body, _ := json.Marshal(fiber.Map{"state": validState, "action": Action{Kind: "Attack", ActorName: "player", TargetName: "Dragon"}, "seed": 1})
+		status, resp := postJSON(t, app, "/tools/apply_action", body)
+		if status != 400 || !strings.Contains(resp, "not found") {
+			t.Errorf("Expected 400 character not found, got %d: %s", status, resp)
+		}
+	})
+
+	t.Run("RejectedAction", func(t *testing.T) {
+		body, _ := json.Marshal(fiber.Map{"state": validState, "action": Action{Kind: "Ability", Actor: player.ID, Ability: "ghost"}, "seed": 1})
+		status, resp := postJSON(t, app, "/tools/apply_action", body)
+		if status != 422 || !strings.Contains(resp, `"rejectReason":"ability_not_found"`) {
+			t.Errorf("Expected 422 ability_not_found, got %d: %s", status, resp)
+		}
+
+		body, _ = json.Marshal(fiber.Map{"state": validState, "action": Action{Kind: "Delay", Actor: GetCurrentCharacter(validState).ID, Slot: 99}, "seed": 1})
+		if status, resp := postJSON(t, app, "/tools/apply_action", body); status != 422 || !strings.Contains(resp, "invalid_slot") {
+			t.Errorf("Expected 422 invalid_slot, got %d: %s", status, resp)
+		}
+
+		other := player.ID
+		if GetCurrentCharacter(validState).ID == player.ID {
+			other = enemy.ID
+		}
+		body, _ = json.Marshal(fiber.Map{"state": validState, "action": Action{Kind: "Delay", Actor: other}, "seed": 1})
+		if status, resp := postJSON(t, app, "/tools/apply_action", body); status != 409 || !strings.Contains(resp, "not_your_turn") {
+			t.Errorf("Expected 409 not_your_turn, got %d: %s", status, resp)
+		}
+	})
+
+	t.Run("ActionByName", func(t *testing.T) {
+		body, _ := json.Marshal(fiber.Map{"state": validState, "action": Action{Kind: "Defend", ActorName: "player"}, "seed": 1})
+		status, resp := postJSON(t, app, "/tools/apply_action", body)
+		if status != 200 || !strings.Contains(resp, "Player takes a defensive stance") {
+			t.Errorf("Expected defend by name to resolve, got %d: %s", status, resp)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/websocket/v2"
+)
+
+// wsConn is the part of a WebSocket connection the message loop needs, so the
+// loop can be driven by a fake connection in tests
+type wsConn interface {
+	ReadJSON(v interface{}) error
+	WriteJSON(v interface{}) error
+}
+
+// lockedConn is a WebSocket connection whose writes take turns: broadcasts
+// from other goroutines and replies to the connection's own messages would
+// otherwise write to it at the same time
+type lockedConn struct {
+	*websocket.Conn
+	mu sync.Mutex
+}
+
+// WriteJSON sends a message once no other write is in progress
+func (lc *lockedConn) WriteJSON(v interface{}) error {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return lc.Conn.WriteJSON(v)
+}
+
+// serveWebSocketMessages reads messages from a connection until it closes,
+// applying action messages and replying to anything it can't accept
+func serveWebSocketMessages(conn wsConn, sessionID, role, token string) {
+	logger := sessionLogger(sessionID).With("role", role)
+
+	for {
+		var msg map[string]interface{}
+		if err := conn.ReadJSON(&msg); err != nil {
+			logger.Debug("WebSocket read error", "error", err)
+			return
+		}
+
+		err := validateWebSocketMessage(role, msg)
+		if err == nil {
+			if msgType, _ := msg["type"].(string); msgType == "action" {
+				err = applyWebSocketAction(sessionID, token, msg)
+			} else {
+				// Handle other incoming messages (e.g., ping, etc.)
+				logger.Debug("Received WebSocket message", "message", msg)
+			}
+		}
+
+		if err != nil {
+			if writeErr := conn.WriteJSON(newErrorMessage(err)); writeErr != nil {
+				logger.Error("WebSocket write error", "error", writeErr)
+			}
+		}
+	}
+}
+
+// applyWebSocketAction applies an {"type": "action", "action": {...}} message to
+// the session. The action may also be one of the game page's button names, such
+// as "attack", taken by the current character. The resolved state reaches every
+// connected client through the event bus; the returned error is sent back to
+// the sender alone.
+func applyWebSocketAction(sessionID, token string, msg map[string]interface{}) error {
+	raw, ok := msg["action"]
+	if !ok {
+		return fmt.Errorf("action message is missing an action")
+	}
+
+	unlock := sessionLocks.Lock(sessionID)
+	defer unlock()
+	state, exists := stateManager.GetState(sessionID)
+	if !exists {
+		return fmt.Errorf("session not found")
+	}
+
+	current := GetCurrentCharacter(state)
+	if current == nil {
+		return fmt.Errorf("no current character")
+	}
+
+	var action Action
+	if name, isName := raw.(string); isName {
+		var err error
+		if action, err = gamePageAction(state, current, name); err != nil {
+			return err
+		}
+	} else {
+		payload, err := json.Marshal(raw)
+		if err != nil {
+			return fmt.Errorf("invalid action")
+		}
+		if err := json.Unmarshal(payload, &action); err != nil {
+			return fmt.Errorf("invalid action")
+		}
+		if action, err = ResolveActionNames(state, action); err != nil {
+			return err
+		}
+	}
+
+	if getActorID(action) != current.ID {
+		return fmt.Errorf("it is not that character's turn")
+	}
+
+	if !sessionAuth.Authorize(sessionID, token, current.ID) {
+		return fmt.Errorf("not authorized to act for this character")
+	}
+
+	seed := newSeed()
+	resolution := ApplyAction(state, action, seed)
+	if !actionResolved(state, resolution.State) {
+		return fmt.Errorf("action rejected: %s", strings.Join(resolution.Logs, "; "))
+	}
+
+	persistResolution(sessionID, state, resolution)
+	recordAction(sessionID, state, resolution, action, seed)
+	turnTimers.Reset(sessionID, resolution.State)
+
+	sessionLogger(sessionID).Info("Applied WebSocket action",
+		"action", action.Kind,
+		"actor", current.ID,
+		"round", resolution.State.Round)
+	return nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	fastws "github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+)
+
+// fakeWSConn replays queued client messages and records everything written back
+type fakeWSConn struct {
+	inbound []string
+	written []map[string]interface{}
+}
+
+func (f *fakeWSConn) ReadJSON(v interface{}) error {
+	if len(f.inbound) == 0 {
+		return io.EOF
+	}
+	msg := f.inbound[0]
+	f.inbound = f.inbound[1:]
+	return json.Unmarshal([]byte(msg), v)
+}
+
+func (f *fakeWSConn) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var msg map[string]interface{}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	f.written = append(f.written, msg)
+	return nil
+}
+
+func TestWebSocketActions(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	sessionAuth = NewSessionAuth()
+
+	player := createTestCharacter(true, "Hero")
+	enemy := createTestCharacter(false, "Goblin")
+	state := CreateInitialState([]Character{player}, []Character{enemy}, 42)
+	state.TurnOrder = []ID{player.ID, enemy.ID}
+
+	sessionID := "ws-actions"
+	stateManager.SetState(sessionID, state)
+	playerToken, dmToken := issueSessionTokens(sessionID, state)
+
+	var published []State
+	unsubscribe := eventBus.Subscribe(func(id string, s State, events []Event) {
+		if id == sessionID {
+			published = append(published, s)
+		}
+	})
+	defer unsubscribe()
+
+	conn := &fakeWSConn{inbound: []string{
+		`{"type": "action"}`,
+		`{"type": "action", "action": 42}`,
+		`{"type": "action", "action": {"kind": "Defend", "actor": "` + string(enemy.ID) + `"}}`,
+		`{"type": "ping"}`,
+		`{"type": "action", "action": {"kind": "Attack", "actorName": "Hero", "targetName": "Goblin", "weaponName": "Test Weapon"}}`,
+	}}
+	serveWebSocketMessages(conn, sessionID, wsRolePlayer, playerToken)
+
+	if len(conn.written) != 3 {
+		t.Fatalf("Expected 3 error replies, got %+v", conn.written)
+	}
+	for _, reply := range conn.written {
+		if reply["type"] != "error" || reply["error"] == "" {
+			t.Errorf("Expected an error reply, got %+v", reply)
+		}
+	}
+	if errMsg, _ := conn.written[2]["error"].(string); !strings.Contains(errMsg, "turn") {
+		t.Errorf("Expected an out-of-turn error, got %q", errMsg)
+	}
+
+	if len(published) != 1 {
+		t.Fatalf("Expected the attack to be broadcast once, got %d updates", len(published))
+	}
+	current, _ := stateManager.GetState(sessionID)
+	if GetCurrentCharacter(current).ID != enemy.ID {
+		t.Errorf("Expected the turn to pass to the enemy after the attack")
+	}
+	actions, err := eventStore.GetActions(sessionID)
+	if err != nil || len(actions) != 1 || actions[0].Action.Kind != "Attack" {
+		t.Errorf("Expected the attack in the action log, got %+v (%v)", actions, err)
+	}
+
+	// The player token may not act for the enemy even on the enemy's turn
+	conn = &fakeWSConn{inbound: []string{
+		`{"type": "action", "action": {"kind": "Defend", "actor": "` + string(enemy.ID) + `"}}`,
+	}}
+	serveWebSocketMessages(conn, sessionID, wsRolePlayer, playerToken)
+	if len(conn.written) != 1 || !strings.Contains(conn.written[0]["error"].(string), "not authorized") {
+		t.Errorf("Expected an authorization error, got %+v", conn.written)
+	}
+
+	conn = &fakeWSConn{inbound: []string{
+		`{"type": "action", "action": {"kind": "Defend", "actor": "` + string(enemy.ID) + `"}}`,
+	}}
+	serveWebSocketMessages(conn, sessionID, wsRolePlayer, dmToken)
+	if len(conn.written) != 0 {
+		t.Errorf("Expected the DM's action to be accepted, got %+v", conn.written)
+	}
+	if len(published) != 2 {
+		t.Errorf("Expected the DM's action to be broadcast, got %d updates", len(published))
+	}
+
+	// The game page sends its buttons by name for the current character
+	conn = &fakeWSConn{inbound: []string{
+		`{"type": "action", "action": "dance"}`,
+		`{"type": "action", "action": "defend"}`,
+	}}
+	serveWebSocketMessages(conn, sessionID, wsRolePlayer, playerToken)
+	if len(conn.written) != 1 || !strings.Contains(conn.written[0]["error"].(string), "unknown action") {
+		t.Errorf("Expected only the unknown button to be refused, got %+v", conn.written)
+	}
+	if len(published) != 3 {
+		t.Errorf("Expected the defend button to be applied, got %d updates", len(published))
+	}
+}
+
+func TestWebSocketActionWithStartGameCookie(t *testing.T) {
+	eventStore = NewMemoryEventStore()
+	stateManager = NewStateManager()
+	sessionAuth = NewSessionAuth()
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	setupRoutes(app)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go app.Listener(listener)
+	defer app.Shutdown()
+	baseURL := "http://" + listener.Addr().String()
+
+	// Start a game the way the scenario form does and keep only the cookie it sets
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{
+		Jar: jar,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.PostForm(baseURL+"/game/start", url.Values{"scenario": {"goblin-ambush"}})
+	if err != nil {
+		t.Fatalf("Failed to start a game: %v", err)
+	}
+	resp.Body.Close()
+	sessionID := strings.TrimPrefix(resp.Header.Get("Location"), "/game/")
+	state, exists := stateManager.GetState(sessionID)
+	if !exists {
+		t.Fatalf("Expected a session behind redirect %q", resp.Header.Get("Location"))
+	}
+
+	// Hand the turn to a player so the cookie's token may act
+	for i, id := range state.TurnOrder {
+		if c := GetCharacterByID(state, id); c != nil && c.IsPlayer {
+			state.CurrentTurn = i
+			break
+		}
+	}
+	stateManager.SetState(sessionID, state)
+
+	wsURL, _ := url.Parse(baseURL + "/ws/" + sessionID)
+	header := http.Header{}
+	for _, cookie := range jar.Cookies(wsURL) {
+		header.Add("Cookie", cookie.String())
+	}
+	if header.Get("Cookie") == "" {
+		t.Fatalf("Expected the player token cookie to be sent to %s", wsURL.Path)
+	}
+
+	wsURL.Scheme = "ws"
+	conn, _, err := fastws.DefaultDialer.Dial(wsURL.String(), header)
+	if err != nil {
+		t.Fatalf("Failed to dial the WebSocket: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteJSON(map[string]interface{}{"type": "action", "action": "defend"}); err != nil {
+		t.Fatalf("Failed to send the action: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var msg map[string]interface{}
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("Expected a game_update, got %v", err)
+		}
+		if msg["type"] == "error" {
+			t.Fatalf("Expected the cookie's token to be accepted, got %+v", msg)
+		}
+		if msg["type"] == "game_update" {
+			break
+		}
+	}
+}